@@ -143,6 +143,13 @@ func AssertDependentResourceCleanupFunc(ctx context.Context, namespace string, k
 			g.Expect(err).To(Succeed())
 			g.Expect(len(list.Items)).To(BeZero())
 		}).Should(Succeed())
+		Eventually(func(g Gomega) {
+			list, err := kclient.PolicyV1().PodDisruptionBudgets(namespace).List(ctx, metav1.ListOptions{
+				LabelSelector: fmt.Sprintf("%s=%s,%s=%s", metadata.ComponentLabelKey, metadata.ComponentPDBLabel, metadata.OwnerLabelKey, owner),
+			})
+			g.Expect(err).To(Succeed())
+			g.Expect(len(list.Items)).To(BeZero())
+		}).Should(Succeed())
 	}
 }
 
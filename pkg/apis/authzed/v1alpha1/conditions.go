@@ -16,15 +16,50 @@ func (c *SpiceDBCluster) NamespacedName() types.NamespacedName {
 }
 
 const (
-	ConditionTypeValidating          = "Validating"
-	ConditionValidatingFailed        = "ValidatingFailed"
-	ConditionTypeMigrating           = "Migrating"
-	ConditionTypeConfigWarnings      = "ConfigurationWarning"
-	ConditionTypePreconditionsFailed = "PreconditionsFailed"
-	ConditionTypeRolling             = "RollingDeployment"
-	ConditionTypeRolloutError        = "RolloutError"
-
-	ConditionReasonMissingSecret = "MissingSecret"
+	ConditionTypeValidating            = "Validating"
+	ConditionValidatingFailed          = "ValidatingFailed"
+	ConditionTypeMigrating             = "Migrating"
+	ConditionTypeConfigWarnings        = "ConfigurationWarning"
+	ConditionTypePreconditionsFailed   = "PreconditionsFailed"
+	ConditionTypeRolling               = "RollingDeployment"
+	ConditionTypeRolloutError          = "RolloutError"
+	ConditionTypePresharedKeyRotation  = "PresharedKeyRotation"
+	ConditionTypeUpdatePendingApproval = "UpdatePendingApproval"
+	ConditionTypeUpdatePending         = "UpdatePending"
+	ConditionTypeRolledBack            = "RolledBack"
+	ConditionTypeConnectionSecret      = "ConnectionSecretFailed"
+
+	// Aggregate conditions, derived from the ones above at the end of every
+	// reconcile.
+	ConditionTypeReady       = "Ready"
+	ConditionTypeAvailable   = "Available"
+	ConditionTypeProgressing = "Progressing"
+	ConditionTypeDegraded    = "Degraded"
+
+	ConditionReasonMissingSecret           = "MissingSecret"
+	ConditionReasonMissingReferencedSecret = "MissingReferencedSecret"
+	ConditionReasonCertificateNotReady     = "CertificateNotReady"
+	ConditionReasonSecretNotOwned          = "SecretNotOwned"
+	ConditionReasonRollingOutNextKey       = "RollingOutNextKey"
+	ConditionReasonWaitingForClients       = "WaitingForClients"
+	ConditionReasonRotationComplete        = "RotationComplete"
+	ConditionReasonWaitingForApproval      = "WaitingForApproval"
+	ConditionReasonOutsideMaintenance      = "OutsideMaintenanceWindow"
+	ConditionReasonReleaseSoaking          = "ReleaseSoaking"
+	ConditionReasonWaitingForWave          = "WaitingForWave"
+	ConditionReasonRolloutFailed           = "RolloutFailed"
+	ConditionReasonPodError                = "PodError"
+	ConditionReasonUnschedulable           = "Unschedulable"
+	ConditionReasonImagePullFailed         = "ImagePullFailed"
+	ConditionReasonInitContainerFailed     = "InitContainerFailed"
+	ConditionReasonContainerConfigError    = "ContainerConfigError"
+	ConditionReasonReadinessProbeFailed    = "ReadinessProbeFailed"
+	ConditionReasonProgressDeadline        = "ProgressDeadlineExceeded"
+	ConditionReasonClusterReady            = "ClusterReady"
+	ConditionReasonReplicasAvailable       = "MinimumReplicasAvailable"
+	ConditionReasonNoReplicasAvailable     = "NoReplicasAvailable"
+	ConditionReasonIdle                    = "Idle"
+	ConditionReasonAsExpected              = "AsExpected"
 )
 
 func NewValidatingConfigCondition(secretHash string) metav1.Condition {
@@ -87,6 +122,120 @@ func NewMissingSecretCondition(nn types.NamespacedName) metav1.Condition {
 	}
 }
 
+func NewMissingReferencedSecretCondition(nn types.NamespacedName) metav1.Condition {
+	return metav1.Condition{
+		Type:               ConditionTypePreconditionsFailed,
+		Status:             metav1.ConditionTrue,
+		Reason:             ConditionReasonMissingReferencedSecret,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+		Message:            fmt.Sprintf("Secret %s referenced in config not found", nn.String()),
+	}
+}
+
+func NewCertificateNotReadyCondition(message string) metav1.Condition {
+	return metav1.Condition{
+		Type:               ConditionTypePreconditionsFailed,
+		Status:             metav1.ConditionTrue,
+		Reason:             ConditionReasonCertificateNotReady,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+		Message:            message,
+	}
+}
+
+func NewConnectionSecretNotOwnedCondition(namespace, name string) metav1.Condition {
+	return metav1.Condition{
+		Type:               ConditionTypeConnectionSecret,
+		Status:             metav1.ConditionTrue,
+		Reason:             ConditionReasonSecretNotOwned,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+		Message:            fmt.Sprintf("Secret %s/%s already exists and wasn't published by this cluster; choose another connectionSecretName or delete the secret", namespace, name),
+	}
+}
+
+func NewPresharedKeyRotationRollingOutCondition() metav1.Condition {
+	return metav1.Condition{
+		Type:               ConditionTypePresharedKeyRotation,
+		Status:             metav1.ConditionTrue,
+		Reason:             ConditionReasonRollingOutNextKey,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+		Message:            "Rolling the pods to accept both preshared_key and preshared_key_next; clients can switch once the rollout finishes",
+	}
+}
+
+func NewPresharedKeyRotationInProgressCondition() metav1.Condition {
+	return metav1.Condition{
+		Type:               ConditionTypePresharedKeyRotation,
+		Status:             metav1.ConditionTrue,
+		Reason:             ConditionReasonWaitingForClients,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+		Message:            "SpiceDB accepts both preshared_key and preshared_key_next; move clients to preshared_key_next, then complete the rotation",
+	}
+}
+
+func NewPresharedKeyRotationCompleteCondition() metav1.Condition {
+	return metav1.Condition{
+		Type:               ConditionTypePresharedKeyRotation,
+		Status:             metav1.ConditionFalse,
+		Reason:             ConditionReasonRotationComplete,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+		Message:            "preshared_key_next replaced preshared_key; SpiceDB accepts only the new key once the rollout finishes",
+	}
+}
+
+func NewUpdatePendingApprovalCondition(message string) metav1.Condition {
+	return metav1.Condition{
+		Type:               ConditionTypeUpdatePendingApproval,
+		Status:             metav1.ConditionTrue,
+		Reason:             ConditionReasonWaitingForApproval,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+		Message:            message,
+	}
+}
+
+func NewUpdatePendingCondition(target SpiceDBVersion, next time.Time) metav1.Condition {
+	message := fmt.Sprintf("Update to %s in channel %s is waiting for the maintenance window at %s", target.Name, target.Channel, next.Format(time.RFC3339))
+	if next.IsZero() {
+		message = fmt.Sprintf("Update to %s in channel %s is waiting, but no maintenance window is open outside the blackout dates", target.Name, target.Channel)
+	}
+	return metav1.Condition{
+		Type:               ConditionTypeUpdatePending,
+		Status:             metav1.ConditionTrue,
+		Reason:             ConditionReasonOutsideMaintenance,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+		Message:            message,
+	}
+}
+
+func NewReleaseSoakingCondition(target SpiceDBVersion, until time.Time) metav1.Condition {
+	return metav1.Condition{
+		Type:               ConditionTypeUpdatePending,
+		Status:             metav1.ConditionTrue,
+		Reason:             ConditionReasonReleaseSoaking,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+		Message:            fmt.Sprintf("Update to %s in channel %s is held back until %s, when it has been released for the minimum soak time", target.Name, target.Channel, until.Format(time.RFC3339)),
+	}
+}
+
+func NewWaitingForWaveCondition(target SpiceDBVersion, wave string, updated, clusters int) metav1.Condition {
+	return metav1.Condition{
+		Type:               ConditionTypeUpdatePending,
+		Status:             metav1.ConditionTrue,
+		Reason:             ConditionReasonWaitingForWave,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+		Message:            fmt.Sprintf("Update to %s in channel %s is waiting for rollout wave %q to finish (%d of %d clusters updated)", target.Name, target.Channel, wave, updated, clusters),
+	}
+}
+
+func NewRolledBackCondition(from, to string, cause string) metav1.Condition {
+	return metav1.Condition{
+		Type:               ConditionTypeRolledBack,
+		Status:             metav1.ConditionTrue,
+		Reason:             ConditionReasonRolloutFailed,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+		Message:            fmt.Sprintf("Rolled back from %s to %s because %s. The cluster stays on %s until spec.version is set or another release comes next in the channel", from, to, cause, to),
+	}
+}
+
 func NewRollingCondition(message string) metav1.Condition {
 	return metav1.Condition{
 		Type:               ConditionTypeRolling,
@@ -98,11 +247,110 @@ func NewRollingCondition(message string) metav1.Condition {
 }
 
 func NewPodErrorCondition(message string) metav1.Condition {
+	return NewRolloutErrorCondition(ConditionReasonPodError, message)
+}
+
+// NewRolloutErrorCondition reports why a rollout isn't progressing. The
+// reason classifies the failure, i.e. ConditionReasonUnschedulable.
+func NewRolloutErrorCondition(reason, message string) metav1.Condition {
 	return metav1.Condition{
 		Type:               ConditionTypeRolloutError,
 		Status:             metav1.ConditionTrue,
-		Reason:             "PodError",
+		Reason:             reason,
 		LastTransitionTime: metav1.NewTime(time.Now()),
 		Message:            message,
 	}
 }
+
+// SetAggregateConditions sets the Ready, Available, Progressing and Degraded
+// conditions from the rest of the status, so that generic tooling (kstatus,
+// `kubectl wait`, Argo CD health checks) doesn't need to know about the
+// operator-specific conditions.
+//
+// Progressing and Degraded take their reason and message from the condition
+// that caused them, and Ready takes them from whichever of Degraded,
+// Progressing or Available is keeping the cluster from being ready.
+func (c *SpiceDBCluster) SetAggregateConditions() {
+	available := metav1.Condition{
+		Type:    ConditionTypeAvailable,
+		Status:  metav1.ConditionTrue,
+		Reason:  ConditionReasonReplicasAvailable,
+		Message: fmt.Sprintf("%d SpiceDB pods are available", c.Status.AvailableReplicas),
+	}
+	if c.Status.AvailableReplicas == 0 {
+		available.Status = metav1.ConditionFalse
+		available.Reason = ConditionReasonNoReplicasAvailable
+		available.Message = "No SpiceDB pods are available"
+	}
+
+	progressing := metav1.Condition{
+		Type:    ConditionTypeProgressing,
+		Status:  metav1.ConditionFalse,
+		Reason:  ConditionReasonIdle,
+		Message: "No changes are being rolled out",
+	}
+	for _, cond := range []*metav1.Condition{
+		c.FindStatusCondition(ConditionTypeValidating),
+		c.FindStatusCondition(ConditionTypeMigrating),
+		c.FindStatusCondition(ConditionTypeRolling),
+	} {
+		if cond != nil && cond.Status == metav1.ConditionTrue {
+			progressing.Status = metav1.ConditionTrue
+			progressing.Reason = cond.Reason
+			progressing.Message = cond.Message
+			break
+		}
+	}
+
+	degraded := metav1.Condition{
+		Type:    ConditionTypeDegraded,
+		Status:  metav1.ConditionFalse,
+		Reason:  ConditionReasonAsExpected,
+		Message: "No errors reported",
+	}
+	for _, cond := range []*metav1.Condition{
+		c.FindStatusCondition(ConditionValidatingFailed),
+		c.FindStatusCondition(ConditionTypePreconditionsFailed),
+		c.FindStatusCondition(ConditionTypeRolloutError),
+		c.FindStatusCondition(ConditionTypeConnectionSecret),
+	} {
+		if cond != nil && cond.Status == metav1.ConditionTrue {
+			degraded.Status = metav1.ConditionTrue
+			degraded.Reason = cond.Reason
+			degraded.Message = cond.Message
+			break
+		}
+	}
+	// a failed migration is reported as Migrating=False
+	if migrating := c.FindStatusCondition(ConditionTypeMigrating); degraded.Status == metav1.ConditionFalse &&
+		migrating != nil && migrating.Status == metav1.ConditionFalse {
+		degraded.Status = metav1.ConditionTrue
+		degraded.Reason = migrating.Reason
+		degraded.Message = migrating.Message
+	}
+
+	ready := metav1.Condition{
+		Type:    ConditionTypeReady,
+		Status:  metav1.ConditionTrue,
+		Reason:  ConditionReasonClusterReady,
+		Message: "SpiceDB is available and running the desired configuration",
+	}
+	for _, cond := range []metav1.Condition{degraded, progressing} {
+		if cond.Status == metav1.ConditionTrue {
+			ready.Status = metav1.ConditionFalse
+			ready.Reason = cond.Reason
+			ready.Message = cond.Message
+			break
+		}
+	}
+	if ready.Status == metav1.ConditionTrue && available.Status == metav1.ConditionFalse {
+		ready.Status = metav1.ConditionFalse
+		ready.Reason = available.Reason
+		ready.Message = available.Message
+	}
+
+	for _, cond := range []metav1.Condition{ready, available, progressing, degraded} {
+		cond.ObservedGeneration = c.Generation
+		c.SetStatusCondition(cond)
+	}
+}
@@ -0,0 +1,144 @@
+package v1alpha1
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestSetAggregateConditions(t *testing.T) {
+	type aggregate struct {
+		status metav1.ConditionStatus
+		reason string
+	}
+	tests := []struct {
+		name              string
+		availableReplicas int32
+		conditions        []metav1.Condition
+
+		ready       aggregate
+		available   aggregate
+		progressing aggregate
+		degraded    aggregate
+	}{
+		{
+			name:        "new cluster",
+			ready:       aggregate{metav1.ConditionFalse, ConditionReasonNoReplicasAvailable},
+			available:   aggregate{metav1.ConditionFalse, ConditionReasonNoReplicasAvailable},
+			progressing: aggregate{metav1.ConditionFalse, ConditionReasonIdle},
+			degraded:    aggregate{metav1.ConditionFalse, ConditionReasonAsExpected},
+		},
+		{
+			name:              "ready",
+			availableReplicas: 2,
+			ready:             aggregate{metav1.ConditionTrue, ConditionReasonClusterReady},
+			available:         aggregate{metav1.ConditionTrue, ConditionReasonReplicasAvailable},
+			progressing:       aggregate{metav1.ConditionFalse, ConditionReasonIdle},
+			degraded:          aggregate{metav1.ConditionFalse, ConditionReasonAsExpected},
+		},
+		{
+			name:              "rolling out",
+			availableReplicas: 2,
+			conditions:        []metav1.Condition{NewRollingCondition("rolling")},
+			ready:             aggregate{metav1.ConditionFalse, "WaitingForDeploymentAvailability"},
+			available:         aggregate{metav1.ConditionTrue, ConditionReasonReplicasAvailable},
+			progressing:       aggregate{metav1.ConditionTrue, "WaitingForDeploymentAvailability"},
+			degraded:          aggregate{metav1.ConditionFalse, ConditionReasonAsExpected},
+		},
+		{
+			name:              "migrating",
+			availableReplicas: 2,
+			conditions:        []metav1.Condition{NewMigratingCondition("postgres", "head")},
+			ready:             aggregate{metav1.ConditionFalse, "MigrationJobRunning"},
+			available:         aggregate{metav1.ConditionTrue, ConditionReasonReplicasAvailable},
+			progressing:       aggregate{metav1.ConditionTrue, "MigrationJobRunning"},
+			degraded:          aggregate{metav1.ConditionFalse, ConditionReasonAsExpected},
+		},
+		{
+			name:              "failed migration",
+			availableReplicas: 2,
+			conditions:        []metav1.Condition{NewMigrationFailedCondition("postgres", "head", errors.New("failed"))},
+			ready:             aggregate{metav1.ConditionFalse, "MigrationFailed"},
+			available:         aggregate{metav1.ConditionTrue, ConditionReasonReplicasAvailable},
+			progressing:       aggregate{metav1.ConditionFalse, ConditionReasonIdle},
+			degraded:          aggregate{metav1.ConditionTrue, "MigrationFailed"},
+		},
+		{
+			name:              "pod errors while rolling out",
+			availableReplicas: 1,
+			conditions:        []metav1.Condition{NewRollingCondition("rolling"), NewPodErrorCondition("crashed")},
+			ready:             aggregate{metav1.ConditionFalse, "PodError"},
+			available:         aggregate{metav1.ConditionTrue, ConditionReasonReplicasAvailable},
+			progressing:       aggregate{metav1.ConditionTrue, "WaitingForDeploymentAvailability"},
+			degraded:          aggregate{metav1.ConditionTrue, "PodError"},
+		},
+		{
+			name:              "invalid config",
+			availableReplicas: 2,
+			conditions:        []metav1.Condition{NewInvalidConfigCondition("hash", errors.New("invalid"))},
+			ready:             aggregate{metav1.ConditionFalse, "InvalidConfig"},
+			available:         aggregate{metav1.ConditionTrue, ConditionReasonReplicasAvailable},
+			progressing:       aggregate{metav1.ConditionFalse, ConditionReasonIdle},
+			degraded:          aggregate{metav1.ConditionTrue, "InvalidConfig"},
+		},
+		{
+			name:        "missing secret",
+			conditions:  []metav1.Condition{NewMissingSecretCondition(types.NamespacedName{Namespace: "test", Name: "secret"})},
+			ready:       aggregate{metav1.ConditionFalse, ConditionReasonMissingSecret},
+			available:   aggregate{metav1.ConditionFalse, ConditionReasonNoReplicasAvailable},
+			progressing: aggregate{metav1.ConditionFalse, ConditionReasonIdle},
+			degraded:    aggregate{metav1.ConditionTrue, ConditionReasonMissingSecret},
+		},
+		{
+			name:              "warnings don't affect readiness",
+			availableReplicas: 2,
+			conditions:        []metav1.Condition{NewConfigWarningCondition(errors.New("warning"))},
+			ready:             aggregate{metav1.ConditionTrue, ConditionReasonClusterReady},
+			available:         aggregate{metav1.ConditionTrue, ConditionReasonReplicasAvailable},
+			progressing:       aggregate{metav1.ConditionFalse, ConditionReasonIdle},
+			degraded:          aggregate{metav1.ConditionFalse, ConditionReasonAsExpected},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := &SpiceDBCluster{
+				ObjectMeta: metav1.ObjectMeta{Generation: 3},
+				Status: ClusterStatus{
+					AvailableReplicas: tt.availableReplicas,
+					Conditions:        tt.conditions,
+				},
+			}
+			cluster.SetAggregateConditions()
+
+			for conditionType, want := range map[string]aggregate{
+				ConditionTypeReady:       tt.ready,
+				ConditionTypeAvailable:   tt.available,
+				ConditionTypeProgressing: tt.progressing,
+				ConditionTypeDegraded:    tt.degraded,
+			} {
+				got := cluster.FindStatusCondition(conditionType)
+				require.NotNil(t, got, conditionType)
+				require.Equal(t, want, aggregate{got.Status, got.Reason}, conditionType)
+				require.NotEmpty(t, got.Message, conditionType)
+				require.Equal(t, int64(3), got.ObservedGeneration, conditionType)
+			}
+		})
+	}
+}
+
+func TestSetAggregateConditionsKeepsTransitionTime(t *testing.T) {
+	cluster := &SpiceDBCluster{Status: ClusterStatus{AvailableReplicas: 1}}
+	cluster.SetAggregateConditions()
+	cluster.FindStatusCondition(ConditionTypeReady).LastTransitionTime = metav1.Unix(0, 0)
+
+	cluster.Status.AvailableReplicas = 2
+	cluster.SetAggregateConditions()
+	require.Equal(t, metav1.Unix(0, 0), cluster.FindStatusCondition(ConditionTypeReady).LastTransitionTime)
+
+	cluster.SetStatusCondition(NewRollingCondition("rolling"))
+	cluster.SetAggregateConditions()
+	require.NotEqual(t, metav1.Unix(0, 0), cluster.FindStatusCondition(ConditionTypeReady).LastTransitionTime)
+}
@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 
 	"golang.org/x/exp/slices"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
 )
 
 const (
@@ -18,16 +20,24 @@ const (
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 // +kubebuilder:subresource:status
+// +kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.replicas,selectorpath=.status.selector
+// +kubebuilder:storageversion
 // +kubebuilder:resource:categories=authzed,shortName=spicedbs
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=".status.conditions[?(@.type=='Ready')].status"
+// +kubebuilder:printcolumn:name="Reason",type=string,priority=1,JSONPath=".status.conditions[?(@.type=='Ready')].reason"
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 // +kubebuilder:printcolumn:name="Channel",type=string,JSONPath=".spec.channel"
 // +kubebuilder:printcolumn:name="Desired",type=string,JSONPath=".spec.version"
 // +kubebuilder:printcolumn:name="Current",type=string,JSONPath=".status.version.name"
 // +kubebuilder:printcolumn:name="Warnings",type=string,JSONPath=".status.conditions[?(@.type=='ConfigurationWarning')].status"
-// +kubebuilder:printcolumn:name="Migrating",type=string,JSONPath=".status.conditions[?(@.type=='Migrating')].status"
-// +kubebuilder:printcolumn:name="Updating",type=string,JSONPath=".status.conditions[?(@.type=='RollingDeployment')].status"
-// +kubebuilder:printcolumn:name="Invalid",type=string,JSONPath=".status.conditions[?(@.type=='ConditionValidatingFailed')].status"
+// +kubebuilder:printcolumn:name="Progressing",type=string,JSONPath=".status.conditions[?(@.type=='Progressing')].status"
+// +kubebuilder:printcolumn:name="Degraded",type=string,JSONPath=".status.conditions[?(@.type=='Degraded')].status"
 // +kubebuilder:printcolumn:name="Paused",type=string,JSONPath=".status.conditions[?(@.type=='Paused')].status"
+// +kubebuilder:printcolumn:name="Desired Replicas",type=integer,priority=1,JSONPath=".status.desiredReplicas"
+// +kubebuilder:printcolumn:name="Ready Replicas",type=integer,priority=1,JSONPath=".status.readyReplicas"
+// +kubebuilder:printcolumn:name="Available Replicas",type=integer,priority=1,JSONPath=".status.availableReplicas"
+// +kubebuilder:printcolumn:name="Ready Endpoints",type=integer,priority=1,JSONPath=".status.readyEndpoints"
+// +kubebuilder:printcolumn:name="Image",type=string,priority=1,JSONPath=".status.rollout.image"
 type SpiceDBCluster struct {
 	metav1.TypeMeta `json:",inline"`
 	// +optional
@@ -87,9 +97,29 @@ type ClusterSpec struct {
 	// +kubebuilder:validation:Type=object
 	Config json.RawMessage `json:"config,omitempty"`
 
+	// Replicas is the number of SpiceDB pods to run. It takes precedence over
+	// `config.replicas` and is the field modified by the `/scale`
+	// subresource (i.e. `kubectl scale`).
+	// It is ignored if `autoscaling` is set.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Autoscaling configures a HorizontalPodAutoscaler for the SpiceDB
+	// deployment. When set, the autoscaler owns the replica count.
+	// +optional
+	Autoscaling *ClusterAutoscaling `json:"autoscaling,omitempty"`
+
+	// UpdatePolicy controls how the operator moves the cluster between
+	// versions.
+	// +optional
+	UpdatePolicy *UpdatePolicy `json:"updatePolicy,omitempty"`
+
 	// SecretName points to a secret (in the same namespace) that holds secret
 	// config for the cluster like passwords, credentials, etc.
-	// If the secret is omitted, one will be generated
+	// If the secret is omitted, `<name>-spicedb-config` will be generated
+	// with a random preshared_key; a datastore_uri must be added to it for
+	// any engine other than memory.
 	// +optional
 	SecretRef string `json:"secretName,omitempty"`
 
@@ -100,6 +130,116 @@ type ClusterSpec struct {
 	Patches []Patch `json:"patches,omitempty"`
 }
 
+// ClusterAutoscaling holds the configuration for the HorizontalPodAutoscaler
+// that is generated for a cluster.
+type ClusterAutoscaling struct {
+	// MinReplicas is the lower limit for the number of replicas.
+	// Defaults to 1.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+
+	// MaxReplicas is the upper limit for the number of replicas.
+	// +kubebuilder:validation:Minimum=1
+	MaxReplicas int32 `json:"maxReplicas"`
+
+	// TargetCPUUtilizationPercentage is the target average CPU utilization
+	// of the SpiceDB pods, as a percentage of their requested CPU.
+	// If neither this nor `metrics` are set, the autoscaler targets 80%.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	TargetCPUUtilizationPercentage *int32 `json:"targetCPUUtilizationPercentage,omitempty"`
+
+	// Metrics are additional metric targets (i.e. custom or external
+	// metrics) that are passed to the HorizontalPodAutoscaler as-is.
+	// +optional
+	Metrics []autoscalingv2.MetricSpec `json:"metrics,omitempty"`
+}
+
+// UpdateApproval is how update steps that run migrations are approved.
+// +kubebuilder:validation:Enum=Automatic;Manual
+type UpdateApproval string
+
+const (
+	UpdateApprovalAutomatic UpdateApproval = "Automatic"
+	UpdateApprovalManual    UpdateApproval = "Manual"
+)
+
+// UpdatePolicy holds the rules the operator follows when updating a cluster.
+type UpdatePolicy struct {
+	// Approval is either `Automatic` (the default) or `Manual`. With manual
+	// approval, the operator waits before running the migration job of each
+	// update step until the cluster is annotated with
+	// `authzed.com/approved-migration` set to the `status.targetMigrationHash`
+	// of that step.
+	// +optional
+	Approval UpdateApproval `json:"approval,omitempty"`
+
+	// MaintenanceWindows limit when a cluster that follows the head of its
+	// channel (no `version` set) starts moving to a new version. Rollouts
+	// that have already started are finished, and config changes are
+	// applied right away. If empty, updates can start at any time.
+	// +optional
+	MaintenanceWindows []MaintenanceWindow `json:"maintenanceWindows,omitempty"`
+
+	// BlackoutDates are days (`YYYY-MM-DD`) on which no update starts, even
+	// inside a maintenance window.
+	// +optional
+	BlackoutDates []string `json:"blackoutDates,omitempty"`
+
+	// TimeZone is the IANA name of the time zone that maintenance windows
+	// and blackout dates are in, i.e. `Europe/Berlin`. Defaults to UTC.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// MinimumSoak is how long a release has to be out before the cluster is
+	// updated to it, or suggests it in `status.availableVersions`. It
+	// overrides the `minimumSoak` of the channel, and doesn't apply to a
+	// release that's requested explicitly with `version`.
+	// +optional
+	MinimumSoak *metav1.Duration `json:"minimumSoak,omitempty"`
+
+	// Rollback, if set, returns the cluster to the version it ran before
+	// when the rollout of a new version fails. Steps that ran a migration
+	// are never rolled back. The cluster then stays on the previous version
+	// until `version` is set or a different release comes next in the
+	// channel.
+	// +optional
+	Rollback *RollbackPolicy `json:"rollback,omitempty"`
+}
+
+// DefaultRollbackFailedAttempts is the number of failed attempts after which
+// a rollout is rolled back if the RollbackPolicy sets neither limit.
+const DefaultRollbackFailedAttempts = 3
+
+// RollbackPolicy decides when the rollout of a new version has failed.
+type RollbackPolicy struct {
+	// MaxFailedAttempts is how many times a SpiceDB container of the new
+	// version can restart before the rollout has failed.
+	// +optional
+	MaxFailedAttempts int32 `json:"maxFailedAttempts,omitempty"`
+
+	// Timeout is how long the new version has to become available before
+	// the rollout has failed.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// MaintenanceWindow is a recurring period in which updates can start.
+type MaintenanceWindow struct {
+	// Schedule is a standard cron expression for the start of the window,
+	// i.e. `0 2 * * SAT` for 2am every Saturday.
+	Schedule string `json:"schedule"`
+
+	// Duration is how long the window stays open after it starts, i.e. `4h`.
+	Duration metav1.Duration `json:"duration"`
+}
+
+// RequiresApproval returns true if migrations wait for manual approval.
+func (p *UpdatePolicy) RequiresApproval() bool {
+	return p != nil && p.Approval == UpdateApprovalManual
+}
+
 // Patch represents a single change to apply to generated manifests
 type Patch struct {
 	// Kind targets an object by its kubernetes Kind name.
@@ -134,6 +274,23 @@ type ClusterStatus struct {
 	// SecretHash is a digest of the last applied secret
 	SecretHash string `json:"secretHash,omitempty"`
 
+	// SecretName is the name of the generated config secret, if
+	// spec.secretName is omitted.
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+
+	// ReferencedSecrets are the TLS, CA and credential secrets named in the
+	// config, with a digest of their current contents. The digests are part
+	// of the deployment's secret hash, so a change to any of them rolls the
+	// pods.
+	// +optional
+	ReferencedSecrets []ReferencedSecret `json:"referencedSecrets,omitempty"`
+
+	// ConnectionSecret is the secret that the operator publishes with the
+	// connection details for the cluster, if `connectionSecretName` is set.
+	// +optional
+	ConnectionSecret *ConnectionSecretReference `json:"connectionSecret,omitempty"`
+
 	// Image is the image that is or will be used for this cluster
 	Image string `json:"image,omitempty"`
 
@@ -151,6 +308,72 @@ type ClusterStatus struct {
 	// version can be updated to. Only applies if using an update channel.
 	AvailableVersions []SpiceDBVersion `json:"availableVersions,omitempty"`
 
+	// UpgradePlan lists the steps the operator will take after the current
+	// version to reach `spec.version`, or the head of the channel if no
+	// version is set. Only applies if using an update channel.
+	// +optional
+	UpgradePlan []UpgradeStep `json:"upgradePlan,omitempty"`
+
+	// UpdateGraphHash identifies the update graph that the status was last
+	// computed from. It is only set if the operator has a fleet rollout
+	// policy, which uses it to tell whether a cluster has caught up with the
+	// current graph.
+	// +optional
+	UpdateGraphHash string `json:"updateGraphHash,omitempty"`
+
+	// Replicas is the number of SpiceDB pods that currently exist.
+	// +optional
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// DesiredReplicas is the number of SpiceDB pods the deployment is scaling
+	// to, which is chosen by the autoscaler if autoscaling is enabled.
+	// +optional
+	DesiredReplicas int32 `json:"desiredReplicas,omitempty"`
+
+	// UpdatedReplicas is the number of SpiceDB pods running the current
+	// deployment.
+	// +optional
+	UpdatedReplicas int32 `json:"updatedReplicas,omitempty"`
+
+	// ReadyReplicas is the number of SpiceDB pods that are ready.
+	// +optional
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+
+	// AvailableReplicas is the number of SpiceDB pods that are available to
+	// serve requests.
+	// +optional
+	AvailableReplicas int32 `json:"availableReplicas,omitempty"`
+
+	// ReadyEndpoints is the number of ready endpoints behind the SpiceDB
+	// Service.
+	// +optional
+	ReadyEndpoints int32 `json:"readyEndpoints,omitempty"`
+
+	// Rollout describes the most recent rollout of the SpiceDB deployment.
+	// +optional
+	Rollout *RolloutStatus `json:"rollout,omitempty"`
+
+	// DeploymentSlot is the slot of the SpiceDB deployment that the Service
+	// sends requests to. It's empty for the original deployment, and switches
+	// between `blue` and `green` with each blue/green rollout.
+	// +optional
+	DeploymentSlot string `json:"deploymentSlot,omitempty"`
+
+	// Selector is the label selector for SpiceDB pods, used by the `/scale`
+	// subresource.
+	// +optional
+	Selector string `json:"selector,omitempty"`
+
+	// Endpoints are the external addresses for SpiceDB, once the generated
+	// Ingress or Gateway API routes have been admitted.
+	// +optional
+	Endpoints []ClusterEndpoint `json:"endpoints,omitempty"`
+
+	// History lists the most recent update steps the cluster completed or
+	// failed, oldest first. At most MaxHistoryEntries are kept.
+	// +optional
+	History []HistoryEntry `json:"history,omitempty"`
+
 	// Conditions for the current state of the Stack.
 	// +optional
 	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
@@ -162,13 +385,31 @@ func (s ClusterStatus) Equals(other ClusterStatus) bool {
 		s.TargetMigrationHash == other.TargetMigrationHash &&
 		s.CurrentMigrationHash == other.TargetMigrationHash &&
 		s.SecretHash == other.SecretHash &&
+		s.SecretName == other.SecretName &&
+		slices.Equal(s.ReferencedSecrets, other.ReferencedSecrets) &&
+		ptr.Equal(s.ConnectionSecret, other.ConnectionSecret) &&
 		s.Image == other.Image &&
 		s.Migration == other.Migration &&
 		s.Phase == other.Phase &&
+		s.Replicas == other.Replicas &&
+		s.DesiredReplicas == other.DesiredReplicas &&
+		s.UpdatedReplicas == other.UpdatedReplicas &&
+		s.ReadyReplicas == other.ReadyReplicas &&
+		s.AvailableReplicas == other.AvailableReplicas &&
+		s.ReadyEndpoints == other.ReadyEndpoints &&
+		s.Rollout.Equals(other.Rollout) &&
+		s.DeploymentSlot == other.DeploymentSlot &&
+		slices.EqualFunc(s.History, other.History, func(a, b HistoryEntry) bool {
+			return a.Equals(&b)
+		}) &&
+		s.Selector == other.Selector &&
+		slices.Equal(s.Endpoints, other.Endpoints) &&
 		s.CurrentVersion.Equals(other.CurrentVersion) &&
 		slices.EqualFunc(s.AvailableVersions, other.AvailableVersions, func(a, b SpiceDBVersion) bool {
 			return a.Equals(&b)
 		}) &&
+		slices.Equal(s.UpgradePlan, other.UpgradePlan) &&
+		s.UpdateGraphHash == other.UpdateGraphHash &&
 		slices.Equal(s.Conditions, other.Conditions):
 		return true
 	default:
@@ -176,6 +417,180 @@ func (s ClusterStatus) Equals(other ClusterStatus) bool {
 	}
 }
 
+// ClusterEndpoint is an externally reachable address for SpiceDB.
+type ClusterEndpoint struct {
+	// Name is the service port the endpoint routes to (grpc or gateway).
+	Name string `json:"name"`
+
+	// Address is the host and port that clients should connect to.
+	Address string `json:"address"`
+}
+
+// ReferencedSecret is a secret that is mounted into the SpiceDB pods.
+type ReferencedSecret struct {
+	// Name is the name of the secret.
+	Name string `json:"name"`
+
+	// Hash is a digest of the secret's current data, recorded before any
+	// roll that a change to it causes.
+	Hash string `json:"hash"`
+}
+
+// RolloutStatus describes a rollout of the SpiceDB deployment.
+type RolloutStatus struct {
+	// Image is the SpiceDB image being rolled out.
+	Image string `json:"image"`
+
+	// PreviousImage is the image that was running before the rollout
+	// started.
+	// +optional
+	PreviousImage string `json:"previousImage,omitempty"`
+
+	// StartTime is when the rollout started.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is when every pod was updated and available. It's unset
+	// while the rollout is in progress.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Slot is the deployment slot that a blue/green rollout brings up next to
+	// the serving deployment. It's empty for rolling updates.
+	// +optional
+	Slot string `json:"slot,omitempty"`
+}
+
+const (
+	DeploymentSlotBlue  = "blue"
+	DeploymentSlotGreen = "green"
+)
+
+// NextDeploymentSlot returns the slot that a blue/green rollout brings up
+// when the deployment in `serving` is serving requests.
+func NextDeploymentSlot(serving string) string {
+	if serving == DeploymentSlotBlue {
+		return DeploymentSlotGreen
+	}
+	return DeploymentSlotBlue
+}
+
+func (r *RolloutStatus) Equals(other *RolloutStatus) bool {
+	if r == other {
+		return true
+	}
+	if r != nil && other != nil && r.Image == other.Image && r.PreviousImage == other.PreviousImage &&
+		r.StartTime.Equal(other.StartTime) && r.CompletionTime.Equal(other.CompletionTime) && r.Slot == other.Slot {
+		return true
+	}
+	return false
+}
+
+// MaxHistoryEntries is the number of entries kept in status.history.
+const MaxHistoryEntries = 10
+
+type HistoryOutcome string
+
+const (
+	HistoryOutcomeSucceeded HistoryOutcome = "Succeeded"
+	HistoryOutcomeFailed    HistoryOutcome = "Failed"
+)
+
+// HistoryEntry records one step of an update: a migration (if there was
+// one) and the rollout that followed it.
+type HistoryEntry struct {
+	// FromVersion is the version that was running before the step.
+	// +optional
+	FromVersion string `json:"fromVersion,omitempty"`
+
+	// ToVersion is the version the step updated to.
+	// +optional
+	ToVersion string `json:"toVersion,omitempty"`
+
+	// Channel is the update channel that ToVersion was selected from.
+	// +optional
+	Channel string `json:"channel,omitempty"`
+
+	// Image is the SpiceDB image the step updated to.
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Migration is the name of the migration that was run.
+	// +optional
+	Migration string `json:"migration,omitempty"`
+
+	// Phase is the migration phase that was run.
+	// +optional
+	Phase string `json:"phase,omitempty"`
+
+	// MigrationJob is the name of the job that ran the migration, if one
+	// was needed.
+	// +optional
+	MigrationJob string `json:"migrationJob,omitempty"`
+
+	// StartTime is when the migration job or rollout started.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// EndTime is when the rollout completed or the step failed.
+	// +optional
+	EndTime *metav1.Time `json:"endTime,omitempty"`
+
+	// Outcome is Succeeded or Failed.
+	Outcome HistoryOutcome `json:"outcome"`
+}
+
+func (h *HistoryEntry) Equals(other *HistoryEntry) bool {
+	if h == other {
+		return true
+	}
+	if h != nil && other != nil && h.FromVersion == other.FromVersion && h.ToVersion == other.ToVersion &&
+		h.Channel == other.Channel && h.Image == other.Image && h.Migration == other.Migration &&
+		h.Phase == other.Phase && h.MigrationJob == other.MigrationJob && h.Outcome == other.Outcome &&
+		h.StartTime.Equal(other.StartTime) && h.EndTime.Equal(other.EndTime) {
+		return true
+	}
+	return false
+}
+
+// AppendHistory adds an entry to status.history, dropping the oldest entries
+// beyond MaxHistoryEntries.
+func (s *ClusterStatus) AppendHistory(entry HistoryEntry) {
+	s.History = append(s.History, entry)
+	if len(s.History) > MaxHistoryEntries {
+		s.History = slices.Clone(s.History[len(s.History)-MaxHistoryEntries:])
+	}
+}
+
+// LastSucceededHistory returns the most recent step that succeeded, or nil if
+// none are recorded.
+func (s *ClusterStatus) LastSucceededHistory() *HistoryEntry {
+	for i := len(s.History) - 1; i >= 0; i-- {
+		if s.History[i].Outcome == HistoryOutcomeSucceeded {
+			return &s.History[i]
+		}
+	}
+	return nil
+}
+
+// LastFailedHistory returns the most recent step that failed, or nil if none
+// are recorded.
+func (s *ClusterStatus) LastFailedHistory() *HistoryEntry {
+	for i := len(s.History) - 1; i >= 0; i-- {
+		if s.History[i].Outcome == HistoryOutcomeFailed {
+			return &s.History[i]
+		}
+	}
+	return nil
+}
+
+// ConnectionSecretReference locates the published connection secret, which
+// may be in another namespace.
+type ConnectionSecretReference struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
 type SpiceDBVersionAttributes string
 
 var (
@@ -186,6 +601,33 @@ var (
 	SpiceDBVersionAttributesNotInChannel         SpiceDBVersionAttributes = "notInDesiredChannel"
 )
 
+// UpgradeStep is one step of an upgrade plan.
+type UpgradeStep struct {
+	// Version is the version the step updates to.
+	Version string `json:"version"`
+
+	// Channel is the channel the version is in.
+	Channel string `json:"channel"`
+
+	// Migration is the migration that the version runs with.
+	// +optional
+	Migration string `json:"migration,omitempty"`
+
+	// Phase is the migration phase that the version runs with.
+	// +optional
+	Phase string `json:"phase,omitempty"`
+
+	// RequiresMigrationJob is true if a migration job runs before the
+	// rollout.
+	// +optional
+	RequiresMigrationJob bool `json:"requiresMigrationJob,omitempty"`
+
+	// IncompatibleDispatch is true if the version can't dispatch to the one
+	// before it, so the step is rolled out blue/green.
+	// +optional
+	IncompatibleDispatch bool `json:"incompatibleDispatch,omitempty"`
+}
+
 type SpiceDBVersion struct {
 	// Name is the identifier for this version
 	Name string `json:"name"`
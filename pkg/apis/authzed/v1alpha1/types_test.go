@@ -0,0 +1,23 @@
+package v1alpha1
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendHistory(t *testing.T) {
+	var status ClusterStatus
+	require.Nil(t, status.LastSucceededHistory())
+
+	for i := range MaxHistoryEntries + 2 {
+		status.AppendHistory(HistoryEntry{ToVersion: strconv.Itoa(i), Outcome: HistoryOutcomeSucceeded})
+	}
+	status.AppendHistory(HistoryEntry{ToVersion: "failed", Outcome: HistoryOutcomeFailed})
+
+	require.Len(t, status.History, MaxHistoryEntries)
+	require.Equal(t, "3", status.History[0].ToVersion)
+	require.Equal(t, "failed", status.History[MaxHistoryEntries-1].ToVersion)
+	require.Equal(t, strconv.Itoa(MaxHistoryEntries+1), status.LastSucceededHistory().ToVersion)
+}
@@ -0,0 +1,220 @@
+package v1beta1
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+)
+
+const passthroughKey = "passthrough"
+
+// configFields maps the json name of each typed config field to its index in
+// ClusterConfig. Any v1alpha1 config key that isn't in this map is stored in
+// the passthrough map when converting.
+var configFields = func() map[string]int {
+	fields := make(map[string]int)
+	t := reflect.TypeOf(ClusterConfig{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == passthroughKey {
+			continue
+		}
+		fields[name] = i
+	}
+	return fields
+}()
+
+// ConvertFrom populates the cluster from a v1alpha1 SpiceDBCluster.
+func (c *SpiceDBCluster) ConvertFrom(in *v1alpha1.SpiceDBCluster) error {
+	c.TypeMeta = in.TypeMeta
+	c.APIVersion = SchemeGroupVersion.String()
+	in.ObjectMeta.DeepCopyInto(&c.ObjectMeta)
+	in.Status.DeepCopyInto(&c.Status)
+
+	c.Spec = ClusterSpec{
+		Version:      in.Spec.Version,
+		Channel:      in.Spec.Channel,
+		SecretRef:    in.Spec.SecretRef,
+		Replicas:     copyInt32(in.Spec.Replicas),
+		Autoscaling:  in.Spec.Autoscaling.DeepCopy(),
+		UpdatePolicy: in.Spec.UpdatePolicy.DeepCopy(),
+	}
+	for _, p := range in.Spec.Patches {
+		c.Spec.Patches = append(c.Spec.Patches, Patch{
+			Kind:  p.Kind,
+			Patch: append(json.RawMessage(nil), p.Patch...),
+		})
+	}
+
+	config, err := ConfigFromRaw(in.Spec.Config)
+	if err != nil {
+		return err
+	}
+	c.Spec.Config = config
+	return nil
+}
+
+// ConvertTo populates a v1alpha1 SpiceDBCluster from the cluster.
+func (c *SpiceDBCluster) ConvertTo(out *v1alpha1.SpiceDBCluster) error {
+	out.TypeMeta = c.TypeMeta
+	out.APIVersion = v1alpha1.SchemeGroupVersion.String()
+	c.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	c.Status.DeepCopyInto(&out.Status)
+
+	out.Spec = v1alpha1.ClusterSpec{
+		Version:      c.Spec.Version,
+		Channel:      c.Spec.Channel,
+		SecretRef:    c.Spec.SecretRef,
+		Replicas:     copyInt32(c.Spec.Replicas),
+		Autoscaling:  c.Spec.Autoscaling.DeepCopy(),
+		UpdatePolicy: c.Spec.UpdatePolicy.DeepCopy(),
+	}
+	for _, p := range c.Spec.Patches {
+		out.Spec.Patches = append(out.Spec.Patches, v1alpha1.Patch{
+			Kind:  p.Kind,
+			Patch: append(json.RawMessage(nil), p.Patch...),
+		})
+	}
+
+	raw, err := c.Spec.Config.ToRaw()
+	if err != nil {
+		return err
+	}
+	out.Spec.Config = raw
+	return nil
+}
+
+// ConfigFromRaw converts a schemaless v1alpha1 config into a ClusterConfig.
+// Values are only set on a typed field if they're already in its typed form,
+// and empty values are never set, since they'd be dropped when converting
+// back. Every other key is stored in the passthrough map with its original
+// JSON, so that the config survives a round trip unchanged.
+func ConfigFromRaw(raw json.RawMessage) (ClusterConfig, error) {
+	var out ClusterConfig
+	if len(raw) == 0 {
+		return out, nil
+	}
+
+	values := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return out, fmt.Errorf("couldn't parse config: %w", err)
+	}
+
+	typed := reflect.ValueOf(&out).Elem()
+	for k, v := range values {
+		i, ok := configFields[k]
+		if !ok || !setConfigField(typed.Field(i), v) {
+			if out.Passthrough == nil {
+				out.Passthrough = make(map[string]apiextensionsv1.JSON)
+			}
+			out.Passthrough[k] = apiextensionsv1.JSON{Raw: append([]byte(nil), v...)}
+		}
+	}
+	return out, nil
+}
+
+// ToRaw converts a ClusterConfig into a schemaless v1alpha1 config. It
+// returns an error if a passthrough key is also set as a typed field.
+func (c ClusterConfig) ToRaw() (json.RawMessage, error) {
+	if reflect.ValueOf(c).IsZero() {
+		return nil, nil
+	}
+
+	typed := c
+	typed.Passthrough = nil
+	encoded, err := json.Marshal(typed)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(encoded, &values); err != nil {
+		return nil, err
+	}
+	for k, v := range c.Passthrough {
+		if _, ok := values[k]; ok {
+			return nil, fmt.Errorf("config key %q is set both as a typed field and in passthrough", k)
+		}
+		values[k] = v.Raw
+	}
+	return json.Marshal(values)
+}
+
+// setConfigField sets a typed config field from the JSON of a value,
+// returning false if the value isn't in the field's typed form or is empty.
+func setConfigField(field reflect.Value, raw json.RawMessage) bool {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var v any
+	if err := decoder.Decode(&v); err != nil {
+		return false
+	}
+
+	switch field.Interface().(type) {
+	case string:
+		s, ok := v.(string)
+		if !ok || len(s) == 0 {
+			return false
+		}
+		field.SetString(s)
+	case *bool:
+		b, ok := v.(bool)
+		if !ok {
+			return false
+		}
+		field.Set(reflect.ValueOf(&b))
+	case *int32:
+		n, ok := v.(json.Number)
+		if !ok {
+			return false
+		}
+		parsed, err := strconv.ParseInt(n.String(), 10, 32)
+		if err != nil {
+			return false
+		}
+		i := int32(parsed)
+		field.Set(reflect.ValueOf(&i))
+	case map[string]string:
+		m, ok := metadataMap(v)
+		if !ok {
+			return false
+		}
+		field.Set(reflect.ValueOf(m))
+	default:
+		return false
+	}
+	return true
+}
+
+// metadataMap parses labels or annotations written as a map of strings. The
+// `k=v,k2=v2` form accepted by the v1alpha1 API is passed through instead.
+func metadataMap(v any) (map[string]string, bool) {
+	value, ok := v.(map[string]any)
+	if !ok || len(value) == 0 {
+		return nil, false
+	}
+	out := make(map[string]string, len(value))
+	for k, v := range value {
+		s, ok := v.(string)
+		if !ok {
+			return nil, false
+		}
+		out[k] = s
+	}
+	return out, true
+}
+
+func copyInt32(in *int32) *int32 {
+	if in == nil {
+		return nil
+	}
+	out := *in
+	return &out
+}
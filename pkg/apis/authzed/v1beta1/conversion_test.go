@@ -0,0 +1,189 @@
+package v1beta1
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+)
+
+func TestConfigFromRaw(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want ClusterConfig
+	}{
+		{
+			name: "empty",
+			raw:  "",
+			want: ClusterConfig{},
+		},
+		{
+			name: "typed values",
+			raw: `{
+				"datastoreEngine": "cockroachdb",
+				"replicas": 3,
+				"dispatchEnabled": false,
+				"tlsSecretName": "tls",
+				"extraPodLabels": {"a": "b"}
+			}`,
+			want: ClusterConfig{
+				DatastoreEngine: "cockroachdb",
+				Replicas:        ptr.To[int32](3),
+				DispatchEnabled: ptr.To(false),
+				TLSSecretName:   "tls",
+				ExtraPodLabels:  map[string]string{"a": "b"},
+			},
+		},
+		{
+			name: "values that aren't in their typed form are passed through",
+			raw: `{
+				"datastoreEngine": "postgres",
+				"replicas": "3",
+				"skipMigrations": "true",
+				"extraPodAnnotations": "a=b,c=d",
+				"extraPodLabels": {},
+				"logLevel": ""
+			}`,
+			want: ClusterConfig{
+				DatastoreEngine: "postgres",
+				Passthrough: map[string]apiextensionsv1.JSON{
+					"replicas":            {Raw: []byte(`"3"`)},
+					"skipMigrations":      {Raw: []byte(`"true"`)},
+					"extraPodAnnotations": {Raw: []byte(`"a=b,c=d"`)},
+					"extraPodLabels":      {Raw: []byte(`{}`)},
+					"logLevel":            {Raw: []byte(`""`)},
+				},
+			},
+		},
+		{
+			name: "unknown values keep their json",
+			raw: `{
+				"datastoreEngine": "postgres",
+				"datastoreConnPoolReadMaxOpen": "10",
+				"datastoreGCWindow": 5,
+				"telemetryEnabled": false
+			}`,
+			want: ClusterConfig{
+				DatastoreEngine: "postgres",
+				Passthrough: map[string]apiextensionsv1.JSON{
+					"datastoreConnPoolReadMaxOpen": {Raw: []byte(`"10"`)},
+					"datastoreGCWindow":            {Raw: []byte(`5`)},
+					"telemetryEnabled":             {Raw: []byte(`false`)},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ConfigFromRaw(json.RawMessage(tt.raw))
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestConfigToRaw(t *testing.T) {
+	raw, err := ClusterConfig{}.ToRaw()
+	require.NoError(t, err)
+	require.Nil(t, raw)
+
+	raw, err = ClusterConfig{
+		DatastoreEngine: "cockroachdb",
+		Replicas:        ptr.To[int32](0),
+		ExtraPodLabels:  map[string]string{"a": "b"},
+		Passthrough: map[string]apiextensionsv1.JSON{
+			"datastoreGCWindow":  {Raw: []byte(`"5m"`)},
+			"datastoreMaxTxWait": {Raw: []byte(`5`)},
+		},
+	}.ToRaw()
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"datastoreEngine": "cockroachdb",
+		"replicas": 0,
+		"extraPodLabels": {"a": "b"},
+		"datastoreGCWindow": "5m",
+		"datastoreMaxTxWait": 5
+	}`, string(raw))
+
+	_, err = ClusterConfig{
+		DatastoreEngine: "cockroachdb",
+		Passthrough:     map[string]apiextensionsv1.JSON{"datastoreEngine": {Raw: []byte(`"postgres"`)}},
+	}.ToRaw()
+	require.ErrorContains(t, err, `config key "datastoreEngine" is set both as a typed field and in passthrough`)
+}
+
+func TestConfigRoundTrip(t *testing.T) {
+	for _, raw := range []string{
+		`{"datastoreEngine": "postgres", "replicas": 2}`,
+		`{"datastoreEngine": "postgres", "replicas": "2"}`,
+		`{"datastoreEngine": "postgres", "skipMigrations": "true", "dispatchEnabled": false}`,
+		`{"datastoreEngine": "postgres", "datastoreConnPoolReadMaxOpen": 10, "datastoreGCWindow": "2"}`,
+		`{"datastoreEngine": "postgres", "telemetryEnabled": true, "grpcMaxWorkers": 1.5, "extra": null}`,
+		`{"datastoreEngine": "postgres", "extraPodLabels": "a=b", "extraPodAnnotations": {"c": "d"}, "logLevel": ""}`,
+	} {
+		config, err := ConfigFromRaw(json.RawMessage(raw))
+		require.NoError(t, err)
+		back, err := config.ToRaw()
+		require.NoError(t, err)
+		require.JSONEq(t, raw, string(back))
+	}
+}
+
+func TestConversionRoundTrip(t *testing.T) {
+	alpha := &v1alpha1.SpiceDBCluster{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       v1alpha1.SpiceDBClusterKind,
+			APIVersion: v1alpha1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"},
+		Spec: v1alpha1.ClusterSpec{
+			Version:   "v1.2.3",
+			Channel:   "stable",
+			SecretRef: "secret",
+			Replicas:  ptr.To[int32](4),
+			Autoscaling: &v1alpha1.ClusterAutoscaling{
+				MinReplicas: ptr.To[int32](2),
+				MaxReplicas: 5,
+			},
+			UpdatePolicy: &v1alpha1.UpdatePolicy{Approval: v1alpha1.UpdateApprovalManual},
+			Config: json.RawMessage(`{
+				"datastoreEngine": "cockroachdb",
+				"replicas": 3,
+				"logLevel": "debug",
+				"datastoreGCWindow": "5m"
+			}`),
+			Patches: []v1alpha1.Patch{{
+				Kind:  "Deployment",
+				Patch: json.RawMessage(`{"metadata":{"labels":{"a":"b"}}}`),
+			}},
+		},
+		Status: v1alpha1.ClusterStatus{
+			Image: "image:v1.2.3",
+			CurrentVersion: &v1alpha1.SpiceDBVersion{
+				Name:    "v1.2.3",
+				Channel: "stable",
+			},
+		},
+	}
+
+	var beta SpiceDBCluster
+	require.NoError(t, beta.ConvertFrom(alpha))
+	require.Equal(t, SchemeGroupVersion.String(), beta.APIVersion)
+	require.Equal(t, "cockroachdb", beta.Spec.Config.DatastoreEngine)
+	require.Equal(t, ptr.To[int32](3), beta.Spec.Config.Replicas)
+	require.Equal(t, "debug", beta.Spec.Config.LogLevel)
+	require.Equal(t, map[string]apiextensionsv1.JSON{"datastoreGCWindow": {Raw: []byte(`"5m"`)}}, beta.Spec.Config.Passthrough)
+	require.Equal(t, alpha.Status, beta.Status)
+
+	var back v1alpha1.SpiceDBCluster
+	require.NoError(t, beta.ConvertTo(&back))
+	require.JSONEq(t, string(alpha.Spec.Config), string(back.Spec.Config))
+	back.Spec.Config = alpha.Spec.Config
+	require.Equal(t, alpha, &back)
+}
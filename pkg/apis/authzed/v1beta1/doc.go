@@ -0,0 +1,3 @@
+// +k8s:deepcopy-gen=package,register
+// +groupName=authzed.com
+package v1beta1
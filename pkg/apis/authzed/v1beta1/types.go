@@ -0,0 +1,389 @@
+package v1beta1
+
+import (
+	"encoding/json"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+)
+
+const (
+	SpiceDBClusterResourceName = "spicedbclusters"
+	SpiceDBClusterKind         = "SpiceDBCluster"
+)
+
+// SpiceDBCluster defines all options for a full SpiceDB cluster
+//
+// +crd
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:unservedversion
+// +kubebuilder:subresource:status
+// +kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.replicas,selectorpath=.status.selector
+// +kubebuilder:resource:categories=authzed,shortName=spicedbs
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=".status.conditions[?(@.type=='Ready')].status"
+// +kubebuilder:printcolumn:name="Reason",type=string,priority=1,JSONPath=".status.conditions[?(@.type=='Ready')].reason"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:printcolumn:name="Channel",type=string,JSONPath=".spec.channel"
+// +kubebuilder:printcolumn:name="Desired",type=string,JSONPath=".spec.version"
+// +kubebuilder:printcolumn:name="Current",type=string,JSONPath=".status.version.name"
+// +kubebuilder:printcolumn:name="Warnings",type=string,JSONPath=".status.conditions[?(@.type=='ConfigurationWarning')].status"
+// +kubebuilder:printcolumn:name="Progressing",type=string,JSONPath=".status.conditions[?(@.type=='Progressing')].status"
+// +kubebuilder:printcolumn:name="Degraded",type=string,JSONPath=".status.conditions[?(@.type=='Degraded')].status"
+// +kubebuilder:printcolumn:name="Paused",type=string,JSONPath=".status.conditions[?(@.type=='Paused')].status"
+// +kubebuilder:printcolumn:name="Desired Replicas",type=integer,priority=1,JSONPath=".status.desiredReplicas"
+// +kubebuilder:printcolumn:name="Ready Replicas",type=integer,priority=1,JSONPath=".status.readyReplicas"
+// +kubebuilder:printcolumn:name="Available Replicas",type=integer,priority=1,JSONPath=".status.availableReplicas"
+// +kubebuilder:printcolumn:name="Ready Endpoints",type=integer,priority=1,JSONPath=".status.readyEndpoints"
+// +kubebuilder:printcolumn:name="Image",type=string,priority=1,JSONPath=".status.rollout.image"
+type SpiceDBCluster struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +optional
+	Spec ClusterSpec `json:"spec,omitempty"`
+
+	// Status is written by the operator and is identical across API versions.
+	// +optional
+	Status v1alpha1.ClusterStatus `json:"status,omitempty"`
+}
+
+// ClusterSpec holds the desired state of the cluster.
+type ClusterSpec struct {
+	// Version is the name of the version of SpiceDB that will be run.
+	// The version is usually a simple version string like `v1.13.0`, but the
+	// operator is configured with a data source that tells it what versions
+	// are allowed, and they may have other names.
+	// If omitted, the newest version in the head of the channel will be used.
+	// Note that the `config.image` field will take precedence over
+	// version/channel, if it is specified
+	Version string `json:"version,omitempty"`
+
+	// Channel is a defined series of updates that operator should follow.
+	// The operator is configured with a datasource that configures available
+	// channels and update paths.
+	// If `version` is not specified, then the operator will keep SpiceDB
+	// up-to-date with the current head of the channel.
+	// If `version` is specified, then the operator will write available updates
+	// in the status.
+	Channel string `json:"channel,omitempty"`
+
+	// Config values to be passed to the cluster
+	// +optional
+	Config ClusterConfig `json:"config,omitempty"`
+
+	// Replicas is the number of SpiceDB pods to run. It takes precedence over
+	// `config.replicas` and is the field modified by the `/scale`
+	// subresource (i.e. `kubectl scale`).
+	// It is ignored if `autoscaling` is set.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Autoscaling configures a HorizontalPodAutoscaler for the SpiceDB
+	// deployment. When set, the autoscaler owns the replica count.
+	// +optional
+	Autoscaling *v1alpha1.ClusterAutoscaling `json:"autoscaling,omitempty"`
+
+	// UpdatePolicy controls how the operator moves the cluster between
+	// versions.
+	// +optional
+	UpdatePolicy *v1alpha1.UpdatePolicy `json:"updatePolicy,omitempty"`
+
+	// SecretName points to a secret (in the same namespace) that holds secret
+	// config for the cluster like passwords, credentials, etc.
+	// If the secret is omitted, `<name>-spicedb-config` will be generated
+	// with a random preshared_key; a datastore_uri must be added to it for
+	// any engine other than memory.
+	// +optional
+	SecretRef string `json:"secretName,omitempty"`
+
+	// Patches is a list of patches to apply to generated resources.
+	// If multiple patches apply to the same object and field, later patches
+	// in the list take precedence over earlier ones.
+	// +optional
+	Patches []Patch `json:"patches,omitempty"`
+}
+
+// ClusterConfig holds the typed configuration for a SpiceDB cluster.
+// The json names of the fields match the keys of the schemaless v1alpha1
+// config, so that objects can be converted between versions.
+type ClusterConfig struct {
+	// DatastoreEngine is the datastore that SpiceDB will use, i.e.
+	// `cockroachdb`, `postgres`, `mysql`, `spanner` or `memory`.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	DatastoreEngine string `json:"datastoreEngine,omitempty"`
+
+	// Image overrides the image selected from the update channel.
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Replicas is the number of SpiceDB pods to run.
+	// Defaults to 2, or 1 for the memory datastore.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// LogLevel is the log level for SpiceDB pods.
+	// +optional
+	LogLevel string `json:"logLevel,omitempty"`
+
+	// MigrationLogLevel is the log level for migration jobs.
+	// +optional
+	MigrationLogLevel string `json:"migrationLogLevel,omitempty"`
+
+	// SkipMigrations disables the migration job.
+	// +optional
+	SkipMigrations *bool `json:"skipMigrations,omitempty"`
+
+	// TargetMigration overrides the migration that is run.
+	// +optional
+	TargetMigration string `json:"targetMigration,omitempty"`
+
+	// DatastoreMigrationPhase overrides the migration phase that is run.
+	// +optional
+	DatastoreMigrationPhase string `json:"datastoreMigrationPhase,omitempty"`
+
+	// EnvPrefix is the prefix used for environment variables passed to SpiceDB.
+	// +optional
+	EnvPrefix string `json:"envPrefix,omitempty"`
+
+	// Cmd is the SpiceDB binary invoked in the container.
+	// +optional
+	Cmd string `json:"cmd,omitempty"`
+
+	// ServiceAccountName is the name of the generated service account.
+	// Defaults to the name of the cluster.
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// TLSSecretName is the name of a secret with serving TLS for SpiceDB.
+	// +optional
+	TLSSecretName string `json:"tlsSecretName,omitempty"`
+
+	// SelfSignedTLS has the operator issue a per-cluster CA and serving
+	// certificate when TLSSecretName is not set. The CA is published in the
+	// `<name>-spicedb-ca` ConfigMap.
+	// +optional
+	SelfSignedTLS *bool `json:"selfSignedTLS,omitempty"`
+
+	// TLSIssuerRef requests the serving certificate from a cert-manager
+	// issuer, written as `name`, `kind/name` or `kind.group/name`.
+	// +optional
+	TLSIssuerRef string `json:"tlsIssuerRef,omitempty"`
+
+	// DispatchIssuerRef requests a separate certificate for dispatch from a
+	// cert-manager issuer, in the same format as TLSIssuerRef.
+	// +optional
+	DispatchIssuerRef string `json:"dispatchIssuerRef,omitempty"`
+
+	// DispatchEnabled enables dispatching between SpiceDB pods.
+	// +optional
+	DispatchEnabled *bool `json:"dispatchEnabled,omitempty"`
+
+	// DispatchUpstreamCASecretName is the name of a secret with a CA used to
+	// verify dispatch connections.
+	// +optional
+	DispatchUpstreamCASecretName string `json:"dispatchUpstreamCASecretName,omitempty"`
+
+	// DispatchUpstreamCAFilePath is the key of the CA in the dispatch CA secret.
+	// +optional
+	DispatchUpstreamCAFilePath string `json:"dispatchUpstreamCAFilePath,omitempty"`
+
+	// TelemetryCASecretName is the name of a secret with a CA used for
+	// telemetry connections.
+	// +optional
+	TelemetryCASecretName string `json:"telemetryCASecretName,omitempty"`
+
+	// DatastoreTLSSecretName is the name of a secret with TLS for the
+	// datastore connection.
+	// +optional
+	DatastoreTLSSecretName string `json:"datastoreTLSSecretName,omitempty"`
+
+	// SpannerCredentials is the name of a secret with spanner credentials.
+	// +optional
+	SpannerCredentials string `json:"spannerCredentials,omitempty"`
+
+	// ProjectLabels projects pod labels into the pod via the downward API.
+	// +optional
+	ProjectLabels *bool `json:"projectLabels,omitempty"`
+
+	// ProjectAnnotations projects pod annotations into the pod via the
+	// downward API.
+	// +optional
+	ProjectAnnotations *bool `json:"projectAnnotations,omitempty"`
+
+	// ExtraPodLabels are added to SpiceDB and migration pods.
+	// +optional
+	ExtraPodLabels map[string]string `json:"extraPodLabels,omitempty"`
+
+	// ExtraPodAnnotations are added to SpiceDB and migration pods.
+	// +optional
+	ExtraPodAnnotations map[string]string `json:"extraPodAnnotations,omitempty"`
+
+	// ExtraServiceAccountAnnotations are added to the generated service account.
+	// +optional
+	ExtraServiceAccountAnnotations map[string]string `json:"extraServiceAccountAnnotations,omitempty"`
+
+	// GRPCTLSKeyPath is the path of the gRPC TLS key within the TLS secret mount.
+	// +optional
+	GRPCTLSKeyPath string `json:"grpcTLSKeyPath,omitempty"`
+
+	// GRPCTLSCertPath is the path of the gRPC TLS cert within the TLS secret mount.
+	// +optional
+	GRPCTLSCertPath string `json:"grpcTLSCertPath,omitempty"`
+
+	// DispatchClusterTLSKeyPath is the path of the dispatch TLS key within the
+	// TLS secret mount.
+	// +optional
+	DispatchClusterTLSKeyPath string `json:"dispatchClusterTLSKeyPath,omitempty"`
+
+	// DispatchClusterTLSCertPath is the path of the dispatch TLS cert within
+	// the TLS secret mount.
+	// +optional
+	DispatchClusterTLSCertPath string `json:"dispatchClusterTLSCertPath,omitempty"`
+
+	// HTTPTLSKeyPath is the path of the HTTP TLS key within the TLS secret mount.
+	// +optional
+	HTTPTLSKeyPath string `json:"httpTLSKeyPath,omitempty"`
+
+	// HTTPTLSCertPath is the path of the HTTP TLS cert within the TLS secret mount.
+	// +optional
+	HTTPTLSCertPath string `json:"httpTLSCertPath,omitempty"`
+
+	// DashboardTLSKeyPath is the path of the dashboard TLS key within the TLS
+	// secret mount.
+	// +optional
+	DashboardTLSKeyPath string `json:"dashboardTLSKeyPath,omitempty"`
+
+	// DashboardTLSCertPath is the path of the dashboard TLS cert within the
+	// TLS secret mount.
+	// +optional
+	DashboardTLSCertPath string `json:"dashboardTLSCertPath,omitempty"`
+
+	// NetworkPolicyEnabled generates a NetworkPolicy for SpiceDB pods that
+	// only allows dispatch from other SpiceDB pods in the cluster.
+	// +optional
+	NetworkPolicyEnabled *bool `json:"networkPolicyEnabled,omitempty"`
+
+	// NetworkPolicyClientNamespaces is a comma-separated list of namespaces
+	// allowed to connect to the gRPC and gateway ports. When neither this nor
+	// NetworkPolicyClientPodSelector is set, clients are allowed from anywhere.
+	// +optional
+	NetworkPolicyClientNamespaces string `json:"networkPolicyClientNamespaces,omitempty"`
+
+	// NetworkPolicyClientPodSelector is a label selector (i.e. `app=api`) for
+	// pods allowed to connect to the gRPC and gateway ports.
+	// +optional
+	NetworkPolicyClientPodSelector string `json:"networkPolicyClientPodSelector,omitempty"`
+
+	// NetworkPolicyMetricsNamespaces is a comma-separated list of namespaces
+	// allowed to scrape the metrics port. When unset, scraping is allowed from
+	// anywhere.
+	// +optional
+	NetworkPolicyMetricsNamespaces string `json:"networkPolicyMetricsNamespaces,omitempty"`
+
+	// PrometheusMonitor is the kind of prometheus operator monitor to create
+	// for the metrics port, either `ServiceMonitor` or `PodMonitor`.
+	// +optional
+	// +kubebuilder:validation:Enum=ServiceMonitor;PodMonitor
+	PrometheusMonitor string `json:"prometheusMonitor,omitempty"`
+
+	// PrometheusRules creates a PrometheusRule with default alerts for the
+	// cluster.
+	// +optional
+	PrometheusRules *bool `json:"prometheusRules,omitempty"`
+
+	// PrometheusLabels are added to the generated prometheus operator
+	// resources, i.e. to match a Prometheus instance's selectors.
+	// +optional
+	PrometheusLabels map[string]string `json:"prometheusLabels,omitempty"`
+
+	// IngressType exposes SpiceDB outside the cluster with either an
+	// `Ingress` or Gateway API routes (`GatewayAPI`).
+	// +optional
+	// +kubebuilder:validation:Enum=Ingress;GatewayAPI
+	IngressType string `json:"ingressType,omitempty"`
+
+	// IngressHost is the hostname that routes to the gRPC API. Required when
+	// IngressType is set.
+	// +optional
+	IngressHost string `json:"ingressHost,omitempty"`
+
+	// IngressHTTPHost is the hostname that routes to the HTTP gateway.
+	// +optional
+	IngressHTTPHost string `json:"ingressHTTPHost,omitempty"`
+
+	// IngressClassName is the IngressClass for the generated Ingress.
+	// +optional
+	IngressClassName string `json:"ingressClassName,omitempty"`
+
+	// IngressAnnotations are added to the generated Ingress or routes.
+	// +optional
+	IngressAnnotations map[string]string `json:"ingressAnnotations,omitempty"`
+
+	// GatewayName is the Gateway that generated routes attach to. Required
+	// when IngressType is `GatewayAPI`.
+	// +optional
+	GatewayName string `json:"gatewayName,omitempty"`
+
+	// GatewayNamespace is the namespace of the Gateway, if it's not in the
+	// same namespace as the cluster.
+	// +optional
+	GatewayNamespace string `json:"gatewayNamespace,omitempty"`
+
+	// GatewayPort is the port of the Gateway listener that generated routes
+	// attach to, and that is published in the cluster's endpoints.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	GatewayPort int32 `json:"gatewayPort,omitempty"`
+
+	// ConnectionSecretName publishes a secret with the endpoint, gRPC port,
+	// CA and preshared key of the cluster for its clients.
+	// +optional
+	ConnectionSecretName string `json:"connectionSecretName,omitempty"`
+
+	// ConnectionSecretNamespace publishes the connection secret into another
+	// namespace, which must be allowed by the operator config.
+	// +optional
+	ConnectionSecretNamespace string `json:"connectionSecretNamespace,omitempty"`
+
+	// Passthrough holds any SpiceDB flags that don't have a typed field.
+	// Keys are camelCased flag names (i.e. `datastoreConnPoolReadMaxOpen`)
+	// and are passed to SpiceDB as environment variables. Values keep the
+	// JSON type they were written with, and v1alpha1 values of typed fields
+	// that aren't in their typed form (i.e. `replicas: "2"`) are kept here
+	// too, so that they convert back unchanged.
+	// +optional
+	Passthrough map[string]apiextensionsv1.JSON `json:"passthrough,omitempty"`
+}
+
+// Patch represents a single change to apply to generated manifests
+type Patch struct {
+	// Kind targets an object by its kubernetes Kind name.
+	// +optional
+	Kind string `json:"kind,omitempty"`
+
+	// Patch is an inlined representation of a structured merge patch (one that
+	// just specifies the structure and fields to be modified) or a an explicit
+	// JSON6902 patch operation.
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Type=object
+	Patch json.RawMessage `json:"patch"`
+}
+
+// SpiceDBClusterList is a list of SpiceDBCluster resources
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type SpiceDBClusterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []SpiceDBCluster `json:"items"`
+}
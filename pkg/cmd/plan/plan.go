@@ -0,0 +1,130 @@
+package plan
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+	"github.com/authzed/spicedb-operator/pkg/config"
+	"github.com/authzed/spicedb-operator/pkg/updates"
+)
+
+// Options contains the input to the plan command.
+type Options struct {
+	OperatorConfigPath string
+	DatastoreEngine    string
+	Channel            string
+	From               string
+	Version            string
+	Output             string
+}
+
+// NewCmdPlan creates a command object for "plan"
+func NewCmdPlan(o *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "plan --config FILE --datastore-engine ENGINE --from VERSION [--version VERSION] [flags]",
+		DisableFlagsInUseLine: true,
+		Short:                 "preview the steps taken to update a SpiceDB cluster",
+		Long: `Lists every step the operator takes to update a cluster running the --from
+version to --version (or to the head of the channel), with the migration and
+phase of each step and whether it runs a migration job. This is the same plan
+that is reported in status.upgradePlan. Without --version, releases that are
+still within the channel's minimumSoak are left out.`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			cmdutil.CheckErr(o.Validate())
+			cmdutil.CheckErr(o.Run(cmd.OutOrStdout()))
+		},
+	}
+
+	cmd.Flags().StringVar(&o.OperatorConfigPath, "config", "", "path to the operator's config file, which contains the update graph")
+	cmd.Flags().StringVar(&o.DatastoreEngine, "datastore-engine", "", "the datastore engine of the cluster")
+	cmd.Flags().StringVar(&o.Channel, "channel", "", "the update channel (defaults to the default channel for the datastore engine)")
+	cmd.Flags().StringVar(&o.From, "from", "", "the version the cluster is running (status.version.name)")
+	cmd.Flags().StringVar(&o.Version, "version", "", "the version to update to (defaults to the head of the channel)")
+	cmd.Flags().StringVarP(&o.Output, "output", "o", "table", "output format, one of: table, json")
+
+	return cmd
+}
+
+// Validate checks the set of flags provided by the user.
+func (o *Options) Validate() error {
+	var errs []error
+	if len(o.OperatorConfigPath) == 0 {
+		errs = append(errs, fmt.Errorf("--config is required"))
+	}
+	if len(o.DatastoreEngine) == 0 {
+		errs = append(errs, fmt.Errorf("--datastore-engine is required"))
+	}
+	if len(o.From) == 0 {
+		errs = append(errs, fmt.Errorf("--from is required"))
+	}
+	if o.Output != "table" && o.Output != "json" {
+		errs = append(errs, fmt.Errorf("invalid --output %q, must be one of: table, json", o.Output))
+	}
+	return errors.NewAggregate(errs)
+}
+
+// Run computes the plan and writes it to out.
+func (o *Options) Run(out io.Writer) error {
+	contents, err := os.ReadFile(o.OperatorConfigPath)
+	if err != nil {
+		return err
+	}
+	var cfg config.OperatorConfig
+	if err := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(contents), 100).Decode(&cfg); err != nil {
+		return fmt.Errorf("error reading operator config %s: %w", o.OperatorConfigPath, err)
+	}
+
+	channel := o.Channel
+	if len(channel) == 0 {
+		channel, err = cfg.DefaultChannelForDatastore(o.DatastoreEngine)
+		if err != nil {
+			return err
+		}
+	}
+
+	plan, err := cfg.UpgradePlan(o.DatastoreEngine, v1alpha1.SpiceDBVersion{Name: o.From, Channel: channel}, o.Version, updates.Soak{Now: time.Now()})
+	if err != nil {
+		return err
+	}
+	// the memory datastore never runs migrations
+	if o.DatastoreEngine == "memory" {
+		for i := range plan {
+			plan[i].RequiresMigrationJob = false
+		}
+	}
+
+	if o.Output == "json" {
+		if plan == nil {
+			plan = []v1alpha1.UpgradeStep{}
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(plan)
+	}
+
+	if len(plan) == 0 {
+		_, err := fmt.Fprintf(out, "%s is up to date in channel %q\n", o.From, channel)
+		return err
+	}
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "STEP\tVERSION\tCHANNEL\tMIGRATION\tPHASE\tMIGRATION JOB")
+	for i, step := range plan {
+		job := "no"
+		if step.RequiresMigrationJob {
+			job = "yes"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", i+1, step.Version, step.Channel, step.Migration, step.Phase, job)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,87 @@
+package plan
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testGraph = `
+channels:
+- name: stable
+  metadata:
+    datastore: postgres
+    default: "true"
+  nodes:
+  - id: v1.14.0
+    migration: b
+  - id: v1.13.1
+    migration: a
+  - id: v1.13.0
+    migration: a
+  edges:
+    v1.13.0: [v1.13.1, v1.14.0]
+    v1.13.1: [v1.14.0]
+`
+
+func TestPlan(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testGraph), 0o600))
+
+	tests := []struct {
+		name      string
+		options   Options
+		expected  string
+		expectErr string
+	}{
+		{
+			name:    "table",
+			options: Options{DatastoreEngine: "postgres", From: "v1.13.0", Output: "table"},
+			expected: "STEP  VERSION  CHANNEL  MIGRATION  PHASE  MIGRATION JOB\n" +
+				"1     v1.14.0  stable   b                 yes\n",
+		},
+		{
+			name:     "json",
+			options:  Options{DatastoreEngine: "postgres", Channel: "stable", From: "v1.13.0", Version: "v1.13.1", Output: "json"},
+			expected: "[\n  {\n    \"version\": \"v1.13.1\",\n    \"channel\": \"stable\",\n    \"migration\": \"a\"\n  }\n]\n",
+		},
+		{
+			name:     "up to date",
+			options:  Options{DatastoreEngine: "postgres", From: "v1.14.0", Output: "table"},
+			expected: "v1.14.0 is up to date in channel \"stable\"\n",
+		},
+		{
+			name:      "unknown datastore",
+			options:   Options{DatastoreEngine: "spanner", From: "v1.14.0", Output: "table"},
+			expectErr: `no channel found for datastore "spanner"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := tt.options
+			o.OperatorConfigPath = path
+			require.NoError(t, o.Validate())
+
+			var out bytes.Buffer
+			err := o.Run(&out)
+			if tt.expectErr != "" {
+				require.ErrorContains(t, err, tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, out.String())
+		})
+	}
+}
+
+func TestPlanValidate(t *testing.T) {
+	o := Options{Output: "yaml"}
+	err := o.Validate()
+	require.ErrorContains(t, err, "--config is required")
+	require.ErrorContains(t, err, "--datastore-engine is required")
+	require.ErrorContains(t, err, "--from is required")
+	require.ErrorContains(t, err, `invalid --output "yaml"`)
+}
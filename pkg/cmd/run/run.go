@@ -2,10 +2,15 @@ package run
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/errors"
 	genericapiserver "k8s.io/apiserver/pkg/server"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
@@ -13,6 +18,7 @@ import (
 	"k8s.io/client-go/kubernetes"
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	cliflag "k8s.io/component-base/cli/flag"
 	"k8s.io/component-base/cli/globalflag"
@@ -30,6 +36,7 @@ import (
 	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
 	"github.com/authzed/spicedb-operator/pkg/controller"
 	"github.com/authzed/spicedb-operator/pkg/crds"
+	"github.com/authzed/spicedb-operator/pkg/webhook"
 )
 
 var v1alpha1ClusterGVR = v1alpha1.SchemeGroupVersion.WithResource(v1alpha1.SpiceDBClusterResourceName)
@@ -43,6 +50,12 @@ type Options struct {
 	BootstrapCRDs         bool
 	BootstrapSpicedbsPath string
 	OperatorConfigPath    string
+	MigrateStoredVersions bool
+
+	WebhookAddress    string
+	WebhookCertDir    string
+	WebhookService    string
+	WebhookDefaulting bool
 
 	MetricNamespace string
 
@@ -78,6 +91,12 @@ func NewCmdRun(o *Options) *cobra.Command {
 	bootstrapFlags := namedFlagSets.FlagSet("bootstrap")
 	bootstrapFlags.BoolVar(&o.BootstrapCRDs, "crd", true, "if set, the operator will attempt to install/update the CRDs before starting up.")
 	bootstrapFlags.StringVar(&o.BootstrapSpicedbsPath, "bootstrap-spicedbs", "", "set a path to a config file for spicedbs to load on start up.")
+	bootstrapFlags.BoolVar(&o.MigrateStoredVersions, "migrate-stored-versions", false, "if set, the operator will rewrite all SpiceDBClusters in the CRD's storage version before starting up.")
+	webhookFlags := namedFlagSets.FlagSet("webhook")
+	webhookFlags.StringVar(&o.WebhookAddress, "webhook-address", "", "address where webhooks are served. webhooks are disabled if empty.")
+	webhookFlags.StringVar(&o.WebhookCertDir, "webhook-cert-dir", "", "directory containing tls.crt and tls.key for serving webhooks, and optionally ca.crt.")
+	webhookFlags.BoolVar(&o.WebhookDefaulting, "webhook-defaulting", false, "if set, the defaulting webhook pins the channel, version, and replicas of new SpiceDBClusters in their spec.")
+	webhookFlags.StringVar(&o.WebhookService, "webhook-service", "", "namespace/name of the service that fronts the webhook server. if set with --crd, the CRD is configured to use the conversion webhook.")
 	debugFlags := namedFlagSets.FlagSet("debug")
 	debugFlags.StringVar(&o.DebugAddress, "debug-address", o.DebugAddress, "address where debug information is served (/healthz, /metrics/, /debug/pprof, etc)")
 	o.DebugFlags.AddFlags(debugFlags)
@@ -100,7 +119,22 @@ func NewCmdRun(o *Options) *cobra.Command {
 
 // Validate checks the set of flags provided by the user.
 func (o *Options) Validate() error {
-	return errors.NewAggregate(o.DebugFlags.Validate())
+	errs := o.DebugFlags.Validate()
+	if len(o.WebhookAddress) > 0 && len(o.WebhookCertDir) == 0 {
+		errs = append(errs, fmt.Errorf("--webhook-cert-dir is required when --webhook-address is set"))
+	}
+	if o.WebhookDefaulting && len(o.WebhookAddress) == 0 {
+		errs = append(errs, fmt.Errorf("--webhook-address is required when --webhook-defaulting is set"))
+	}
+	if len(o.WebhookService) > 0 {
+		if len(o.WebhookAddress) == 0 {
+			errs = append(errs, fmt.Errorf("--webhook-address is required when --webhook-service is set"))
+		}
+		if _, _, err := cache.SplitMetaNamespaceKey(o.WebhookService); err != nil {
+			errs = append(errs, fmt.Errorf("invalid --webhook-service: %w", err))
+		}
+	}
+	return errors.NewAggregate(errs)
 }
 
 // Run performs the apply operation.
@@ -128,6 +162,24 @@ func (o *Options) Run(ctx context.Context, f cmdutil.Factory) error {
 		if err := crds.BootstrapCRD(ctx, restConfig); err != nil {
 			return err
 		}
+		if len(o.WebhookService) > 0 {
+			namespace, name, _ := cache.SplitMetaNamespaceKey(o.WebhookService)
+			caBundle, err := os.ReadFile(filepath.Join(o.WebhookCertDir, webhook.CAFileName))
+			if err != nil {
+				return fmt.Errorf("unable to read webhook ca bundle: %w", err)
+			}
+			logger.V(3).Info("configuring conversion webhook", "service", o.WebhookService)
+			if err := crds.EnableConversionWebhook(ctx, restConfig, types.NamespacedName{Namespace: namespace, Name: name}, webhook.ConversionPath, caBundle); err != nil {
+				return err
+			}
+		}
+	}
+
+	if o.MigrateStoredVersions {
+		logger.V(3).Info("migrating stored versions")
+		if err := crds.MigrateStoredVersions(ctx, restConfig); err != nil {
+			return err
+		}
 	}
 
 	resources, err := f.OpenAPISchema()
@@ -159,19 +211,36 @@ func (o *Options) Run(ctx context.Context, f cmdutil.Factory) error {
 	}
 	controllers = append(controllers, ctrl)
 
+	if len(o.WebhookAddress) > 0 {
+		webhookServer := webhook.NewServer(o.WebhookAddress, o.WebhookCertDir)
+		webhookServer.Handle(webhook.ConversionPath, webhook.NewConversionHandler())
+		webhookServer.Handle(webhook.ValidationPath, webhook.NewValidationHandler(ctrl.OperatorConfig, resources,
+			func(ctx context.Context, nn types.NamespacedName) (*corev1.Secret, error) {
+				return kclient.CoreV1().Secrets(nn.Namespace).Get(ctx, nn.Name, metav1.GetOptions{})
+			}))
+		if o.WebhookDefaulting {
+			webhookServer.Handle(webhook.DefaultingPath, webhook.NewDefaultingHandler(ctrl.OperatorConfig))
+		}
+		controllers = append(controllers, webhookServer)
+	}
+
 	// register with metrics collector
 	spiceDBClusterMetrics := ctrlmetrics.NewConditionStatusCollector[*v1alpha1.SpiceDBCluster](o.MetricNamespace, "clusters", v1alpha1.SpiceDBClusterResourceName)
+	fleetRolloutMetrics := controller.NewFleetRolloutCollector(o.MetricNamespace, ctrl.OperatorConfig)
 
 	if len(o.WatchNamespaces) == 0 {
 		o.WatchNamespaces = []string{corev1.NamespaceAll}
 	}
 	for _, n := range o.WatchNamespaces {
 		lister := typed.MustListerForKey[*v1alpha1.SpiceDBCluster](registry, typed.NewRegistryKey(controller.OwnedFactoryKey(n), v1alpha1ClusterGVR))
-		spiceDBClusterMetrics.AddListerBuilder(func() ([]*v1alpha1.SpiceDBCluster, error) {
+		listClusters := func() ([]*v1alpha1.SpiceDBCluster, error) {
 			return lister.List(labels.Everything())
-		})
+		}
+		spiceDBClusterMetrics.AddListerBuilder(listClusters)
+		fleetRolloutMetrics.AddListerBuilder(listClusters)
 	}
 	legacyregistry.CustomMustRegister(spiceDBClusterMetrics)
+	legacyregistry.CustomMustRegister(fleetRolloutMetrics)
 
 	if ctx.Err() != nil {
 		return ctx.Err()
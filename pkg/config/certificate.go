@@ -0,0 +1,96 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/authzed/spicedb-operator/pkg/metadata"
+)
+
+const CertificateKind = "Certificate"
+
+var (
+	CertManagerGroupVersion = schema.GroupVersion{Group: "cert-manager.io", Version: "v1"}
+	CertificateResource     = CertManagerGroupVersion.WithResource("certificates")
+)
+
+// IssuerRef is a reference to a cert-manager issuer.
+type IssuerRef struct {
+	Name  string
+	Kind  string
+	Group string
+}
+
+// parseIssuerRef parses `name`, `kind/name` or `kind.group/name`. The kind
+// defaults to Issuer and the group to cert-manager.io.
+func parseIssuerRef(value string) (*IssuerRef, error) {
+	ref := &IssuerRef{Name: value, Kind: "Issuer", Group: CertManagerGroupVersion.Group}
+	if kind, name, ok := strings.Cut(value, "/"); ok {
+		ref.Name = name
+		ref.Kind = kind
+		if k, group, ok := strings.Cut(kind, "."); ok {
+			ref.Kind = k
+			ref.Group = group
+		}
+	}
+	if len(ref.Name) == 0 || len(ref.Kind) == 0 || len(ref.Group) == 0 || strings.Contains(ref.Name, "/") {
+		return nil, fmt.Errorf("must be of the form name, kind/name or kind.group/name")
+	}
+	return ref, nil
+}
+
+// ManagedDispatchTLSSecretName is the name of the secret that cert-manager
+// writes the dispatch certificate to.
+func ManagedDispatchTLSSecretName(clusterName string) string {
+	return fmt.Sprintf("%s-spicedb-dispatch-tls", clusterName)
+}
+
+func (c *Config) unpatchedCertificate() *CustomResourceApplyConfiguration {
+	return newCustomResource(CertManagerGroupVersion, CertificateKind, c.Name, c.Namespace).
+		WithLabels(metadata.LabelsForComponent(c.Name, metadata.ComponentCertificateLabel)).
+		WithSpec(c.certificateSpec(c.TLSSecretName, c.TLSIssuer))
+}
+
+func (c *Config) Certificate() *CustomResourceApplyConfiguration {
+	unpatched := c.unpatchedCertificate()
+	return c.patchedCustomResource(unpatched, metadata.ComponentCertificateLabel, "secretName", "issuerRef", "secretTemplate")
+}
+
+func (c *Config) unpatchedDispatchCertificate() *CustomResourceApplyConfiguration {
+	return newCustomResource(CertManagerGroupVersion, CertificateKind, c.Name+"-dispatch", c.Namespace).
+		WithLabels(metadata.LabelsForComponent(c.Name, metadata.ComponentDispatchCertificateLabel)).
+		WithSpec(c.certificateSpec(c.DispatchTLSSecretName, c.DispatchIssuer))
+}
+
+func (c *Config) DispatchCertificate() *CustomResourceApplyConfiguration {
+	unpatched := c.unpatchedDispatchCertificate()
+	return c.patchedCustomResource(unpatched, metadata.ComponentDispatchCertificateLabel, "secretName", "issuerRef", "secretTemplate").
+		WithName(c.Name + "-dispatch")
+}
+
+// certificateSpec requests a certificate for the service names. The secret
+// gets the operator's labels so that it's visible to the operator's cache.
+func (c *Config) certificateSpec(secretName string, issuer *IssuerRef) map[string]any {
+	issuerRef := map[string]any{}
+	if issuer != nil {
+		issuerRef = map[string]any{"name": issuer.Name, "kind": issuer.Kind, "group": issuer.Group}
+	}
+	dnsNames := make([]any, 0, len(c.SelfSignedDNSNames))
+	for _, n := range c.SelfSignedDNSNames {
+		dnsNames = append(dnsNames, n)
+	}
+	return map[string]any{
+		"secretName": secretName,
+		"secretTemplate": map[string]any{
+			"labels": labelsAsAny(metadata.LabelsForComponent(c.Name, metadata.ComponentCertificateSecretLabel)),
+		},
+		"dnsNames":  dnsNames,
+		"issuerRef": issuerRef,
+		"usages":    []any{"server auth", "client auth"},
+		"privateKey": map[string]any{
+			"rotationPolicy": "Always",
+		},
+	}
+}
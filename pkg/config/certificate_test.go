@@ -0,0 +1,180 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+	"github.com/authzed/spicedb-operator/pkg/metadata"
+)
+
+func TestParseIssuerRef(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    *IssuerRef
+		wantErr bool
+	}{
+		{value: "letsencrypt", want: &IssuerRef{Name: "letsencrypt", Kind: "Issuer", Group: "cert-manager.io"}},
+		{value: "ClusterIssuer/letsencrypt", want: &IssuerRef{Name: "letsencrypt", Kind: "ClusterIssuer", Group: "cert-manager.io"}},
+		{value: "AWSPCAIssuer.awspca.cert-manager.io/pca", want: &IssuerRef{Name: "pca", Kind: "AWSPCAIssuer", Group: "awspca.cert-manager.io"}},
+		{value: "ClusterIssuer/", wantErr: true},
+		{value: "/letsencrypt", wantErr: true},
+		{value: "a/b/c", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseIssuerRef(tt.value)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCertManagerTLS(t *testing.T) {
+	tests := []struct {
+		name               string
+		config             string
+		wantTLSSecret      string
+		wantDispatchTLS    string
+		wantDispatchCA     string
+		wantDispatchCert   string
+		wantSelfSigned     bool
+		wantTLSIssuer      bool
+		wantDispatchIssuer bool
+		wantWarning        string
+		wantErr            string
+	}{
+		{
+			name:             "serving certificate",
+			config:           `{"datastoreEngine": "cockroachdb", "tlsIssuerRef": "ClusterIssuer/letsencrypt"}`,
+			wantTLSSecret:    "test-spicedb-tls",
+			wantDispatchCA:   "test-spicedb-tls",
+			wantDispatchCert: "/tls/tls.crt",
+			wantTLSIssuer:    true,
+		},
+		{
+			name:             "serving certificate into a named secret",
+			config:           `{"datastoreEngine": "cockroachdb", "tlsIssuerRef": "letsencrypt", "tlsSecretName": "tls"}`,
+			wantTLSSecret:    "tls",
+			wantDispatchCA:   "tls",
+			wantDispatchCert: "/tls/tls.crt",
+			wantTLSIssuer:    true,
+		},
+		{
+			name:               "separate dispatch certificate",
+			config:             `{"datastoreEngine": "cockroachdb", "tlsIssuerRef": "letsencrypt", "dispatchIssuerRef": "ClusterIssuer/internal-ca"}`,
+			wantTLSSecret:      "test-spicedb-tls",
+			wantDispatchTLS:    "test-spicedb-dispatch-tls",
+			wantDispatchCA:     "test-spicedb-dispatch-tls",
+			wantDispatchCert:   "/dispatch-cert/tls.crt",
+			wantTLSIssuer:      true,
+			wantDispatchIssuer: true,
+		},
+		{
+			name:               "explicit dispatch paths are kept",
+			config:             `{"datastoreEngine": "cockroachdb", "tlsSecretName": "tls", "dispatchIssuerRef": "internal-ca", "dispatchClusterTLSCertPath": "/tls/other.crt"}`,
+			wantTLSSecret:      "tls",
+			wantDispatchTLS:    "test-spicedb-dispatch-tls",
+			wantDispatchCA:     "test-spicedb-dispatch-tls",
+			wantDispatchCert:   "/tls/other.crt",
+			wantDispatchIssuer: true,
+		},
+		{
+			name:             "self-signed is ignored",
+			config:           `{"datastoreEngine": "cockroachdb", "tlsIssuerRef": "letsencrypt", "selfSignedTLS": true}`,
+			wantTLSSecret:    "test-spicedb-tls",
+			wantDispatchCA:   "test-spicedb-tls",
+			wantDispatchCert: "/tls/tls.crt",
+			wantTLSIssuer:    true,
+			wantWarning:      `"selfSignedTLS" is ignored because "tlsIssuerRef" is set`,
+		},
+		{
+			name:             "dispatch issuer without dispatch",
+			config:           `{"datastoreEngine": "cockroachdb", "tlsSecretName": "tls", "dispatchEnabled": false, "dispatchIssuerRef": "internal-ca"}`,
+			wantTLSSecret:    "tls",
+			wantDispatchCert: "/tls/tls.crt",
+			wantWarning:      `"dispatchIssuerRef" is ignored because dispatch is disabled`,
+		},
+		{
+			name:    "invalid issuer",
+			config:  `{"datastoreEngine": "cockroachdb", "tlsIssuerRef": "a/b/c"}`,
+			wantErr: `invalid value for "tlsIssuerRef"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := &v1alpha1.SpiceDBCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test", UID: "1"},
+				Spec:       v1alpha1.ClusterSpec{Config: json.RawMessage(tt.config)},
+			}
+			secret := &corev1.Secret{Data: map[string][]byte{
+				"datastore_uri": []byte("uri"),
+				"preshared_key": []byte("psk"),
+			}}
+			got, warning, err := NewConfig(cluster, ptr.To(testGlobalConfig.Copy()), secret, newFakeResources())
+			if len(tt.wantErr) > 0 {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			if len(tt.wantWarning) > 0 {
+				require.ErrorContains(t, warning, tt.wantWarning)
+			}
+			require.Equal(t, tt.wantSelfSigned, got.SelfSignedTLS)
+			require.Equal(t, tt.wantTLSIssuer, got.TLSIssuer != nil)
+			require.Equal(t, tt.wantDispatchIssuer, got.DispatchIssuer != nil)
+			require.Equal(t, tt.wantTLSSecret, got.TLSSecretName)
+			require.Equal(t, tt.wantDispatchTLS, got.DispatchTLSSecretName)
+			require.Equal(t, tt.wantDispatchCA, got.DispatchUpstreamCASecretName)
+			require.Equal(t, tt.wantDispatchCert, got.Passthrough["dispatchClusterTLSCertPath"])
+			if got.DispatchIssuer != nil {
+				require.Equal(t, "/dispatch-cert/tls.key", got.Passthrough["dispatchClusterTLSKeyPath"])
+				volumes := got.deploymentVolumes()
+				require.Equal(t, "test-spicedb-dispatch-tls", *volumes[len(volumes)-1].Secret.SecretName)
+				mounts := got.deploymentVolumeMounts()
+				require.Equal(t, "/dispatch-cert", *mounts[len(mounts)-1].MountPath)
+			}
+		})
+	}
+}
+
+func TestCertificate(t *testing.T) {
+	got := newTestConfig(t, v1alpha1.ClusterSpec{
+		Config: json.RawMessage(`{"datastoreEngine": "cockroachdb", "tlsIssuerRef": "ClusterIssuer/letsencrypt", "dispatchIssuerRef": "internal-ca"}`),
+		Patches: []v1alpha1.Patch{{
+			Kind:  "Certificate",
+			Patch: json.RawMessage(`{"spec": {"duration": "720h", "secretName": "other", "issuerRef": {"name": "other"}}}`),
+		}},
+	})
+	dnsNames := []any{"test", "test.test", "test.test.svc", "test.test.svc.cluster.local", "test-spicedb-blue.test", "test-spicedb-green.test"}
+	secretLabels := labelsAsAny(metadata.LabelsForComponent("test", metadata.ComponentCertificateSecretLabel))
+
+	cert := got.Certificate()
+	require.Equal(t, "test", *cert.Name)
+	require.Equal(t, "cert-manager.io/v1", *cert.APIVersion)
+	require.Equal(t, CertificateKind, *cert.Kind)
+	require.Equal(t, metadata.LabelsForComponent("test", metadata.ComponentCertificateLabel), cert.Labels)
+	require.Len(t, cert.OwnerReferences, 1)
+	require.Equal(t, "720h", cert.Spec["duration"])
+	require.Equal(t, "test-spicedb-tls", cert.Spec["secretName"])
+	require.Equal(t, map[string]any{"name": "letsencrypt", "kind": "ClusterIssuer", "group": "cert-manager.io"}, cert.Spec["issuerRef"])
+	require.Equal(t, dnsNames, cert.Spec["dnsNames"])
+	require.Equal(t, map[string]any{"labels": secretLabels}, cert.Spec["secretTemplate"])
+
+	dispatch := got.DispatchCertificate()
+	require.Equal(t, "test-dispatch", *dispatch.Name)
+	require.Equal(t, metadata.LabelsForComponent("test", metadata.ComponentDispatchCertificateLabel), dispatch.Labels)
+	require.Equal(t, "720h", dispatch.Spec["duration"])
+	require.Equal(t, "test-spicedb-dispatch-tls", dispatch.Spec["secretName"])
+	require.Equal(t, map[string]any{"name": "internal-ca", "kind": "Issuer", "group": "cert-manager.io"}, dispatch.Spec["issuerRef"])
+	require.Equal(t, dnsNames, dispatch.Spec["dnsNames"])
+}
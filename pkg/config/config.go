@@ -5,28 +5,37 @@ import (
 	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/authzed/controller-idioms/hash"
 	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/fatih/camelcase"
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
 	applyappsv1 "k8s.io/client-go/applyconfigurations/apps/v1"
+	applyautoscalingv2 "k8s.io/client-go/applyconfigurations/autoscaling/v2"
 	applybatchv1 "k8s.io/client-go/applyconfigurations/batch/v1"
 	applycorev1 "k8s.io/client-go/applyconfigurations/core/v1"
 	applymetav1 "k8s.io/client-go/applyconfigurations/meta/v1"
+	applynetworkingv1 "k8s.io/client-go/applyconfigurations/networking/v1"
+	applypolicyv1 "k8s.io/client-go/applyconfigurations/policy/v1"
 	applyrbacv1 "k8s.io/client-go/applyconfigurations/rbac/v1"
 	"k8s.io/kubectl/pkg/util/openapi"
 
 	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
 	"github.com/authzed/spicedb-operator/pkg/metadata"
+	"github.com/authzed/spicedb-operator/pkg/updates"
 )
 
 const (
@@ -36,6 +45,7 @@ const (
 	spannerVolume      = "spanner"
 	tlsVolume          = "tls"
 	dispatchTLSVolume  = "dispatch-tls"
+	dispatchCertVolume = "dispatch-cert"
 	telemetryTLSVolume = "telemetry-tls"
 	labelsVolume       = "podlabels"
 	annotationsVolume  = "podannotations"
@@ -44,6 +54,12 @@ const (
 	DefaultTLSKeyFile = "/tls/tls.key"
 	DefaultTLSCrtFile = "/tls/tls.crt"
 
+	// PresharedKeySecretKey and PresharedKeyNextSecretKey are the keys in the
+	// config secret that hold the preshared key and, during a rotation, its
+	// replacement.
+	PresharedKeySecretKey     = "preshared_key"
+	PresharedKeyNextSecretKey = "preshared_key_next"
+
 	// nolint:gosec // Creds in the naming causes a false positive here.
 	spannerCredsPath     = "/spanner-credentials"
 	spannerCredsFileName = "credentials.json"
@@ -61,6 +77,9 @@ var (
 	projectLabels                     = newBoolOrStringKey("projectLabels", true)
 	projectAnnotations                = newBoolOrStringKey("projectAnnotations", true)
 	tlsSecretNameKey                  = newStringKey("tlsSecretName")
+	selfSignedTLSKey                  = newBoolOrStringKey("selfSignedTLS", false)
+	tlsIssuerRefKey                   = newStringKey("tlsIssuerRef")
+	dispatchIssuerRefKey              = newStringKey("dispatchIssuerRef")
 	dispatchCAKey                     = newStringKey("dispatchUpstreamCASecretName")
 	dispatchCAFilePathKey             = newKey("dispatchUpstreamCAFilePath", "tls.crt")
 	dispatchEnabledKey                = newBoolOrStringKey("dispatchEnabled", true)
@@ -89,6 +108,24 @@ var (
 	httpTLSCertPathKey                = newKey("httpTLSCertPath", DefaultTLSCrtFile)
 	dashboardTLSKeyPathKey            = newKey("dashboardTLSKeyPath", DefaultTLSKeyFile)
 	dashboardTLSCertPathKey           = newKey("dashboardTLSCertPath", DefaultTLSCrtFile)
+	networkPolicyEnabledKey           = newBoolOrStringKey("networkPolicyEnabled", false)
+	networkPolicyClientNamespacesKey  = newStringKey("networkPolicyClientNamespaces")
+	networkPolicyClientPodSelectorKey = newStringKey("networkPolicyClientPodSelector")
+	networkPolicyMetricsNamespacesKey = newStringKey("networkPolicyMetricsNamespaces")
+	prometheusMonitorKey              = newStringKey("prometheusMonitor")
+	prometheusRulesKey                = newBoolOrStringKey("prometheusRules", false)
+	prometheusLabelsKey               = metadataSetKey("prometheusLabels")
+	ingressTypeKey                    = newStringKey("ingressType")
+	ingressHostKey                    = newStringKey("ingressHost")
+	ingressHTTPHostKey                = newStringKey("ingressHTTPHost")
+	ingressClassNameKey               = newStringKey("ingressClassName")
+	ingressAnnotationsKey             = metadataSetKey("ingressAnnotations")
+	gatewayNameKey                    = newStringKey("gatewayName")
+	gatewayNamespaceKey               = newStringKey("gatewayNamespace")
+	gatewayPortKey                    = newIntOrStringKey[int32]("gatewayPort", 0)
+	connectionSecretNameKey           = newStringKey("connectionSecretName")
+	connectionSecretNamespaceKey      = newStringKey("connectionSecretNamespace")
+	progressDeadlineKey               = newStringKey("progressDeadline")
 )
 
 // Warning is an issue with configuration that we will report as undesirable
@@ -148,23 +185,79 @@ type SpiceConfig struct {
 	UID                            string
 	Replicas                       int32
 	PresharedKey                   string
+	PresharedKeyNext               string
+	PublishPresharedKeyNext        bool
 	EnvPrefix                      string
 	SpiceDBCmd                     string
 	TLSSecretName                  string
+	SelfSignedTLS                  bool
+	SelfSignedDNSNames             []string
+	TLSIssuer                      *IssuerRef
+	DispatchIssuer                 *IssuerRef
+	DispatchTLSSecretName          string
 	DispatchEnabled                bool
 	DispatchUpstreamCASecretName   string
 	DispatchUpstreamCASecretPath   string
+	ServingSlot                    string
+	DeploymentSlot                 string
 	TelemetryTLSCASecretName       string
 	SecretName                     string
+	ReferencedSecrets              []string
 	ExtraPodLabels                 map[string]string
 	ExtraPodAnnotations            map[string]string
 	ExtraServiceAccountAnnotations map[string]string
 	ServiceAccountName             string
 	ProjectLabels                  bool
 	ProjectAnnotations             bool
+	Autoscaling                    *v1alpha1.ClusterAutoscaling
+	AutoscalingMetrics             []*applyautoscalingv2.MetricSpecApplyConfiguration
+	NetworkPolicyEnabled           bool
+	NetworkPolicyClientNamespaces  []string
+	NetworkPolicyClientPodSelector *metav1.LabelSelector
+	NetworkPolicyMetricsNamespaces []string
+	PrometheusMonitor              string
+	PrometheusRulesEnabled         bool
+	PrometheusLabels               map[string]string
+	IngressType                    string
+	IngressHost                    string
+	IngressHTTPHost                string
+	IngressClassName               string
+	IngressAnnotations             map[string]string
+	GatewayName                    string
+	GatewayNamespace               string
+	GatewayPort                    int32
+	ConnectionSecretName           string
+	ConnectionSecretNamespace      string
+	ProgressDeadline               time.Duration
 	Passthrough                    map[string]string
 }
 
+// DefaultProgressDeadline is how long a rollout can take before it's
+// reported as stuck, if the config doesn't set `progressDeadline`.
+const DefaultProgressDeadline = 10 * time.Minute
+
+// deploymentSlot returns the slot of the deployment that runs the target
+// image. An update to a release that can't dispatch to the running one is
+// brought up in the other slot (blue/green), so that the two releases never
+// share a dispatch ring. Everything else updates the serving deployment.
+func deploymentSlot(cluster *v1alpha1.SpiceDBCluster, target *v1alpha1.SpiceDBVersion, image string, dispatchEnabled bool) string {
+	serving, rollout := cluster.Status.DeploymentSlot, cluster.Status.Rollout
+	if rollout != nil && rollout.CompletionTime == nil && len(rollout.Slot) > 0 {
+		return rollout.Slot
+	}
+	// the image of the last rollout is the one that's deployed; clusters that
+	// haven't recorded a rollout yet only have the image in their status
+	deployed := cluster.Status.Image
+	if rollout != nil {
+		deployed = rollout.Image
+	}
+	if dispatchEnabled && len(deployed) > 0 && deployed != image &&
+		target != nil && slices.Contains(target.Attributes, v1alpha1.SpiceDBVersionAttributesIncompatibleDispatch) {
+		return v1alpha1.NextDeploymentSlot(serving)
+	}
+	return serving
+}
+
 // NewConfig checks that the values in the config + the secret are sane
 func NewConfig(cluster *v1alpha1.SpiceDBCluster, globalConfig *OperatorConfig, secret *corev1.Secret, resources openapi.Resources) (*Config, Warning, error) {
 	if cluster.Spec.Config == nil {
@@ -222,10 +315,17 @@ func NewConfig(cluster *v1alpha1.SpiceDBCluster, globalConfig *OperatorConfig, s
 	// unless the current config is equal to the input.
 	image := imageKey.pop(config)
 
-	baseImage, targetSpiceDBVersion, state, err := globalConfig.ComputeTarget(globalConfig.ImageName, image, cluster.Spec.Version, cluster.Spec.Channel, datastoreEngine, cluster.Status.CurrentVersion, cluster.RolloutInProgress())
+	baseImage, targetSpiceDBVersion, state, err := globalConfig.ComputeTarget(globalConfig.ImageName, image, cluster.Spec.Version, cluster.Spec.Channel, datastoreEngine, cluster.Status.CurrentVersion, cluster.RolloutInProgress(), updates.NewSoak(cluster.Spec.UpdatePolicy, time.Now()))
 	if err != nil {
 		errs = append(errs, err)
 	}
+	_, notSoaked := err.(*updates.NotSoakedError)
+
+	// maintenance windows are applied by the controller, but a policy it
+	// can't parse makes the config invalid
+	if _, err := updates.NewMaintenanceSchedule(cluster.Spec.UpdatePolicy); err != nil {
+		errs = append(errs, err)
+	}
 
 	migrationConfig.SpiceDBVersion = targetSpiceDBVersion
 	migrationConfig.TargetPhase = state.Phase
@@ -243,6 +343,8 @@ func NewConfig(cluster *v1alpha1.SpiceDBCluster, globalConfig *OperatorConfig, s
 		migrationConfig.TargetSpiceDBImage = baseImage + "@" + state.Digest
 	case len(state.Tag) > 0:
 		migrationConfig.TargetSpiceDBImage = baseImage + ":" + state.Tag
+	case notSoaked:
+		// there's no release to pick yet, which is reported on its own
 	default:
 		errs = append(errs, fmt.Errorf("no update found in channel"))
 	}
@@ -257,6 +359,9 @@ func NewConfig(cluster *v1alpha1.SpiceDBCluster, globalConfig *OperatorConfig, s
 		spiceConfig.DispatchEnabled = false
 	}
 
+	spiceConfig.ServingSlot = cluster.Status.DeploymentSlot
+	spiceConfig.DeploymentSlot = deploymentSlot(cluster, targetSpiceDBVersion, migrationConfig.TargetSpiceDBImage, spiceConfig.DispatchEnabled)
+
 	migrationConfig.DatastoreEngine = datastoreEngine
 	passthroughConfig["datastoreEngine"] = datastoreEngine
 	passthroughConfig["dispatchClusterEnabled"] = strconv.FormatBool(spiceConfig.DispatchEnabled)
@@ -275,11 +380,18 @@ func NewConfig(cluster *v1alpha1.SpiceDBCluster, globalConfig *OperatorConfig, s
 			errs = append(errs, fmt.Errorf("secret must contain a datastore_uri field"))
 		}
 		migrationConfig.DatastoreURI = string(datastoreURI)
-		psk, ok = secret.Data["preshared_key"]
+		psk, ok = secret.Data[PresharedKeySecretKey]
 		if !ok {
 			errs = append(errs, fmt.Errorf("secret must contain a preshared_key field"))
 		}
 		spiceConfig.PresharedKey = string(psk)
+		spiceConfig.PresharedKeyNext = string(secret.Data[PresharedKeyNextSecretKey])
+
+		// clients are only handed the next key once the pods accept it
+		if rotation := cluster.FindStatusCondition(v1alpha1.ConditionTypePresharedKeyRotation); rotation != nil {
+			spiceConfig.PublishPresharedKeyNext = rotation.Reason == v1alpha1.ConditionReasonWaitingForClients ||
+				rotation.Reason == v1alpha1.ConditionReasonRotationComplete
+		}
 	}
 
 	if len(migrationConfig.SpannerCredsSecretRef) > 0 {
@@ -295,16 +407,127 @@ func NewConfig(cluster *v1alpha1.SpiceDBCluster, globalConfig *OperatorConfig, s
 	if err != nil {
 		errs = append(errs, fmt.Errorf("invalid value for replicas %q: %w", replicas, err))
 	}
+	if cluster.Spec.Replicas != nil {
+		replicas = *cluster.Spec.Replicas
+	}
 
 	spiceConfig.Replicas = replicas
 	if replicas > 1 && datastoreEngine == "memory" {
 		errs = append(errs, fmt.Errorf("cannot set replicas > 1 for memory engine"))
 	}
+
+	if autoscaling := cluster.Spec.Autoscaling; autoscaling != nil {
+		if datastoreEngine == "memory" {
+			errs = append(errs, fmt.Errorf("cannot autoscale memory engine"))
+		}
+		if autoscaling.MaxReplicas < 1 {
+			errs = append(errs, fmt.Errorf("autoscaling.maxReplicas must be at least 1"))
+		}
+		if autoscaling.MinReplicas != nil && *autoscaling.MinReplicas > autoscaling.MaxReplicas {
+			errs = append(errs, fmt.Errorf("autoscaling.minReplicas must not be greater than autoscaling.maxReplicas"))
+		}
+		if cluster.Spec.Replicas != nil {
+			warnings = append(warnings, fmt.Errorf("replicas is ignored when autoscaling is set"))
+		}
+		spiceConfig.Autoscaling = autoscaling.DeepCopy()
+		spiceConfig.AutoscalingMetrics, err = autoscalingMetrics(autoscaling.Metrics)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
 	spiceConfig.SkipMigrations, err = skipMigrationsKey.pop(config)
 	if err != nil {
 		errs = append(errs, err)
 	}
 
+	spiceConfig.NetworkPolicyEnabled, err = networkPolicyEnabledKey.pop(config)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	spiceConfig.NetworkPolicyClientNamespaces = splitList(networkPolicyClientNamespacesKey.pop(config))
+	spiceConfig.NetworkPolicyMetricsNamespaces = splitList(networkPolicyMetricsNamespacesKey.pop(config))
+	if podSelector := networkPolicyClientPodSelectorKey.pop(config); len(podSelector) > 0 {
+		spiceConfig.NetworkPolicyClientPodSelector, err = metav1.ParseToLabelSelector(podSelector)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid value for %s %q: %w", networkPolicyClientPodSelectorKey.key, podSelector, err))
+		}
+	}
+	if !spiceConfig.NetworkPolicyEnabled && (len(spiceConfig.NetworkPolicyClientNamespaces) > 0 ||
+		len(spiceConfig.NetworkPolicyMetricsNamespaces) > 0 ||
+		spiceConfig.NetworkPolicyClientPodSelector != nil) {
+		warnings = append(warnings, fmt.Errorf("network policy allowlists are ignored unless %q is set", networkPolicyEnabledKey.key))
+	}
+
+	spiceConfig.PrometheusMonitor = prometheusMonitorKey.pop(config)
+	switch spiceConfig.PrometheusMonitor {
+	case "", ServiceMonitorKind, PodMonitorKind:
+	default:
+		errs = append(errs, fmt.Errorf("invalid value for %s %q: must be %q or %q", prometheusMonitorKey.key, spiceConfig.PrometheusMonitor, ServiceMonitorKind, PodMonitorKind))
+	}
+	spiceConfig.PrometheusRulesEnabled, err = prometheusRulesKey.pop(config)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	var prometheusLabelWarnings []error
+	spiceConfig.PrometheusLabels, prometheusLabelWarnings, err = prometheusLabelsKey.pop(config, "prometheus", "label")
+	if err != nil {
+		errs = append(errs, err)
+	}
+	warnings = append(warnings, prometheusLabelWarnings...)
+
+	if deadline := progressDeadlineKey.pop(config); len(deadline) > 0 {
+		spiceConfig.ProgressDeadline, err = time.ParseDuration(deadline)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid value for %s %q: %w", progressDeadlineKey.key, deadline, err))
+		} else if spiceConfig.ProgressDeadline <= 0 {
+			errs = append(errs, fmt.Errorf("invalid value for %s %q: must be positive", progressDeadlineKey.key, deadline))
+		}
+	}
+
+	spiceConfig.IngressType = ingressTypeKey.pop(config)
+	spiceConfig.IngressHost = ingressHostKey.pop(config)
+	spiceConfig.IngressHTTPHost = ingressHTTPHostKey.pop(config)
+	spiceConfig.IngressClassName = ingressClassNameKey.pop(config)
+	spiceConfig.GatewayName = gatewayNameKey.pop(config)
+	spiceConfig.GatewayNamespace = gatewayNamespaceKey.pop(config)
+	spiceConfig.GatewayPort, err = gatewayPortKey.pop(config)
+	if err != nil || spiceConfig.GatewayPort < 0 || spiceConfig.GatewayPort > 65535 {
+		errs = append(errs, fmt.Errorf("invalid value for %s: must be a port number", gatewayPortKey.key))
+	}
+	switch spiceConfig.IngressType {
+	case "":
+	case IngressTypeIngress, IngressTypeGatewayAPI:
+		if len(spiceConfig.IngressHost) == 0 {
+			errs = append(errs, fmt.Errorf("%s is required when %s is set", ingressHostKey.key, ingressTypeKey.key))
+		}
+		if spiceConfig.IngressType == IngressTypeGatewayAPI && len(spiceConfig.GatewayName) == 0 {
+			errs = append(errs, fmt.Errorf("%s is required when %s is %q", gatewayNameKey.key, ingressTypeKey.key, IngressTypeGatewayAPI))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("invalid value for %s %q: must be %q or %q", ingressTypeKey.key, spiceConfig.IngressType, IngressTypeIngress, IngressTypeGatewayAPI))
+	}
+	var ingressAnnotationWarnings []error
+	spiceConfig.IngressAnnotations, ingressAnnotationWarnings, err = ingressAnnotationsKey.pop(config, "ingress", "annotation")
+	if err != nil {
+		errs = append(errs, err)
+	}
+	warnings = append(warnings, ingressAnnotationWarnings...)
+
+	spiceConfig.ConnectionSecretName = connectionSecretNameKey.pop(config)
+	spiceConfig.ConnectionSecretNamespace = connectionSecretNamespaceKey.pop(config)
+	switch {
+	case len(spiceConfig.ConnectionSecretName) == 0:
+		if len(spiceConfig.ConnectionSecretNamespace) > 0 {
+			warnings = append(warnings, fmt.Errorf("%q is ignored unless %q is set", connectionSecretNamespaceKey.key, connectionSecretNameKey.key))
+			spiceConfig.ConnectionSecretNamespace = ""
+		}
+	case len(spiceConfig.ConnectionSecretNamespace) == 0:
+		spiceConfig.ConnectionSecretNamespace = cluster.Namespace
+	case spiceConfig.ConnectionSecretNamespace != cluster.Namespace &&
+		!slices.Contains(globalConfig.ConnectionSecretNamespaces[cluster.Namespace], spiceConfig.ConnectionSecretNamespace):
+		errs = append(errs, fmt.Errorf("invalid value for %s %q: the operator only allows connection secrets in other namespaces listed for namespace %q in connectionSecretNamespaces", connectionSecretNamespaceKey.key, spiceConfig.ConnectionSecretNamespace, cluster.Namespace))
+	}
+
 	var labelWarnings []error
 	spiceConfig.ExtraPodLabels, labelWarnings, err = extraPodLabelsKey.pop(config, "pod", "label")
 	if err != nil {
@@ -333,6 +556,55 @@ func NewConfig(cluster *v1alpha1.SpiceDBCluster, globalConfig *OperatorConfig, s
 		warnings = append(warnings, saAnnotationWarnings...)
 	}
 
+	spiceConfig.SelfSignedTLS, err = selfSignedTLSKey.pop(config)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if ref := tlsIssuerRefKey.pop(config); len(ref) > 0 {
+		spiceConfig.TLSIssuer, err = parseIssuerRef(ref)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid value for %q: %w", tlsIssuerRefKey.key, err))
+		}
+	}
+	if ref := dispatchIssuerRefKey.pop(config); len(ref) > 0 {
+		if !spiceConfig.DispatchEnabled {
+			warnings = append(warnings, fmt.Errorf("%q is ignored because dispatch is disabled", dispatchIssuerRefKey.key))
+		} else {
+			spiceConfig.DispatchIssuer, err = parseIssuerRef(ref)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid value for %q: %w", dispatchIssuerRefKey.key, err))
+			}
+		}
+	}
+	if spiceConfig.DispatchIssuer != nil {
+		spiceConfig.DispatchTLSSecretName = ManagedDispatchTLSSecretName(cluster.Name)
+		if len(spiceConfig.DispatchUpstreamCASecretName) == 0 {
+			spiceConfig.DispatchUpstreamCASecretName = spiceConfig.DispatchTLSSecretName
+			spiceConfig.DispatchUpstreamCASecretPath = CACertKey
+		}
+	}
+
+	if spiceConfig.SelfSignedTLS && spiceConfig.TLSIssuer != nil {
+		warnings = append(warnings, fmt.Errorf("%q is ignored because %q is set", selfSignedTLSKey.key, tlsIssuerRefKey.key))
+		spiceConfig.SelfSignedTLS = false
+	}
+	if spiceConfig.SelfSignedTLS && len(spiceConfig.TLSSecretName) > 0 {
+		warnings = append(warnings, fmt.Errorf("%q is ignored because %q is set", selfSignedTLSKey.key, tlsSecretNameKey.key))
+		spiceConfig.SelfSignedTLS = false
+	}
+	if spiceConfig.SelfSignedTLS || spiceConfig.TLSIssuer != nil {
+		// the operator-managed secret is used like any other tls secret,
+		// and its CA verifies dispatch unless another CA was provided.
+		// cert-manager may also write into a user-named secret.
+		if len(spiceConfig.TLSSecretName) == 0 {
+			spiceConfig.TLSSecretName = SelfSignedTLSSecretName(cluster.Name)
+		}
+		if len(spiceConfig.DispatchUpstreamCASecretName) == 0 {
+			spiceConfig.DispatchUpstreamCASecretName = spiceConfig.TLSSecretName
+			spiceConfig.DispatchUpstreamCASecretPath = CACertKey
+		}
+	}
+
 	// generate secret refs for tls if specified
 	if len(spiceConfig.TLSSecretName) > 0 {
 		passthroughKeys := []*key[string]{
@@ -352,6 +624,25 @@ func NewConfig(cluster *v1alpha1.SpiceDBCluster, globalConfig *OperatorConfig, s
 		warnings = append(warnings, fmt.Errorf("no TLS configured, consider setting %q", "tlsSecretName"))
 	}
 
+	// the dispatch certificate replaces the serving certificate for dispatch
+	// unless the paths were changed explicitly
+	if len(spiceConfig.DispatchTLSSecretName) > 0 {
+		dispatchPaths := map[*key[string]]string{
+			dispatchClusterTLSCertPathKey: "/" + dispatchCertVolume + "/tls.crt",
+			dispatchClusterTLSKeyPathKey:  "/" + dispatchCertVolume + "/tls.key",
+		}
+		for k, path := range dispatchPaths {
+			v, ok := passthroughConfig[k.key]
+			if !ok {
+				v = k.pop(config)
+			}
+			if v == k.defaultValue {
+				v = path
+			}
+			passthroughConfig[k.key] = v
+		}
+	}
+
 	if len(spiceConfig.DispatchUpstreamCASecretName) > 0 && spiceConfig.DispatchEnabled {
 		passthroughConfig["dispatchUpstreamCAPath"] = "/dispatch-tls/" + spiceConfig.DispatchUpstreamCASecretPath
 	}
@@ -375,6 +666,7 @@ func NewConfig(cluster *v1alpha1.SpiceDBCluster, globalConfig *OperatorConfig, s
 		"grpcPresharedKey",
 		"presharedKey",
 		"preshared_key",
+		"preshared_key_next",
 		"datastore_uri",
 	}
 	// strip sensitive values from passthrough config (if they have been
@@ -400,17 +692,62 @@ func NewConfig(cluster *v1alpha1.SpiceDBCluster, globalConfig *OperatorConfig, s
 		Resources:       resources,
 	}
 	out.Patches = fixDeploymentPatches(out.Name, cluster.Spec.Patches)
+	if out.SelfSignedTLS || out.TLSIssuer != nil || out.DispatchIssuer != nil {
+		out.SelfSignedDNSNames = out.selfSignedDNSNames()
+	}
+	out.ReferencedSecrets = out.referencedSecrets()
+	if out.connectionSecretConflicts() {
+		errs = append(errs, fmt.Errorf("invalid value for %s %q: the secret is already used by the cluster", connectionSecretNameKey.key, out.ConnectionSecretName))
+	}
 
 	// Validate that patches apply cleanly ahead of time
 	totalAppliedPatches := 0
-	for _, obj := range []any{
+	objs := []any{
 		out.unpatchedServiceAccount(),
 		out.unpatchedRole(),
 		out.unpatchedRoleBinding(),
 		out.unpatchedService(),
+		out.unpatchedPodDisruptionBudget(),
 		out.unpatchedMigrationJob(hash.Object("")),
 		out.unpatchedDeployment(hash.Object(""), hash.Object("")),
-	} {
+	}
+	if out.Autoscaling != nil {
+		objs = append(objs, out.unpatchedHorizontalPodAutoscaler())
+	}
+	if out.NetworkPolicyEnabled {
+		objs = append(objs, out.unpatchedNetworkPolicy())
+	}
+	switch out.PrometheusMonitor {
+	case ServiceMonitorKind:
+		objs = append(objs, out.unpatchedServiceMonitor())
+	case PodMonitorKind:
+		objs = append(objs, out.unpatchedPodMonitor())
+	}
+	if out.PrometheusRulesEnabled {
+		objs = append(objs, out.unpatchedPrometheusRule())
+	}
+	switch out.IngressType {
+	case IngressTypeIngress:
+		objs = append(objs, out.unpatchedIngress())
+	case IngressTypeGatewayAPI:
+		objs = append(objs, out.unpatchedGRPCRoute())
+		if len(out.IngressHTTPHost) > 0 {
+			objs = append(objs, out.unpatchedHTTPRoute())
+		}
+	}
+	if out.SelfSignedTLS {
+		objs = append(objs, out.unpatchedCAConfigMap(""))
+	}
+	if len(out.ConnectionSecretName) > 0 {
+		objs = append(objs, out.unpatchedConnectionSecret(""))
+	}
+	if out.TLSIssuer != nil {
+		objs = append(objs, out.unpatchedCertificate())
+	}
+	if out.DispatchIssuer != nil {
+		objs = append(objs, out.unpatchedDispatchCertificate())
+	}
+	for _, obj := range objs {
 		applied, diff, err := ApplyPatches(obj, obj, out.Patches, resources)
 		if err != nil {
 			errs = append(errs, err)
@@ -435,6 +772,40 @@ func NewConfig(cluster *v1alpha1.SpiceDBCluster, globalConfig *OperatorConfig, s
 	return out, warning, nil
 }
 
+// NotSoaked returns the error from NewConfig if the only thing missing is a
+// release of the cluster's channel that has soaked. The cluster can be
+// configured once it has.
+func NotSoaked(err error) (*updates.NotSoakedError, bool) {
+	if agg, ok := err.(errors.Aggregate); ok && len(agg.Errors()) == 1 {
+		err = agg.Errors()[0]
+	}
+	notSoaked, ok := err.(*updates.NotSoakedError)
+	return notSoaked, ok
+}
+
+// presharedKeyEnvVars passes the preshared key to SpiceDB. During a rotation
+// both keys are accepted; kube expands the references to the earlier env vars
+// into a comma-separated list.
+func (c *Config) presharedKeyEnvVars() []*applycorev1.EnvVarApplyConfiguration {
+	presharedKeyVar := applycorev1.EnvVar().WithName(c.SpiceConfig.EnvPrefix + "_GRPC_PRESHARED_KEY")
+	if len(c.PresharedKeyNext) == 0 {
+		return []*applycorev1.EnvVarApplyConfiguration{
+			presharedKeyVar.WithValueFrom(applycorev1.EnvVarSource().WithSecretKeyRef(
+				applycorev1.SecretKeySelector().WithName(c.SecretName).WithKey(PresharedKeySecretKey))),
+		}
+	}
+
+	envVars := make([]*applycorev1.EnvVarApplyConfiguration, 0, 3)
+	refs := make([]string, 0, 2)
+	for _, key := range []string{PresharedKeySecretKey, PresharedKeyNextSecretKey} {
+		name := c.SpiceConfig.EnvPrefix + "_" + strings.ToUpper(key)
+		envVars = append(envVars, applycorev1.EnvVar().WithName(name).WithValueFrom(applycorev1.EnvVarSource().WithSecretKeyRef(
+			applycorev1.SecretKeySelector().WithName(c.SecretName).WithKey(key))))
+		refs = append(refs, "$("+name+")")
+	}
+	return append(envVars, presharedKeyVar.WithValue(strings.Join(refs, ",")))
+}
+
 // toEnvVarApplyConfiguration returns a set of env variables to apply to a
 // spicedb container
 func (c *Config) toEnvVarApplyConfiguration() []*applycorev1.EnvVarApplyConfiguration {
@@ -445,10 +816,8 @@ func (c *Config) toEnvVarApplyConfiguration() []*applycorev1.EnvVarApplyConfigur
 		applycorev1.EnvVar().WithName(c.SpiceConfig.EnvPrefix + "_POD_NAME").WithValueFrom(
 			applycorev1.EnvVarSource().WithFieldRef(applycorev1.ObjectFieldSelector().WithFieldPath("metadata.name"))),
 		applycorev1.EnvVar().WithName(c.SpiceConfig.EnvPrefix + "_LOG_LEVEL").WithValue(c.LogLevel),
-		applycorev1.EnvVar().WithName(c.SpiceConfig.EnvPrefix + "_GRPC_PRESHARED_KEY").
-			WithValueFrom(applycorev1.EnvVarSource().WithSecretKeyRef(
-				applycorev1.SecretKeySelector().WithName(c.SecretName).WithKey("preshared_key"))),
 	}
+	envVars = append(envVars, c.presharedKeyEnvVars()...)
 	if c.DatastoreEngine != "memory" {
 		envVars = append(envVars,
 			applycorev1.EnvVar().WithName(c.SpiceConfig.EnvPrefix+"_DATASTORE_CONN_URI").WithValueFrom(applycorev1.EnvVarSource().WithSecretKeyRef(
@@ -457,7 +826,7 @@ func (c *Config) toEnvVarApplyConfiguration() []*applycorev1.EnvVarApplyConfigur
 	if c.DispatchEnabled {
 		envVars = append(envVars,
 			applycorev1.EnvVar().WithName(c.SpiceConfig.EnvPrefix+"_DISPATCH_UPSTREAM_ADDR").
-				WithValue(fmt.Sprintf("kubernetes:///%s.%s:dispatch", c.Name, c.Namespace)))
+				WithValue(fmt.Sprintf("kubernetes:///%s.%s:dispatch", c.dispatchServiceName(c.DeploymentSlot), c.Namespace)))
 	}
 
 	// Passthrough config is user-provided and only affects spicedb runtime.
@@ -546,11 +915,69 @@ func (c *Config) unpatchedService() *applycorev1.ServiceApplyConfiguration {
 	return applycorev1.Service(c.Name, c.Namespace).
 		WithLabels(metadata.LabelsForComponent(c.Name, metadata.ComponentServiceLabel)).
 		WithSpec(applycorev1.ServiceSpec().
-			WithSelector(metadata.LabelsForComponent(c.Name, metadata.ComponentSpiceDBLabelValue)).
+			WithSelector(c.serviceSelector()).
 			WithPorts(c.servicePorts()...),
 		)
 }
 
+// serviceSelector selects the SpiceDB pods that serve requests. Once there's
+// more than one deployment slot, only the serving deployment is selected.
+func (c *Config) serviceSelector() map[string]string {
+	selector := metadata.LabelsForComponent(c.Name, metadata.ComponentSpiceDBLabelValue)
+	if len(c.ServingSlot) > 0 || c.DeploymentSlot != c.ServingSlot {
+		selector["app.kubernetes.io/instance"] = DeploymentName(c.Name, c.ServingSlot)
+	}
+	return selector
+}
+
+// dispatchServiceName is the name of the service that the pods of a
+// deployment slot dispatch through. The original deployment dispatches
+// through the main Service, the blue and green deployments through a service
+// of their own, so that each has its own dispatch ring.
+func (c *Config) dispatchServiceName(slot string) string {
+	if len(slot) == 0 {
+		return c.Name
+	}
+	return DeploymentName(c.Name, slot)
+}
+
+func (c *Config) unpatchedDispatchService(slot string) *applycorev1.ServiceApplyConfiguration {
+	return applycorev1.Service(c.dispatchServiceName(slot), c.Namespace).
+		WithLabels(metadata.LabelsForComponent(c.Name, metadata.ComponentDispatchServiceLabel)).
+		WithSpec(applycorev1.ServiceSpec().
+			WithClusterIP(corev1.ClusterIPNone).
+			WithSelector(c.dispatchSelector(slot)).
+			WithPorts(applycorev1.ServicePort().WithName("dispatch").WithPort(50053)),
+		)
+}
+
+func (c *Config) dispatchSelector(slot string) map[string]string {
+	selector := metadata.LabelsForComponent(c.Name, metadata.ComponentSpiceDBLabelValue)
+	selector["app.kubernetes.io/instance"] = DeploymentName(c.Name, slot)
+	return selector
+}
+
+// DispatchService is the service that the pods of the blue or green
+// deployment dispatch through.
+func (c *Config) DispatchService(slot string) *applycorev1.ServiceApplyConfiguration {
+	name := c.dispatchServiceName(slot)
+	s := applycorev1.Service(name, c.Namespace)
+	unpatched := c.unpatchedDispatchService(slot)
+	_, _, _ = ApplyPatches(unpatched, s, c.Patches, c.Resources)
+
+	// not allowed to patch out the spec
+	if s.Spec == nil {
+		s.Spec = unpatched.Spec
+	}
+
+	// ensure patches don't overwrite anything critical for operator function
+	s.WithName(name).WithNamespace(c.Namespace).
+		WithLabels(metadata.LabelsForComponent(c.Name, metadata.ComponentDispatchServiceLabel)).
+		WithOwnerReferences(c.ownerRef())
+	s.Spec.WithSelector(c.dispatchSelector(slot))
+	return s
+}
+
 func (c *Config) Service() *applycorev1.ServiceApplyConfiguration {
 	s := applycorev1.Service(c.Name, c.Namespace)
 	unpatched := c.unpatchedService()
@@ -565,10 +992,144 @@ func (c *Config) Service() *applycorev1.ServiceApplyConfiguration {
 	s.WithName(c.Name).WithNamespace(c.Namespace).
 		WithLabels(metadata.LabelsForComponent(c.Name, metadata.ComponentServiceLabel)).
 		WithOwnerReferences(c.ownerRef())
-	s.Spec.WithSelector(metadata.LabelsForComponent(c.Name, metadata.ComponentSpiceDBLabelValue))
+	s.Spec.WithSelector(c.serviceSelector())
 	return s
 }
 
+func (c *Config) unpatchedPodDisruptionBudget() *applypolicyv1.PodDisruptionBudgetApplyConfiguration {
+	return applypolicyv1.PodDisruptionBudget(c.Name, c.Namespace).
+		WithLabels(metadata.LabelsForComponent(c.Name, metadata.ComponentPDBLabel)).
+		WithSpec(applypolicyv1.PodDisruptionBudgetSpec().
+			WithSelector(applymetav1.LabelSelector().
+				WithMatchLabels(metadata.LabelsForComponent(c.Name, metadata.ComponentSpiceDBLabelValue))).
+			WithMaxUnavailable(c.maxUnavailable()),
+		)
+}
+
+// maxUnavailable allows one pod to be disrupted at a time for small clusters,
+// and a quarter of the pods for larger ones. Autoscaled clusters use a
+// percentage so that the budget follows the autoscaler.
+func (c *Config) maxUnavailable() intstr.IntOrString {
+	if c.Autoscaling != nil {
+		return intstr.FromString("25%")
+	}
+	return intstr.FromInt32(max(1, c.Replicas/4))
+}
+
+func (c *Config) PodDisruptionBudget() *applypolicyv1.PodDisruptionBudgetApplyConfiguration {
+	pdb := applypolicyv1.PodDisruptionBudget(c.Name, c.Namespace)
+	unpatched := c.unpatchedPodDisruptionBudget()
+	_, _, _ = ApplyPatches(unpatched, pdb, c.Patches, c.Resources)
+
+	// not allowed to patch out the spec
+	if pdb.Spec == nil {
+		pdb.Spec = unpatched.Spec
+	}
+
+	// ensure patches don't overwrite anything critical for operator function
+	pdb.WithName(c.Name).WithNamespace(c.Namespace).
+		WithLabels(metadata.LabelsForComponent(c.Name, metadata.ComponentPDBLabel)).
+		WithOwnerReferences(c.ownerRef())
+	pdb.Spec.WithSelector(unpatched.Spec.Selector)
+	return pdb
+}
+
+func (c *Config) unpatchedNetworkPolicy() *applynetworkingv1.NetworkPolicyApplyConfiguration {
+	// clients may reach grpc and the gateway from anywhere unless an
+	// allowlist is configured
+	clientRule := applynetworkingv1.NetworkPolicyIngressRule().WithPorts(
+		networkPolicyPort(50051),
+		networkPolicyPort(8443),
+	)
+	if len(c.NetworkPolicyClientNamespaces) > 0 || c.NetworkPolicyClientPodSelector != nil {
+		peer := applynetworkingv1.NetworkPolicyPeer()
+		if len(c.NetworkPolicyClientNamespaces) > 0 {
+			peer.WithNamespaceSelector(namespaceSelector(c.NetworkPolicyClientNamespaces))
+		}
+		if c.NetworkPolicyClientPodSelector != nil {
+			peer.WithPodSelector(labelSelector(c.NetworkPolicyClientPodSelector))
+		}
+		clientRule.WithFrom(peer)
+	}
+
+	metricsRule := applynetworkingv1.NetworkPolicyIngressRule().WithPorts(networkPolicyPort(9090))
+	if len(c.NetworkPolicyMetricsNamespaces) > 0 {
+		metricsRule.WithFrom(applynetworkingv1.NetworkPolicyPeer().
+			WithNamespaceSelector(namespaceSelector(c.NetworkPolicyMetricsNamespaces)))
+	}
+
+	spec := applynetworkingv1.NetworkPolicySpec().
+		WithPodSelector(applymetav1.LabelSelector().
+			WithMatchLabels(metadata.LabelsForComponent(c.Name, metadata.ComponentSpiceDBLabelValue))).
+		WithPolicyTypes(networkingv1.PolicyTypeIngress).
+		WithIngress(clientRule, metricsRule)
+
+	// only other spicedb pods in the cluster may dispatch
+	if c.DispatchEnabled {
+		spec.WithIngress(applynetworkingv1.NetworkPolicyIngressRule().
+			WithPorts(networkPolicyPort(50053)).
+			WithFrom(applynetworkingv1.NetworkPolicyPeer().
+				WithPodSelector(applymetav1.LabelSelector().
+					WithMatchLabels(metadata.LabelsForComponent(c.Name, metadata.ComponentSpiceDBLabelValue)))))
+	}
+
+	return applynetworkingv1.NetworkPolicy(c.Name, c.Namespace).
+		WithLabels(metadata.LabelsForComponent(c.Name, metadata.ComponentNetworkPolicyLabel)).
+		WithSpec(spec)
+}
+
+func (c *Config) NetworkPolicy() *applynetworkingv1.NetworkPolicyApplyConfiguration {
+	np := applynetworkingv1.NetworkPolicy(c.Name, c.Namespace)
+	unpatched := c.unpatchedNetworkPolicy()
+	_, _, _ = ApplyPatches(unpatched, np, c.Patches, c.Resources)
+
+	// not allowed to patch out the spec
+	if np.Spec == nil {
+		np.Spec = unpatched.Spec
+	}
+
+	// ensure patches don't overwrite anything critical for operator function
+	np.WithName(c.Name).WithNamespace(c.Namespace).
+		WithLabels(metadata.LabelsForComponent(c.Name, metadata.ComponentNetworkPolicyLabel)).
+		WithOwnerReferences(c.ownerRef())
+	np.Spec.WithPodSelector(unpatched.Spec.PodSelector)
+	return np
+}
+
+func networkPolicyPort(port int32) *applynetworkingv1.NetworkPolicyPortApplyConfiguration {
+	return applynetworkingv1.NetworkPolicyPort().WithProtocol(corev1.ProtocolTCP).WithPort(intstr.FromInt32(port))
+}
+
+// namespaceSelector selects namespaces by name
+func namespaceSelector(namespaces []string) *applymetav1.LabelSelectorApplyConfiguration {
+	return applymetav1.LabelSelector().WithMatchExpressions(applymetav1.LabelSelectorRequirement().
+		WithKey(corev1.LabelMetadataName).
+		WithOperator(metav1.LabelSelectorOpIn).
+		WithValues(namespaces...))
+}
+
+func labelSelector(selector *metav1.LabelSelector) *applymetav1.LabelSelectorApplyConfiguration {
+	out := applymetav1.LabelSelector().WithMatchLabels(selector.MatchLabels)
+	for _, r := range selector.MatchExpressions {
+		out.WithMatchExpressions(applymetav1.LabelSelectorRequirement().
+			WithKey(r.Key).
+			WithOperator(r.Operator).
+			WithValues(r.Values...))
+	}
+	return out
+}
+
+// splitList splits a comma-separated config value, dropping empty entries
+func splitList(value string) []string {
+	var out []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); len(v) > 0 {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
 func (c *Config) servicePorts() []*applycorev1.ServicePortApplyConfiguration {
 	ports := []*applycorev1.ServicePortApplyConfiguration{
 		applycorev1.ServicePort().WithName("grpc").WithPort(50051),
@@ -739,6 +1300,9 @@ func (c *Config) deploymentVolumes() []*applycorev1.VolumeApplyConfiguration {
 	if len(c.DispatchUpstreamCASecretName) > 0 && c.DispatchEnabled {
 		volumes = append(volumes, applycorev1.Volume().WithName(dispatchTLSVolume).WithSecret(applycorev1.SecretVolumeSource().WithDefaultMode(420).WithSecretName(c.DispatchUpstreamCASecretName)))
 	}
+	if len(c.DispatchTLSSecretName) > 0 && c.DispatchEnabled {
+		volumes = append(volumes, applycorev1.Volume().WithName(dispatchCertVolume).WithSecret(applycorev1.SecretVolumeSource().WithDefaultMode(420).WithSecretName(c.DispatchTLSSecretName)))
+	}
 	if len(c.TelemetryTLSCASecretName) > 0 {
 		volumes = append(volumes, applycorev1.Volume().WithName(telemetryTLSVolume).WithSecret(applycorev1.SecretVolumeSource().WithDefaultMode(420).WithSecretName(c.TelemetryTLSCASecretName)))
 	}
@@ -754,6 +1318,9 @@ func (c *Config) deploymentVolumeMounts() []*applycorev1.VolumeMountApplyConfigu
 	if len(c.DispatchUpstreamCASecretName) > 0 && c.DispatchEnabled {
 		volumeMounts = append(volumeMounts, applycorev1.VolumeMount().WithName(dispatchTLSVolume).WithMountPath("/dispatch-tls").WithReadOnly(true))
 	}
+	if len(c.DispatchTLSSecretName) > 0 && c.DispatchEnabled {
+		volumeMounts = append(volumeMounts, applycorev1.VolumeMount().WithName(dispatchCertVolume).WithMountPath("/"+dispatchCertVolume).WithReadOnly(true))
+	}
 	if len(c.TelemetryTLSCASecretName) > 0 {
 		volumeMounts = append(volumeMounts, applycorev1.VolumeMount().WithName(telemetryTLSVolume).WithMountPath("/telemetry-tls").WithReadOnly(true))
 	}
@@ -773,14 +1340,13 @@ func (c *Config) unpatchedDeployment(migrationHash, secretHash string) *applyapp
 	if c.SkipMigrations {
 		migrationHash = "skipped"
 	}
-	name := deploymentName(c.Name)
+	name := DeploymentName(c.Name, c.DeploymentSlot)
 	return applyappsv1.Deployment(name, c.Namespace).
 		WithLabels(metadata.LabelsForComponent(c.Name, metadata.ComponentSpiceDBLabelValue)).
 		WithAnnotations(map[string]string{
 			metadata.SpiceDBMigrationRequirementsKey: migrationHash,
 		}).
-		WithSpec(applyappsv1.DeploymentSpec().
-			WithReplicas(c.Replicas).
+		WithSpec(c.deploymentSpec().
 			WithStrategy(applyappsv1.DeploymentStrategy().
 				WithType(appsv1.RollingUpdateDeploymentStrategyType).
 				WithRollingUpdate(applyappsv1.RollingUpdateDeployment().WithMaxUnavailable(intstr.FromInt32(0)))).
@@ -812,8 +1378,18 @@ func (c *Config) unpatchedDeployment(migrationHash, secretHash string) *applyapp
 				).WithVolumes(c.deploymentVolumes()...))))
 }
 
+// deploymentSpec returns the base spec for the deployment. The replica count
+// is left unset when autoscaling, since it is owned by the autoscaler.
+func (c *Config) deploymentSpec() *applyappsv1.DeploymentSpecApplyConfiguration {
+	spec := applyappsv1.DeploymentSpec()
+	if c.Autoscaling == nil {
+		spec.WithReplicas(c.Replicas)
+	}
+	return spec
+}
+
 func (c *Config) Deployment(migrationHash, secretHash string) *applyappsv1.DeploymentApplyConfiguration {
-	name := deploymentName(c.Name)
+	name := DeploymentName(c.Name, c.DeploymentSlot)
 	d := applyappsv1.Deployment(name, c.Namespace)
 	unpatched := c.unpatchedDeployment(migrationHash, secretHash)
 	_, _, _ = ApplyPatches(unpatched, d, c.Patches, c.Resources)
@@ -850,6 +1426,91 @@ func (c *Config) Deployment(migrationHash, secretHash string) *applyappsv1.Deplo
 	return d
 }
 
+func (c *Config) unpatchedHorizontalPodAutoscaler() *applyautoscalingv2.HorizontalPodAutoscalerApplyConfiguration {
+	spec := applyautoscalingv2.HorizontalPodAutoscalerSpec().
+		WithScaleTargetRef(applyautoscalingv2.CrossVersionObjectReference().
+			WithAPIVersion(appsv1.SchemeGroupVersion.String()).
+			WithKind("Deployment").
+			WithName(DeploymentName(c.Name, c.ServingSlot)))
+	if c.Autoscaling != nil {
+		spec.WithMaxReplicas(c.Autoscaling.MaxReplicas)
+		if c.Autoscaling.MinReplicas != nil {
+			spec.WithMinReplicas(*c.Autoscaling.MinReplicas)
+		}
+		if c.Autoscaling.TargetCPUUtilizationPercentage != nil {
+			spec.WithMetrics(applyautoscalingv2.MetricSpec().
+				WithType(autoscalingv2.ResourceMetricSourceType).
+				WithResource(applyautoscalingv2.ResourceMetricSource().
+					WithName(corev1.ResourceCPU).
+					WithTarget(applyautoscalingv2.MetricTarget().
+						WithType(autoscalingv2.UtilizationMetricType).
+						WithAverageUtilization(*c.Autoscaling.TargetCPUUtilizationPercentage))))
+		}
+		spec.WithMetrics(c.AutoscalingMetrics...)
+	}
+
+	return applyautoscalingv2.HorizontalPodAutoscaler(c.Name, c.Namespace).
+		WithLabels(metadata.LabelsForComponent(c.Name, metadata.ComponentHPALabel)).
+		WithSpec(spec)
+}
+
+// autoscalingMetrics converts the additional autoscaling metrics to apply
+// configurations, returning an error for any metric that doesn't have the
+// source its type names.
+func autoscalingMetrics(metrics []autoscalingv2.MetricSpec) ([]*applyautoscalingv2.MetricSpecApplyConfiguration, error) {
+	out := make([]*applyautoscalingv2.MetricSpecApplyConfiguration, 0, len(metrics))
+	for i, m := range metrics {
+		var hasSource bool
+		switch m.Type {
+		case autoscalingv2.ObjectMetricSourceType:
+			hasSource = m.Object != nil
+		case autoscalingv2.PodsMetricSourceType:
+			hasSource = m.Pods != nil
+		case autoscalingv2.ResourceMetricSourceType:
+			hasSource = m.Resource != nil
+		case autoscalingv2.ContainerResourceMetricSourceType:
+			hasSource = m.ContainerResource != nil
+		case autoscalingv2.ExternalMetricSourceType:
+			hasSource = m.External != nil
+		default:
+			return nil, fmt.Errorf("autoscaling.metrics[%d]: unknown metric type %q", i, m.Type)
+		}
+		if !hasSource {
+			return nil, fmt.Errorf("autoscaling.metrics[%d]: metric of type %q has no matching source", i, m.Type)
+		}
+
+		// the apply configuration has the same structure as the api type
+		var metric applyautoscalingv2.MetricSpecApplyConfiguration
+		encoded, err := json.Marshal(m)
+		if err != nil {
+			return nil, fmt.Errorf("autoscaling.metrics[%d]: %w", i, err)
+		}
+		if err := json.Unmarshal(encoded, &metric); err != nil {
+			return nil, fmt.Errorf("autoscaling.metrics[%d]: %w", i, err)
+		}
+		out = append(out, &metric)
+	}
+	return out, nil
+}
+
+func (c *Config) HorizontalPodAutoscaler() *applyautoscalingv2.HorizontalPodAutoscalerApplyConfiguration {
+	hpa := applyautoscalingv2.HorizontalPodAutoscaler(c.Name, c.Namespace)
+	unpatched := c.unpatchedHorizontalPodAutoscaler()
+	_, _, _ = ApplyPatches(unpatched, hpa, c.Patches, c.Resources)
+
+	// not allowed to patch out the spec
+	if hpa.Spec == nil {
+		hpa.Spec = unpatched.Spec
+	}
+
+	// ensure patches don't overwrite anything critical for operator function
+	hpa.WithName(c.Name).WithNamespace(c.Namespace).
+		WithLabels(metadata.LabelsForComponent(c.Name, metadata.ComponentHPALabel)).
+		WithOwnerReferences(c.ownerRef())
+	hpa.Spec.WithScaleTargetRef(unpatched.Spec.ScaleTargetRef)
+	return hpa
+}
+
 // fixDeploymentPatches modifies any patches that could apply to the deployment
 // referencing the old container names and rewrites them to use the new
 // stable name
@@ -919,7 +1580,17 @@ func toEnvVarName(prefix string, key string) string {
 	return strings.Join(envVarParts, "_")
 }
 
-// deploymentName returns the name of the unpatchedDeployment given a SpiceDBCluster name
-func deploymentName(name string) string {
-	return fmt.Sprintf("%s-spicedb", name)
+// GeneratedSecretName is the name of the config secret that is created for a
+// cluster without a spec.secretName.
+func GeneratedSecretName(clusterName string) string {
+	return fmt.Sprintf("%s-spicedb-config", clusterName)
+}
+
+// DeploymentName returns the name of the SpiceDB deployment in a slot given
+// a SpiceDBCluster name. The original deployment has no slot.
+func DeploymentName(name, slot string) string {
+	if len(slot) == 0 {
+		return fmt.Sprintf("%s-spicedb", name)
+	}
+	return fmt.Sprintf("%s-spicedb-%s", name, slot)
 }
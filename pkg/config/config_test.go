@@ -12,7 +12,9 @@ import (
 
 	"github.com/stretchr/testify/require"
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -20,9 +22,12 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
 	applyappsv1 "k8s.io/client-go/applyconfigurations/apps/v1"
+	applyautoscalingv2 "k8s.io/client-go/applyconfigurations/autoscaling/v2"
 	applybatchv1 "k8s.io/client-go/applyconfigurations/batch/v1"
 	applycorev1 "k8s.io/client-go/applyconfigurations/core/v1"
 	applymetav1 "k8s.io/client-go/applyconfigurations/meta/v1"
+	applynetworkingv1 "k8s.io/client-go/applyconfigurations/networking/v1"
+	applypolicyv1 "k8s.io/client-go/applyconfigurations/policy/v1"
 	applyrbacv1 "k8s.io/client-go/applyconfigurations/rbac/v1"
 	openapitesting "k8s.io/kubectl/pkg/util/openapi/testing"
 	"k8s.io/utils/ptr"
@@ -33,7 +38,7 @@ import (
 )
 
 func newFakeResources() *openapitesting.FakeResources {
-	return openapitesting.NewFakeResources(filepath.Join("testdata", "swagger.1.26.3.json"))
+	return openapitesting.NewFakeResources(filepath.Join("testdata", "swagger.1.30.2.json"))
 }
 
 func TestToEnvVarName(t *testing.T) {
@@ -57,6 +62,30 @@ func TestToEnvVarName(t *testing.T) {
 	}
 }
 
+func TestPresharedKeyEnvVars(t *testing.T) {
+	envVarString := func(c *Config) []string {
+		out := make([]string, 0)
+		for _, e := range c.presharedKeyEnvVars() {
+			if e.Value != nil {
+				out = append(out, *e.Name+"="+*e.Value)
+				continue
+			}
+			out = append(out, *e.Name+"="+*e.ValueFrom.SecretKeyRef.Name+"/"+*e.ValueFrom.SecretKeyRef.Key)
+		}
+		return out
+	}
+
+	cfg := &Config{SpiceConfig: SpiceConfig{EnvPrefix: "SPICEDB", SecretName: "secret", PresharedKey: "old"}}
+	require.Equal(t, []string{"SPICEDB_GRPC_PRESHARED_KEY=secret/preshared_key"}, envVarString(cfg))
+
+	cfg.PresharedKeyNext = "new"
+	require.Equal(t, []string{
+		"SPICEDB_PRESHARED_KEY=secret/preshared_key",
+		"SPICEDB_PRESHARED_KEY_NEXT=secret/preshared_key_next",
+		"SPICEDB_GRPC_PRESHARED_KEY=$(SPICEDB_PRESHARED_KEY),$(SPICEDB_PRESHARED_KEY_NEXT)",
+	}, envVarString(cfg))
+}
+
 func TestNewConfig(t *testing.T) {
 	resources := newFakeResources()
 	type args struct {
@@ -537,6 +566,300 @@ func TestNewConfig(t *testing.T) {
 			},
 			wantPortCount: 4,
 		},
+		{
+			name: "spec replicas take precedence over config",
+			args: args{
+				cluster: v1alpha1.ClusterSpec{
+					Replicas: ptr.To[int32](4),
+					Config: json.RawMessage(`
+					{
+						"datastoreEngine": "cockroachdb",
+						"replicas": 3
+					}
+				`),
+				},
+				globalConfig: OperatorConfig{
+					ImageName: "image",
+					UpdateGraph: updates.UpdateGraph{
+						Channels: []updates.Channel{
+							{
+								Name:     "cockroachdb",
+								Metadata: map[string]string{"datastore": "cockroachdb", "default": "true"},
+								Nodes: []updates.State{
+									{ID: "v1", Tag: "v1"},
+								},
+								Edges: map[string][]string{"v1": {}},
+							},
+						},
+					},
+				},
+				secret: &corev1.Secret{Data: map[string][]byte{
+					"datastore_uri": []byte("uri"),
+					"preshared_key": []byte("psk"),
+				}},
+			},
+			wantWarnings: []error{fmt.Errorf("no TLS configured, consider setting \"tlsSecretName\"")},
+			want: &Config{
+				MigrationConfig: MigrationConfig{
+					MigrationLogLevel:  "debug",
+					DatastoreEngine:    "cockroachdb",
+					DatastoreURI:       "uri",
+					TargetSpiceDBImage: "image:v1",
+					EnvPrefix:          "SPICEDB",
+					SpiceDBCmd:         "spicedb",
+					TargetMigration:    "head",
+					SpiceDBVersion: &v1alpha1.SpiceDBVersion{
+						Name:    "v1",
+						Channel: "cockroachdb",
+						Attributes: []v1alpha1.SpiceDBVersionAttributes{
+							v1alpha1.SpiceDBVersionAttributesMigration,
+						},
+					},
+				},
+				SpiceConfig: SpiceConfig{
+					LogLevel:                     "info",
+					SkipMigrations:               false,
+					Name:                         "test",
+					Namespace:                    "test",
+					UID:                          "1",
+					Replicas:                     4,
+					PresharedKey:                 "psk",
+					EnvPrefix:                    "SPICEDB",
+					SpiceDBCmd:                   "spicedb",
+					ServiceAccountName:           "test",
+					DispatchEnabled:              true,
+					DispatchUpstreamCASecretPath: "tls.crt",
+					ProjectLabels:                true,
+					ProjectAnnotations:           true,
+					Passthrough: map[string]string{
+						"datastoreEngine":        "cockroachdb",
+						"dispatchClusterEnabled": "true",
+						"terminationLogPath":     "/dev/termination-log",
+					},
+				},
+			},
+			wantEnvs: []string{
+				"SPICEDB_POD_NAME=FIELD_REF=metadata.name",
+				"SPICEDB_LOG_LEVEL=info",
+				"SPICEDB_GRPC_PRESHARED_KEY=preshared_key",
+				"SPICEDB_DATASTORE_CONN_URI=datastore_uri",
+				"SPICEDB_DISPATCH_UPSTREAM_ADDR=kubernetes:///test.test:dispatch",
+				"SPICEDB_DATASTORE_ENGINE=cockroachdb",
+				"SPICEDB_DISPATCH_CLUSTER_ENABLED=true",
+				"SPICEDB_TERMINATION_LOG_PATH=/dev/termination-log",
+			},
+			wantPortCount: 4,
+		},
+		{
+			name: "invalid autoscaling",
+			args: args{
+				cluster: v1alpha1.ClusterSpec{
+					Replicas: ptr.To[int32](1),
+					Autoscaling: &v1alpha1.ClusterAutoscaling{
+						MinReplicas: ptr.To[int32](3),
+						MaxReplicas: 2,
+					},
+					Config: json.RawMessage(`
+					{
+						"datastoreEngine": "memory",
+						"tlsSecretName": "tls"
+					}
+				`),
+				},
+				globalConfig: OperatorConfig{
+					ImageName: "image",
+					UpdateGraph: updates.UpdateGraph{
+						Channels: []updates.Channel{
+							{
+								Name:     "memory",
+								Metadata: map[string]string{"datastore": "memory", "default": "true"},
+								Nodes: []updates.State{
+									{ID: "v1", Tag: "v1"},
+								},
+								Edges: map[string][]string{"v1": {}},
+							},
+						},
+					},
+				},
+				secret: &corev1.Secret{Data: map[string][]byte{
+					"preshared_key": []byte("psk"),
+				}},
+			},
+			wantErrs: []error{
+				fmt.Errorf("cannot autoscale memory engine"),
+				fmt.Errorf("autoscaling.minReplicas must not be greater than autoscaling.maxReplicas"),
+			},
+			wantWarnings: []error{fmt.Errorf("replicas is ignored when autoscaling is set")},
+		},
+		{
+			name: "network policy allowlists require networkPolicyEnabled",
+			args: args{
+				cluster: v1alpha1.ClusterSpec{Config: json.RawMessage(`
+					{
+						"datastoreEngine": "memory",
+						"tlsSecretName": "tls",
+						"networkPolicyMetricsNamespaces": "monitoring",
+						"networkPolicyClientPodSelector": "app in (a"
+					}
+				`)},
+				globalConfig: OperatorConfig{
+					ImageName: "image",
+					UpdateGraph: updates.UpdateGraph{
+						Channels: []updates.Channel{
+							{
+								Name:     "memory",
+								Metadata: map[string]string{"datastore": "memory", "default": "true"},
+								Nodes: []updates.State{
+									{ID: "v1", Tag: "v1"},
+								},
+								Edges: map[string][]string{"v1": {}},
+							},
+						},
+					},
+				},
+				secret: &corev1.Secret{Data: map[string][]byte{
+					"preshared_key": []byte("psk"),
+				}},
+			},
+			wantErrs: []error{
+				fmt.Errorf(`invalid value for networkPolicyClientPodSelector "app in (a": %w`,
+					fmt.Errorf(`couldn't parse the selector string "app in (a": unable to parse requirement: found '', expected: ',' or ')'`)),
+			},
+			wantWarnings: []error{fmt.Errorf(`network policy allowlists are ignored unless "networkPolicyEnabled" is set`)},
+		},
+		{
+			name: "invalid prometheus monitor",
+			args: args{
+				cluster: v1alpha1.ClusterSpec{Config: json.RawMessage(`
+					{
+						"datastoreEngine": "memory",
+						"tlsSecretName": "tls",
+						"prometheusMonitor": "servicemonitor"
+					}
+				`)},
+				globalConfig: OperatorConfig{
+					ImageName: "image",
+					UpdateGraph: updates.UpdateGraph{
+						Channels: []updates.Channel{
+							{
+								Name:     "memory",
+								Metadata: map[string]string{"datastore": "memory", "default": "true"},
+								Nodes: []updates.State{
+									{ID: "v1", Tag: "v1"},
+								},
+								Edges: map[string][]string{"v1": {}},
+							},
+						},
+					},
+				},
+				secret: &corev1.Secret{Data: map[string][]byte{
+					"preshared_key": []byte("psk"),
+				}},
+			},
+			wantErrs: []error{
+				fmt.Errorf(`invalid value for prometheusMonitor "servicemonitor": must be "ServiceMonitor" or "PodMonitor"`),
+			},
+		},
+		{
+			name: "invalid progress deadline",
+			args: args{
+				cluster: v1alpha1.ClusterSpec{Config: json.RawMessage(`
+					{
+						"datastoreEngine": "memory",
+						"tlsSecretName": "tls",
+						"progressDeadline": "-5m"
+					}
+				`)},
+				globalConfig: OperatorConfig{
+					ImageName: "image",
+					UpdateGraph: updates.UpdateGraph{
+						Channels: []updates.Channel{
+							{
+								Name:     "memory",
+								Metadata: map[string]string{"datastore": "memory", "default": "true"},
+								Nodes: []updates.State{
+									{ID: "v1", Tag: "v1"},
+								},
+								Edges: map[string][]string{"v1": {}},
+							},
+						},
+					},
+				},
+				secret: &corev1.Secret{Data: map[string][]byte{
+					"preshared_key": []byte("psk"),
+				}},
+			},
+			wantErrs: []error{
+				fmt.Errorf(`invalid value for progressDeadline "-5m": must be positive`),
+			},
+		},
+		{
+			name: "gateway api ingress without a host or gateway",
+			args: args{
+				cluster: v1alpha1.ClusterSpec{Config: json.RawMessage(`
+					{
+						"datastoreEngine": "memory",
+						"tlsSecretName": "tls",
+						"ingressType": "GatewayAPI"
+					}
+				`)},
+				globalConfig: OperatorConfig{
+					ImageName: "image",
+					UpdateGraph: updates.UpdateGraph{
+						Channels: []updates.Channel{
+							{
+								Name:     "memory",
+								Metadata: map[string]string{"datastore": "memory", "default": "true"},
+								Nodes: []updates.State{
+									{ID: "v1", Tag: "v1"},
+								},
+								Edges: map[string][]string{"v1": {}},
+							},
+						},
+					},
+				},
+				secret: &corev1.Secret{Data: map[string][]byte{
+					"preshared_key": []byte("psk"),
+				}},
+			},
+			wantErrs: []error{
+				fmt.Errorf("ingressHost is required when ingressType is set"),
+				fmt.Errorf(`gatewayName is required when ingressType is "GatewayAPI"`),
+			},
+		},
+		{
+			name: "invalid ingress type",
+			args: args{
+				cluster: v1alpha1.ClusterSpec{Config: json.RawMessage(`
+					{
+						"datastoreEngine": "memory",
+						"tlsSecretName": "tls",
+						"ingressType": "Route"
+					}
+				`)},
+				globalConfig: OperatorConfig{
+					ImageName: "image",
+					UpdateGraph: updates.UpdateGraph{
+						Channels: []updates.Channel{
+							{
+								Name:     "memory",
+								Metadata: map[string]string{"datastore": "memory", "default": "true"},
+								Nodes: []updates.State{
+									{ID: "v1", Tag: "v1"},
+								},
+								Edges: map[string][]string{"v1": {}},
+							},
+						},
+					},
+				},
+				secret: &corev1.Secret{Data: map[string][]byte{
+					"preshared_key": []byte("psk"),
+				}},
+			},
+			wantErrs: []error{
+				fmt.Errorf(`invalid value for ingressType "Route": must be "Ingress" or "GatewayAPI"`),
+			},
+		},
 		{
 			name: "set replicas as string",
 			args: args{
@@ -1807,6 +2130,7 @@ func TestNewConfig(t *testing.T) {
 					ServiceAccountName:           "test",
 					DispatchEnabled:              true,
 					DispatchUpstreamCASecretPath: "tls.crt",
+					ReferencedSecrets:            []string{"spanner-creds-secret-name"},
 					ProjectLabels:                true,
 					ProjectAnnotations:           true,
 					Passthrough: map[string]string{
@@ -2381,6 +2705,491 @@ metadata:
 	}
 }
 
+func TestDeploymentSlots(t *testing.T) {
+	globalConfig := OperatorConfig{
+		ImageName: "image",
+		UpdateGraph: updates.UpdateGraph{Channels: []updates.Channel{{
+			Name:     "stable",
+			Metadata: map[string]string{"datastore": "cockroachdb", "default": "true"},
+			Nodes: []updates.State{
+				{ID: "v2", Tag: "v2", Migration: "to-v1", Dispatch: "v2"},
+				{ID: "v1", Tag: "v1", Migration: "to-v1", Dispatch: "v1"},
+			},
+			Edges: map[string][]string{"v1": {"v2"}, "v2": {}},
+		}}},
+	}
+	completed := &v1alpha1.RolloutStatus{Image: "image:v1", CompletionTime: ptr.To(metav1.Now())}
+	incompatible := []v1alpha1.SpiceDBVersionAttributes{v1alpha1.SpiceDBVersionAttributesIncompatibleDispatch}
+
+	tests := []struct {
+		name             string
+		config           string
+		status           v1alpha1.ClusterStatus
+		wantServing      string
+		wantSlot         string
+		wantDeployment   string
+		wantSelected     string
+		wantDispatchAddr string
+	}{
+		{
+			name:             "original deployment",
+			config:           `{"datastoreEngine": "cockroachdb"}`,
+			wantDeployment:   "test-spicedb",
+			wantDispatchAddr: "kubernetes:///test.test:dispatch",
+		},
+		{
+			name:             "update to a new dispatch api starts a blue/green rollout",
+			config:           `{"datastoreEngine": "cockroachdb"}`,
+			status:           v1alpha1.ClusterStatus{CurrentVersion: &v1alpha1.SpiceDBVersion{Name: "v1", Channel: "stable"}, Rollout: completed},
+			wantSlot:         "blue",
+			wantDeployment:   "test-spicedb-blue",
+			wantSelected:     "test-spicedb",
+			wantDispatchAddr: "kubernetes:///test-spicedb-blue.test:dispatch",
+		},
+		{
+			name:             "clusters without a recorded rollout start a blue/green rollout",
+			config:           `{"datastoreEngine": "cockroachdb"}`,
+			status:           v1alpha1.ClusterStatus{Image: "image:v1", CurrentVersion: &v1alpha1.SpiceDBVersion{Name: "v1", Channel: "stable"}},
+			wantSlot:         "blue",
+			wantDeployment:   "test-spicedb-blue",
+			wantSelected:     "test-spicedb",
+			wantDispatchAddr: "kubernetes:///test-spicedb-blue.test:dispatch",
+		},
+		{
+			name:   "blue/green rollout stays in its slot",
+			config: `{"datastoreEngine": "cockroachdb"}`,
+			status: v1alpha1.ClusterStatus{
+				DeploymentSlot: "blue",
+				CurrentVersion: &v1alpha1.SpiceDBVersion{Name: "v2", Channel: "stable", Attributes: incompatible},
+				Rollout:        &v1alpha1.RolloutStatus{Image: "image:v2", PreviousImage: "image:v1", Slot: "green"},
+			},
+			wantServing:      "blue",
+			wantSlot:         "green",
+			wantDeployment:   "test-spicedb-green",
+			wantSelected:     "test-spicedb-blue",
+			wantDispatchAddr: "kubernetes:///test-spicedb-green.test:dispatch",
+		},
+		{
+			name:   "finished blue/green rollout serves from its slot",
+			config: `{"datastoreEngine": "cockroachdb"}`,
+			status: v1alpha1.ClusterStatus{
+				DeploymentSlot: "green",
+				CurrentVersion: &v1alpha1.SpiceDBVersion{Name: "v2", Channel: "stable", Attributes: incompatible},
+				Rollout:        &v1alpha1.RolloutStatus{Image: "image:v2", Slot: "green", CompletionTime: ptr.To(metav1.Now())},
+			},
+			wantServing:      "green",
+			wantSlot:         "green",
+			wantDeployment:   "test-spicedb-green",
+			wantSelected:     "test-spicedb-green",
+			wantDispatchAddr: "kubernetes:///test-spicedb-green.test:dispatch",
+		},
+		{
+			name:           "without dispatch, updates roll the serving deployment",
+			config:         `{"datastoreEngine": "cockroachdb", "dispatchEnabled": false}`,
+			status:         v1alpha1.ClusterStatus{CurrentVersion: &v1alpha1.SpiceDBVersion{Name: "v1", Channel: "stable"}, Rollout: completed},
+			wantDeployment: "test-spicedb",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := &v1alpha1.SpiceDBCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test", UID: "1"},
+				Spec:       v1alpha1.ClusterSpec{Config: json.RawMessage(tt.config)},
+				Status:     tt.status,
+			}
+			secret := &corev1.Secret{Data: map[string][]byte{
+				"datastore_uri": []byte("uri"),
+				"preshared_key": []byte("psk"),
+			}}
+			got, _, err := NewConfig(cluster, &globalConfig, secret, newFakeResources())
+			require.NoError(t, err)
+			require.Equal(t, tt.wantServing, got.ServingSlot)
+			require.Equal(t, tt.wantSlot, got.DeploymentSlot)
+
+			deployment := got.Deployment("", "")
+			require.Equal(t, tt.wantDeployment, *deployment.Name)
+			require.Equal(t, tt.wantSelected, got.Service().Spec.Selector["app.kubernetes.io/instance"])
+
+			var dispatchAddr string
+			for _, env := range deployment.Spec.Template.Spec.Containers[0].Env {
+				if *env.Name == "SPICEDB_DISPATCH_UPSTREAM_ADDR" {
+					dispatchAddr = *env.Value
+				}
+			}
+			require.Equal(t, tt.wantDispatchAddr, dispatchAddr)
+
+			if len(tt.wantSlot) > 0 {
+				service := got.DispatchService(tt.wantSlot)
+				require.Equal(t, tt.wantDeployment, *service.Name)
+				require.Equal(t, tt.wantDeployment, service.Spec.Selector["app.kubernetes.io/instance"])
+				require.Equal(t, metadata.LabelsForComponent("test", metadata.ComponentDispatchServiceLabel), service.Labels)
+			}
+		})
+	}
+}
+
+func TestHorizontalPodAutoscaler(t *testing.T) {
+	resources := newFakeResources()
+	tests := []struct {
+		name    string
+		cluster v1alpha1.ClusterSpec
+		wantHPA *applyautoscalingv2.HorizontalPodAutoscalerApplyConfiguration
+		wantErr string
+	}{
+		{
+			name: "cpu and custom metrics",
+			cluster: v1alpha1.ClusterSpec{
+				Config: json.RawMessage(`{"datastoreEngine": "cockroachdb"}`),
+				Autoscaling: &v1alpha1.ClusterAutoscaling{
+					MinReplicas:                    ptr.To[int32](2),
+					MaxReplicas:                    5,
+					TargetCPUUtilizationPercentage: ptr.To[int32](70),
+					Metrics: []autoscalingv2.MetricSpec{{
+						Type: autoscalingv2.PodsMetricSourceType,
+						Pods: &autoscalingv2.PodsMetricSource{
+							Metric: autoscalingv2.MetricIdentifier{Name: "grpc_requests_per_second"},
+							Target: autoscalingv2.MetricTarget{
+								Type:         autoscalingv2.AverageValueMetricType,
+								AverageValue: ptr.To(resource.MustParse("100")),
+							},
+						},
+					}},
+				},
+			},
+			wantHPA: applyautoscalingv2.HorizontalPodAutoscaler("test", "test").
+				WithLabels(metadata.LabelsForComponent("test", metadata.ComponentHPALabel)).
+				WithOwnerReferences(applymetav1.OwnerReference().
+					WithName("test").
+					WithKind(v1alpha1.SpiceDBClusterKind).
+					WithAPIVersion(v1alpha1.SchemeGroupVersion.String()).
+					WithUID("1")).
+				WithSpec(applyautoscalingv2.HorizontalPodAutoscalerSpec().
+					WithScaleTargetRef(applyautoscalingv2.CrossVersionObjectReference().
+						WithAPIVersion("apps/v1").
+						WithKind("Deployment").
+						WithName("test-spicedb")).
+					WithMinReplicas(2).
+					WithMaxReplicas(5).
+					WithMetrics(
+						applyautoscalingv2.MetricSpec().
+							WithType(autoscalingv2.ResourceMetricSourceType).
+							WithResource(applyautoscalingv2.ResourceMetricSource().
+								WithName(corev1.ResourceCPU).
+								WithTarget(applyautoscalingv2.MetricTarget().
+									WithType(autoscalingv2.UtilizationMetricType).
+									WithAverageUtilization(70))),
+						applyautoscalingv2.MetricSpec().
+							WithType(autoscalingv2.PodsMetricSourceType).
+							WithPods(applyautoscalingv2.PodsMetricSource().
+								WithMetric(applyautoscalingv2.MetricIdentifier().WithName("grpc_requests_per_second")).
+								WithTarget(applyautoscalingv2.MetricTarget().
+									WithType(autoscalingv2.AverageValueMetricType).
+									WithAverageValue(resource.MustParse("100")))),
+					),
+				),
+		},
+		{
+			name: "patches can't change the scale target",
+			cluster: v1alpha1.ClusterSpec{
+				Config:      json.RawMessage(`{"datastoreEngine": "cockroachdb"}`),
+				Autoscaling: &v1alpha1.ClusterAutoscaling{MaxReplicas: 3},
+				Patches: []v1alpha1.Patch{{
+					Kind: "HorizontalPodAutoscaler",
+					Patch: json.RawMessage(`
+spec:
+  maxReplicas: 4
+  scaleTargetRef:
+    name: other
+`),
+				}},
+			},
+			wantHPA: applyautoscalingv2.HorizontalPodAutoscaler("test", "test").
+				WithLabels(metadata.LabelsForComponent("test", metadata.ComponentHPALabel)).
+				WithOwnerReferences(applymetav1.OwnerReference().
+					WithName("test").
+					WithKind(v1alpha1.SpiceDBClusterKind).
+					WithAPIVersion(v1alpha1.SchemeGroupVersion.String()).
+					WithUID("1")).
+				WithSpec(applyautoscalingv2.HorizontalPodAutoscalerSpec().
+					WithScaleTargetRef(applyautoscalingv2.CrossVersionObjectReference().
+						WithAPIVersion("apps/v1").
+						WithKind("Deployment").
+						WithName("test-spicedb")).
+					WithMaxReplicas(4),
+				),
+		},
+		{
+			name: "a metric without the source its type names is an error",
+			cluster: v1alpha1.ClusterSpec{
+				Config: json.RawMessage(`{"datastoreEngine": "cockroachdb"}`),
+				Autoscaling: &v1alpha1.ClusterAutoscaling{
+					MaxReplicas: 3,
+					Metrics: []autoscalingv2.MetricSpec{{
+						Type: autoscalingv2.PodsMetricSourceType,
+						External: &autoscalingv2.ExternalMetricSource{
+							Metric: autoscalingv2.MetricIdentifier{Name: "queue_depth"},
+						},
+					}},
+				},
+			},
+			wantErr: `autoscaling.metrics[0]: metric of type "Pods" has no matching source`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret := &corev1.Secret{Data: map[string][]byte{
+				"datastore_uri": []byte("uri"),
+				"preshared_key": []byte("psk"),
+			}}
+			cluster := &v1alpha1.SpiceDBCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test",
+					Namespace: "test",
+					UID:       types.UID("1"),
+				},
+				Spec: tt.cluster,
+			}
+			got, _, err := NewConfig(cluster, ptr.To(testGlobalConfig.Copy()), secret, resources)
+			if len(tt.wantErr) > 0 {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			wantHPA, err := json.Marshal(tt.wantHPA)
+			require.NoError(t, err)
+			gotHPA, err := json.Marshal(got.HorizontalPodAutoscaler())
+			require.NoError(t, err)
+			require.JSONEq(t, string(wantHPA), string(gotHPA))
+
+			// the autoscaler owns the replica count
+			require.Nil(t, got.Deployment("1", "2").Spec.Replicas)
+		})
+	}
+}
+
+func TestPodDisruptionBudget(t *testing.T) {
+	resources := newFakeResources()
+	tests := []struct {
+		name    string
+		cluster v1alpha1.ClusterSpec
+		wantPDB *applypolicyv1.PodDisruptionBudgetApplyConfiguration
+	}{
+		{
+			name: "single replica",
+			cluster: v1alpha1.ClusterSpec{
+				Config: json.RawMessage(`{"datastoreEngine": "cockroachdb", "replicas": 1}`),
+			},
+			wantPDB: expectedPDB(intstr.FromInt32(1)),
+		},
+		{
+			name: "default replicas",
+			cluster: v1alpha1.ClusterSpec{
+				Config: json.RawMessage(`{"datastoreEngine": "cockroachdb"}`),
+			},
+			wantPDB: expectedPDB(intstr.FromInt32(1)),
+		},
+		{
+			name: "larger clusters allow more disruptions",
+			cluster: v1alpha1.ClusterSpec{
+				Config: json.RawMessage(`{"datastoreEngine": "cockroachdb", "replicas": 8}`),
+			},
+			wantPDB: expectedPDB(intstr.FromInt32(2)),
+		},
+		{
+			name: "autoscaled clusters use a percentage",
+			cluster: v1alpha1.ClusterSpec{
+				Config:      json.RawMessage(`{"datastoreEngine": "cockroachdb"}`),
+				Autoscaling: &v1alpha1.ClusterAutoscaling{MaxReplicas: 10},
+			},
+			wantPDB: expectedPDB(intstr.FromString("25%")),
+		},
+		{
+			name: "patches can't change the selector",
+			cluster: v1alpha1.ClusterSpec{
+				Config: json.RawMessage(`{"datastoreEngine": "cockroachdb", "replicas": 3}`),
+				Patches: []v1alpha1.Patch{{
+					Kind: "PodDisruptionBudget",
+					Patch: json.RawMessage(`
+spec:
+  maxUnavailable: 2
+  selector:
+    matchLabels:
+      app: other
+`),
+				}},
+			},
+			wantPDB: expectedPDB(intstr.FromInt32(2)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret := &corev1.Secret{Data: map[string][]byte{
+				"datastore_uri": []byte("uri"),
+				"preshared_key": []byte("psk"),
+			}}
+			cluster := &v1alpha1.SpiceDBCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test",
+					Namespace: "test",
+					UID:       types.UID("1"),
+				},
+				Spec: tt.cluster,
+			}
+			got, _, err := NewConfig(cluster, ptr.To(testGlobalConfig.Copy()), secret, resources)
+			require.NoError(t, err)
+
+			wantPDB, err := json.Marshal(tt.wantPDB)
+			require.NoError(t, err)
+			gotPDB, err := json.Marshal(got.PodDisruptionBudget())
+			require.NoError(t, err)
+			require.JSONEq(t, string(wantPDB), string(gotPDB))
+		})
+	}
+}
+
+func expectedPDB(maxUnavailable intstr.IntOrString) *applypolicyv1.PodDisruptionBudgetApplyConfiguration {
+	return applypolicyv1.PodDisruptionBudget("test", "test").
+		WithLabels(metadata.LabelsForComponent("test", metadata.ComponentPDBLabel)).
+		WithOwnerReferences(applymetav1.OwnerReference().
+			WithName("test").
+			WithKind(v1alpha1.SpiceDBClusterKind).
+			WithAPIVersion(v1alpha1.SchemeGroupVersion.String()).
+			WithUID("1")).
+		WithSpec(applypolicyv1.PodDisruptionBudgetSpec().
+			WithSelector(applymetav1.LabelSelector().
+				WithMatchLabels(metadata.LabelsForComponent("test", metadata.ComponentSpiceDBLabelValue))).
+			WithMaxUnavailable(maxUnavailable),
+		)
+}
+
+func TestNetworkPolicy(t *testing.T) {
+	resources := newFakeResources()
+	clusterPods := applymetav1.LabelSelector().
+		WithMatchLabels(metadata.LabelsForComponent("test", metadata.ComponentSpiceDBLabelValue))
+	ownerRef := applymetav1.OwnerReference().
+		WithName("test").
+		WithKind(v1alpha1.SpiceDBClusterKind).
+		WithAPIVersion(v1alpha1.SchemeGroupVersion.String()).
+		WithUID("1")
+	dispatchRule := applynetworkingv1.NetworkPolicyIngressRule().
+		WithPorts(networkPolicyPort(50053)).
+		WithFrom(applynetworkingv1.NetworkPolicyPeer().WithPodSelector(clusterPods))
+	tests := []struct {
+		name    string
+		cluster v1alpha1.ClusterSpec
+		wantNP  *applynetworkingv1.NetworkPolicyApplyConfiguration
+	}{
+		{
+			name: "only restricts dispatch by default",
+			cluster: v1alpha1.ClusterSpec{
+				Config: json.RawMessage(`{"datastoreEngine": "cockroachdb", "networkPolicyEnabled": true}`),
+			},
+			wantNP: applynetworkingv1.NetworkPolicy("test", "test").
+				WithLabels(metadata.LabelsForComponent("test", metadata.ComponentNetworkPolicyLabel)).
+				WithOwnerReferences(ownerRef).
+				WithSpec(applynetworkingv1.NetworkPolicySpec().
+					WithPodSelector(clusterPods).
+					WithPolicyTypes(networkingv1.PolicyTypeIngress).
+					WithIngress(
+						applynetworkingv1.NetworkPolicyIngressRule().WithPorts(networkPolicyPort(50051), networkPolicyPort(8443)),
+						applynetworkingv1.NetworkPolicyIngressRule().WithPorts(networkPolicyPort(9090)),
+						dispatchRule,
+					),
+				),
+		},
+		{
+			name: "allowlisted clients and metrics",
+			cluster: v1alpha1.ClusterSpec{
+				Config: json.RawMessage(`{
+					"datastoreEngine": "cockroachdb",
+					"networkPolicyEnabled": "true",
+					"networkPolicyClientNamespaces": "app, frontend",
+					"networkPolicyClientPodSelector": "role=client",
+					"networkPolicyMetricsNamespaces": "monitoring"
+				}`),
+			},
+			wantNP: applynetworkingv1.NetworkPolicy("test", "test").
+				WithLabels(metadata.LabelsForComponent("test", metadata.ComponentNetworkPolicyLabel)).
+				WithOwnerReferences(ownerRef).
+				WithSpec(applynetworkingv1.NetworkPolicySpec().
+					WithPodSelector(clusterPods).
+					WithPolicyTypes(networkingv1.PolicyTypeIngress).
+					WithIngress(
+						applynetworkingv1.NetworkPolicyIngressRule().
+							WithPorts(networkPolicyPort(50051), networkPolicyPort(8443)).
+							WithFrom(applynetworkingv1.NetworkPolicyPeer().
+								WithNamespaceSelector(applymetav1.LabelSelector().WithMatchExpressions(
+									applymetav1.LabelSelectorRequirement().
+										WithKey("kubernetes.io/metadata.name").
+										WithOperator(metav1.LabelSelectorOpIn).
+										WithValues("app", "frontend"))).
+								WithPodSelector(applymetav1.LabelSelector().WithMatchLabels(map[string]string{"role": "client"}))),
+						applynetworkingv1.NetworkPolicyIngressRule().
+							WithPorts(networkPolicyPort(9090)).
+							WithFrom(applynetworkingv1.NetworkPolicyPeer().
+								WithNamespaceSelector(applymetav1.LabelSelector().WithMatchExpressions(
+									applymetav1.LabelSelectorRequirement().
+										WithKey("kubernetes.io/metadata.name").
+										WithOperator(metav1.LabelSelectorOpIn).
+										WithValues("monitoring")))),
+						dispatchRule,
+					),
+				),
+		},
+		{
+			name: "no dispatch rule when dispatch is disabled",
+			cluster: v1alpha1.ClusterSpec{
+				Config: json.RawMessage(`{"datastoreEngine": "cockroachdb", "networkPolicyEnabled": true, "dispatchEnabled": false}`),
+				Patches: []v1alpha1.Patch{{
+					Kind: "NetworkPolicy",
+					Patch: json.RawMessage(`
+spec:
+  podSelector:
+    matchLabels:
+      app: other
+`),
+				}},
+			},
+			wantNP: applynetworkingv1.NetworkPolicy("test", "test").
+				WithLabels(metadata.LabelsForComponent("test", metadata.ComponentNetworkPolicyLabel)).
+				WithOwnerReferences(ownerRef).
+				WithSpec(applynetworkingv1.NetworkPolicySpec().
+					WithPodSelector(clusterPods).
+					WithPolicyTypes(networkingv1.PolicyTypeIngress).
+					WithIngress(
+						applynetworkingv1.NetworkPolicyIngressRule().WithPorts(networkPolicyPort(50051), networkPolicyPort(8443)),
+						applynetworkingv1.NetworkPolicyIngressRule().WithPorts(networkPolicyPort(9090)),
+					),
+				),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret := &corev1.Secret{Data: map[string][]byte{
+				"datastore_uri": []byte("uri"),
+				"preshared_key": []byte("psk"),
+			}}
+			cluster := &v1alpha1.SpiceDBCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test",
+					Namespace: "test",
+					UID:       types.UID("1"),
+				},
+				Spec: tt.cluster,
+			}
+			got, _, err := NewConfig(cluster, ptr.To(testGlobalConfig.Copy()), secret, resources)
+			require.NoError(t, err)
+
+			wantNP, err := json.Marshal(tt.wantNP)
+			require.NoError(t, err)
+			gotNP, err := json.Marshal(got.NetworkPolicy())
+			require.NoError(t, err)
+			require.JSONEq(t, string(wantNP), string(gotNP))
+		})
+	}
+}
+
 func TestRole(t *testing.T) {
 	resources := newFakeResources()
 	tests := []struct {
@@ -2741,7 +3550,7 @@ var testGlobalConfig = OperatorConfig{
 				Name:     "cockroachdb",
 				Metadata: map[string]string{"datastore": "cockroachdb", "default": "true"},
 				Nodes: []updates.State{
-					{ID: "v1", Tag: "v1", Migration: "to-v1"},
+					{ID: "v1", Tag: "v1", Migration: "to-v1", Dispatch: "v1"},
 				},
 				Edges: map[string][]string{"v1": {}},
 			},
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	applycorev1 "k8s.io/client-go/applyconfigurations/core/v1"
+
+	"github.com/authzed/spicedb-operator/pkg/metadata"
+)
+
+// Keys in the published connection secret. The preshared key is the one that
+// clients should use, so during a rotation it's the new key once the pods
+// accept it.
+const (
+	ConnectionEndpointKey     = "endpoint"
+	ConnectionGRPCPortKey     = "grpc_port"
+	ConnectionCACertKey       = "tls_ca.crt"
+	ConnectionPresharedKeyKey = "preshared_key"
+
+	grpcPort = 50051
+)
+
+// connectionSecretConflicts reports whether the connection secret would
+// overwrite a secret that the cluster reads.
+func (c *Config) connectionSecretConflicts() bool {
+	if len(c.ConnectionSecretName) == 0 || c.ConnectionSecretNamespace != c.Namespace {
+		return false
+	}
+	return c.ConnectionSecretName == c.SecretName ||
+		c.ConnectionSecretName == c.TLSSecretName ||
+		c.ConnectionSecretName == SelfSignedCAName(c.Name) ||
+		slices.Contains(c.ReferencedSecrets, c.ConnectionSecretName)
+}
+
+// connectionSecretData holds the in-cluster address of the service; the CA
+// is only included if the TLS secret has one.
+func (c *Config) connectionSecretData(caCert string) map[string][]byte {
+	psk := c.PresharedKey
+	if c.PublishPresharedKeyNext && len(c.PresharedKeyNext) > 0 {
+		psk = c.PresharedKeyNext
+	}
+	data := map[string][]byte{
+		ConnectionEndpointKey:     []byte(net.JoinHostPort(fmt.Sprintf("%s.%s.svc", c.Name, c.Namespace), strconv.Itoa(grpcPort))),
+		ConnectionGRPCPortKey:     []byte(strconv.Itoa(grpcPort)),
+		ConnectionPresharedKeyKey: []byte(psk),
+	}
+	if len(caCert) > 0 {
+		data[ConnectionCACertKey] = []byte(caCert)
+	}
+	return data
+}
+
+// connectionSecretLabels name the cluster's namespace when the secret is
+// published elsewhere, so that changes to it still requeue the cluster.
+func (c *Config) connectionSecretLabels() map[string]string {
+	labels := metadata.LabelsForComponent(c.Name, metadata.ComponentConnectionSecretLabel)
+	if c.ConnectionSecretNamespace != c.Namespace {
+		labels[metadata.OwnerNamespaceLabelKey] = c.Namespace
+	}
+	return labels
+}
+
+func (c *Config) unpatchedConnectionSecret(caCert string) *applycorev1.SecretApplyConfiguration {
+	return applycorev1.Secret(c.ConnectionSecretName, c.ConnectionSecretNamespace).
+		WithLabels(c.connectionSecretLabels()).
+		WithType(corev1.SecretTypeOpaque).
+		WithData(c.connectionSecretData(caCert))
+}
+
+func (c *Config) ConnectionSecret(caCert string) *applycorev1.SecretApplyConfiguration {
+	s := applycorev1.Secret(c.ConnectionSecretName, c.ConnectionSecretNamespace)
+	_, _, _ = ApplyPatches(c.unpatchedConnectionSecret(caCert), s, c.Patches, c.Resources)
+
+	// ensure patches don't overwrite anything critical for operator function
+	s.WithName(c.ConnectionSecretName).WithNamespace(c.ConnectionSecretNamespace).
+		WithLabels(c.connectionSecretLabels()).
+		WithData(c.connectionSecretData(caCert))
+
+	// owner references can't cross namespaces; the controller deletes
+	// secrets in other namespaces itself
+	if c.ConnectionSecretNamespace == c.Namespace {
+		s.WithOwnerReferences(c.ownerRef())
+	}
+	return s
+}
@@ -0,0 +1,188 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+	"github.com/authzed/spicedb-operator/pkg/metadata"
+)
+
+func TestConnectionSecretConfig(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        string
+		wantName      string
+		wantNamespace string
+		wantWarning   string
+		wantErr       string
+	}{
+		{
+			name:   "disabled",
+			config: `{"datastoreEngine": "cockroachdb"}`,
+		},
+		{
+			name:          "same namespace",
+			config:        `{"datastoreEngine": "cockroachdb", "connectionSecretName": "spicedb"}`,
+			wantName:      "spicedb",
+			wantNamespace: "test",
+		},
+		{
+			name:          "allowed namespace",
+			config:        `{"datastoreEngine": "cockroachdb", "connectionSecretName": "spicedb", "connectionSecretNamespace": "app"}`,
+			wantName:      "spicedb",
+			wantNamespace: "app",
+		},
+		{
+			name:    "namespace that isn't allowed",
+			config:  `{"datastoreEngine": "cockroachdb", "connectionSecretName": "spicedb", "connectionSecretNamespace": "other"}`,
+			wantErr: `invalid value for connectionSecretNamespace "other"`,
+		},
+		{
+			name:    "namespace allowed for another namespace",
+			config:  `{"datastoreEngine": "cockroachdb", "connectionSecretName": "spicedb", "connectionSecretNamespace": "app2"}`,
+			wantErr: `invalid value for connectionSecretNamespace "app2"`,
+		},
+		{
+			name:        "namespace without a name",
+			config:      `{"datastoreEngine": "cockroachdb", "connectionSecretNamespace": "app"}`,
+			wantWarning: `"connectionSecretNamespace" is ignored unless "connectionSecretName" is set`,
+		},
+		{
+			name:    "overwrites the config secret",
+			config:  `{"datastoreEngine": "cockroachdb", "connectionSecretName": "config"}`,
+			wantErr: `invalid value for connectionSecretName "config"`,
+		},
+		{
+			name:    "overwrites the tls secret",
+			config:  `{"datastoreEngine": "cockroachdb", "selfSignedTLS": true, "connectionSecretName": "test-spicedb-tls"}`,
+			wantErr: `invalid value for connectionSecretName "test-spicedb-tls"`,
+		},
+		{
+			name:          "config secret name in another namespace",
+			config:        `{"datastoreEngine": "cockroachdb", "connectionSecretName": "config", "connectionSecretNamespace": "app"}`,
+			wantName:      "config",
+			wantNamespace: "app",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := &v1alpha1.SpiceDBCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test", UID: "1"},
+				Spec:       v1alpha1.ClusterSpec{Config: json.RawMessage(tt.config)},
+			}
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "config"},
+				Data: map[string][]byte{
+					"datastore_uri": []byte("uri"),
+					"preshared_key": []byte("psk"),
+				},
+			}
+			globalConfig := testGlobalConfig.Copy()
+			globalConfig.ConnectionSecretNamespaces = map[string][]string{"test": {"app"}, "other": {"app2"}}
+			got, warning, err := NewConfig(cluster, &globalConfig, secret, newFakeResources())
+			if len(tt.wantErr) > 0 {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			if len(tt.wantWarning) > 0 {
+				require.ErrorContains(t, warning, tt.wantWarning)
+			}
+			require.Equal(t, tt.wantName, got.ConnectionSecretName)
+			require.Equal(t, tt.wantNamespace, got.ConnectionSecretNamespace)
+		})
+	}
+}
+
+func TestConnectionSecret(t *testing.T) {
+	got := newTestConfig(t, v1alpha1.ClusterSpec{
+		Config: json.RawMessage(`{"datastoreEngine": "cockroachdb", "connectionSecretName": "spicedb"}`),
+		Patches: []v1alpha1.Patch{{
+			Kind:  "Secret",
+			Patch: json.RawMessage(`{"metadata": {"annotations": {"reflector": "true"}}, "data": {"preshared_key": "b3RoZXI="}}`),
+		}},
+	})
+
+	secret := got.ConnectionSecret("ca")
+	require.Equal(t, "spicedb", *secret.Name)
+	require.Equal(t, "test", *secret.Namespace)
+	require.Equal(t, metadata.LabelsForComponent("test", metadata.ComponentConnectionSecretLabel), secret.Labels)
+	require.Equal(t, map[string]string{"reflector": "true"}, secret.Annotations)
+	require.Len(t, secret.OwnerReferences, 1)
+	require.Equal(t, map[string][]byte{
+		"endpoint":      []byte("test.test.svc:50051"),
+		"grpc_port":     []byte("50051"),
+		"tls_ca.crt":    []byte("ca"),
+		"preshared_key": []byte("psk"),
+	}, secret.Data)
+
+	// clients are handed the new key while both are accepted
+	got.PresharedKeyNext = "next"
+	got.PublishPresharedKeyNext = true
+	require.Equal(t, []byte("next"), got.ConnectionSecret("").Data["preshared_key"])
+	require.NotContains(t, got.ConnectionSecret("").Data, "tls_ca.crt")
+
+	// secrets in other namespaces can't be owned, but point back at the cluster
+	got.ConnectionSecretNamespace = "app"
+	secret = got.ConnectionSecret("")
+	require.Equal(t, "app", *secret.Namespace)
+	require.Empty(t, secret.OwnerReferences)
+	require.Equal(t, "test", secret.Labels[metadata.OwnerNamespaceLabelKey])
+	keys, err := metadata.GetClusterKeyFromMeta(&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "app", Labels: secret.Labels}})
+	require.NoError(t, err)
+	require.Equal(t, []string{"test/test"}, keys)
+}
+
+func TestConnectionSecretDuringRotation(t *testing.T) {
+	secret := &corev1.Secret{Data: map[string][]byte{
+		"datastore_uri":      []byte("uri"),
+		"preshared_key":      []byte("psk"),
+		"preshared_key_next": []byte("next"),
+	}}
+	tests := []struct {
+		name      string
+		condition *metav1.Condition
+		expectKey string
+	}{
+		{
+			name:      "key added by hand",
+			expectKey: "psk",
+		},
+		{
+			name:      "pods are rolling out the next key",
+			condition: ptr.To(v1alpha1.NewPresharedKeyRotationRollingOutCondition()),
+			expectKey: "psk",
+		},
+		{
+			name:      "pods accept both keys",
+			condition: ptr.To(v1alpha1.NewPresharedKeyRotationInProgressCondition()),
+			expectKey: "next",
+		},
+		{
+			name:      "rotation completed",
+			condition: ptr.To(v1alpha1.NewPresharedKeyRotationCompleteCondition()),
+			expectKey: "next",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := &v1alpha1.SpiceDBCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test", UID: types.UID("1")},
+				Spec:       v1alpha1.ClusterSpec{Config: json.RawMessage(`{"datastoreEngine": "cockroachdb", "connectionSecretName": "spicedb"}`)},
+			}
+			if tt.condition != nil {
+				cluster.SetStatusCondition(*tt.condition)
+			}
+			got, _, err := NewConfig(cluster, ptr.To(testGlobalConfig.Copy()), secret, newFakeResources())
+			require.NoError(t, err)
+			require.Equal(t, []byte(tt.expectKey), got.ConnectionSecret("").Data["preshared_key"])
+		})
+	}
+}
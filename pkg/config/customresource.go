@@ -0,0 +1,126 @@
+package config
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	applymetav1 "k8s.io/client-go/applyconfigurations/meta/v1"
+
+	"github.com/authzed/spicedb-operator/pkg/metadata"
+)
+
+// CustomResourceApplyConfiguration is an apply configuration for third-party
+// resources (i.e. prometheus operator or gateway api types) that don't have
+// generated apply configurations. The spec is left schemaless.
+type CustomResourceApplyConfiguration struct {
+	applymetav1.TypeMetaApplyConfiguration    `json:",inline"`
+	*applymetav1.ObjectMetaApplyConfiguration `json:"metadata,omitempty"`
+	Spec                                      map[string]any `json:"spec,omitempty"`
+}
+
+func newCustomResource(gv schema.GroupVersion, kind, name, namespace string) *CustomResourceApplyConfiguration {
+	b := &CustomResourceApplyConfiguration{}
+	b.WithKind(kind)
+	b.WithAPIVersion(gv.String())
+	b.WithName(name)
+	b.WithNamespace(namespace)
+	return b
+}
+
+func (b *CustomResourceApplyConfiguration) ensureObjectMetaApplyConfigurationExists() {
+	if b.ObjectMetaApplyConfiguration == nil {
+		b.ObjectMetaApplyConfiguration = &applymetav1.ObjectMetaApplyConfiguration{}
+	}
+}
+
+func (b *CustomResourceApplyConfiguration) WithKind(value string) *CustomResourceApplyConfiguration {
+	b.Kind = &value
+	return b
+}
+
+func (b *CustomResourceApplyConfiguration) WithAPIVersion(value string) *CustomResourceApplyConfiguration {
+	b.APIVersion = &value
+	return b
+}
+
+func (b *CustomResourceApplyConfiguration) WithName(value string) *CustomResourceApplyConfiguration {
+	b.ensureObjectMetaApplyConfigurationExists()
+	b.Name = &value
+	return b
+}
+
+func (b *CustomResourceApplyConfiguration) WithNamespace(value string) *CustomResourceApplyConfiguration {
+	b.ensureObjectMetaApplyConfigurationExists()
+	b.Namespace = &value
+	return b
+}
+
+// WithLabels puts the entries into the Labels field, overwriting existing
+// values with the same key.
+func (b *CustomResourceApplyConfiguration) WithLabels(entries map[string]string) *CustomResourceApplyConfiguration {
+	b.ensureObjectMetaApplyConfigurationExists()
+	if b.Labels == nil && len(entries) > 0 {
+		b.Labels = make(map[string]string, len(entries))
+	}
+	for k, v := range entries {
+		b.Labels[k] = v
+	}
+	return b
+}
+
+// WithAnnotations puts the entries into the Annotations field, overwriting
+// existing values with the same key.
+func (b *CustomResourceApplyConfiguration) WithAnnotations(entries map[string]string) *CustomResourceApplyConfiguration {
+	b.ensureObjectMetaApplyConfigurationExists()
+	if b.Annotations == nil && len(entries) > 0 {
+		b.Annotations = make(map[string]string, len(entries))
+	}
+	for k, v := range entries {
+		b.Annotations[k] = v
+	}
+	return b
+}
+
+func (b *CustomResourceApplyConfiguration) WithOwnerReferences(values ...*applymetav1.OwnerReferenceApplyConfiguration) *CustomResourceApplyConfiguration {
+	b.ensureObjectMetaApplyConfigurationExists()
+	for _, v := range values {
+		if v == nil {
+			panic("nil value passed to WithOwnerReferences")
+		}
+		b.OwnerReferences = append(b.OwnerReferences, *v)
+	}
+	return b
+}
+
+func (b *CustomResourceApplyConfiguration) WithSpec(value map[string]any) *CustomResourceApplyConfiguration {
+	b.Spec = value
+	return b
+}
+
+// patchedCustomResource applies patches to a custom resource and restores
+// the fields the operator relies on, including the named spec fields.
+func (c *Config) patchedCustomResource(unpatched *CustomResourceApplyConfiguration, component string, requiredSpecFields ...string) *CustomResourceApplyConfiguration {
+	obj := &CustomResourceApplyConfiguration{}
+	_, _, _ = ApplyPatches(unpatched, obj, c.Patches, c.Resources)
+
+	// not allowed to patch out the spec
+	if obj.Spec == nil {
+		obj.Spec = unpatched.Spec
+	}
+	for _, field := range requiredSpecFields {
+		obj.Spec[field] = unpatched.Spec[field]
+	}
+
+	// ensure patches don't overwrite anything critical for operator function
+	obj.WithKind(*unpatched.Kind).WithAPIVersion(*unpatched.APIVersion).
+		WithName(c.Name).WithNamespace(c.Namespace).
+		WithLabels(metadata.LabelsForComponent(c.Name, component)).
+		WithOwnerReferences(c.ownerRef())
+	return obj
+}
+
+func labelsAsAny(labels map[string]string) map[string]any {
+	out := make(map[string]any, len(labels))
+	for k, v := range labels {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+	"github.com/authzed/spicedb-operator/pkg/updates"
+)
+
+// Defaults holds values that are picked by the operator when they are
+// omitted from a cluster's spec.
+type Defaults struct {
+	// Channel and Version are empty if the cluster specifies an image
+	// explicitly, since no update graph is used in that case. Version is
+	// also empty until a release of the channel has soaked.
+	Channel  string
+	Version  string
+	Replicas int32
+
+	// ReplicasSet is true if the cluster already sets replicas, either in
+	// its spec or config, or if the replica count is owned by an autoscaler.
+	ReplicasSet bool
+}
+
+// NewDefaults resolves the channel, version, and replicas that NewConfig
+// would select for a new cluster.
+func NewDefaults(cluster *v1alpha1.SpiceDBCluster, globalConfig *OperatorConfig) (*Defaults, error) {
+	if cluster.Spec.Config == nil {
+		return nil, fmt.Errorf("couldn't parse empty config")
+	}
+
+	config := RawConfig(make(map[string]any))
+	if err := json.Unmarshal(cluster.Spec.Config, &config); err != nil {
+		return nil, fmt.Errorf("couldn't parse config: %w", err)
+	}
+
+	datastoreEngine := datastoreEngineKey.pop(config)
+	if len(datastoreEngine) == 0 {
+		return nil, fmt.Errorf("datastoreEngine is a required field")
+	}
+
+	var defaults Defaults
+	_, defaults.ReplicasSet = config[replicasKey.key]
+	defaults.ReplicasSet = defaults.ReplicasSet || cluster.Spec.Replicas != nil || cluster.Spec.Autoscaling != nil
+
+	selectedReplicaKey := replicasKey
+	if datastoreEngine == "memory" {
+		selectedReplicaKey = replicasKeyForMemory
+	}
+	replicas, err := selectedReplicaKey.pop(config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for replicas %q: %w", replicas, err)
+	}
+	defaults.Replicas = replicas
+	if cluster.Spec.Replicas != nil {
+		defaults.Replicas = *cluster.Spec.Replicas
+	}
+
+	_, target, _, err := globalConfig.ComputeTarget(globalConfig.ImageName, imageKey.pop(config), cluster.Spec.Version, cluster.Spec.Channel, datastoreEngine, nil, false, updates.NewSoak(cluster.Spec.UpdatePolicy, time.Now()))
+	if notSoaked, ok := err.(*updates.NotSoakedError); ok {
+		// the version is picked once a release has soaked
+		defaults.Channel = notSoaked.Channel
+		return &defaults, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if target != nil {
+		defaults.Channel = target.Channel
+		defaults.Version = target.Name
+	}
+	return &defaults, nil
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+	"github.com/authzed/spicedb-operator/pkg/updates"
+)
+
+func TestNewDefaults(t *testing.T) {
+	globalConfig := &OperatorConfig{
+		ImageName: "image",
+		UpdateGraph: updates.UpdateGraph{
+			Channels: []updates.Channel{
+				{
+					Name:     "cockroachdb",
+					Metadata: map[string]string{"datastore": "cockroachdb", "default": "true"},
+					Nodes: []updates.State{
+						{ID: "v2", Tag: "v2"},
+						{ID: "v1", Tag: "v1"},
+					},
+					Edges: map[string][]string{"v1": {"v2"}},
+				},
+				{
+					Name:     "memory",
+					Metadata: map[string]string{"datastore": "memory", "default": "true"},
+					Nodes:    []updates.State{{ID: "v1", Tag: "v1"}},
+					Edges:    map[string][]string{"v1": {}},
+				},
+				{
+					Name:     "soaking",
+					Metadata: map[string]string{"datastore": "cockroachdb", updates.MinimumSoakMetadataKey: "24h"},
+					Nodes:    []updates.State{{ID: "v3", Tag: "v3", ReleasedAt: &metav1.Time{Time: time.Now()}}},
+					Edges:    map[string][]string{"v3": {}},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		version  string
+		channel  string
+		replicas *int32
+		config   map[string]any
+		want     *Defaults
+		wantErr  string
+	}{
+		{
+			name:   "default channel and head",
+			config: map[string]any{"datastoreEngine": "cockroachdb"},
+			want:   &Defaults{Channel: "cockroachdb", Version: "v2", Replicas: 2},
+		},
+		{
+			name:   "memory replicas",
+			config: map[string]any{"datastoreEngine": "memory"},
+			want:   &Defaults{Channel: "memory", Version: "v1", Replicas: 1},
+		},
+		{
+			name:    "explicit version",
+			version: "v1",
+			config:  map[string]any{"datastoreEngine": "cockroachdb", "replicas": "3"},
+			want:    &Defaults{Channel: "cockroachdb", Version: "v1", Replicas: 3, ReplicasSet: true},
+		},
+		{
+			name:     "spec replicas",
+			replicas: ptr.To[int32](4),
+			config:   map[string]any{"datastoreEngine": "cockroachdb", "replicas": "3"},
+			want:     &Defaults{Channel: "cockroachdb", Version: "v2", Replicas: 4, ReplicasSet: true},
+		},
+		{
+			name:   "explicit image",
+			config: map[string]any{"datastoreEngine": "cockroachdb", "image": "other:v3"},
+			want:   &Defaults{Replicas: 2},
+		},
+		{
+			name:    "no release has soaked",
+			channel: "soaking",
+			config:  map[string]any{"datastoreEngine": "cockroachdb"},
+			want:    &Defaults{Channel: "soaking", Replicas: 2},
+		},
+		{
+			name:    "unknown channel",
+			channel: "unknown",
+			config:  map[string]any{"datastoreEngine": "cockroachdb"},
+			wantErr: `error fetching update source: no channel for "cockroachdb" found with name "unknown"`,
+		},
+		{
+			name:    "missing engine",
+			config:  map[string]any{},
+			wantErr: "datastoreEngine is a required field",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rawConfig, err := json.Marshal(tt.config)
+			require.NoError(t, err)
+			cluster := &v1alpha1.SpiceDBCluster{Spec: v1alpha1.ClusterSpec{
+				Version:  tt.version,
+				Channel:  tt.channel,
+				Replicas: tt.replicas,
+				Config:   rawConfig,
+			}}
+
+			got, err := NewDefaults(cluster, globalConfig)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -1,10 +1,23 @@
 package config
 
-import "github.com/authzed/spicedb-operator/pkg/updates"
+import (
+	"slices"
+
+	"github.com/authzed/spicedb-operator/pkg/updates"
+)
 
 // OperatorConfig holds operator-wide config that is used across all objects
 type OperatorConfig struct {
 	ImageName string `json:"imageName,omitempty"`
+
+	// ConnectionSecretNamespaces lists, for the namespace of a cluster, the
+	// other namespaces that it may publish its connection secret into.
+	ConnectionSecretNamespaces map[string][]string `json:"connectionSecretNamespaces,omitempty"`
+
+	// FleetRollout, if set, updates clusters in waves when the update graph
+	// changes, rather than all at once.
+	FleetRollout *updates.FleetRollout `json:"fleetRollout,omitempty"`
+
 	updates.UpdateGraph
 }
 
@@ -18,7 +31,20 @@ func NewOperatorConfig() OperatorConfig {
 
 func (o OperatorConfig) Copy() OperatorConfig {
 	return OperatorConfig{
-		ImageName:   o.ImageName,
-		UpdateGraph: o.UpdateGraph.Copy(),
+		ImageName:                  o.ImageName,
+		ConnectionSecretNamespaces: copyConnectionSecretNamespaces(o.ConnectionSecretNamespaces),
+		FleetRollout:               o.FleetRollout.Copy(),
+		UpdateGraph:                o.UpdateGraph.Copy(),
+	}
+}
+
+func copyConnectionSecretNamespaces(in map[string][]string) map[string][]string {
+	if in == nil {
+		return nil
+	}
+	out := make(map[string][]string, len(in))
+	for namespace, targets := range in {
+		out[namespace] = slices.Clone(targets)
 	}
+	return out
 }
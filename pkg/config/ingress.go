@@ -0,0 +1,122 @@
+package config
+
+import (
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	applynetworkingv1 "k8s.io/client-go/applyconfigurations/networking/v1"
+
+	"github.com/authzed/spicedb-operator/pkg/metadata"
+)
+
+const (
+	IngressTypeIngress    = "Ingress"
+	IngressTypeGatewayAPI = "GatewayAPI"
+
+	GRPCRouteKind = "GRPCRoute"
+	HTTPRouteKind = "HTTPRoute"
+)
+
+var (
+	GatewayGroupVersion = schema.GroupVersion{Group: "gateway.networking.k8s.io", Version: "v1"}
+	GRPCRouteResource   = GatewayGroupVersion.WithResource("grpcroutes")
+	HTTPRouteResource   = GatewayGroupVersion.WithResource("httproutes")
+)
+
+func (c *Config) unpatchedIngress() *applynetworkingv1.IngressApplyConfiguration {
+	spec := applynetworkingv1.IngressSpec().WithRules(c.ingressRule(c.IngressHost, "grpc"))
+	hosts := []string{c.IngressHost}
+	if len(c.IngressHTTPHost) > 0 {
+		spec.WithRules(c.ingressRule(c.IngressHTTPHost, "gateway"))
+		hosts = append(hosts, c.IngressHTTPHost)
+	}
+	if len(c.IngressClassName) > 0 {
+		spec.WithIngressClassName(c.IngressClassName)
+	}
+	if len(c.TLSSecretName) > 0 {
+		spec.WithTLS(applynetworkingv1.IngressTLS().WithHosts(hosts...).WithSecretName(c.TLSSecretName))
+	}
+
+	return applynetworkingv1.Ingress(c.Name, c.Namespace).
+		WithLabels(metadata.LabelsForComponent(c.Name, metadata.ComponentIngressLabel)).
+		WithAnnotations(c.IngressAnnotations).
+		WithSpec(spec)
+}
+
+func (c *Config) Ingress() *applynetworkingv1.IngressApplyConfiguration {
+	ing := applynetworkingv1.Ingress(c.Name, c.Namespace)
+	unpatched := c.unpatchedIngress()
+	_, _, _ = ApplyPatches(unpatched, ing, c.Patches, c.Resources)
+
+	// not allowed to patch out the spec
+	if ing.Spec == nil {
+		ing.Spec = unpatched.Spec
+	}
+
+	// ensure patches don't overwrite anything critical for operator function
+	ing.WithName(c.Name).WithNamespace(c.Namespace).
+		WithLabels(metadata.LabelsForComponent(c.Name, metadata.ComponentIngressLabel)).
+		WithOwnerReferences(c.ownerRef())
+	return ing
+}
+
+// ingressRule routes all requests for the host to a port of the service
+func (c *Config) ingressRule(host, port string) *applynetworkingv1.IngressRuleApplyConfiguration {
+	return applynetworkingv1.IngressRule().
+		WithHost(host).
+		WithHTTP(applynetworkingv1.HTTPIngressRuleValue().WithPaths(
+			applynetworkingv1.HTTPIngressPath().
+				WithPath("/").
+				WithPathType(networkingv1.PathTypePrefix).
+				WithBackend(applynetworkingv1.IngressBackend().
+					WithService(applynetworkingv1.IngressServiceBackend().
+						WithName(c.Name).
+						WithPort(applynetworkingv1.ServiceBackendPort().WithName(port)))),
+		))
+}
+
+func (c *Config) unpatchedGRPCRoute() *CustomResourceApplyConfiguration {
+	return newCustomResource(GatewayGroupVersion, GRPCRouteKind, c.Name, c.Namespace).
+		WithLabels(metadata.LabelsForComponent(c.Name, metadata.ComponentGRPCRouteLabel)).
+		WithAnnotations(c.IngressAnnotations).
+		WithSpec(c.routeSpec(c.IngressHost, 50051))
+}
+
+func (c *Config) GRPCRoute() *CustomResourceApplyConfiguration {
+	unpatched := c.unpatchedGRPCRoute()
+	return c.patchedCustomResource(unpatched, metadata.ComponentGRPCRouteLabel)
+}
+
+func (c *Config) unpatchedHTTPRoute() *CustomResourceApplyConfiguration {
+	return newCustomResource(GatewayGroupVersion, HTTPRouteKind, c.Name, c.Namespace).
+		WithLabels(metadata.LabelsForComponent(c.Name, metadata.ComponentHTTPRouteLabel)).
+		WithAnnotations(c.IngressAnnotations).
+		WithSpec(c.routeSpec(c.IngressHTTPHost, 8443))
+}
+
+func (c *Config) HTTPRoute() *CustomResourceApplyConfiguration {
+	unpatched := c.unpatchedHTTPRoute()
+	return c.patchedCustomResource(unpatched, metadata.ComponentHTTPRouteLabel)
+}
+
+// routeSpec attaches a route for the host to the configured gateway (and
+// listener port, if set) and sends all requests to a port of the service
+func (c *Config) routeSpec(host string, port int64) map[string]any {
+	parentRef := map[string]any{"name": c.GatewayName}
+	if len(c.GatewayNamespace) > 0 {
+		parentRef["namespace"] = c.GatewayNamespace
+	}
+	if c.GatewayPort > 0 {
+		parentRef["port"] = int64(c.GatewayPort)
+	}
+	return map[string]any{
+		"parentRefs": []any{parentRef},
+		"hostnames":  []any{host},
+		"rules": []any{
+			map[string]any{
+				"backendRefs": []any{
+					map[string]any{"name": c.Name, "port": port},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	networkingv1 "k8s.io/api/networking/v1"
+	applymetav1 "k8s.io/client-go/applyconfigurations/meta/v1"
+	applynetworkingv1 "k8s.io/client-go/applyconfigurations/networking/v1"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+	"github.com/authzed/spicedb-operator/pkg/metadata"
+)
+
+func TestIngress(t *testing.T) {
+	ownerRef := applymetav1.OwnerReference().
+		WithName("test").
+		WithKind(v1alpha1.SpiceDBClusterKind).
+		WithAPIVersion(v1alpha1.SchemeGroupVersion.String()).
+		WithUID("1")
+	rule := func(host, port string) *applynetworkingv1.IngressRuleApplyConfiguration {
+		return applynetworkingv1.IngressRule().
+			WithHost(host).
+			WithHTTP(applynetworkingv1.HTTPIngressRuleValue().WithPaths(
+				applynetworkingv1.HTTPIngressPath().
+					WithPath("/").
+					WithPathType(networkingv1.PathTypePrefix).
+					WithBackend(applynetworkingv1.IngressBackend().
+						WithService(applynetworkingv1.IngressServiceBackend().
+							WithName("test").
+							WithPort(applynetworkingv1.ServiceBackendPort().WithName(port)))),
+			))
+	}
+	tests := []struct {
+		name    string
+		cluster v1alpha1.ClusterSpec
+		want    *applynetworkingv1.IngressApplyConfiguration
+	}{
+		{
+			name: "grpc only",
+			cluster: v1alpha1.ClusterSpec{
+				Config: json.RawMessage(`{"datastoreEngine": "cockroachdb", "ingressType": "Ingress", "ingressHost": "spicedb.example.com"}`),
+			},
+			want: applynetworkingv1.Ingress("test", "test").
+				WithLabels(metadata.LabelsForComponent("test", metadata.ComponentIngressLabel)).
+				WithOwnerReferences(ownerRef).
+				WithSpec(applynetworkingv1.IngressSpec().WithRules(rule("spicedb.example.com", "grpc"))),
+		},
+		{
+			name: "grpc and http with tls",
+			cluster: v1alpha1.ClusterSpec{
+				Config: json.RawMessage(`{
+					"datastoreEngine": "cockroachdb",
+					"tlsSecretName": "spicedb-tls",
+					"ingressType": "Ingress",
+					"ingressHost": "grpc.example.com",
+					"ingressHTTPHost": "http.example.com",
+					"ingressClassName": "nginx",
+					"ingressAnnotations": "nginx.ingress.kubernetes.io/backend-protocol=GRPCS"
+				}`),
+			},
+			want: applynetworkingv1.Ingress("test", "test").
+				WithLabels(metadata.LabelsForComponent("test", metadata.ComponentIngressLabel)).
+				WithAnnotations(map[string]string{"nginx.ingress.kubernetes.io/backend-protocol": "GRPCS"}).
+				WithOwnerReferences(ownerRef).
+				WithSpec(applynetworkingv1.IngressSpec().
+					WithRules(rule("grpc.example.com", "grpc"), rule("http.example.com", "gateway")).
+					WithIngressClassName("nginx").
+					WithTLS(applynetworkingv1.IngressTLS().
+						WithHosts("grpc.example.com", "http.example.com").
+						WithSecretName("spicedb-tls"))),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newTestConfig(t, tt.cluster)
+			require.Equal(t, tt.want, got.Ingress())
+		})
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	got := newTestConfig(t, v1alpha1.ClusterSpec{
+		Config: json.RawMessage(`{
+			"datastoreEngine": "cockroachdb",
+			"ingressType": "GatewayAPI",
+			"ingressHost": "grpc.example.com",
+			"ingressHTTPHost": "http.example.com",
+			"gatewayName": "public",
+			"gatewayNamespace": "gateways",
+			"gatewayPort": 443
+		}`),
+		Patches: []v1alpha1.Patch{{
+			Kind:  "HTTPRoute",
+			Patch: json.RawMessage(`{"op": "add", "path": "/spec/hostnames/-", "value": "www.example.com"}`),
+		}},
+	})
+
+	tests := []struct {
+		name      string
+		route     *CustomResourceApplyConfiguration
+		kind      string
+		component string
+		hostnames []string
+		port      float64
+	}{
+		{"grpc", got.GRPCRoute(), GRPCRouteKind, metadata.ComponentGRPCRouteLabel, []string{"grpc.example.com"}, 50051},
+		{"http", got.HTTPRoute(), HTTPRouteKind, metadata.ComponentHTTPRouteLabel, []string{"http.example.com", "www.example.com"}, 8443},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.kind, *tt.route.Kind)
+			require.Equal(t, GatewayGroupVersion.String(), *tt.route.APIVersion)
+			require.Equal(t, metadata.LabelsForComponent("test", tt.component), tt.route.Labels)
+
+			encoded, err := json.Marshal(tt.route.Spec)
+			require.NoError(t, err)
+			var spec struct {
+				ParentRefs []map[string]any `json:"parentRefs"`
+				Hostnames  []string         `json:"hostnames"`
+				Rules      []struct {
+					BackendRefs []struct {
+						Name string  `json:"name"`
+						Port float64 `json:"port"`
+					} `json:"backendRefs"`
+				} `json:"rules"`
+			}
+			require.NoError(t, json.Unmarshal(encoded, &spec))
+			require.Equal(t, []map[string]any{{"name": "public", "namespace": "gateways", "port": float64(443)}}, spec.ParentRefs)
+			require.Equal(t, tt.hostnames, spec.Hostnames)
+			require.Len(t, spec.Rules, 1)
+			require.Len(t, spec.Rules[0].BackendRefs, 1)
+			require.Equal(t, "test", spec.Rules[0].BackendRefs[0].Name)
+			require.Equal(t, tt.port, spec.Rules[0].BackendRefs[0].Port)
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/authzed/spicedb-operator/pkg/metadata"
+)
+
+const (
+	ServiceMonitorKind = "ServiceMonitor"
+	PodMonitorKind     = "PodMonitor"
+	PrometheusRuleKind = "PrometheusRule"
+)
+
+var (
+	MonitoringGroupVersion = schema.GroupVersion{Group: "monitoring.coreos.com", Version: "v1"}
+	ServiceMonitorResource = MonitoringGroupVersion.WithResource("servicemonitors")
+	PodMonitorResource     = MonitoringGroupVersion.WithResource("podmonitors")
+	PrometheusRuleResource = MonitoringGroupVersion.WithResource("prometheusrules")
+)
+
+// monitoringLabels are the labels for a generated prometheus operator
+// resource. The extra labels come first so that they can't replace the
+// labels the operator relies on.
+func (c *Config) monitoringLabels(component string) map[string]string {
+	labels := make(map[string]string, len(c.PrometheusLabels)+3)
+	for k, v := range c.PrometheusLabels {
+		labels[k] = v
+	}
+	for k, v := range metadata.LabelsForComponent(c.Name, component) {
+		labels[k] = v
+	}
+	return labels
+}
+
+func (c *Config) unpatchedServiceMonitor() *CustomResourceApplyConfiguration {
+	return newCustomResource(MonitoringGroupVersion, ServiceMonitorKind, c.Name, c.Namespace).
+		WithLabels(c.monitoringLabels(metadata.ComponentServiceMonitorLabel)).
+		WithSpec(map[string]any{
+			"selector": map[string]any{
+				"matchLabels": labelsAsAny(metadata.LabelsForComponent(c.Name, metadata.ComponentServiceLabel)),
+			},
+			"endpoints": []any{
+				map[string]any{"port": "metrics"},
+			},
+		})
+}
+
+func (c *Config) ServiceMonitor() *CustomResourceApplyConfiguration {
+	unpatched := c.unpatchedServiceMonitor()
+	return c.patchedCustomResource(unpatched, metadata.ComponentServiceMonitorLabel, "selector")
+}
+
+func (c *Config) unpatchedPodMonitor() *CustomResourceApplyConfiguration {
+	return newCustomResource(MonitoringGroupVersion, PodMonitorKind, c.Name, c.Namespace).
+		WithLabels(c.monitoringLabels(metadata.ComponentPodMonitorLabel)).
+		WithSpec(map[string]any{
+			"selector": map[string]any{
+				"matchLabels": labelsAsAny(metadata.LabelsForComponent(c.Name, metadata.ComponentSpiceDBLabelValue)),
+			},
+			"podMetricsEndpoints": []any{
+				map[string]any{"port": "metrics"},
+			},
+		})
+}
+
+func (c *Config) PodMonitor() *CustomResourceApplyConfiguration {
+	unpatched := c.unpatchedPodMonitor()
+	return c.patchedCustomResource(unpatched, metadata.ComponentPodMonitorLabel, "selector")
+}
+
+func (c *Config) unpatchedPrometheusRule() *CustomResourceApplyConfiguration {
+	pods := fmt.Sprintf(`namespace=%q,pod=~"%s-.*"`, c.Namespace, DeploymentName(c.Name, ""))
+	dispatch := fmt.Sprintf(`grpc_service="dispatch.v1.DispatchService",%s`, pods)
+	api := fmt.Sprintf(`grpc_type="unary",grpc_service=~"authzed.api.v1.*",%s`, pods)
+	jobs := fmt.Sprintf(`namespace=%q,job_name=~"%s-migrate-.*"`, c.Namespace, c.Name)
+
+	return newCustomResource(MonitoringGroupVersion, PrometheusRuleKind, c.Name, c.Namespace).
+		WithLabels(c.monitoringLabels(metadata.ComponentPrometheusRuleLabel)).
+		WithSpec(map[string]any{
+			"groups": []any{
+				map[string]any{
+					"name": fmt.Sprintf("spicedb-%s", c.Name),
+					"rules": []any{
+						alertingRule("SpiceDBDispatchErrors", "10m", "warning",
+							fmt.Sprintf(`sum(rate(grpc_client_handled_total{grpc_code!~"OK|Canceled",%[1]s}[5m])) / sum(rate(grpc_client_handled_total{%[1]s}[5m])) > 0.05`, dispatch),
+							fmt.Sprintf("More than 5%% of dispatch requests between SpiceDB pods in %s/%s are failing.", c.Namespace, c.Name)),
+						alertingRule("SpiceDBHighLatency", "10m", "warning",
+							fmt.Sprintf(`histogram_quantile(0.99, sum by (le, grpc_method) (rate(grpc_server_handling_seconds_bucket{%s}[5m]))) > 1`, api),
+							fmt.Sprintf("The p99 latency of {{ $labels.grpc_method }} requests to SpiceDB in %s/%s is over 1s.", c.Namespace, c.Name)),
+						alertingRule("SpiceDBPodsNotReady", "15m", "warning",
+							fmt.Sprintf(`sum(kube_pod_status_ready{condition="false",%s}) > 0`, pods),
+							fmt.Sprintf("SpiceDB pods in %s/%s have not been ready for 15 minutes.", c.Namespace, c.Name)),
+						alertingRule("SpiceDBMigrationFailing", "5m", "critical",
+							fmt.Sprintf(`sum(kube_job_status_failed{%s}) > 0`, jobs),
+							fmt.Sprintf("The datastore migration job for SpiceDB in %s/%s is failing.", c.Namespace, c.Name)),
+					},
+				},
+			},
+		})
+}
+
+func (c *Config) PrometheusRule() *CustomResourceApplyConfiguration {
+	unpatched := c.unpatchedPrometheusRule()
+	return c.patchedCustomResource(unpatched, metadata.ComponentPrometheusRuleLabel)
+}
+
+func alertingRule(alert, forDuration, severity, expr, description string) map[string]any {
+	return map[string]any{
+		"alert": alert,
+		"expr":  expr,
+		"for":   forDuration,
+		"labels": map[string]any{
+			"severity": severity,
+		},
+		"annotations": map[string]any{
+			"description": description,
+		},
+	}
+}
@@ -0,0 +1,165 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+	"github.com/authzed/spicedb-operator/pkg/metadata"
+)
+
+func TestMonitoringObjects(t *testing.T) {
+	ownerRef := map[string]any{
+		"apiVersion": v1alpha1.SchemeGroupVersion.String(),
+		"kind":       v1alpha1.SpiceDBClusterKind,
+		"name":       "test",
+		"uid":        "1",
+	}
+	tests := []struct {
+		name    string
+		cluster v1alpha1.ClusterSpec
+		object  func(c *Config) *CustomResourceApplyConfiguration
+		want    map[string]any
+	}{
+		{
+			name: "service monitor with extra labels",
+			cluster: v1alpha1.ClusterSpec{
+				Config: json.RawMessage(`{
+					"datastoreEngine": "cockroachdb",
+					"prometheusMonitor": "ServiceMonitor",
+					"prometheusLabels": "release=prometheus"
+				}`),
+			},
+			object: (*Config).ServiceMonitor,
+			want: map[string]any{
+				"apiVersion": "monitoring.coreos.com/v1",
+				"kind":       "ServiceMonitor",
+				"metadata": map[string]any{
+					"name":            "test",
+					"namespace":       "test",
+					"labels":          withLabels(metadata.LabelsForComponent("test", metadata.ComponentServiceMonitorLabel), "release", "prometheus"),
+					"ownerReferences": []any{ownerRef},
+				},
+				"spec": map[string]any{
+					"selector": map[string]any{
+						"matchLabels": labelsAsAny(metadata.LabelsForComponent("test", metadata.ComponentServiceLabel)),
+					},
+					"endpoints": []any{map[string]any{"port": "metrics"}},
+				},
+			},
+		},
+		{
+			name: "patches can't change the pod monitor selector",
+			cluster: v1alpha1.ClusterSpec{
+				Config: json.RawMessage(`{"datastoreEngine": "cockroachdb", "prometheusMonitor": "PodMonitor"}`),
+				Patches: []v1alpha1.Patch{{
+					Kind: "PodMonitor",
+					Patch: json.RawMessage(`
+spec:
+  selector:
+    matchLabels:
+      app: other
+  podMetricsEndpoints:
+  - port: metrics
+    interval: 15s
+`),
+				}},
+			},
+			object: (*Config).PodMonitor,
+			want: map[string]any{
+				"apiVersion": "monitoring.coreos.com/v1",
+				"kind":       "PodMonitor",
+				"metadata": map[string]any{
+					"name":            "test",
+					"namespace":       "test",
+					"labels":          labelsAsAny(metadata.LabelsForComponent("test", metadata.ComponentPodMonitorLabel)),
+					"ownerReferences": []any{ownerRef},
+				},
+				"spec": map[string]any{
+					"selector": map[string]any{
+						"matchLabels": labelsAsAny(metadata.LabelsForComponent("test", metadata.ComponentSpiceDBLabelValue)),
+					},
+					"podMetricsEndpoints": []any{map[string]any{"port": "metrics", "interval": "15s"}},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newTestConfig(t, tt.cluster)
+
+			wantJSON, err := json.Marshal(tt.want)
+			require.NoError(t, err)
+			gotJSON, err := json.Marshal(tt.object(got))
+			require.NoError(t, err)
+			require.JSONEq(t, string(wantJSON), string(gotJSON))
+		})
+	}
+}
+
+func TestPrometheusRule(t *testing.T) {
+	got := newTestConfig(t, v1alpha1.ClusterSpec{
+		Config: json.RawMessage(`{"datastoreEngine": "cockroachdb", "prometheusRules": true}`),
+		Patches: []v1alpha1.Patch{{
+			Kind:  "PrometheusRule",
+			Patch: json.RawMessage(`{"op": "replace", "path": "/spec/groups/0/rules/1/for", "value": "30m"}`),
+		}},
+	})
+	rule := got.PrometheusRule()
+	require.Equal(t, PrometheusRuleKind, *rule.Kind)
+	require.Equal(t, "test", *rule.Name)
+	require.Equal(t, metadata.LabelsForComponent("test", metadata.ComponentPrometheusRuleLabel), rule.Labels)
+
+	encoded, err := json.Marshal(rule.Spec)
+	require.NoError(t, err)
+	var spec struct {
+		Groups []struct {
+			Rules []struct {
+				Alert string `json:"alert"`
+				Expr  string `json:"expr"`
+				For   string `json:"for"`
+			} `json:"rules"`
+		} `json:"groups"`
+	}
+	require.NoError(t, json.Unmarshal(encoded, &spec))
+	require.Len(t, spec.Groups, 1)
+
+	alerts := make([]string, 0)
+	for _, r := range spec.Groups[0].Rules {
+		alerts = append(alerts, r.Alert)
+		require.Contains(t, r.Expr, `namespace="test"`)
+	}
+	require.Equal(t, []string{"SpiceDBDispatchErrors", "SpiceDBHighLatency", "SpiceDBPodsNotReady", "SpiceDBMigrationFailing"}, alerts)
+	require.Equal(t, "30m", spec.Groups[0].Rules[1].For)
+}
+
+func newTestConfig(t *testing.T, spec v1alpha1.ClusterSpec) *Config {
+	t.Helper()
+	secret := &corev1.Secret{Data: map[string][]byte{
+		"datastore_uri": []byte("uri"),
+		"preshared_key": []byte("psk"),
+	}}
+	cluster := &v1alpha1.SpiceDBCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "test",
+			UID:       types.UID("1"),
+		},
+		Spec: spec,
+	}
+	got, _, err := NewConfig(cluster, ptr.To(testGlobalConfig.Copy()), secret, newFakeResources())
+	require.NoError(t, err)
+	return got
+}
+
+func withLabels(labels map[string]string, key, value string) map[string]any {
+	out := labelsAsAny(labels)
+	out[key] = value
+	return out
+}
@@ -75,7 +75,15 @@ func ApplyPatches[K any](object, out K, patches []v1alpha1.Patch, resources open
 					continue
 				}
 				gvkSchema := resources.LookupResource(gv.WithKind(*typeMeta.Kind))
-				patched, err := strategicpatch.StrategicMergePatchUsingLookupPatchMeta(encoded, jsonPatch, strategicpatch.NewPatchMetaFromOpenAPI(gvkSchema))
+				var patched []byte
+				if gvkSchema == nil {
+					// kinds without a published schema (i.e. CRDs that aren't
+					// installed) have no merge strategies, so fall back to a
+					// plain merge patch
+					patched, err = jsonpatch.MergePatch(encoded, jsonPatch)
+				} else {
+					patched, err = strategicpatch.StrategicMergePatchUsingLookupPatchMeta(encoded, jsonPatch, strategicpatch.NewPatchMetaFromOpenAPI(gvkSchema))
+				}
 				if err != nil {
 					errs = append(errs, fmt.Errorf("error applying patch %d, to object: %w", i, err))
 					continue
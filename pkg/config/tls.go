@@ -0,0 +1,88 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+
+	applycorev1 "k8s.io/client-go/applyconfigurations/core/v1"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+	"github.com/authzed/spicedb-operator/pkg/metadata"
+)
+
+// CACertKey is the key that holds the CA certificate in generated TLS
+// secrets and in the CA ConfigMap.
+const CACertKey = "ca.crt"
+
+// SelfSignedCAName is the name of the secret holding the operator-managed CA
+// for a cluster, and of the ConfigMap that publishes its certificate.
+func SelfSignedCAName(clusterName string) string {
+	return fmt.Sprintf("%s-spicedb-ca", clusterName)
+}
+
+// SelfSignedTLSSecretName is the name of the secret holding the
+// operator-managed serving certificate for a cluster.
+func SelfSignedTLSSecretName(clusterName string) string {
+	return fmt.Sprintf("%s-spicedb-tls", clusterName)
+}
+
+// selfSignedDNSNames are the SANs of the serving certificate: the service,
+// which is also the authority used by dispatch, the dispatch services of the
+// blue and green deployments, and any ingress hosts.
+func (c *Config) selfSignedDNSNames() []string {
+	names := []string{
+		c.Name,
+		fmt.Sprintf("%s.%s", c.Name, c.Namespace),
+		fmt.Sprintf("%s.%s.svc", c.Name, c.Namespace),
+		fmt.Sprintf("%s.%s.svc.cluster.local", c.Name, c.Namespace),
+	}
+	if c.DispatchEnabled {
+		for _, slot := range []string{v1alpha1.DeploymentSlotBlue, v1alpha1.DeploymentSlotGreen} {
+			names = append(names, fmt.Sprintf("%s.%s", c.dispatchServiceName(slot), c.Namespace))
+		}
+	}
+	if len(c.IngressHost) > 0 {
+		names = append(names, c.IngressHost)
+	}
+	if len(c.IngressHTTPHost) > 0 {
+		names = append(names, c.IngressHTTPHost)
+	}
+	return names
+}
+
+// referencedSecrets are the secrets mounted into the pods besides the config
+// secret, in a stable order. The self-signed serving certificate is left out
+// because the operator tracks the certificates it issues itself.
+func (c *Config) referencedSecrets() []string {
+	names := []string{c.TLSSecretName, c.TelemetryTLSCASecretName, c.DatastoreTLSSecretName, c.SpannerCredsSecretRef}
+	if c.DispatchEnabled {
+		names = append(names, c.DispatchUpstreamCASecretName, c.DispatchTLSSecretName)
+	}
+	var referenced []string
+	for _, name := range names {
+		if len(name) == 0 || name == c.SecretName || (c.SelfSignedTLS && name == c.TLSSecretName) || slices.Contains(referenced, name) {
+			continue
+		}
+		referenced = append(referenced, name)
+	}
+	slices.Sort(referenced)
+	return referenced
+}
+
+func (c *Config) unpatchedCAConfigMap(caCert string) *applycorev1.ConfigMapApplyConfiguration {
+	return applycorev1.ConfigMap(SelfSignedCAName(c.Name), c.Namespace).
+		WithLabels(metadata.LabelsForComponent(c.Name, metadata.ComponentCAConfigMapLabel)).
+		WithData(map[string]string{CACertKey: caCert})
+}
+
+func (c *Config) CAConfigMap(caCert string) *applycorev1.ConfigMapApplyConfiguration {
+	cm := applycorev1.ConfigMap(SelfSignedCAName(c.Name), c.Namespace)
+	_, _, _ = ApplyPatches(c.unpatchedCAConfigMap(caCert), cm, c.Patches, c.Resources)
+
+	// ensure patches don't overwrite anything critical for operator function
+	cm.WithName(SelfSignedCAName(c.Name)).WithNamespace(c.Namespace).
+		WithLabels(metadata.LabelsForComponent(c.Name, metadata.ComponentCAConfigMapLabel)).
+		WithOwnerReferences(c.ownerRef()).
+		WithData(map[string]string{CACertKey: caCert})
+	return cm
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	applycorev1 "k8s.io/client-go/applyconfigurations/core/v1"
+	applymetav1 "k8s.io/client-go/applyconfigurations/meta/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+	"github.com/authzed/spicedb-operator/pkg/metadata"
+)
+
+func TestSelfSignedTLS(t *testing.T) {
+	tests := []struct {
+		name             string
+		config           string
+		wantSelfSigned   bool
+		wantTLSSecret    string
+		wantDispatchCA   string
+		wantDispatchPath string
+		wantDNSNames     []string
+		wantWarning      string
+	}{
+		{
+			name:             "operator-managed certificates",
+			config:           `{"datastoreEngine": "cockroachdb", "selfSignedTLS": true, "ingressType": "Ingress", "ingressHost": "spicedb.example.com"}`,
+			wantSelfSigned:   true,
+			wantTLSSecret:    "test-spicedb-tls",
+			wantDispatchCA:   "test-spicedb-tls",
+			wantDispatchPath: "ca.crt",
+			wantDNSNames:     []string{"test", "test.test", "test.test.svc", "test.test.svc.cluster.local", "test-spicedb-blue.test", "test-spicedb-green.test", "spicedb.example.com"},
+		},
+		{
+			name:             "no dispatch services without dispatch",
+			config:           `{"datastoreEngine": "cockroachdb", "selfSignedTLS": true, "dispatchEnabled": false}`,
+			wantSelfSigned:   true,
+			wantTLSSecret:    "test-spicedb-tls",
+			wantDispatchCA:   "test-spicedb-tls",
+			wantDispatchPath: "ca.crt",
+			wantDNSNames:     []string{"test", "test.test", "test.test.svc", "test.test.svc.cluster.local"},
+		},
+		{
+			name:             "provided dispatch CA is kept",
+			config:           `{"datastoreEngine": "cockroachdb", "selfSignedTLS": "true", "dispatchUpstreamCASecretName": "dispatch-ca"}`,
+			wantSelfSigned:   true,
+			wantTLSSecret:    "test-spicedb-tls",
+			wantDispatchCA:   "dispatch-ca",
+			wantDispatchPath: "tls.crt",
+			wantDNSNames:     []string{"test", "test.test", "test.test.svc", "test.test.svc.cluster.local", "test-spicedb-blue.test", "test-spicedb-green.test"},
+		},
+		{
+			name:             "ignored with a tls secret",
+			config:           `{"datastoreEngine": "cockroachdb", "selfSignedTLS": true, "tlsSecretName": "tls"}`,
+			wantTLSSecret:    "tls",
+			wantDispatchPath: "tls.crt",
+			wantWarning:      `"selfSignedTLS" is ignored because "tlsSecretName" is set`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := &v1alpha1.SpiceDBCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test", UID: "1"},
+				Spec:       v1alpha1.ClusterSpec{Config: json.RawMessage(tt.config)},
+			}
+			secret := &corev1.Secret{Data: map[string][]byte{
+				"datastore_uri": []byte("uri"),
+				"preshared_key": []byte("psk"),
+			}}
+			got, warning, err := NewConfig(cluster, ptr.To(testGlobalConfig.Copy()), secret, newFakeResources())
+			require.NoError(t, err)
+			if len(tt.wantWarning) > 0 {
+				require.ErrorContains(t, warning, tt.wantWarning)
+			}
+			require.Equal(t, tt.wantSelfSigned, got.SelfSignedTLS)
+			require.Equal(t, tt.wantTLSSecret, got.TLSSecretName)
+			require.Equal(t, tt.wantDispatchCA, got.DispatchUpstreamCASecretName)
+			require.Equal(t, tt.wantDispatchPath, got.DispatchUpstreamCASecretPath)
+			require.Equal(t, tt.wantDNSNames, got.SelfSignedDNSNames)
+		})
+	}
+}
+
+func TestCAConfigMap(t *testing.T) {
+	got := newTestConfig(t, v1alpha1.ClusterSpec{
+		Config: json.RawMessage(`{"datastoreEngine": "cockroachdb", "selfSignedTLS": true}`),
+		Patches: []v1alpha1.Patch{{
+			Kind:  "ConfigMap",
+			Patch: json.RawMessage(`{"metadata": {"annotations": {"reflector": "true"}}, "data": {"ca.crt": "other"}}`),
+		}},
+	})
+	require.Equal(t, applycorev1.ConfigMap("test-spicedb-ca", "test").
+		WithLabels(metadata.LabelsForComponent("test", metadata.ComponentCAConfigMapLabel)).
+		WithAnnotations(map[string]string{"reflector": "true"}).
+		WithOwnerReferences(applymetav1.OwnerReference().
+			WithName("test").
+			WithKind(v1alpha1.SpiceDBClusterKind).
+			WithAPIVersion(v1alpha1.SchemeGroupVersion.String()).
+			WithUID("1")).
+		WithData(map[string]string{"ca.crt": "cert"}), got.CAConfigMap("cert"))
+}
+
+func TestReferencedSecrets(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   []string
+	}{
+		{
+			name:   "none",
+			config: `{"datastoreEngine": "cockroachdb"}`,
+		},
+		{
+			name:   "all referenced secrets, deduplicated and sorted",
+			config: `{"datastoreEngine": "cockroachdb", "tlsSecretName": "tls", "dispatchUpstreamCASecretName": "tls", "telemetryCASecretName": "telemetry-ca", "datastoreTLSSecretName": "db-tls", "spannerCredentials": "spanner"}`,
+			want:   []string{"db-tls", "spanner", "telemetry-ca", "tls"},
+		},
+		{
+			name:   "dispatch secrets are ignored without dispatch",
+			config: `{"datastoreEngine": "cockroachdb", "dispatchEnabled": false, "dispatchUpstreamCASecretName": "dispatch-ca"}`,
+		},
+		{
+			name:   "self-signed certificates are tracked by the operator",
+			config: `{"datastoreEngine": "cockroachdb", "selfSignedTLS": true, "telemetryCASecretName": "telemetry-ca"}`,
+			want:   []string{"telemetry-ca"},
+		},
+		{
+			name:   "cert-manager certificates",
+			config: `{"datastoreEngine": "cockroachdb", "tlsIssuerRef": "letsencrypt", "dispatchIssuerRef": "internal-ca"}`,
+			want:   []string{"test-spicedb-dispatch-tls", "test-spicedb-tls"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newTestConfig(t, v1alpha1.ClusterSpec{Config: json.RawMessage(tt.config)})
+			require.Equal(t, tt.want, got.ReferencedSecrets)
+		})
+	}
+}
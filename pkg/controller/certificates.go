@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/authzed/controller-idioms/handler"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+	"github.com/authzed/spicedb-operator/pkg/config"
+	"github.com/authzed/spicedb-operator/pkg/metadata"
+)
+
+// WaitForCertificatesHandler holds the rollout until cert-manager has issued
+// the requested certificates, so that pods never start without their secrets.
+// The cluster is requeued when a certificate changes.
+type WaitForCertificatesHandler struct {
+	// getCertificate returns the certificate for a component, or false if
+	// certificates are not served by the cluster
+	getCertificate func(ctx context.Context, component string) (*unstructured.Unstructured, bool)
+	patchStatus    func(ctx context.Context, patch *v1alpha1.SpiceDBCluster) error
+	next           handler.ContextHandler
+}
+
+func (h *WaitForCertificatesHandler) Handle(ctx context.Context) {
+	cfg := CtxConfig.MustValue(ctx)
+	cluster := CtxCluster.MustValue(ctx)
+
+	certificates := []struct {
+		enabled   bool
+		component string
+		name      string
+	}{
+		{cfg.TLSIssuer != nil, metadata.ComponentCertificateLabel, cluster.Name},
+		{cfg.DispatchIssuer != nil, metadata.ComponentDispatchCertificateLabel, cluster.Name + "-dispatch"},
+	}
+	var message string
+	var pending []string
+	for _, cert := range certificates {
+		if !cert.enabled {
+			continue
+		}
+		obj, served := h.getCertificate(ctx, cert.component)
+		if !served {
+			message = fmt.Sprintf("Certificates were requested but %s is not served by the cluster; they will be requested once cert-manager is installed", config.CertificateResource.GroupResource())
+			break
+		}
+		if obj == nil || !certificateReady(obj) {
+			pending = append(pending, cert.name)
+		}
+	}
+	if len(message) == 0 && len(pending) > 0 {
+		message = fmt.Sprintf("Waiting for Certificate %s to be Ready", strings.Join(pending, ", "))
+	}
+
+	existing := cluster.FindStatusCondition(v1alpha1.ConditionTypePreconditionsFailed)
+	if len(message) > 0 {
+		if existing == nil || existing.Reason != v1alpha1.ConditionReasonCertificateNotReady || existing.Message != message {
+			cluster.SetStatusCondition(v1alpha1.NewCertificateNotReadyCondition(message))
+			if err := h.patchStatus(ctx, cluster); err != nil {
+				QueueOps.RequeueAPIErr(ctx, err)
+				return
+			}
+		}
+		// the certificate's status update requeues the cluster
+		QueueOps.Done(ctx)
+		return
+	}
+
+	if existing != nil && existing.Reason == v1alpha1.ConditionReasonCertificateNotReady {
+		cluster.RemoveStatusCondition(v1alpha1.ConditionTypePreconditionsFailed)
+		if err := h.patchStatus(ctx, cluster); err != nil {
+			QueueOps.RequeueAPIErr(ctx, err)
+			return
+		}
+		ctx = CtxCluster.WithValue(ctx, cluster)
+	}
+	h.next.Handle(ctx)
+}
+
+// certificateReady reports whether cert-manager has marked the certificate
+// as Ready.
+func certificateReady(cert *unstructured.Unstructured) bool {
+	conditions, _, _ := unstructured.NestedSlice(cert.Object, "status", "conditions")
+	for _, cond := range conditions {
+		cond, ok := cond.(map[string]any)
+		if ok && cond["type"] == "Ready" && cond["status"] == string(metav1.ConditionTrue) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,174 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/authzed/controller-idioms/handler"
+	"github.com/authzed/controller-idioms/queue/fake"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+	"github.com/authzed/spicedb-operator/pkg/config"
+	"github.com/authzed/spicedb-operator/pkg/metadata"
+)
+
+func TestWaitForCertificatesHandler(t *testing.T) {
+	var nextKey handler.Key = "next"
+	certificate := func(status string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]any{
+			"status": map[string]any{
+				"conditions": []any{map[string]any{"type": "Ready", "status": status}},
+			},
+		}}
+	}
+	notReady := v1alpha1.NewCertificateNotReadyCondition("Waiting for Certificate test to be Ready")
+	tests := []struct {
+		name string
+
+		tlsIssuer      bool
+		dispatchIssuer bool
+		notServed      bool
+		certificates   map[string]*unstructured.Unstructured
+		conditions     []metav1.Condition
+		patchError     error
+
+		expectPatchStatus bool
+		expectCondition   string
+		expectNext        handler.Key
+		expectRequeue     bool
+		expectDone        bool
+	}{
+		{
+			name:       "no issuers",
+			expectNext: nextKey,
+		},
+		{
+			name:      "ready",
+			tlsIssuer: true,
+			certificates: map[string]*unstructured.Unstructured{
+				metadata.ComponentCertificateLabel: certificate("True"),
+			},
+			expectNext: nextKey,
+		},
+		{
+			name:              "waits for a missing certificate",
+			tlsIssuer:         true,
+			expectPatchStatus: true,
+			expectCondition:   "Waiting for Certificate test to be Ready",
+			expectDone:        true,
+		},
+		{
+			name:           "waits for every certificate",
+			tlsIssuer:      true,
+			dispatchIssuer: true,
+			certificates: map[string]*unstructured.Unstructured{
+				metadata.ComponentCertificateLabel:         certificate("False"),
+				metadata.ComponentDispatchCertificateLabel: certificate("False"),
+			},
+			expectPatchStatus: true,
+			expectCondition:   "Waiting for Certificate test, test-dispatch to be Ready",
+			expectDone:        true,
+		},
+		{
+			name:       "doesn't patch an unchanged condition",
+			tlsIssuer:  true,
+			conditions: []metav1.Condition{notReady},
+			certificates: map[string]*unstructured.Unstructured{
+				metadata.ComponentCertificateLabel: certificate("False"),
+			},
+			expectCondition: notReady.Message,
+			expectDone:      true,
+		},
+		{
+			name:              "cert-manager not installed",
+			tlsIssuer:         true,
+			notServed:         true,
+			expectPatchStatus: true,
+			expectCondition:   fmt.Sprintf("Certificates were requested but %s is not served by the cluster; they will be requested once cert-manager is installed", config.CertificateResource.GroupResource()),
+			expectDone:        true,
+		},
+		{
+			name:       "removes the condition once ready",
+			tlsIssuer:  true,
+			conditions: []metav1.Condition{notReady},
+			certificates: map[string]*unstructured.Unstructured{
+				metadata.ComponentCertificateLabel: certificate("True"),
+			},
+			expectPatchStatus: true,
+			expectNext:        nextKey,
+		},
+		{
+			name:       "keeps other preconditions",
+			conditions: []metav1.Condition{v1alpha1.NewMissingSecretCondition(types.NamespacedName{Namespace: "test", Name: "secret"})},
+			expectNext: nextKey,
+		},
+		{
+			name:              "requeues on status patch error",
+			tlsIssuer:         true,
+			patchError:        fmt.Errorf("error patching"),
+			expectPatchStatus: true,
+			expectCondition:   "Waiting for Certificate test to be Ready",
+			expectRequeue:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrls := &fake.FakeInterface{}
+			cluster := &v1alpha1.SpiceDBCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"},
+				Status:     v1alpha1.ClusterStatus{Conditions: tt.conditions},
+			}
+			cfg := &config.Config{}
+			if tt.tlsIssuer {
+				cfg.TLSIssuer = &config.IssuerRef{Name: "issuer"}
+			}
+			if tt.dispatchIssuer {
+				cfg.DispatchIssuer = &config.IssuerRef{Name: "issuer"}
+			}
+
+			ctx := context.Background()
+			ctx = QueueOps.WithValue(ctx, ctrls)
+			ctx = CtxCluster.WithValue(ctx, cluster)
+			ctx = CtxConfig.WithValue(ctx, cfg)
+			patchCalled := false
+			var called handler.Key
+			h := &WaitForCertificatesHandler{
+				getCertificate: func(_ context.Context, component string) (*unstructured.Unstructured, bool) {
+					if tt.notServed {
+						return nil, false
+					}
+					return tt.certificates[component], true
+				},
+				patchStatus: func(_ context.Context, patch *v1alpha1.SpiceDBCluster) error {
+					patchCalled = true
+					return tt.patchError
+				},
+				next: handler.ContextHandlerFunc(func(ctx context.Context) {
+					called = nextKey
+				}),
+			}
+			h.Handle(ctx)
+
+			require.Equal(t, tt.expectPatchStatus, patchCalled)
+			require.Equal(t, tt.expectNext, called)
+			require.Equal(t, tt.expectRequeue, ctrls.RequeueAPIErrCallCount() == 1)
+			require.Equal(t, tt.expectDone, ctrls.DoneCallCount() == 1)
+			cond := cluster.FindStatusCondition(v1alpha1.ConditionTypePreconditionsFailed)
+			if len(tt.expectCondition) == 0 {
+				if cond != nil {
+					require.NotEqual(t, v1alpha1.ConditionReasonCertificateNotReady, cond.Reason)
+				}
+				return
+			}
+			require.NotNil(t, cond)
+			require.Equal(t, v1alpha1.ConditionReasonCertificateNotReady, cond.Reason)
+			require.Equal(t, tt.expectCondition, cond.Message)
+		})
+	}
+}
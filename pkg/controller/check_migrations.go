@@ -2,6 +2,7 @@ package controller
 
 import (
 	"context"
+	"fmt"
 
 	"golang.org/x/exp/slices"
 	corev1 "k8s.io/api/core/v1"
@@ -15,7 +16,8 @@ import (
 )
 
 const (
-	EventRunningMigrations = "RunningMigrations"
+	EventRunningMigrations      = "RunningMigrations"
+	EventMigrationNeedsApproval = "MigrationPendingApproval"
 
 	HandlerDeploymentKey        handler.Key = "deploymentChain"
 	HandlerMigrationRunKey      handler.Key = "runMigration"
@@ -23,7 +25,8 @@ const (
 )
 
 type MigrationCheckHandler struct {
-	recorder record.EventRecorder
+	recorder    record.EventRecorder
+	patchStatus func(ctx context.Context, patch *v1alpha1.SpiceDBCluster) error
 
 	nextMigrationRunHandler handler.ContextHandler
 	nextWaitForJobHandler   handler.ContextHandler
@@ -53,19 +56,49 @@ func (m *MigrationCheckHandler) Handle(ctx context.Context) {
 	// `memory` datastore is used, or if the update graph says there are no
 	// migrations for this step.
 	config := CtxConfig.MustValue(ctx)
-	if config.SkipMigrations || config.DatastoreEngine == "memory" {
-		m.nextDeploymentHandler.Handle(ctx)
+	cluster := CtxCluster.MustValue(ctx)
+	runsMigrations := !config.SkipMigrations && config.DatastoreEngine != "memory" &&
+		(cluster.Status.CurrentVersion == nil || slices.Contains(cluster.Status.CurrentVersion.Attributes, v1alpha1.SpiceDBVersionAttributesMigration))
+
+	// a new job for an existing cluster waits for approval of this specific
+	// migration hash if the update policy asks for it; the first install
+	// doesn't need one.
+	pendingApproval := runsMigrations && !hasDeployment && !hasJob &&
+		len(CtxDeployments.MustValue(ctx)) > 0 &&
+		cluster.Spec.UpdatePolicy.RequiresApproval() &&
+		!hash.SecureEqual(cluster.Annotations[metadata.ApprovedMigrationKey], migrationHash)
+	if pendingApproval {
+		condition := v1alpha1.NewUpdatePendingApprovalCondition(fmt.Sprintf(
+			"Migration %q (phase %q) for %s is waiting for approval; annotate the cluster with %s=%s to run it",
+			config.TargetMigration, config.TargetPhase, config.TargetSpiceDBImage, metadata.ApprovedMigrationKey, migrationHash))
+		if cluster.IsStatusConditionChanged(condition.Type, &condition) {
+			cluster.SetStatusCondition(condition)
+			if err := m.patchStatus(ctx, cluster); err != nil {
+				QueueOps.RequeueAPIErr(ctx, err)
+				return
+			}
+			m.recorder.Eventf(cluster, corev1.EventTypeNormal, EventMigrationNeedsApproval, "Migration job for %s is waiting for approval of migration hash %s", config.TargetSpiceDBImage, migrationHash)
+		}
+		QueueOps.Done(ctx)
 		return
 	}
-	status := CtxCluster.MustValue(ctx).Status
-	if status.CurrentVersion != nil && !slices.Contains(status.CurrentVersion.Attributes, v1alpha1.SpiceDBVersionAttributesMigration) {
+	if cluster.FindStatusCondition(v1alpha1.ConditionTypeUpdatePendingApproval) != nil {
+		cluster.RemoveStatusCondition(v1alpha1.ConditionTypeUpdatePendingApproval)
+		if err := m.patchStatus(ctx, cluster); err != nil {
+			QueueOps.RequeueAPIErr(ctx, err)
+			return
+		}
+		ctx = CtxCluster.WithValue(ctx, cluster)
+	}
+
+	if !runsMigrations {
 		m.nextDeploymentHandler.Handle(ctx)
 		return
 	}
 
 	// if there's no job and no (updated) deployment, create the job
 	if !hasDeployment && !hasJob {
-		m.recorder.Eventf(CtxCluster.MustValue(ctx), corev1.EventTypeNormal, EventRunningMigrations, "Running migration job for %s", CtxConfig.MustValue(ctx).TargetSpiceDBImage)
+		m.recorder.Eventf(cluster, corev1.EventTypeNormal, EventRunningMigrations, "Running migration job for %s", config.TargetSpiceDBImage)
 		m.nextMigrationRunHandler.Handle(ctx)
 		return
 	}
@@ -26,10 +26,14 @@ func TestCheckMigrationsHandler(t *testing.T) {
 		migrationHash       string
 		existingJobs        []*batchv1.Job
 		existingDeployments []*appsv1.Deployment
+		cluster             *v1alpha1.SpiceDBCluster
 
-		expectEvents     []string
-		expectRequeueErr error
-		expectNext       handler.Key
+		expectEvents      []string
+		expectRequeueErr  error
+		expectPatchStatus bool
+		expectConditions  []string
+		expectDone        bool
+		expectNext        handler.Key
 	}{
 		{
 			name:                "run migrations if no job, no deployment",
@@ -96,25 +100,113 @@ func TestCheckMigrationsHandler(t *testing.T) {
 			}}}},
 			expectNext: HandlerDeploymentKey,
 		},
+		{
+			name:          "wait for approval of a migration in manual mode",
+			config:        config.Config{MigrationConfig: config.MigrationConfig{TargetSpiceDBImage: "test", TargetMigration: "add-ns-config-id", TargetPhase: "write-both-read-old"}},
+			migrationHash: "hash",
+			existingDeployments: []*appsv1.Deployment{{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+				metadata.SpiceDBMigrationRequirementsKey: "old",
+			}}}},
+			cluster:           manualApprovalCluster(nil),
+			expectEvents:      []string{"Normal MigrationPendingApproval Migration job for test is waiting for approval of migration hash hash"},
+			expectPatchStatus: true,
+			expectConditions:  []string{v1alpha1.ConditionTypeUpdatePendingApproval},
+			expectDone:        true,
+		},
+		{
+			name:          "keep waiting without another event",
+			config:        config.Config{MigrationConfig: config.MigrationConfig{TargetSpiceDBImage: "test", TargetMigration: "add-ns-config-id", TargetPhase: "write-both-read-old"}},
+			migrationHash: "hash",
+			existingDeployments: []*appsv1.Deployment{{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+				metadata.SpiceDBMigrationRequirementsKey: "old",
+			}}}},
+			cluster: func() *v1alpha1.SpiceDBCluster {
+				c := manualApprovalCluster(nil)
+				c.SetStatusCondition(v1alpha1.NewUpdatePendingApprovalCondition(
+					`Migration "add-ns-config-id" (phase "write-both-read-old") for test is waiting for approval; annotate the cluster with authzed.com/approved-migration=hash to run it`))
+				return c
+			}(),
+			expectConditions: []string{v1alpha1.ConditionTypeUpdatePendingApproval},
+			expectDone:       true,
+		},
+		{
+			name:          "an approval for another hop doesn't count",
+			config:        config.Config{MigrationConfig: config.MigrationConfig{TargetSpiceDBImage: "test"}},
+			migrationHash: "hash",
+			existingDeployments: []*appsv1.Deployment{{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+				metadata.SpiceDBMigrationRequirementsKey: "old",
+			}}}},
+			cluster:           manualApprovalCluster(map[string]string{metadata.ApprovedMigrationKey: "old"}),
+			expectEvents:      []string{"Normal MigrationPendingApproval Migration job for test is waiting for approval of migration hash hash"},
+			expectPatchStatus: true,
+			expectConditions:  []string{v1alpha1.ConditionTypeUpdatePendingApproval},
+			expectDone:        true,
+		},
+		{
+			name:          "run an approved migration",
+			config:        config.Config{MigrationConfig: config.MigrationConfig{TargetSpiceDBImage: "test"}},
+			migrationHash: "hash",
+			existingDeployments: []*appsv1.Deployment{{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+				metadata.SpiceDBMigrationRequirementsKey: "old",
+			}}}},
+			cluster: func() *v1alpha1.SpiceDBCluster {
+				c := manualApprovalCluster(map[string]string{metadata.ApprovedMigrationKey: "hash"})
+				c.SetStatusCondition(v1alpha1.NewUpdatePendingApprovalCondition("waiting"))
+				return c
+			}(),
+			expectEvents:      []string{"Normal RunningMigrations Running migration job for test"},
+			expectPatchStatus: true,
+			expectNext:        HandlerMigrationRunKey,
+		},
+		{
+			name:                "don't wait for approval on the first install",
+			config:              config.Config{MigrationConfig: config.MigrationConfig{TargetSpiceDBImage: "test"}},
+			migrationHash:       "hash",
+			existingDeployments: []*appsv1.Deployment{},
+			cluster:             manualApprovalCluster(nil),
+			expectEvents:        []string{"Normal RunningMigrations Running migration job for test"},
+			expectNext:          HandlerMigrationRunKey,
+		},
+		{
+			name:          "don't wait for approval of a running job",
+			config:        config.Config{MigrationConfig: config.MigrationConfig{TargetSpiceDBImage: "test"}},
+			migrationHash: "hash",
+			existingJobs: []*batchv1.Job{{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+				metadata.SpiceDBMigrationRequirementsKey: "hash",
+			}}}},
+			existingDeployments: []*appsv1.Deployment{{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+				metadata.SpiceDBMigrationRequirementsKey: "old",
+			}}}},
+			cluster:    manualApprovalCluster(nil),
+			expectNext: HandlerWaitForMigrationsKey,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt := tt
 			ctrls := &fake.FakeInterface{}
+			cluster := tt.cluster
+			if cluster == nil {
+				cluster = &v1alpha1.SpiceDBCluster{}
+			}
 
 			ctx := CtxConfig.WithValue(context.Background(), &tt.config)
 			ctx = QueueOps.WithValue(ctx, ctrls)
-			ctx = CtxCluster.WithValue(ctx, &v1alpha1.SpiceDBCluster{})
-			ctx = CtxCluster.WithValue(ctx, &v1alpha1.SpiceDBCluster{})
+			ctx = CtxCluster.WithValue(ctx, cluster)
 			ctx = CtxJobs.WithValue(ctx, tt.existingJobs)
 			ctx = CtxDeployments.WithValue(ctx, tt.existingDeployments)
 			ctx = CtxMigrationHash.WithValue(ctx, "hash")
 
 			recorder := record.NewFakeRecorder(1)
 
+			patchCalled := false
 			var called handler.Key
 			h := &MigrationCheckHandler{
 				recorder: recorder,
+				patchStatus: func(_ context.Context, _ *v1alpha1.SpiceDBCluster) error {
+					patchCalled = true
+					return nil
+				},
 				nextDeploymentHandler: handler.ContextHandlerFunc(func(_ context.Context) {
 					called = HandlerDeploymentKey
 				}),
@@ -129,6 +221,13 @@ func TestCheckMigrationsHandler(t *testing.T) {
 
 			require.Equal(t, tt.expectNext, called)
 			ExpectEvents(t, recorder, tt.expectEvents)
+			require.Equal(t, tt.expectPatchStatus, patchCalled)
+			require.Equal(t, tt.expectDone, ctrls.DoneCallCount() == 1)
+			conditions := make([]string, 0)
+			for _, c := range cluster.Status.Conditions {
+				conditions = append(conditions, c.Type)
+			}
+			require.ElementsMatch(t, tt.expectConditions, conditions)
 
 			if tt.expectRequeueErr != nil {
 				require.Equal(t, 1, ctrls.RequeueErrCallCount())
@@ -137,3 +236,12 @@ func TestCheckMigrationsHandler(t *testing.T) {
 		})
 	}
 }
+
+func manualApprovalCluster(annotations map[string]string) *v1alpha1.SpiceDBCluster {
+	return &v1alpha1.SpiceDBCluster{
+		ObjectMeta: metav1.ObjectMeta{Annotations: annotations},
+		Spec: v1alpha1.ClusterSpec{
+			UpdatePolicy: &v1alpha1.UpdatePolicy{Approval: v1alpha1.UpdateApprovalManual},
+		},
+	}
+}
@@ -10,9 +10,11 @@ import (
 	"github.com/authzed/spicedb-operator/pkg/metadata"
 )
 
+// PatchStatus writes the status of a cluster. The aggregate conditions are
+// left as they are; Handle derives them once the handlers are done.
 func (c *Controller) PatchStatus(ctx context.Context, patch *v1alpha1.SpiceDBCluster) error {
-	for _, c := range patch.Status.Conditions {
-		c.ObservedGeneration = patch.Generation
+	for i := range patch.Status.Conditions {
+		patch.Status.Conditions[i].ObservedGeneration = patch.Generation
 	}
 	patch.ManagedFields = nil
 	patch.Status.ObservedGeneration = patch.Generation
@@ -21,7 +23,13 @@ func (c *Controller) PatchStatus(ctx context.Context, patch *v1alpha1.SpiceDBClu
 		return err
 	}
 	_, err = c.client.Resource(v1alpha1ClusterGVR).Namespace(patch.Namespace).Patch(ctx, patch.Name, types.ApplyPatchType, data, metadata.PatchForceOwned, "status")
-	return err
+	if err != nil {
+		return err
+	}
+
+	// the box was set up by Handle, so this updates it in place
+	CtxWrittenCluster.WithValue(ctx, patch.DeepCopy())
+	return nil
 }
 
 func (c *Controller) Patch(ctx context.Context, patch *v1alpha1.SpiceDBCluster) error {
@@ -32,3 +40,24 @@ func (c *Controller) Patch(ctx context.Context, patch *v1alpha1.SpiceDBCluster)
 	_, err = c.client.Resource(v1alpha1ClusterGVR).Namespace(patch.Namespace).Patch(ctx, patch.Name, types.ApplyPatchType, data, metadata.PatchForceOwned)
 	return err
 }
+
+// ApplyFinalizers sets the operator's finalizers on a cluster. They're
+// applied with their own field manager so that they don't disturb the fields
+// applied by Patch.
+func (c *Controller) ApplyFinalizers(ctx context.Context, nn types.NamespacedName, finalizers []string) error {
+	meta := map[string]any{"name": nn.Name, "namespace": nn.Namespace}
+	if len(finalizers) > 0 {
+		// leaving them out releases the ones applied before
+		meta["finalizers"] = finalizers
+	}
+	data, err := json.Marshal(map[string]any{
+		"apiVersion": v1alpha1.SchemeGroupVersion.String(),
+		"kind":       v1alpha1.SpiceDBClusterKind,
+		"metadata":   meta,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.client.Resource(v1alpha1ClusterGVR).Namespace(nn.Namespace).Patch(ctx, nn.Name, types.ApplyPatchType, data, metadata.PatchFinalizersForceOwned)
+	return err
+}
@@ -0,0 +1,195 @@
+package controller
+
+import (
+	"context"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	applycorev1 "k8s.io/client-go/applyconfigurations/core/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/authzed/controller-idioms/handler"
+	"github.com/authzed/controller-idioms/hash"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+	"github.com/authzed/spicedb-operator/pkg/config"
+	"github.com/authzed/spicedb-operator/pkg/metadata"
+)
+
+const connectionSecretHashKey = "authzed.com/controller-component-hash"
+
+// ConnectionSecretHandler publishes the address, CA and preshared key of the
+// cluster for its clients when `connectionSecretName` is set. The secret is
+// rewritten whenever any of them change, and the previous one is deleted if
+// it's moved. Secrets that the cluster didn't publish are never overwritten
+// or deleted.
+//
+// Owner references can't point across namespaces, so a cluster that
+// publishes into another namespace gets a finalizer that deletes the secret.
+type ConnectionSecretHandler struct {
+	getSecret       func(ctx context.Context, nn types.NamespacedName) (*corev1.Secret, error)
+	applySecret     func(ctx context.Context, secret *applycorev1.SecretApplyConfiguration) (*corev1.Secret, error)
+	deleteSecret    func(ctx context.Context, nn types.NamespacedName) error
+	applyFinalizers func(ctx context.Context, nn types.NamespacedName, finalizers []string) error
+	patchStatus     func(ctx context.Context, patch *v1alpha1.SpiceDBCluster) error
+	next            handler.ContextHandler
+}
+
+func (h *ConnectionSecretHandler) Handle(ctx context.Context) {
+	cfg := CtxConfig.MustValue(ctx)
+	cluster := CtxCluster.MustValue(ctx)
+
+	var desired *v1alpha1.ConnectionSecretReference
+	if len(cfg.ConnectionSecretName) > 0 {
+		desired = &v1alpha1.ConnectionSecretReference{Namespace: cfg.ConnectionSecretNamespace, Name: cfg.ConnectionSecretName}
+	}
+	crossNamespace := desired != nil && desired.Namespace != cluster.Namespace
+	hasFinalizer := slices.Contains(cluster.Finalizers, metadata.ConnectionSecretFinalizer)
+	notOwned := false
+
+	// the finalizer has to exist before anything it cleans up
+	if crossNamespace && !hasFinalizer {
+		if err := h.applyFinalizers(ctx, cluster.NamespacedName(), []string{metadata.ConnectionSecretFinalizer}); err != nil {
+			QueueOps.RequeueAPIErr(ctx, err)
+			return
+		}
+	}
+
+	if previous := cluster.Status.ConnectionSecret; previous != nil && !ptr.Equal(previous, desired) {
+		if err := deleteConnectionSecret(ctx, h.getSecret, h.deleteSecret, cluster, previous); err != nil {
+			QueueOps.RequeueAPIErr(ctx, err)
+			return
+		}
+	}
+
+	if desired != nil {
+		var caCert string
+		if len(cfg.TLSSecretName) > 0 {
+			tlsSecret, err := h.getSecret(ctx, types.NamespacedName{Namespace: cluster.Namespace, Name: cfg.TLSSecretName})
+			if err != nil {
+				QueueOps.RequeueErr(ctx, err)
+				return
+			}
+			caCert = string(tlsSecret.Data[config.CACertKey])
+		}
+
+		secret := cfg.ConnectionSecret(caCert)
+		secretHash := hash.SecureObject(secret)
+		secret.WithAnnotations(map[string]string{connectionSecretHashKey: secretHash})
+
+		existing, err := h.getSecret(ctx, types.NamespacedName{Namespace: desired.Namespace, Name: desired.Name})
+		if err != nil && !apierrors.IsNotFound(err) {
+			QueueOps.RequeueErr(ctx, err)
+			return
+		}
+		switch {
+		case existing != nil && !ownsConnectionSecret(cluster, existing):
+			// don't take over a secret that someone else created
+			desired = nil
+			notOwned = true
+		case existing == nil || existing.Annotations[connectionSecretHashKey] != secretHash:
+			if _, err := h.applySecret(ctx, secret); err != nil {
+				QueueOps.RequeueAPIErr(ctx, err)
+				return
+			}
+		}
+	}
+
+	conditionChanged := false
+	if notOwned {
+		condition := v1alpha1.NewConnectionSecretNotOwnedCondition(cfg.ConnectionSecretNamespace, cfg.ConnectionSecretName)
+		if existing := cluster.FindStatusCondition(condition.Type); existing == nil || existing.Message != condition.Message {
+			cluster.SetStatusCondition(condition)
+			conditionChanged = true
+		}
+	} else if cluster.FindStatusCondition(v1alpha1.ConditionTypeConnectionSecret) != nil {
+		cluster.RemoveStatusCondition(v1alpha1.ConditionTypeConnectionSecret)
+		conditionChanged = true
+	}
+
+	if conditionChanged || !ptr.Equal(cluster.Status.ConnectionSecret, desired) {
+		cluster.Status.ConnectionSecret = desired
+		if err := h.patchStatus(ctx, cluster); err != nil {
+			QueueOps.RequeueAPIErr(ctx, err)
+			return
+		}
+		ctx = CtxCluster.WithValue(ctx, cluster)
+	}
+
+	if !crossNamespace && hasFinalizer {
+		if err := h.applyFinalizers(ctx, cluster.NamespacedName(), nil); err != nil {
+			QueueOps.RequeueAPIErr(ctx, err)
+			return
+		}
+	}
+
+	h.next.Handle(ctx)
+}
+
+// ConnectionSecretFinalizerHandler deletes a connection secret published in
+// another namespace once the cluster is deleted.
+type ConnectionSecretFinalizerHandler struct {
+	getSecret       func(ctx context.Context, nn types.NamespacedName) (*corev1.Secret, error)
+	deleteSecret    func(ctx context.Context, nn types.NamespacedName) error
+	applyFinalizers func(ctx context.Context, nn types.NamespacedName, finalizers []string) error
+	next            handler.ContextHandler
+}
+
+func (h *ConnectionSecretFinalizerHandler) Handle(ctx context.Context) {
+	cluster := CtxCluster.MustValue(ctx)
+	if cluster.DeletionTimestamp == nil || !slices.Contains(cluster.Finalizers, metadata.ConnectionSecretFinalizer) {
+		h.next.Handle(ctx)
+		return
+	}
+
+	if ref := cluster.Status.ConnectionSecret; ref != nil {
+		if err := deleteConnectionSecret(ctx, h.getSecret, h.deleteSecret, cluster, ref); err != nil {
+			QueueOps.RequeueAPIErr(ctx, err)
+			return
+		}
+	}
+	if err := h.applyFinalizers(ctx, cluster.NamespacedName(), nil); err != nil {
+		QueueOps.RequeueAPIErr(ctx, err)
+		return
+	}
+	QueueOps.Done(ctx)
+}
+
+// ownsConnectionSecret returns true if the secret carries the labels that the
+// cluster publishes its connection secret with.
+func ownsConnectionSecret(cluster *v1alpha1.SpiceDBCluster, secret *corev1.Secret) bool {
+	labels := secret.GetLabels()
+	namespace, ok := labels[metadata.OwnerNamespaceLabelKey]
+	if !ok {
+		namespace = secret.Namespace
+	}
+	return labels[metadata.ComponentLabelKey] == metadata.ComponentConnectionSecretLabel &&
+		labels[metadata.OwnerLabelKey] == cluster.Name &&
+		namespace == cluster.Namespace
+}
+
+// deleteConnectionSecret deletes the referenced secret if the cluster
+// published it, and leaves it alone otherwise.
+func deleteConnectionSecret(ctx context.Context,
+	getSecret func(ctx context.Context, nn types.NamespacedName) (*corev1.Secret, error),
+	deleteSecret func(ctx context.Context, nn types.NamespacedName) error,
+	cluster *v1alpha1.SpiceDBCluster, ref *v1alpha1.ConnectionSecretReference,
+) error {
+	nn := types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}
+	secret, err := getSecret(ctx, nn)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !ownsConnectionSecret(cluster, secret) {
+		return nil
+	}
+	if err := deleteSecret(ctx, nn); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
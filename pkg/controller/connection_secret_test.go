@@ -0,0 +1,356 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	applycorev1 "k8s.io/client-go/applyconfigurations/core/v1"
+
+	"github.com/authzed/controller-idioms/handler"
+	"github.com/authzed/controller-idioms/hash"
+	"github.com/authzed/controller-idioms/queue/fake"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+	"github.com/authzed/spicedb-operator/pkg/config"
+	"github.com/authzed/spicedb-operator/pkg/metadata"
+)
+
+func TestConnectionSecretHandler(t *testing.T) {
+	var nextKey handler.Key = "next"
+	tlsSecret := &corev1.Secret{Data: map[string][]byte{"ca.crt": []byte("ca")}}
+	ref := func(namespace, name string) *v1alpha1.ConnectionSecretReference {
+		return &v1alpha1.ConnectionSecretReference{Namespace: namespace, Name: name}
+	}
+	published := func(namespace, name string) *corev1.Secret {
+		labels := metadata.LabelsForComponent("test", metadata.ComponentConnectionSecretLabel)
+		if namespace != "test" {
+			labels[metadata.OwnerNamespaceLabelKey] = "test"
+		}
+		return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels}}
+	}
+
+	tests := []struct {
+		name string
+
+		secretName      string
+		secretNamespace string
+		tls             bool
+		existing        func(cfg *config.Config) *corev1.Secret
+		previous        *corev1.Secret
+		status          *v1alpha1.ConnectionSecretReference
+		conditions      []metav1.Condition
+		finalizers      []string
+
+		expectApplied     bool
+		expectCA          string
+		expectDeleted     []types.NamespacedName
+		expectFinalizers  [][]string
+		expectPatchStatus bool
+		expectStatus      *v1alpha1.ConnectionSecretReference
+		expectCondition   bool
+		expectNext        handler.Key
+	}{
+		{
+			name:       "disabled",
+			expectNext: nextKey,
+		},
+		{
+			name:              "publishes a secret",
+			secretName:        "conn",
+			secretNamespace:   "test",
+			expectApplied:     true,
+			expectPatchStatus: true,
+			expectStatus:      ref("test", "conn"),
+			expectNext:        nextKey,
+		},
+		{
+			name:              "includes the CA",
+			secretName:        "conn",
+			secretNamespace:   "test",
+			tls:               true,
+			expectApplied:     true,
+			expectCA:          "ca",
+			expectPatchStatus: true,
+			expectStatus:      ref("test", "conn"),
+			expectNext:        nextKey,
+		},
+		{
+			name:            "doesn't reapply an unchanged secret",
+			secretName:      "conn",
+			secretNamespace: "test",
+			existing: func(cfg *config.Config) *corev1.Secret {
+				secret := published("test", "conn")
+				secret.Annotations = map[string]string{connectionSecretHashKey: hash.SecureObject(cfg.ConnectionSecret(""))}
+				return secret
+			},
+			status:       ref("test", "conn"),
+			expectStatus: ref("test", "conn"),
+			expectNext:   nextKey,
+		},
+		{
+			name:            "reapplies a changed secret",
+			secretName:      "conn",
+			secretNamespace: "test",
+			existing: func(cfg *config.Config) *corev1.Secret {
+				secret := published("test", "conn")
+				secret.Annotations = map[string]string{connectionSecretHashKey: "old"}
+				return secret
+			},
+			status:        ref("test", "conn"),
+			expectApplied: true,
+			expectStatus:  ref("test", "conn"),
+			expectNext:    nextKey,
+		},
+		{
+			name:              "adds a finalizer for another namespace",
+			secretName:        "conn",
+			secretNamespace:   "app",
+			expectApplied:     true,
+			expectFinalizers:  [][]string{{metadata.ConnectionSecretFinalizer}},
+			expectPatchStatus: true,
+			expectStatus:      ref("app", "conn"),
+			expectNext:        nextKey,
+		},
+		{
+			name:              "moves the secret back and removes the finalizer",
+			secretName:        "conn",
+			secretNamespace:   "test",
+			previous:          published("app", "conn"),
+			status:            ref("app", "conn"),
+			finalizers:        []string{metadata.ConnectionSecretFinalizer},
+			expectApplied:     true,
+			expectDeleted:     []types.NamespacedName{{Namespace: "app", Name: "conn"}},
+			expectFinalizers:  [][]string{nil},
+			expectPatchStatus: true,
+			expectStatus:      ref("test", "conn"),
+			expectNext:        nextKey,
+		},
+		{
+			name:              "deletes the secret when disabled",
+			previous:          published("test", "conn"),
+			status:            ref("test", "conn"),
+			expectDeleted:     []types.NamespacedName{{Namespace: "test", Name: "conn"}},
+			expectPatchStatus: true,
+			expectNext:        nextKey,
+		},
+		{
+			name:              "doesn't delete a secret it didn't publish",
+			previous:          &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "conn"}},
+			status:            ref("test", "conn"),
+			expectPatchStatus: true,
+			expectNext:        nextKey,
+		},
+		{
+			name:            "doesn't overwrite a secret it didn't publish",
+			secretName:      "conn",
+			secretNamespace: "test",
+			existing: func(cfg *config.Config) *corev1.Secret {
+				return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "conn"}}
+			},
+			expectPatchStatus: true,
+			expectCondition:   true,
+			expectNext:        nextKey,
+		},
+		{
+			name:            "doesn't overwrite a secret published for a cluster in another namespace",
+			secretName:      "conn",
+			secretNamespace: "app",
+			existing: func(cfg *config.Config) *corev1.Secret {
+				secret := published("app", "conn")
+				secret.Labels[metadata.OwnerNamespaceLabelKey] = "other"
+				return secret
+			},
+			expectFinalizers:  [][]string{{metadata.ConnectionSecretFinalizer}},
+			expectPatchStatus: true,
+			expectCondition:   true,
+			expectNext:        nextKey,
+		},
+		{
+			name:              "publishes once the conflicting secret is gone",
+			secretName:        "conn",
+			secretNamespace:   "test",
+			conditions:        []metav1.Condition{v1alpha1.NewConnectionSecretNotOwnedCondition("test", "conn")},
+			expectApplied:     true,
+			expectPatchStatus: true,
+			expectStatus:      ref("test", "conn"),
+			expectNext:        nextKey,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrls := &fake.FakeInterface{}
+			cluster := &v1alpha1.SpiceDBCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test", Finalizers: tt.finalizers},
+				Status:     v1alpha1.ClusterStatus{ConnectionSecret: tt.status, Conditions: tt.conditions},
+			}
+			cfg := &config.Config{SpiceConfig: config.SpiceConfig{
+				Name:                      "test",
+				Namespace:                 "test",
+				ConnectionSecretName:      tt.secretName,
+				ConnectionSecretNamespace: tt.secretNamespace,
+			}}
+			if tt.tls {
+				cfg.TLSSecretName = "tls"
+			}
+
+			ctx := context.Background()
+			ctx = QueueOps.WithValue(ctx, ctrls)
+			ctx = CtxCluster.WithValue(ctx, cluster)
+			ctx = CtxConfig.WithValue(ctx, cfg)
+
+			var applied *applycorev1.SecretApplyConfiguration
+			var deleted []types.NamespacedName
+			var finalizers [][]string
+			patchCalled := false
+			var called handler.Key
+			h := &ConnectionSecretHandler{
+				getSecret: func(_ context.Context, nn types.NamespacedName) (*corev1.Secret, error) {
+					if nn.Name == "tls" {
+						return tlsSecret, nil
+					}
+					if tt.previous != nil && nn == (types.NamespacedName{Namespace: tt.previous.Namespace, Name: tt.previous.Name}) {
+						return tt.previous, nil
+					}
+					if tt.existing != nil {
+						return tt.existing(cfg), nil
+					}
+					return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, nn.Name)
+				},
+				applySecret: func(_ context.Context, secret *applycorev1.SecretApplyConfiguration) (*corev1.Secret, error) {
+					applied = secret
+					return nil, nil
+				},
+				deleteSecret: func(_ context.Context, nn types.NamespacedName) error {
+					deleted = append(deleted, nn)
+					return nil
+				},
+				applyFinalizers: func(_ context.Context, nn types.NamespacedName, f []string) error {
+					require.Equal(t, cluster.NamespacedName(), nn)
+					finalizers = append(finalizers, f)
+					return nil
+				},
+				patchStatus: func(_ context.Context, patch *v1alpha1.SpiceDBCluster) error {
+					patchCalled = true
+					return nil
+				},
+				next: handler.ContextHandlerFunc(func(ctx context.Context) {
+					called = nextKey
+				}),
+			}
+			h.Handle(ctx)
+
+			require.Equal(t, tt.expectApplied, applied != nil)
+			if applied != nil {
+				require.Equal(t, tt.secretNamespace, *applied.Namespace)
+				require.Equal(t, tt.secretName, *applied.Name)
+				require.Equal(t, tt.expectCA, string(applied.Data[config.ConnectionCACertKey]))
+				require.NotEmpty(t, applied.Annotations[connectionSecretHashKey])
+			}
+			require.Equal(t, tt.expectDeleted, deleted)
+			require.Equal(t, tt.expectFinalizers, finalizers)
+			require.Equal(t, tt.expectPatchStatus, patchCalled)
+			require.Equal(t, tt.expectStatus, cluster.Status.ConnectionSecret)
+			require.Equal(t, tt.expectCondition, cluster.FindStatusCondition(v1alpha1.ConditionTypeConnectionSecret) != nil)
+			require.Equal(t, tt.expectNext, called)
+			require.Zero(t, ctrls.RequeueAPIErrCallCount()+ctrls.RequeueErrCallCount())
+		})
+	}
+}
+
+func TestConnectionSecretFinalizerHandler(t *testing.T) {
+	var nextKey handler.Key = "next"
+	now := metav1.Now()
+	published := metadata.LabelsForComponent("test", metadata.ComponentConnectionSecretLabel)
+	published[metadata.OwnerNamespaceLabelKey] = "test"
+	tests := []struct {
+		name string
+
+		deleting   bool
+		finalizers []string
+		status     *v1alpha1.ConnectionSecretReference
+		labels     map[string]string
+
+		expectDeleted    []types.NamespacedName
+		expectFinalizers bool
+		expectNext       handler.Key
+		expectDone       bool
+	}{
+		{
+			name:       "not deleting",
+			finalizers: []string{metadata.ConnectionSecretFinalizer},
+			expectNext: nextKey,
+		},
+		{
+			name:       "deleting without the finalizer",
+			deleting:   true,
+			finalizers: []string{"other"},
+			expectNext: nextKey,
+		},
+		{
+			name:             "deletes the secret and releases the cluster",
+			deleting:         true,
+			finalizers:       []string{metadata.ConnectionSecretFinalizer},
+			status:           &v1alpha1.ConnectionSecretReference{Namespace: "app", Name: "conn"},
+			labels:           published,
+			expectDeleted:    []types.NamespacedName{{Namespace: "app", Name: "conn"}},
+			expectFinalizers: true,
+			expectDone:       true,
+		},
+		{
+			name:             "leaves a secret it didn't publish",
+			deleting:         true,
+			finalizers:       []string{metadata.ConnectionSecretFinalizer},
+			status:           &v1alpha1.ConnectionSecretReference{Namespace: "app", Name: "conn"},
+			expectFinalizers: true,
+			expectDone:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrls := &fake.FakeInterface{}
+			cluster := &v1alpha1.SpiceDBCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test", Finalizers: tt.finalizers},
+				Status:     v1alpha1.ClusterStatus{ConnectionSecret: tt.status},
+			}
+			if tt.deleting {
+				cluster.DeletionTimestamp = &now
+			}
+			ctx := context.Background()
+			ctx = QueueOps.WithValue(ctx, ctrls)
+			ctx = CtxCluster.WithValue(ctx, cluster)
+
+			var deleted []types.NamespacedName
+			finalizersApplied := false
+			var called handler.Key
+			h := &ConnectionSecretFinalizerHandler{
+				getSecret: func(_ context.Context, nn types.NamespacedName) (*corev1.Secret, error) {
+					return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: nn.Namespace, Name: nn.Name, Labels: tt.labels}}, nil
+				},
+				deleteSecret: func(_ context.Context, nn types.NamespacedName) error {
+					deleted = append(deleted, nn)
+					return nil
+				},
+				applyFinalizers: func(_ context.Context, _ types.NamespacedName, f []string) error {
+					require.Empty(t, f)
+					finalizersApplied = true
+					return nil
+				},
+				next: handler.ContextHandlerFunc(func(ctx context.Context) {
+					called = nextKey
+				}),
+			}
+			h.Handle(ctx)
+
+			require.Equal(t, tt.expectDeleted, deleted)
+			require.Equal(t, tt.expectFinalizers, finalizersApplied)
+			require.Equal(t, tt.expectNext, called)
+			require.Equal(t, tt.expectDone, ctrls.DoneCallCount() == 1)
+		})
+	}
+}
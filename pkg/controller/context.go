@@ -21,7 +21,9 @@ var (
 	CtxSecretNN               = typedctx.WithDefault(types.NamespacedName{})
 	CtxSecret                 = typedctx.WithDefault[*corev1.Secret](nil)
 	CtxSecretHash             = typedctx.WithDefault("")
+	CtxSelfSignedCA           = typedctx.WithDefault("")
 	CtxCluster                = typedctx.WithDefault[*v1alpha1.SpiceDBCluster](nil)
+	CtxWrittenCluster         = typedctx.Boxed[*v1alpha1.SpiceDBCluster](nil)
 	CtxConfig                 = typedctx.WithDefault[*config.Config](nil)
 	CtxMigrationHash          = typedctx.WithDefault("")
 	CtxDeployments            = typedctx.Boxed(make([]*appsv1.Deployment, 0))
@@ -9,8 +9,8 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"time"
 
-	"github.com/authzed/controller-idioms/adopt"
 	"github.com/authzed/controller-idioms/cachekeys"
 	"github.com/authzed/controller-idioms/component"
 	"github.com/authzed/controller-idioms/fileinformer"
@@ -23,19 +23,30 @@ import (
 	"github.com/go-logr/logr"
 	"go.uber.org/atomic"
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/util/yaml"
 	applyappsv1 "k8s.io/client-go/applyconfigurations/apps/v1"
+	applyautoscalingv2 "k8s.io/client-go/applyconfigurations/autoscaling/v2"
 	applybatchv1 "k8s.io/client-go/applyconfigurations/batch/v1"
 	applycorev1 "k8s.io/client-go/applyconfigurations/core/v1"
+	applynetworkingv1 "k8s.io/client-go/applyconfigurations/networking/v1"
+	applypolicyv1 "k8s.io/client-go/applyconfigurations/policy/v1"
 	applyrbacv1 "k8s.io/client-go/applyconfigurations/rbac/v1"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/dynamic/dynamicinformer"
@@ -58,12 +69,21 @@ import (
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;delete
 // +kubebuilder:rbac:groups="",resources=jobs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=events,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=endpoints,verbs=get;list;watch
+// +kubebuilder:rbac:groups="discovery.k8s.io",resources=endpointslices,verbs=get;list;watch
+// +kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="networking.k8s.io",resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="networking.k8s.io",resources=ingresses,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="gateway.networking.k8s.io",resources=grpcroutes;httproutes,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="cert-manager.io",resources=certificates,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="monitoring.coreos.com",resources=servicemonitors;podmonitors;prometheusrules,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources=roles,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources=rolebindings,verbs=get;list;watch;create;update;patch;delete
 
@@ -96,6 +116,16 @@ type Controller struct {
 	resources   openapi.Resources
 	mainHandler handler.Handler
 
+	// optional third-party resources served by the cluster, which are
+	// rediscovered periodically so that CRDs installed later are picked up
+	customResourcesLock       sync.RWMutex
+	customResources           map[schema.GroupVersionResource]struct{}
+	externalInformerFactories []dynamicinformer.DynamicSharedInformerFactory
+
+	// clusters and components that have been warned about a missing
+	// custom resource, so that the event is only emitted once
+	customResourcesMissing sync.Map
+
 	// config
 	configLock     sync.RWMutex
 	config         config.OperatorConfig
@@ -109,10 +139,11 @@ func NewController(ctx context.Context, registry *typed.Registry, dclient dynami
 	}
 
 	c := Controller{
-		client:     dclient,
-		kclient:    kclient,
-		resources:  resources,
-		namespaces: namespaces,
+		client:          dclient,
+		kclient:         kclient,
+		resources:       resources,
+		namespaces:      namespaces,
+		customResources: discoverCustomResources(ctx, kclient.Discovery(), optionalResources...),
 	}
 	c.OwnedResourceController = manager.NewOwnedResourceController(
 		textlogger.NewLogger(textlogger.NewConfig()),
@@ -173,30 +204,33 @@ func NewController(ctx context.Context, registry *typed.Registry, dclient dynami
 			},
 		)
 
-		for _, gvr := range []schema.GroupVersionResource{
+		gvrs := []schema.GroupVersionResource{
 			appsv1.SchemeGroupVersion.WithResource("deployments"),
 			corev1.SchemeGroupVersion.WithResource("secrets"),
+			corev1.SchemeGroupVersion.WithResource("configmaps"),
 			corev1.SchemeGroupVersion.WithResource("serviceaccounts"),
 			corev1.SchemeGroupVersion.WithResource("services"),
 			corev1.SchemeGroupVersion.WithResource("pods"),
+			discoveryv1.SchemeGroupVersion.WithResource("endpointslices"),
 			batchv1.SchemeGroupVersion.WithResource("jobs"),
 			rbacv1.SchemeGroupVersion.WithResource("roles"),
 			rbacv1.SchemeGroupVersion.WithResource("rolebindings"),
-		} {
-			inf := externalInformerFactory.ForResource(gvr).Informer()
-			if err := inf.AddIndexers(cache.Indexers{metadata.OwningClusterIndex: metadata.GetClusterKeyFromMeta}); err != nil {
-				return nil, err
-			}
-			if _, err := inf.AddEventHandler(cache.ResourceEventHandlerFuncs{
-				AddFunc:    func(obj any) { c.syncExternalResource(obj) },
-				UpdateFunc: func(_, obj any) { c.syncExternalResource(obj) },
-				DeleteFunc: func(obj any) { c.syncExternalResource(obj) },
-			}); err != nil {
+			autoscalingv2.SchemeGroupVersion.WithResource("horizontalpodautoscalers"),
+			policyv1.SchemeGroupVersion.WithResource("poddisruptionbudgets"),
+			networkingv1.SchemeGroupVersion.WithResource("networkpolicies"),
+			networkingv1.SchemeGroupVersion.WithResource("ingresses"),
+		}
+		for gvr := range c.customResources {
+			gvrs = append(gvrs, gvr)
+		}
+		for _, gvr := range gvrs {
+			if err := c.addExternalInformer(externalInformerFactory, gvr); err != nil {
 				return nil, err
 			}
 		}
 		externalInformerFactories = append(externalInformerFactories, externalInformerFactory)
 	}
+	c.externalInformerFactories = externalInformerFactories
 
 	// start informers
 	for _, ownedInformerFactory := range ownedInformerFactories {
@@ -219,6 +253,8 @@ func NewController(ctx context.Context, registry *typed.Registry, dclient dynami
 	}
 	fileInformerFactory.WaitForCacheSync(ctx.Done())
 
+	go wait.UntilWithContext(ctx, c.rediscoverCustomResources, customResourceDiscoveryInterval)
+
 	// Build mainHandler handler
 	mw := middleware.NewHandlerLoggingMiddleware(4)
 	chain := middleware.ChainWithMiddleware(mw)
@@ -235,16 +271,53 @@ func NewController(ctx context.Context, registry *typed.Registry, dclient dynami
 	).WithID(HandlerWaitForMigrationsKey)
 
 	c.mainHandler = chain(
+		c.finalizeConnectionSecret,
 		c.pauseCluster,
+		c.generateSecret,
 		c.secretAdopter,
+		c.rotatePresharedKey,
 		c.checkConfigChanged,
 		c.validateConfig,
+		c.ensureSelfSignedTLS,
 		parallel(
 			c.ensureServiceAccount,
+			c.ensureCAConfigMap,
 			c.ensureRole,
 			c.ensureService,
+			c.ensureDispatchServices,
+			c.ensureHorizontalPodAutoscaler,
+			c.ensurePodDisruptionBudget,
+			c.ensureNetworkPolicy,
+			c.ensureCustomResource(config.ServiceMonitorResource, metadata.ComponentServiceMonitorLabel,
+				func(cfg *config.Config) bool { return cfg.PrometheusMonitor == config.ServiceMonitorKind },
+				(*config.Config).ServiceMonitor),
+			c.ensureCustomResource(config.PodMonitorResource, metadata.ComponentPodMonitorLabel,
+				func(cfg *config.Config) bool { return cfg.PrometheusMonitor == config.PodMonitorKind },
+				(*config.Config).PodMonitor),
+			c.ensureCustomResource(config.PrometheusRuleResource, metadata.ComponentPrometheusRuleLabel,
+				func(cfg *config.Config) bool { return cfg.PrometheusRulesEnabled },
+				(*config.Config).PrometheusRule),
+			c.ensureIngress,
+			c.ensureCustomResource(config.GRPCRouteResource, metadata.ComponentGRPCRouteLabel,
+				func(cfg *config.Config) bool { return cfg.IngressType == config.IngressTypeGatewayAPI },
+				(*config.Config).GRPCRoute),
+			c.ensureCustomResource(config.HTTPRouteResource, metadata.ComponentHTTPRouteLabel,
+				func(cfg *config.Config) bool {
+					return cfg.IngressType == config.IngressTypeGatewayAPI && len(cfg.IngressHTTPHost) > 0
+				},
+				(*config.Config).HTTPRoute),
+			c.ensureCustomResource(config.CertificateResource, metadata.ComponentCertificateLabel,
+				func(cfg *config.Config) bool { return cfg.TLSIssuer != nil },
+				(*config.Config).Certificate),
+			c.ensureCustomResource(config.CertificateResource, metadata.ComponentDispatchCertificateLabel,
+				func(cfg *config.Config) bool { return cfg.DispatchIssuer != nil },
+				(*config.Config).DispatchCertificate),
 		),
+		c.updateEndpoints,
 		c.ensureRoleBinding,
+		c.waitForCertificates,
+		c.adoptReferencedSecrets,
+		c.publishConnectionSecret,
 		CtxDeployments.BoxBuilder("deploymentsPre"),
 		CtxJobs.BoxBuilder("jobsPre"),
 		parallel(
@@ -289,6 +362,12 @@ func (c *Controller) loadConfig(path string) {
 	if err := decoder.Decode(&cfg); err != nil {
 		panic(err)
 	}
+	// a bad edit to the waves keeps the previous config rather than
+	// crashing the operator
+	if err := cfg.FleetRollout.Validate(); err != nil {
+		utilruntime.HandleError(fmt.Errorf("ignoring operator config %s: %w", path, err))
+		return
+	}
 
 	if h := xxhash.Sum64(contents); h != c.lastConfigHash.Load() {
 		func() {
@@ -304,8 +383,10 @@ func (c *Controller) loadConfig(path string) {
 	}
 
 	logger.V(3).Info("updated config", "path", path, "config", c.config)
+	c.requeueAllClusters()
+}
 
-	// requeue all clusters
+func (c *Controller) requeueAllClusters() {
 	for _, ns := range c.namespaces {
 		lister := typed.MustListerForKey[*v1alpha1.SpiceDBCluster](c.Registry, typed.NewRegistryKey(OwnedFactoryKey(ns), v1alpha1ClusterGVR))
 		clusters, err := lister.List(labels.Everything())
@@ -319,6 +400,34 @@ func (c *Controller) loadConfig(path string) {
 	}
 }
 
+// addExternalInformer registers an informer for a dependent resource, which
+// requeues the owning cluster whenever an object changes.
+func (c *Controller) addExternalInformer(factory dynamicinformer.DynamicSharedInformerFactory, gvr schema.GroupVersionResource) error {
+	inf := factory.ForResource(gvr).Informer()
+	indexers := cache.Indexers{metadata.OwningClusterIndex: metadata.GetClusterKeyFromMeta}
+	if gvr == corev1.SchemeGroupVersion.WithResource("secrets") {
+		indexers[metadata.AdoptingClusterIndex] = metadata.GetAdoptingClusterKeyFromMeta
+		indexers[metadata.ReferencingClusterIndex] = metadata.GetReferencingClusterKeyFromMeta
+	}
+	if err := inf.AddIndexers(indexers); err != nil {
+		return err
+	}
+	_, err := inf.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj any) { c.syncExternalResource(obj) },
+		UpdateFunc: func(_, obj any) { c.syncExternalResource(obj) },
+		DeleteFunc: func(obj any) { c.syncExternalResource(obj) },
+	})
+	return err
+}
+
+// OperatorConfig returns a copy of the currently loaded operator config.
+func (c *Controller) OperatorConfig() *config.OperatorConfig {
+	c.configLock.RLock()
+	defer c.configLock.RUnlock()
+	cfg := c.config.Copy()
+	return &cfg
+}
+
 func (c *Controller) enqueue(gvr schema.GroupVersionResource, obj interface{}) {
 	key, err := cachekeys.GVRMetaNamespaceKeyFunc(gvr, obj)
 	if err != nil {
@@ -358,10 +467,7 @@ func (c *Controller) syncOwnedResource(ctx context.Context, gvr schema.GroupVers
 		Namespace: cluster.Namespace,
 	})
 
-	c.configLock.RLock()
-	cfg := c.config.Copy()
-	ctx = CtxOperatorConfig.WithValue(ctx, &cfg)
-	c.configLock.RUnlock()
+	ctx = CtxOperatorConfig.WithValue(ctx, c.OperatorConfig())
 
 	logger.V(4).Info("syncing owned object", "gvr", gvr)
 
@@ -386,7 +492,7 @@ func (c *Controller) syncExternalResource(obj interface{}) {
 	)
 	logger.V(4).Info("syncing external object")
 
-	keys, err := adopt.OwnerKeysFromMeta(metadata.OwnerAnnotationKeyPrefix)(obj)
+	keys, err := metadata.GetClusterKeyFromMeta(obj)
 	if err != nil {
 		utilruntime.HandleError(err)
 		return
@@ -400,7 +506,39 @@ func (c *Controller) syncExternalResource(obj interface{}) {
 // Handle inspects the current SpiceDBCluster object and ensures
 // the desired state is persisted on the cluster.
 func (c *Controller) Handle(ctx context.Context) {
+	cluster := CtxCluster.MustValue(ctx)
+	ctx = CtxWrittenCluster.WithBox(ctx)
+	ctx = CtxWrittenCluster.WithValue(ctx, cluster.DeepCopy())
+
 	c.mainHandler.Handle(ctx)
+
+	if err := c.updateAggregateConditions(ctx, cluster); err != nil {
+		utilruntime.HandleError(fmt.Errorf("error updating the aggregate conditions of %s: %w", cluster.NamespacedName(), err))
+	}
+}
+
+// updateAggregateConditions derives the aggregate conditions from the status
+// that the handlers left behind, so that they're current whether or not the
+// handlers wrote the status.
+func (c *Controller) updateAggregateConditions(ctx context.Context, cluster *v1alpha1.SpiceDBCluster) error {
+	if cluster.DeletionTimestamp != nil {
+		return nil
+	}
+	written := CtxWrittenCluster.MustValue(ctx)
+
+	status := &v1alpha1.SpiceDBCluster{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       v1alpha1.SpiceDBClusterKind,
+			APIVersion: v1alpha1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{Namespace: cluster.Namespace, Name: cluster.Name, Generation: cluster.Generation},
+		Status:     *written.Status.DeepCopy(),
+	}
+	status.SetAggregateConditions()
+	if equality.Semantic.DeepEqual(status.Status.Conditions, written.Status.Conditions) {
+		return nil
+	}
+	return c.PatchStatus(ctx, status)
 }
 
 func (c *Controller) ensureDeployment(next ...handler.Handler) handler.Handler {
@@ -422,7 +560,19 @@ func (c *Controller) ensureDeployment(next ...handler.Handler) handler.Handler {
 				},
 			).List(ctx, CtxClusterNN.MustValue(ctx))
 		},
+		getEndpointSlices: func(ctx context.Context) []*discoveryv1.EndpointSlice {
+			// endpoint slices are labelled with the labels of their service
+			return component.NewIndexedComponent(
+				typed.MustIndexerForKey[*discoveryv1.EndpointSlice](c.Registry, typed.NewRegistryKey(DependentFactoryKey(CtxCacheNamespace.Value(ctx)), discoveryv1.SchemeGroupVersion.WithResource("endpointslices"))),
+				metadata.OwningClusterIndex,
+				func(ctx context.Context) labels.Selector {
+					return metadata.SelectorForComponent(CtxClusterNN.MustValue(ctx).Name, metadata.ComponentServiceLabel)
+				},
+			).List(ctx, CtxClusterNN.MustValue(ctx))
+		},
 		patchStatus: c.PatchStatus,
+		recorder:    c.Recorder,
+		now:         time.Now,
 		next:        handler.Handlers(next).MustOne(),
 	})
 }
@@ -494,10 +644,7 @@ func (c *Controller) secretAdopter(next ...handler.Handler) handler.Handler {
 					Status:     *cluster.Status.DeepCopy(),
 				}
 				status.Status.ObservedGeneration = cluster.GetGeneration()
-				status.SetStatusCondition(v1alpha1.NewMissingSecretCondition(types.NamespacedName{
-					Namespace: cluster.Namespace,
-					Name:      cluster.Spec.SecretRef,
-				}))
+				status.SetStatusCondition(v1alpha1.NewMissingSecretCondition(CtxSecretNN.MustValue(ctx)))
 				if err := c.PatchStatus(ctx, status); err != nil {
 					QueueOps.RequeueAPIErr(ctx, err)
 				}
@@ -521,6 +668,145 @@ func (c *Controller) secretAdopter(next ...handler.Handler) handler.Handler {
 	}, "adoptSecret")
 }
 
+func (c *Controller) adoptReferencedSecrets(next ...handler.Handler) handler.Handler {
+	secretsGVR := corev1.SchemeGroupVersion.WithResource("secrets")
+	return handler.NewTypeHandler(&ReferencedSecretsHandler{
+		recorder: c.Recorder,
+		getSecret: func(ctx context.Context, nn types.NamespacedName) (*corev1.Secret, error) {
+			secret, err := typed.MustListerForKey[*corev1.Secret](c.Registry, typed.NewRegistryKey(DependentFactoryKey(CtxCacheNamespace.Value(ctx)), secretsGVR)).ByNamespace(nn.Namespace).Get(nn.Name)
+			if apierrors.IsNotFound(err) {
+				// secrets are only cached once they're labelled
+				return c.kclient.CoreV1().Secrets(nn.Namespace).Get(ctx, nn.Name, metav1.GetOptions{})
+			}
+			return secret, err
+		},
+		listReferenced: func(ctx context.Context, owner types.NamespacedName) ([]*corev1.Secret, error) {
+			return typed.MustIndexerForKey[*corev1.Secret](c.Registry, typed.NewRegistryKey(DependentFactoryKey(CtxCacheNamespace.Value(ctx)), secretsGVR)).ByIndex(metadata.ReferencingClusterIndex, owner.String())
+		},
+		applySecret: func(ctx context.Context, secret *applycorev1.SecretApplyConfiguration, opts metav1.ApplyOptions) (*corev1.Secret, error) {
+			logr.FromContextOrDiscard(ctx).V(4).Info("applying secret", "namespace", *secret.Namespace, "name", *secret.Name)
+			return c.kclient.CoreV1().Secrets(*secret.Namespace).Apply(ctx, secret, opts)
+		},
+		patchStatus: c.PatchStatus,
+		next:        handler.Handlers(next).MustOne(),
+	})
+}
+
+func (c *Controller) publishConnectionSecret(next ...handler.Handler) handler.Handler {
+	return handler.NewTypeHandler(&ConnectionSecretHandler{
+		getSecret: c.getConnectionSecret,
+		applySecret: func(ctx context.Context, secret *applycorev1.SecretApplyConfiguration) (*corev1.Secret, error) {
+			logr.FromContextOrDiscard(ctx).V(4).Info("applying secret", "namespace", *secret.Namespace, "name", *secret.Name)
+			return c.kclient.CoreV1().Secrets(*secret.Namespace).Apply(ctx, secret, metadata.ApplyForceOwned)
+		},
+		deleteSecret:    c.deleteSecret,
+		applyFinalizers: c.ApplyFinalizers,
+		patchStatus:     c.PatchStatus,
+		next:            handler.Handlers(next).MustOne(),
+	})
+}
+
+func (c *Controller) finalizeConnectionSecret(next ...handler.Handler) handler.Handler {
+	return handler.NewTypeHandler(&ConnectionSecretFinalizerHandler{
+		getSecret:       c.getConnectionSecret,
+		deleteSecret:    c.deleteSecret,
+		applyFinalizers: c.ApplyFinalizers,
+		next:            handler.Handlers(next).MustOne(),
+	})
+}
+
+func (c *Controller) getConnectionSecret(ctx context.Context, nn types.NamespacedName) (*corev1.Secret, error) {
+	secretsGVR := corev1.SchemeGroupVersion.WithResource("secrets")
+	secret, err := typed.MustListerForKey[*corev1.Secret](c.Registry, typed.NewRegistryKey(DependentFactoryKey(CtxCacheNamespace.Value(ctx)), secretsGVR)).ByNamespace(nn.Namespace).Get(nn.Name)
+	if apierrors.IsNotFound(err) {
+		// the connection secret may be in a namespace that isn't watched
+		return c.kclient.CoreV1().Secrets(nn.Namespace).Get(ctx, nn.Name, metav1.GetOptions{})
+	}
+	return secret, err
+}
+
+func (c *Controller) deleteSecret(ctx context.Context, nn types.NamespacedName) error {
+	logr.FromContextOrDiscard(ctx).V(4).Info("deleting secret", "namespace", nn.Namespace, "name", nn.Name)
+	return c.kclient.CoreV1().Secrets(nn.Namespace).Delete(ctx, nn.Name, metav1.DeleteOptions{})
+}
+
+func (c *Controller) rotatePresharedKey(next ...handler.Handler) handler.Handler {
+	return handler.NewTypeHandler(&PresharedKeyRotationHandler{
+		recorder: c.Recorder,
+		updateSecret: func(ctx context.Context, secret *corev1.Secret) (*corev1.Secret, error) {
+			logr.FromContextOrDiscard(ctx).V(4).Info("updating secret", "namespace", secret.Namespace, "name", secret.Name)
+			return c.kclient.CoreV1().Secrets(secret.Namespace).Update(ctx, secret, metav1.UpdateOptions{FieldManager: metadata.FieldManager})
+		},
+		patchStatus: c.PatchStatus,
+		next:        handler.Handlers(next).MustOne(),
+	})
+}
+
+func (c *Controller) generateSecret(next ...handler.Handler) handler.Handler {
+	secretsGVR := corev1.SchemeGroupVersion.WithResource("secrets")
+	return handler.NewTypeHandler(&GenerateSecretHandler{
+		recorder: c.Recorder,
+		getSecret: func(ctx context.Context, nn types.NamespacedName) (*corev1.Secret, error) {
+			return typed.MustListerForKey[*corev1.Secret](c.Registry, typed.NewRegistryKey(DependentFactoryKey(CtxCacheNamespace.Value(ctx)), secretsGVR)).ByNamespace(nn.Namespace).Get(nn.Name)
+		},
+		createSecret: func(ctx context.Context, secret *corev1.Secret) (*corev1.Secret, error) {
+			return c.kclient.CoreV1().Secrets(secret.Namespace).Create(ctx, secret, metav1.CreateOptions{FieldManager: metadata.FieldManager})
+		},
+		patchStatus: c.PatchStatus,
+		next:        handler.Handlers(next).MustOne(),
+	})
+}
+
+func (c *Controller) waitForCertificates(next ...handler.Handler) handler.Handler {
+	return handler.NewTypeHandler(&WaitForCertificatesHandler{
+		getCertificate: func(ctx context.Context, componentLabel string) (*unstructured.Unstructured, bool) {
+			if !c.customResourceServed(config.CertificateResource) {
+				return nil, false
+			}
+			nn := CtxClusterNN.MustValue(ctx)
+			certs := component.NewIndexedComponent(
+				typed.MustIndexerForKey[*unstructured.Unstructured](
+					c.Registry,
+					typed.NewRegistryKey(DependentFactoryKey(CtxCacheNamespace.Value(ctx)), config.CertificateResource)),
+				metadata.OwningClusterIndex,
+				func(ctx context.Context) labels.Selector {
+					return metadata.SelectorForComponent(nn.Name, componentLabel)
+				}).List(ctx, nn)
+			if len(certs) == 0 {
+				return nil, true
+			}
+			return certs[0], true
+		},
+		patchStatus: c.PatchStatus,
+		next:        handler.Handlers(next).MustOne(),
+	})
+}
+
+func (c *Controller) ensureSelfSignedTLS(next ...handler.Handler) handler.Handler {
+	secretsGVR := corev1.SchemeGroupVersion.WithResource("secrets")
+	return handler.NewTypeHandler(&SelfSignedTLSHandler{
+		recorder: c.Recorder,
+		now:      time.Now,
+		getSecret: func(ctx context.Context, nn types.NamespacedName) (*corev1.Secret, error) {
+			secret, err := typed.MustListerForKey[*corev1.Secret](c.Registry, typed.NewRegistryKey(DependentFactoryKey(CtxCacheNamespace.Value(ctx)), secretsGVR)).ByNamespace(nn.Namespace).Get(nn.Name)
+			if apierrors.IsNotFound(err) {
+				// a certificate that was just issued may not be cached yet,
+				// and issuing another would roll the pods twice
+				return c.kclient.CoreV1().Secrets(nn.Namespace).Get(ctx, nn.Name, metav1.GetOptions{})
+			}
+			return secret, err
+		},
+		applySecret: func(ctx context.Context, secret *applycorev1.SecretApplyConfiguration) (*corev1.Secret, error) {
+			logr.FromContextOrDiscard(ctx).V(4).Info("applying secret", "namespace", *secret.Namespace, "name", *secret.Name)
+			return c.kclient.CoreV1().Secrets(*secret.Namespace).Apply(ctx, secret, metadata.ApplyForceOwned)
+		},
+		scheduleRenewal: func(ctx context.Context, after time.Duration) {
+			c.Queue.AddAfter(cachekeys.GVRMetaNamespaceKeyer(v1alpha1ClusterGVR, CtxClusterNN.MustValue(ctx).String()), after)
+		},
+		next: handler.Handlers(next).MustOne(),
+	})
+}
+
 func (c *Controller) checkConfigChanged(next ...handler.Handler) handler.Handler {
 	return handler.NewTypeHandler(&ConfigChangedHandler{
 		patchStatus: c.PatchStatus,
@@ -533,7 +819,23 @@ func (c *Controller) validateConfig(next ...handler.Handler) handler.Handler {
 		patchStatus: c.PatchStatus,
 		recorder:    c.Recorder,
 		resources:   c.resources,
-		next:        handler.Handlers(next).MustOne(),
+		now:         time.Now,
+		listClusters: func() ([]*v1alpha1.SpiceDBCluster, error) {
+			var clusters []*v1alpha1.SpiceDBCluster
+			for _, ns := range c.namespaces {
+				lister := typed.MustListerForKey[*v1alpha1.SpiceDBCluster](c.Registry, typed.NewRegistryKey(OwnedFactoryKey(ns), v1alpha1ClusterGVR))
+				list, err := lister.List(labels.Everything())
+				if err != nil {
+					return nil, err
+				}
+				clusters = append(clusters, list...)
+			}
+			return clusters, nil
+		},
+		scheduleUpdate: func(ctx context.Context, after time.Duration) {
+			c.Queue.AddAfter(cachekeys.GVRMetaNamespaceKeyer(v1alpha1ClusterGVR, CtxClusterNN.MustValue(ctx).String()), after)
+		},
+		next: handler.Handlers(next).MustOne(),
 	})
 }
 
@@ -594,6 +896,7 @@ func (c *Controller) runMigration(next ...handler.Handler) handler.Handler {
 func (c *Controller) checkMigrations(next ...handler.Handler) handler.Handler {
 	return handler.NewTypeHandler(&MigrationCheckHandler{
 		recorder:                c.Recorder,
+		patchStatus:             c.PatchStatus,
 		nextMigrationRunHandler: HandlerMigrationRunKey.MustFind(next),
 		nextWaitForJobHandler:   HandlerWaitForMigrationsKey.MustFind(next),
 		nextDeploymentHandler:   HandlerDeploymentKey.MustFind(next),
@@ -744,3 +1047,291 @@ func (c *Controller) ensureService(next ...handler.Handler) handler.Handler {
 		handler.Handlers(next).MustOne().Handle(ctx)
 	}, "ensureService")
 }
+
+// ensureDispatchServices applies the services that the blue and green
+// deployments dispatch through, and removes the ones that no deployment
+// uses anymore.
+func (c *Controller) ensureDispatchServices(next ...handler.Handler) handler.Handler {
+	return handler.NewHandlerFromFunc(func(ctx context.Context) {
+		cfg := CtxConfig.MustValue(ctx)
+		existing := make(map[string]*corev1.Service)
+		for _, s := range component.NewIndexedComponent(
+			typed.MustIndexerForKey[*corev1.Service](
+				c.Registry,
+				typed.NewRegistryKey(
+					DependentFactoryKey(CtxCacheNamespace.Value(ctx)),
+					corev1.SchemeGroupVersion.WithResource("services"),
+				)),
+			metadata.OwningClusterIndex,
+			func(ctx context.Context) labels.Selector {
+				return metadata.SelectorForComponent(CtxClusterNN.MustValue(ctx).Name, metadata.ComponentDispatchServiceLabel)
+			}).List(ctx, CtxClusterNN.MustValue(ctx)) {
+			existing[s.Name] = s
+		}
+
+		if cfg.DispatchEnabled {
+			for _, slot := range []string{cfg.ServingSlot, cfg.DeploymentSlot} {
+				if len(slot) == 0 {
+					continue
+				}
+				apply := cfg.DispatchService(slot)
+				serviceHash := hash.Object(apply)
+				if s, ok := existing[*apply.Name]; !ok || !hash.Equal(s.Annotations["authzed.com/controller-component-hash"], serviceHash) {
+					logr.FromContextOrDiscard(ctx).V(4).Info("applying dispatch service", "namespace", *apply.Namespace, "name", *apply.Name)
+					if _, err := c.kclient.CoreV1().Services(*apply.Namespace).Apply(ctx,
+						apply.WithAnnotations(map[string]string{"authzed.com/controller-component-hash": serviceHash}),
+						metadata.ApplyForceOwned); err != nil {
+						QueueOps.RequeueAPIErr(ctx, err)
+						return
+					}
+				}
+				delete(existing, *apply.Name)
+			}
+		}
+
+		for _, s := range existing {
+			logr.FromContextOrDiscard(ctx).V(4).Info("deleting dispatch service", "namespace", s.Namespace, "name", s.Name)
+			if err := c.kclient.CoreV1().Services(s.Namespace).Delete(ctx, s.Name, metav1.DeleteOptions{}); err != nil {
+				QueueOps.RequeueAPIErr(ctx, err)
+				return
+			}
+		}
+		handler.Handlers(next).MustOne().Handle(ctx)
+	}, "ensureDispatchServices")
+}
+
+func (c *Controller) ensurePodDisruptionBudget(next ...handler.Handler) handler.Handler {
+	return handler.NewHandlerFromFunc(func(ctx context.Context) {
+		component.NewEnsureComponentByHash(
+			component.NewHashableComponent(
+				component.NewIndexedComponent(
+					typed.MustIndexerForKey[*policyv1.PodDisruptionBudget](
+						c.Registry,
+						typed.NewRegistryKey(
+							DependentFactoryKey(CtxCacheNamespace.Value(ctx)),
+							policyv1.SchemeGroupVersion.WithResource("poddisruptionbudgets"),
+						)),
+					metadata.OwningClusterIndex,
+					func(ctx context.Context) labels.Selector {
+						return metadata.SelectorForComponent(CtxClusterNN.MustValue(ctx).Name, metadata.ComponentPDBLabel)
+					}),
+				hash.NewObjectHash(), "authzed.com/controller-component-hash"),
+			CtxClusterNN,
+			QueueOps,
+			func(ctx context.Context, apply *applypolicyv1.PodDisruptionBudgetApplyConfiguration) (*policyv1.PodDisruptionBudget, error) {
+				logr.FromContextOrDiscard(ctx).V(4).Info("applying poddisruptionbudget", "namespace", *apply.Namespace, "name", *apply.Name)
+				return c.kclient.PolicyV1().PodDisruptionBudgets(*apply.Namespace).Apply(ctx, apply, metadata.ApplyForceOwned)
+			},
+			func(ctx context.Context, nn types.NamespacedName) error {
+				logr.FromContextOrDiscard(ctx).V(4).Info("deleting poddisruptionbudget", "namespace", nn.Namespace, "name", nn.Name)
+				return c.kclient.PolicyV1().PodDisruptionBudgets(nn.Namespace).Delete(ctx, nn.Name, metav1.DeleteOptions{})
+			},
+			func(ctx context.Context) *applypolicyv1.PodDisruptionBudgetApplyConfiguration {
+				return CtxConfig.MustValue(ctx).PodDisruptionBudget()
+			}).Handle(ctx)
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return
+		}
+		handler.Handlers(next).MustOne().Handle(ctx)
+	}, "ensurePodDisruptionBudget")
+}
+
+func (c *Controller) ensureNetworkPolicy(next ...handler.Handler) handler.Handler {
+	return handler.NewHandlerFromFunc(func(ctx context.Context) {
+		networkPolicies := component.NewIndexedComponent(
+			typed.MustIndexerForKey[*networkingv1.NetworkPolicy](
+				c.Registry,
+				typed.NewRegistryKey(
+					DependentFactoryKey(CtxCacheNamespace.Value(ctx)),
+					networkingv1.SchemeGroupVersion.WithResource("networkpolicies"),
+				)),
+			metadata.OwningClusterIndex,
+			func(ctx context.Context) labels.Selector {
+				return metadata.SelectorForComponent(CtxClusterNN.MustValue(ctx).Name, metadata.ComponentNetworkPolicyLabel)
+			})
+		deleteNetworkPolicy := func(ctx context.Context, nn types.NamespacedName) error {
+			logr.FromContextOrDiscard(ctx).V(4).Info("deleting networkpolicy", "namespace", nn.Namespace, "name", nn.Name)
+			return c.kclient.NetworkingV1().NetworkPolicies(nn.Namespace).Delete(ctx, nn.Name, metav1.DeleteOptions{})
+		}
+
+		// remove any policy left over from before it was disabled
+		if !CtxConfig.MustValue(ctx).NetworkPolicyEnabled {
+			for _, np := range networkPolicies.List(ctx, CtxClusterNN.MustValue(ctx)) {
+				if err := deleteNetworkPolicy(ctx, types.NamespacedName{Namespace: np.Namespace, Name: np.Name}); err != nil {
+					QueueOps.RequeueAPIErr(ctx, err)
+					return
+				}
+			}
+			handler.Handlers(next).MustOne().Handle(ctx)
+			return
+		}
+
+		component.NewEnsureComponentByHash(
+			component.NewHashableComponent(networkPolicies, hash.NewObjectHash(), "authzed.com/controller-component-hash"),
+			CtxClusterNN,
+			QueueOps,
+			func(ctx context.Context, apply *applynetworkingv1.NetworkPolicyApplyConfiguration) (*networkingv1.NetworkPolicy, error) {
+				logr.FromContextOrDiscard(ctx).V(4).Info("applying networkpolicy", "namespace", *apply.Namespace, "name", *apply.Name)
+				return c.kclient.NetworkingV1().NetworkPolicies(*apply.Namespace).Apply(ctx, apply, metadata.ApplyForceOwned)
+			},
+			deleteNetworkPolicy,
+			func(ctx context.Context) *applynetworkingv1.NetworkPolicyApplyConfiguration {
+				return CtxConfig.MustValue(ctx).NetworkPolicy()
+			}).Handle(ctx)
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return
+		}
+		handler.Handlers(next).MustOne().Handle(ctx)
+	}, "ensureNetworkPolicy")
+}
+
+func (c *Controller) ensureCAConfigMap(next ...handler.Handler) handler.Handler {
+	return handler.NewHandlerFromFunc(func(ctx context.Context) {
+		configMaps := component.NewIndexedComponent(
+			typed.MustIndexerForKey[*corev1.ConfigMap](
+				c.Registry,
+				typed.NewRegistryKey(
+					DependentFactoryKey(CtxCacheNamespace.Value(ctx)),
+					corev1.SchemeGroupVersion.WithResource("configmaps"),
+				)),
+			metadata.OwningClusterIndex,
+			func(ctx context.Context) labels.Selector {
+				return metadata.SelectorForComponent(CtxClusterNN.MustValue(ctx).Name, metadata.ComponentCAConfigMapLabel)
+			})
+		deleteConfigMap := func(ctx context.Context, nn types.NamespacedName) error {
+			logr.FromContextOrDiscard(ctx).V(4).Info("deleting configmap", "namespace", nn.Namespace, "name", nn.Name)
+			return c.kclient.CoreV1().ConfigMaps(nn.Namespace).Delete(ctx, nn.Name, metav1.DeleteOptions{})
+		}
+
+		// remove the published CA if the operator no longer manages it
+		if !CtxConfig.MustValue(ctx).SelfSignedTLS {
+			for _, cm := range configMaps.List(ctx, CtxClusterNN.MustValue(ctx)) {
+				if err := deleteConfigMap(ctx, types.NamespacedName{Namespace: cm.Namespace, Name: cm.Name}); err != nil {
+					QueueOps.RequeueAPIErr(ctx, err)
+					return
+				}
+			}
+			handler.Handlers(next).MustOne().Handle(ctx)
+			return
+		}
+
+		component.NewEnsureComponentByHash(
+			component.NewHashableComponent(configMaps, hash.NewObjectHash(), "authzed.com/controller-component-hash"),
+			CtxClusterNN,
+			QueueOps,
+			func(ctx context.Context, apply *applycorev1.ConfigMapApplyConfiguration) (*corev1.ConfigMap, error) {
+				logr.FromContextOrDiscard(ctx).V(4).Info("applying configmap", "namespace", *apply.Namespace, "name", *apply.Name)
+				return c.kclient.CoreV1().ConfigMaps(*apply.Namespace).Apply(ctx, apply, metadata.ApplyForceOwned)
+			},
+			deleteConfigMap,
+			func(ctx context.Context) *applycorev1.ConfigMapApplyConfiguration {
+				return CtxConfig.MustValue(ctx).CAConfigMap(CtxSelfSignedCA.MustValue(ctx))
+			}).Handle(ctx)
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return
+		}
+		handler.Handlers(next).MustOne().Handle(ctx)
+	}, "ensureCAConfigMap")
+}
+
+func (c *Controller) ensureIngress(next ...handler.Handler) handler.Handler {
+	return handler.NewHandlerFromFunc(func(ctx context.Context) {
+		ingresses := c.ingresses(ctx)
+		deleteIngress := func(ctx context.Context, nn types.NamespacedName) error {
+			logr.FromContextOrDiscard(ctx).V(4).Info("deleting ingress", "namespace", nn.Namespace, "name", nn.Name)
+			return c.kclient.NetworkingV1().Ingresses(nn.Namespace).Delete(ctx, nn.Name, metav1.DeleteOptions{})
+		}
+
+		// remove any ingress left over from before it was disabled
+		if CtxConfig.MustValue(ctx).IngressType != config.IngressTypeIngress {
+			for _, ing := range ingresses.List(ctx, CtxClusterNN.MustValue(ctx)) {
+				if err := deleteIngress(ctx, types.NamespacedName{Namespace: ing.Namespace, Name: ing.Name}); err != nil {
+					QueueOps.RequeueAPIErr(ctx, err)
+					return
+				}
+			}
+			handler.Handlers(next).MustOne().Handle(ctx)
+			return
+		}
+
+		component.NewEnsureComponentByHash(
+			component.NewHashableComponent(ingresses, hash.NewObjectHash(), "authzed.com/controller-component-hash"),
+			CtxClusterNN,
+			QueueOps,
+			func(ctx context.Context, apply *applynetworkingv1.IngressApplyConfiguration) (*networkingv1.Ingress, error) {
+				logr.FromContextOrDiscard(ctx).V(4).Info("applying ingress", "namespace", *apply.Namespace, "name", *apply.Name)
+				return c.kclient.NetworkingV1().Ingresses(*apply.Namespace).Apply(ctx, apply, metadata.ApplyForceOwned)
+			},
+			deleteIngress,
+			func(ctx context.Context) *applynetworkingv1.IngressApplyConfiguration {
+				return CtxConfig.MustValue(ctx).Ingress()
+			}).Handle(ctx)
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return
+		}
+		handler.Handlers(next).MustOne().Handle(ctx)
+	}, "ensureIngress")
+}
+
+func (c *Controller) ingresses(ctx context.Context) *component.Component[*networkingv1.Ingress] {
+	return component.NewIndexedComponent(
+		typed.MustIndexerForKey[*networkingv1.Ingress](
+			c.Registry,
+			typed.NewRegistryKey(
+				DependentFactoryKey(CtxCacheNamespace.Value(ctx)),
+				networkingv1.SchemeGroupVersion.WithResource("ingresses"),
+			)),
+		metadata.OwningClusterIndex,
+		func(ctx context.Context) labels.Selector {
+			return metadata.SelectorForComponent(CtxClusterNN.MustValue(ctx).Name, metadata.ComponentIngressLabel)
+		})
+}
+
+func (c *Controller) ensureHorizontalPodAutoscaler(next ...handler.Handler) handler.Handler {
+	return handler.NewHandlerFromFunc(func(ctx context.Context) {
+		hpas := component.NewIndexedComponent(
+			typed.MustIndexerForKey[*autoscalingv2.HorizontalPodAutoscaler](
+				c.Registry,
+				typed.NewRegistryKey(
+					DependentFactoryKey(CtxCacheNamespace.Value(ctx)),
+					autoscalingv2.SchemeGroupVersion.WithResource("horizontalpodautoscalers"),
+				)),
+			metadata.OwningClusterIndex,
+			func(ctx context.Context) labels.Selector {
+				return metadata.SelectorForComponent(CtxClusterNN.MustValue(ctx).Name, metadata.ComponentHPALabel)
+			})
+		deleteHPA := func(ctx context.Context, nn types.NamespacedName) error {
+			logr.FromContextOrDiscard(ctx).V(4).Info("deleting horizontalpodautoscaler", "namespace", nn.Namespace, "name", nn.Name)
+			return c.kclient.AutoscalingV2().HorizontalPodAutoscalers(nn.Namespace).Delete(ctx, nn.Name, metav1.DeleteOptions{})
+		}
+
+		// remove any autoscaler left over from before autoscaling was disabled
+		if CtxConfig.MustValue(ctx).Autoscaling == nil {
+			for _, hpa := range hpas.List(ctx, CtxClusterNN.MustValue(ctx)) {
+				if err := deleteHPA(ctx, types.NamespacedName{Namespace: hpa.Namespace, Name: hpa.Name}); err != nil {
+					QueueOps.RequeueAPIErr(ctx, err)
+					return
+				}
+			}
+			handler.Handlers(next).MustOne().Handle(ctx)
+			return
+		}
+
+		component.NewEnsureComponentByHash(
+			component.NewHashableComponent(hpas, hash.NewObjectHash(), "authzed.com/controller-component-hash"),
+			CtxClusterNN,
+			QueueOps,
+			func(ctx context.Context, apply *applyautoscalingv2.HorizontalPodAutoscalerApplyConfiguration) (*autoscalingv2.HorizontalPodAutoscaler, error) {
+				logr.FromContextOrDiscard(ctx).V(4).Info("applying horizontalpodautoscaler", "namespace", *apply.Namespace, "name", *apply.Name)
+				return c.kclient.AutoscalingV2().HorizontalPodAutoscalers(*apply.Namespace).Apply(ctx, apply, metadata.ApplyForceOwned)
+			},
+			deleteHPA,
+			func(ctx context.Context) *applyautoscalingv2.HorizontalPodAutoscalerApplyConfiguration {
+				return CtxConfig.MustValue(ctx).HorizontalPodAutoscaler()
+			}).Handle(ctx)
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return
+		}
+		handler.Handlers(next).MustOne().Handle(ctx)
+	}, "ensureHorizontalPodAutoscaler")
+}
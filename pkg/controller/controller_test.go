@@ -2,6 +2,9 @@ package controller
 
 import (
 	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
@@ -10,9 +13,11 @@ import (
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/dynamic/fake"
 	kfake "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/kubernetes/scheme"
+	k8stesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 
@@ -204,3 +209,94 @@ func TestControllerNamespacing(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateAggregateConditions(t *testing.T) {
+	now := metav1.Now()
+	current := &v1alpha1.SpiceDBCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test", Generation: 2},
+		Status:     v1alpha1.ClusterStatus{AvailableReplicas: 2},
+	}
+	current.SetAggregateConditions()
+
+	rolloutError := current.DeepCopy()
+	rolloutError.SetStatusCondition(v1alpha1.NewRolloutErrorCondition(v1alpha1.ConditionReasonPodError, "pod error"))
+
+	tests := []struct {
+		name     string
+		cluster  *v1alpha1.SpiceDBCluster
+		written  *v1alpha1.SpiceDBCluster
+		deleting bool
+
+		expectPatch bool
+		expectReady metav1.ConditionStatus
+	}{
+		{
+			name:    "doesn't patch current aggregates",
+			cluster: current,
+			written: current,
+		},
+		{
+			name:        "updates the aggregates of a status written without them",
+			cluster:     current,
+			written:     rolloutError,
+			expectPatch: true,
+			expectReady: metav1.ConditionFalse,
+		},
+		{
+			name:     "skips deleted clusters",
+			cluster:  current,
+			written:  rolloutError,
+			deleting: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := tt.cluster.DeepCopy()
+			if tt.deleting {
+				cluster.DeletionTimestamp = &now
+			}
+			dclient := fake.NewSimpleDynamicClient(scheme.Scheme)
+			var patched *v1alpha1.SpiceDBCluster
+			dclient.PrependReactor("patch", "spicedbclusters", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				patched = &v1alpha1.SpiceDBCluster{}
+				require.NoError(t, json.Unmarshal(action.(k8stesting.PatchAction).GetPatch(), patched))
+				return true, nil, nil
+			})
+			c := &Controller{client: dclient}
+
+			ctx := CtxWrittenCluster.WithBox(context.Background())
+			ctx = CtxWrittenCluster.WithValue(ctx, tt.written.DeepCopy())
+			require.NoError(t, c.updateAggregateConditions(ctx, cluster))
+
+			require.Equal(t, tt.expectPatch, patched != nil)
+			if patched == nil {
+				return
+			}
+			require.Equal(t, int64(2), patched.Status.ObservedGeneration)
+			ready := patched.FindStatusCondition(v1alpha1.ConditionTypeReady)
+			require.NotNil(t, ready)
+			require.Equal(t, tt.expectReady, ready.Status)
+			require.Equal(t, int64(2), ready.ObservedGeneration)
+
+			// the box holds the status that was written
+			require.True(t, CtxWrittenCluster.MustValue(ctx).IsStatusConditionFalse(v1alpha1.ConditionTypeReady))
+		})
+	}
+}
+
+func TestLoadConfigKeepsPreviousConfigWithInvalidWaves(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(contents string) {
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+	}
+
+	c := &Controller{}
+	write("imageName: image\nfleetRollout:\n  waves:\n  - name: dev\n")
+	c.loadConfig(path)
+	require.Equal(t, "image", c.config.ImageName)
+
+	write("imageName: other\nfleetRollout:\n  waves:\n  - name: dev\n  - name: dev\n")
+	require.NotPanics(t, func() { c.loadConfig(path) })
+	require.Equal(t, "image", c.config.ImageName)
+	require.Len(t, c.config.FleetRollout.Waves, 1)
+}
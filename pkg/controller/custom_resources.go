@@ -0,0 +1,215 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/discovery"
+
+	"github.com/authzed/controller-idioms/component"
+	"github.com/authzed/controller-idioms/handler"
+	"github.com/authzed/controller-idioms/hash"
+	"github.com/authzed/controller-idioms/typed"
+
+	"github.com/authzed/spicedb-operator/pkg/config"
+	"github.com/authzed/spicedb-operator/pkg/metadata"
+)
+
+const EventCustomResourceMissing = "CustomResourceMissing"
+
+// customResourceDiscoveryInterval is how often the operator checks whether
+// the CRDs for optional resources have been installed.
+const customResourceDiscoveryInterval = 5 * time.Minute
+
+// optionalResources are the third-party resources the operator manages when
+// their CRDs are installed.
+var optionalResources = []schema.GroupVersionResource{
+	config.ServiceMonitorResource,
+	config.PodMonitorResource,
+	config.PrometheusRuleResource,
+	config.GRPCRouteResource,
+	config.HTTPRouteResource,
+	config.CertificateResource,
+}
+
+// discoverCustomResources returns the optional third-party resources (i.e.
+// prometheus operator or gateway api types) that are served by the cluster.
+func discoverCustomResources(ctx context.Context, client discovery.DiscoveryInterface, gvrs ...schema.GroupVersionResource) map[schema.GroupVersionResource]struct{} {
+	available := make(map[schema.GroupVersionResource]struct{})
+	served := make(map[schema.GroupVersion][]metav1.APIResource)
+	for _, gvr := range gvrs {
+		gv := gvr.GroupVersion()
+		if _, ok := served[gv]; !ok {
+			resources, err := client.ServerResourcesForGroupVersion(gv.String())
+			if err != nil {
+				logr.FromContextOrDiscard(ctx).V(3).Info("optional resources not found", "groupVersion", gv.String(), "error", err)
+				served[gv] = nil
+				continue
+			}
+			served[gv] = resources.APIResources
+		}
+		for _, r := range served[gv] {
+			if r.Name == gvr.Resource {
+				available[gvr] = struct{}{}
+			}
+		}
+	}
+	return available
+}
+
+// customResourceServed returns true if the cluster serves an optional
+// resource and the operator is watching it.
+func (c *Controller) customResourceServed(gvr schema.GroupVersionResource) bool {
+	c.customResourcesLock.RLock()
+	defer c.customResourcesLock.RUnlock()
+	_, ok := c.customResources[gvr]
+	return ok
+}
+
+// rediscoverCustomResources starts watching optional resources whose CRDs
+// have been installed since the last check, and requeues every cluster so
+// that any that asked for them are reconciled. Resources are never unwatched;
+// CRDs that are removed need a restart.
+func (c *Controller) rediscoverCustomResources(ctx context.Context) {
+	var added []schema.GroupVersionResource
+	for gvr := range discoverCustomResources(ctx, c.kclient.Discovery(), optionalResources...) {
+		if !c.customResourceServed(gvr) {
+			added = append(added, gvr)
+		}
+	}
+	if len(added) == 0 {
+		return
+	}
+	logr.FromContextOrDiscard(ctx).V(3).Info("discovered optional resources", "resources", added)
+
+	for _, factory := range c.externalInformerFactories {
+		for _, gvr := range added {
+			if err := c.addExternalInformer(factory, gvr); err != nil {
+				utilruntime.HandleError(err)
+				return
+			}
+		}
+		factory.Start(ctx.Done())
+		factory.WaitForCacheSync(ctx.Done())
+	}
+
+	func() {
+		c.customResourcesLock.Lock()
+		defer c.customResourcesLock.Unlock()
+		for _, gvr := range added {
+			c.customResources[gvr] = struct{}{}
+		}
+	}()
+	c.requeueAllClusters()
+}
+
+// warnCustomResourceMissing emits an event the first time a cluster asks for
+// a component whose resource isn't served, and forgets the warning once the
+// component is no longer asked for or the resource is served.
+func (c *Controller) warnCustomResourceMissing(ctx context.Context, gvr schema.GroupVersionResource, componentLabel string, missing bool) {
+	key := CtxClusterNN.MustValue(ctx).String() + "/" + componentLabel
+	if !missing {
+		c.customResourcesMissing.Delete(key)
+		return
+	}
+	if _, warned := c.customResourcesMissing.LoadOrStore(key, struct{}{}); warned {
+		return
+	}
+	c.Recorder.Eventf(CtxCluster.MustValue(ctx), corev1.EventTypeWarning, EventCustomResourceMissing,
+		"%s were requested but are not served by the cluster; they will be created once the CRDs for %s are installed", gvr.Resource, gvr.GroupVersion())
+}
+
+// ensureCustomResource returns a handler builder that manages one kind of
+// optional third-party resource. There are no typed clients for these, so
+// they are listed as metadata and applied with the dynamic client.
+func (c *Controller) ensureCustomResource(
+	gvr schema.GroupVersionResource,
+	componentLabel string,
+	enabled func(cfg *config.Config) bool,
+	newObj func(cfg *config.Config) *config.CustomResourceApplyConfiguration,
+) func(next ...handler.Handler) handler.Handler {
+	return func(next ...handler.Handler) handler.Handler {
+		return handler.NewHandlerFromFunc(func(ctx context.Context) {
+			cfg := CtxConfig.MustValue(ctx)
+			served := c.customResourceServed(gvr)
+			c.warnCustomResourceMissing(ctx, gvr, componentLabel, !served && enabled(cfg))
+			if !served {
+				handler.Handlers(next).MustOne().Handle(ctx)
+				return
+			}
+
+			objs := component.NewIndexedComponent(
+				typed.MustIndexerForKey[*metav1.PartialObjectMetadata](
+					c.Registry,
+					typed.NewRegistryKey(DependentFactoryKey(CtxCacheNamespace.Value(ctx)), gvr)),
+				metadata.OwningClusterIndex,
+				func(ctx context.Context) labels.Selector {
+					return metadata.SelectorForComponent(CtxClusterNN.MustValue(ctx).Name, componentLabel)
+				})
+			deleteObj := func(ctx context.Context, nn types.NamespacedName) error {
+				logr.FromContextOrDiscard(ctx).V(4).Info("deleting "+gvr.Resource, "namespace", nn.Namespace, "name", nn.Name)
+				return c.client.Resource(gvr).Namespace(nn.Namespace).Delete(ctx, nn.Name, metav1.DeleteOptions{})
+			}
+
+			// remove any objects left over from before they were disabled
+			if !enabled(cfg) {
+				for _, o := range objs.List(ctx, CtxClusterNN.MustValue(ctx)) {
+					if err := deleteObj(ctx, types.NamespacedName{Namespace: o.Namespace, Name: o.Name}); err != nil {
+						QueueOps.RequeueAPIErr(ctx, err)
+						return
+					}
+				}
+				handler.Handlers(next).MustOne().Handle(ctx)
+				return
+			}
+
+			component.NewEnsureComponentByHash(
+				component.NewHashableComponent(objs, hash.NewObjectHash(), "authzed.com/controller-component-hash"),
+				CtxClusterNN,
+				QueueOps,
+				func(ctx context.Context, apply *config.CustomResourceApplyConfiguration) (*metav1.PartialObjectMetadata, error) {
+					logr.FromContextOrDiscard(ctx).V(4).Info("applying "+gvr.Resource, "namespace", *apply.Namespace, "name", *apply.Name)
+					return c.applyCustomResource(ctx, gvr, apply)
+				},
+				deleteObj,
+				func(ctx context.Context) *config.CustomResourceApplyConfiguration {
+					return newObj(CtxConfig.MustValue(ctx))
+				}).Handle(ctx)
+			if errors.Is(ctx.Err(), context.Canceled) {
+				return
+			}
+			handler.Handlers(next).MustOne().Handle(ctx)
+		}, handler.Key("ensure"+gvr.Resource))
+	}
+}
+
+func (c *Controller) applyCustomResource(ctx context.Context, gvr schema.GroupVersionResource, apply *config.CustomResourceApplyConfiguration) (*metav1.PartialObjectMetadata, error) {
+	encoded, err := json.Marshal(apply)
+	if err != nil {
+		return nil, err
+	}
+	var u unstructured.Unstructured
+	if err := u.UnmarshalJSON(encoded); err != nil {
+		return nil, err
+	}
+	applied, err := c.client.Resource(gvr).Namespace(*apply.Namespace).Apply(ctx, *apply.Name, &u, metadata.ApplyForceOwned)
+	if err != nil {
+		return nil, err
+	}
+	var out metav1.PartialObjectMetadata
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(applied.Object, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	kfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/authzed/controller-idioms/manager"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+	"github.com/authzed/spicedb-operator/pkg/config"
+	"github.com/authzed/spicedb-operator/pkg/metadata"
+)
+
+func TestDiscoverCustomResources(t *testing.T) {
+	tests := []struct {
+		name      string
+		resources []*metav1.APIResourceList
+		want      map[schema.GroupVersionResource]struct{}
+	}{
+		{
+			name: "prometheus operator not installed",
+			resources: []*metav1.APIResourceList{{
+				GroupVersion: "v1",
+				APIResources: []metav1.APIResource{{Name: "services"}},
+			}},
+			want: map[schema.GroupVersionResource]struct{}{},
+		},
+		{
+			name: "only requested resources are watched",
+			resources: []*metav1.APIResourceList{{
+				GroupVersion: "monitoring.coreos.com/v1",
+				APIResources: []metav1.APIResource{
+					{Name: "servicemonitors"},
+					{Name: "prometheusrules"},
+					{Name: "alertmanagers"},
+				},
+			}},
+			want: map[schema.GroupVersionResource]struct{}{
+				config.ServiceMonitorResource: {},
+				config.PrometheusRuleResource: {},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kclient := kfake.NewSimpleClientset()
+			kclient.Resources = tt.resources
+			require.Equal(t, tt.want, discoverCustomResources(context.Background(), kclient.Discovery(),
+				config.ServiceMonitorResource, config.PodMonitorResource, config.PrometheusRuleResource))
+		})
+	}
+}
+
+func TestWarnCustomResourceMissing(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	c := &Controller{OwnedResourceController: &manager.OwnedResourceController{Recorder: recorder}}
+	ctx := CtxClusterNN.WithValue(context.Background(), types.NamespacedName{Namespace: "test", Name: "test"})
+	ctx = CtxCluster.WithValue(ctx, &v1alpha1.SpiceDBCluster{})
+
+	// repeated reconciles only warn once
+	c.warnCustomResourceMissing(ctx, config.ServiceMonitorResource, metadata.ComponentServiceMonitorLabel, true)
+	c.warnCustomResourceMissing(ctx, config.ServiceMonitorResource, metadata.ComponentServiceMonitorLabel, true)
+
+	// warns again once the resource went away and was asked for again
+	c.warnCustomResourceMissing(ctx, config.ServiceMonitorResource, metadata.ComponentServiceMonitorLabel, false)
+	c.warnCustomResourceMissing(ctx, config.ServiceMonitorResource, metadata.ComponentServiceMonitorLabel, true)
+
+	warning := "Warning CustomResourceMissing servicemonitors were requested but are not served by the cluster; they will be created once the CRDs for monitoring.coreos.com/v1 are installed"
+	ExpectEvents(t, recorder, []string{warning, warning})
+}
@@ -0,0 +1,141 @@
+package controller
+
+import (
+	"context"
+	"net"
+	"slices"
+	"strconv"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/authzed/controller-idioms/component"
+	"github.com/authzed/controller-idioms/handler"
+	"github.com/authzed/controller-idioms/typed"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+	"github.com/authzed/spicedb-operator/pkg/config"
+	"github.com/authzed/spicedb-operator/pkg/metadata"
+)
+
+// updateEndpoints publishes the external addresses of the cluster once the
+// generated Ingress or routes have been picked up by a controller. Changes to
+// their status requeue the cluster, so there's no need to poll.
+func (c *Controller) updateEndpoints(next ...handler.Handler) handler.Handler {
+	return handler.NewHandlerFromFunc(func(ctx context.Context) {
+		cluster := CtxCluster.MustValue(ctx)
+		endpoints := c.resolveEndpoints(ctx)
+		if !slices.Equal(cluster.Status.Endpoints, endpoints) {
+			cluster.Status.Endpoints = endpoints
+			if err := c.PatchStatus(ctx, cluster); err != nil {
+				QueueOps.RequeueAPIErr(ctx, err)
+				return
+			}
+			ctx = CtxCluster.WithValue(ctx, cluster)
+		}
+		handler.Handlers(next).MustOne().Handle(ctx)
+	}, "updateEndpoints")
+}
+
+func (c *Controller) resolveEndpoints(ctx context.Context) []v1alpha1.ClusterEndpoint {
+	cfg := CtxConfig.MustValue(ctx)
+	nn := CtxClusterNN.MustValue(ctx)
+
+	switch cfg.IngressType {
+	case config.IngressTypeIngress:
+		ingresses := c.ingresses(ctx).List(ctx, nn)
+		if len(ingresses) == 0 {
+			return nil
+		}
+		return ingressEndpoints(cfg, ingresses[0])
+	case config.IngressTypeGatewayAPI:
+		var endpoints []v1alpha1.ClusterEndpoint
+		routes := []struct {
+			gvr       schema.GroupVersionResource
+			component string
+			name      string
+			host      string
+		}{
+			{config.GRPCRouteResource, metadata.ComponentGRPCRouteLabel, "grpc", cfg.IngressHost},
+			{config.HTTPRouteResource, metadata.ComponentHTTPRouteLabel, "gateway", cfg.IngressHTTPHost},
+		}
+		for _, r := range routes {
+			if !c.customResourceServed(r.gvr) || len(r.host) == 0 {
+				continue
+			}
+			objs := component.NewIndexedComponent(
+				typed.MustIndexerForKey[*unstructured.Unstructured](
+					c.Registry,
+					typed.NewRegistryKey(DependentFactoryKey(CtxCacheNamespace.Value(ctx)), r.gvr)),
+				metadata.OwningClusterIndex,
+				func(ctx context.Context) labels.Selector {
+					return metadata.SelectorForComponent(nn.Name, r.component)
+				}).List(ctx, nn)
+			if len(objs) > 0 && routeAccepted(objs[0]) {
+				endpoints = append(endpoints, newEndpoint(r.name, r.host, gatewayPort(cfg)))
+			}
+		}
+		return endpoints
+	}
+	return nil
+}
+
+// ingressEndpoints returns the endpoints for an ingress once an ingress
+// controller has assigned it an address.
+func ingressEndpoints(cfg *config.Config, ing *networkingv1.Ingress) []v1alpha1.ClusterEndpoint {
+	if len(ing.Status.LoadBalancer.Ingress) == 0 {
+		return nil
+	}
+	endpoints := []v1alpha1.ClusterEndpoint{newEndpoint("grpc", cfg.IngressHost, ingressPort(ing, cfg.IngressHost))}
+	if len(cfg.IngressHTTPHost) > 0 {
+		endpoints = append(endpoints, newEndpoint("gateway", cfg.IngressHTTPHost, ingressPort(ing, cfg.IngressHTTPHost)))
+	}
+	return endpoints
+}
+
+// ingressPort is the port an ingress controller serves a host on: 443 if the
+// ingress terminates TLS for it (including TLS added by a patch), otherwise 80.
+func ingressPort(ing *networkingv1.Ingress, host string) int32 {
+	for _, tls := range ing.Spec.TLS {
+		if slices.Contains(tls.Hosts, host) {
+			return 443
+		}
+	}
+	return 80
+}
+
+// gatewayPort is the listener port that routes attach to. Without one, the
+// routes may attach to any listener, and the gateway is assumed to terminate
+// TLS on 443.
+func gatewayPort(cfg *config.Config) int32 {
+	if cfg.GatewayPort > 0 {
+		return cfg.GatewayPort
+	}
+	return 443
+}
+
+// routeAccepted reports whether any gateway has accepted the route.
+func routeAccepted(route *unstructured.Unstructured) bool {
+	parents, _, _ := unstructured.NestedSlice(route.Object, "status", "parents")
+	for _, p := range parents {
+		parent, ok := p.(map[string]any)
+		if !ok {
+			continue
+		}
+		conditions, _, _ := unstructured.NestedSlice(parent, "conditions")
+		for _, cond := range conditions {
+			cond, ok := cond.(map[string]any)
+			if ok && cond["type"] == "Accepted" && cond["status"] == string(metav1.ConditionTrue) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func newEndpoint(name, host string, port int32) v1alpha1.ClusterEndpoint {
+	return v1alpha1.ClusterEndpoint{Name: name, Address: net.JoinHostPort(host, strconv.Itoa(int(port)))}
+}
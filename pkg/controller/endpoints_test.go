@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+	"github.com/authzed/spicedb-operator/pkg/config"
+)
+
+func TestIngressEndpoints(t *testing.T) {
+	admitted := networkingv1.IngressStatus{LoadBalancer: networkingv1.IngressLoadBalancerStatus{
+		Ingress: []networkingv1.IngressLoadBalancerIngress{{IP: "10.0.0.1"}},
+	}}
+	tests := []struct {
+		name   string
+		cfg    config.SpiceConfig
+		tls    []networkingv1.IngressTLS
+		status networkingv1.IngressStatus
+		want   []v1alpha1.ClusterEndpoint
+	}{
+		{
+			name: "not admitted",
+			cfg:  config.SpiceConfig{IngressHost: "grpc.example.com"},
+			want: nil,
+		},
+		{
+			name:   "plaintext grpc",
+			cfg:    config.SpiceConfig{IngressHost: "grpc.example.com"},
+			status: admitted,
+			want:   []v1alpha1.ClusterEndpoint{{Name: "grpc", Address: "grpc.example.com:80"}},
+		},
+		{
+			name:   "tls grpc and http",
+			cfg:    config.SpiceConfig{IngressHost: "grpc.example.com", IngressHTTPHost: "http.example.com", TLSSecretName: "tls"},
+			tls:    []networkingv1.IngressTLS{{Hosts: []string{"grpc.example.com", "http.example.com"}, SecretName: "tls"}},
+			status: admitted,
+			want: []v1alpha1.ClusterEndpoint{
+				{Name: "grpc", Address: "grpc.example.com:443"},
+				{Name: "gateway", Address: "http.example.com:443"},
+			},
+		},
+		{
+			name:   "tls patched in for one host",
+			cfg:    config.SpiceConfig{IngressHost: "grpc.example.com", IngressHTTPHost: "http.example.com"},
+			tls:    []networkingv1.IngressTLS{{Hosts: []string{"grpc.example.com"}, SecretName: "other"}},
+			status: admitted,
+			want: []v1alpha1.ClusterEndpoint{
+				{Name: "grpc", Address: "grpc.example.com:443"},
+				{Name: "gateway", Address: "http.example.com:80"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{SpiceConfig: tt.cfg}
+			ing := &networkingv1.Ingress{Spec: networkingv1.IngressSpec{TLS: tt.tls}, Status: tt.status}
+			require.Equal(t, tt.want, ingressEndpoints(cfg, ing))
+		})
+	}
+}
+
+func TestGatewayPort(t *testing.T) {
+	require.Equal(t, int32(443), gatewayPort(&config.Config{}))
+	require.Equal(t, int32(8443), gatewayPort(&config.Config{SpiceConfig: config.SpiceConfig{GatewayPort: 8443}}))
+}
+
+func TestRouteAccepted(t *testing.T) {
+	parent := func(status string) map[string]any {
+		return map[string]any{
+			"parentRef": map[string]any{"name": "public"},
+			"conditions": []any{
+				map[string]any{"type": "ResolvedRefs", "status": "True"},
+				map[string]any{"type": "Accepted", "status": status},
+			},
+		}
+	}
+	tests := []struct {
+		name    string
+		parents []any
+		want    bool
+	}{
+		{name: "no status", want: false},
+		{name: "rejected", parents: []any{parent("False")}, want: false},
+		{name: "accepted by one gateway", parents: []any{parent("False"), parent("True")}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route := &unstructured.Unstructured{Object: map[string]any{}}
+			if tt.parents != nil {
+				require.NoError(t, unstructured.SetNestedSlice(route.Object, tt.parents, "status", "parents"))
+			}
+			require.Equal(t, tt.want, routeAccepted(route))
+		})
+	}
+}
@@ -3,26 +3,51 @@ package controller
 import (
 	"context"
 	"fmt"
+	"slices"
 	"sort"
+	"strings"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	applyappsv1 "k8s.io/client-go/applyconfigurations/apps/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
 
 	"github.com/authzed/controller-idioms/handler"
 	"github.com/authzed/controller-idioms/hash"
 
 	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+	"github.com/authzed/spicedb-operator/pkg/config"
 	"github.com/authzed/spicedb-operator/pkg/metadata"
 )
 
+const (
+	EventRolledBack             = "RolledBack"
+	EventSwitchedDeploymentSlot = "SwitchedDeploymentSlot"
+)
+
+const (
+	// rolloutPollInterval is how often a rollout is checked on while
+	// waiting for the deployment to become available.
+	rolloutPollInterval = 2 * time.Second
+
+	// stuckRolloutPollInterval is how often a rollout is checked on once it
+	// has passed its progress deadline.
+	stuckRolloutPollInterval = time.Minute
+)
+
 type DeploymentHandler struct {
 	applyDeployment   func(ctx context.Context, dep *applyappsv1.DeploymentApplyConfiguration) (*appsv1.Deployment, error)
 	deleteDeployment  func(ctx context.Context, nn types.NamespacedName) error
 	getDeploymentPods func(ctx context.Context) []*corev1.Pod
+	getEndpointSlices func(ctx context.Context) []*discoveryv1.EndpointSlice
 	patchStatus       func(ctx context.Context, patch *v1alpha1.SpiceDBCluster) error
+	recorder          record.EventRecorder
+	now               func() time.Time
 	next              handler.ContextHandler
 }
 
@@ -70,6 +95,9 @@ func (m *DeploymentHandler) Handle(ctx context.Context) {
 
 		// delete extra objects
 		for _, o := range extraObjs {
+			if servingDuringBlueGreen(config, o) {
+				continue
+			}
 			if err := m.deleteDeployment(ctx, types.NamespacedName{Namespace: currentStatus.Namespace, Name: o.GetName()}); err != nil {
 				QueueOps.RequeueAPIErr(ctx, err)
 				return
@@ -79,6 +107,16 @@ func (m *DeploymentHandler) Handle(ctx context.Context) {
 
 	// apply if no matching object in controller
 	if len(matchingObjs) == 0 {
+		// when autoscaling, keep the replica count chosen by the autoscaler
+		// instead of resetting it with the new deployment
+		if config.Autoscaling != nil {
+			for _, o := range extraObjs {
+				if o.Spec.Replicas != nil {
+					newDeployment.Spec.WithReplicas(*o.Spec.Replicas)
+					break
+				}
+			}
+		}
 		deployment, err := m.applyDeployment(ctx,
 			newDeployment.WithAnnotations(
 				map[string]string{metadata.SpiceDBConfigKey: deploymentHash},
@@ -89,6 +127,30 @@ func (m *DeploymentHandler) Handle(ctx context.Context) {
 			return
 		}
 		ctx = CtxCurrentSpiceDeployment.WithValue(ctx, deployment)
+
+		// a new deployment starts a rollout, unless one is already running
+		rollout := currentStatus.Status.Rollout
+		if rollout == nil || rollout.CompletionTime != nil {
+			started := &v1alpha1.RolloutStatus{Image: config.TargetSpiceDBImage, StartTime: ptr.To(metav1.Now())}
+			if rollout != nil {
+				started.PreviousImage = rollout.Image
+			}
+			currentStatus.Status.Rollout = started
+		} else {
+			currentStatus.Status.Rollout = rollout.DeepCopy()
+			currentStatus.Status.Rollout.Image = config.TargetSpiceDBImage
+		}
+		// an update that can't share the dispatch ring moves to the other
+		// slot, even if it replaces a rollout that hasn't finished
+		if config.DeploymentSlot != config.ServingSlot {
+			currentStatus.Status.Rollout.Slot = config.DeploymentSlot
+		}
+		if !currentStatus.Status.Rollout.Equals(rollout) {
+			if err := m.patchStatus(ctx, currentStatus); err != nil {
+				QueueOps.RequeueAPIErr(ctx, err)
+				return
+			}
+		}
 	}
 
 	// if the deployment isn't in the cache yet, wait until another event
@@ -98,58 +160,260 @@ func (m *DeploymentHandler) Handle(ctx context.Context) {
 		return
 	}
 
-	// check if any pods have errors
-	if cachedDeployment.Status.UnavailableReplicas > 0 {
-		// sort pods by newest first
+	// when autoscaling, the autoscaler owns the desired replica count
+	replicas := config.Replicas
+	if config.Autoscaling != nil && cachedDeployment.Spec.Replicas != nil {
+		replicas = *cachedDeployment.Spec.Replicas
+	}
+
+	available := cachedDeployment.Status.AvailableReplicas == replicas &&
+		cachedDeployment.Status.ReadyReplicas == replicas &&
+		cachedDeployment.Status.UpdatedReplicas == replicas &&
+		cachedDeployment.Status.ObservedGeneration == cachedDeployment.Generation
+
+	// a stuck rollout is still watched, but polled less often
+	deadline := progressDeadline(config)
+	stuck := !available && rolloutStuck(currentStatus.Status.Rollout, deadline, m.now())
+	pollInterval := rolloutPollInterval
+	if stuck {
+		pollInterval = stuckRolloutPollInterval
+	}
+
+	if !available {
 		pods := m.getDeploymentPods(ctx)
+
+		// go back to the previous version if the rollout of a new one has
+		// failed
+		if cause := m.rollbackCause(currentStatus, pods); len(cause) > 0 {
+			m.rollback(ctx, currentStatus, cause)
+			return
+		}
+
+		// report why pods aren't ready, starting with the oldest pods
 		sort.Slice(pods, func(i, j int) bool {
 			return pods[i].CreationTimestamp.Before(&pods[j].CreationTimestamp)
 		})
-		for _, p := range m.getDeploymentPods(ctx) {
-			for _, s := range p.Status.ContainerStatuses {
-				if s.LastTerminationState.Terminated != nil {
-					currentStatus.SetStatusCondition(v1alpha1.NewPodErrorCondition(s.LastTerminationState.Terminated.Message))
-					if err := m.patchStatus(ctx, currentStatus); err != nil {
-						QueueOps.RequeueAPIErr(ctx, err)
-						return
-					}
-					QueueOps.RequeueAfter(ctx, 2*time.Second)
-					return
-				}
+		if failure := classifyPodFailures(pods, m.now()); failure != nil {
+			// the pod failure is the more useful reason, but a stuck rollout
+			// still says so
+			condition := failure.condition()
+			if stuck {
+				condition.Message += fmt.Sprintf("; the rollout hasn't finished within the progress deadline of %s", deadline)
+			}
+			m.setReplicaCounts(ctx, currentStatus, cachedDeployment, replicas)
+			currentStatus.SetStatusCondition(condition)
+			if err := m.patchStatus(ctx, currentStatus); err != nil {
+				QueueOps.RequeueAPIErr(ctx, err)
+				return
 			}
+			QueueOps.RequeueAfter(ctx, pollInterval)
+			return
+		}
+	}
+
+	if m.setReplicaCounts(ctx, currentStatus, cachedDeployment, replicas) {
+		if err := m.patchStatus(ctx, currentStatus); err != nil {
+			QueueOps.RequeueAPIErr(ctx, err)
+			return
 		}
 	}
 
-	// wait for deployment to be available
-	if cachedDeployment.Status.AvailableReplicas != config.Replicas ||
-		cachedDeployment.Status.ReadyReplicas != config.Replicas ||
-		cachedDeployment.Status.UpdatedReplicas != config.Replicas ||
-		cachedDeployment.Status.ObservedGeneration != cachedDeployment.Generation {
+	if !available {
+		// wait for deployment to be available
 		currentStatus.SetStatusCondition(v1alpha1.NewRollingCondition(
 			fmt.Sprintf("Waiting for deployment to be available: %d/%d available, %d/%d ready, %d/%d updated, %d/%d generation.",
-				cachedDeployment.Status.AvailableReplicas, config.Replicas,
-				cachedDeployment.Status.ReadyReplicas, config.Replicas,
-				cachedDeployment.Status.UpdatedReplicas, config.Replicas,
+				cachedDeployment.Status.AvailableReplicas, replicas,
+				cachedDeployment.Status.ReadyReplicas, replicas,
+				cachedDeployment.Status.UpdatedReplicas, replicas,
 				cachedDeployment.Status.ObservedGeneration, cachedDeployment.Generation,
 			)))
+		// no pod has failed, so an error from an earlier pass is stale
+		if stuck {
+			currentStatus.SetStatusCondition(v1alpha1.NewRolloutErrorCondition(v1alpha1.ConditionReasonProgressDeadline,
+				fmt.Sprintf("Rollout of %s hasn't finished within the progress deadline of %s", currentStatus.Status.Rollout.Image, deadline)))
+		} else {
+			currentStatus.RemoveStatusCondition(v1alpha1.ConditionTypeRolloutError)
+		}
 		if err := m.patchStatus(ctx, currentStatus); err != nil {
 			QueueOps.RequeueAPIErr(ctx, err)
 			return
 		}
-		QueueOps.RequeueAfter(ctx, 2*time.Second)
+		QueueOps.RequeueAfter(ctx, pollInterval)
 		return
 	}
 
 	// deployment is finished rolling out, remove condition
+	rollout := currentStatus.Status.Rollout
+	switchSlot := rollout != nil && len(rollout.Slot) > 0 && rollout.Slot != currentStatus.Status.DeploymentSlot
 	if currentStatus.IsStatusConditionTrue(v1alpha1.ConditionTypeRolling) ||
-		currentStatus.IsStatusConditionTrue(v1alpha1.ConditionTypeRolloutError) {
+		currentStatus.IsStatusConditionTrue(v1alpha1.ConditionTypeRolloutError) ||
+		(rollout != nil && rollout.CompletionTime == nil) || switchSlot {
 		currentStatus.RemoveStatusCondition(v1alpha1.ConditionTypeRolling)
 		currentStatus.RemoveStatusCondition(v1alpha1.ConditionTypeRolloutError)
+		if rollout != nil && rollout.CompletionTime == nil {
+			rollout.CompletionTime = ptr.To(metav1.Now())
+
+			// rollouts that only change the config aren't update steps
+			entry := newHistoryEntry(currentStatus, CtxCurrentMigrationJob.Value(ctx), v1alpha1.HistoryOutcomeSucceeded, *rollout.CompletionTime)
+			if last := currentStatus.Status.LastSucceededHistory(); last == nil || !sameStep(last, &entry) {
+				currentStatus.Status.AppendHistory(entry)
+			}
+		}
+
+		// the new slot is fully ready, so the Service is switched over to it.
+		// The next pass removes the deployment in the old slot.
+		if switchSlot {
+			currentStatus.Status.DeploymentSlot = rollout.Slot
+		}
 		if err := m.patchStatus(ctx, currentStatus); err != nil {
 			QueueOps.RequeueAPIErr(ctx, err)
 			return
 		}
+		if switchSlot {
+			m.recorder.Eventf(currentStatus, corev1.EventTypeNormal, EventSwitchedDeploymentSlot, "Switched requests over to the %s deployment running %s", rollout.Slot, rollout.Image)
+		}
 	}
 
 	m.next.Handle(ctx)
 }
+
+// setReplicaCounts reports the replica counts (`replicas` is used by the
+// scale subresource, `availableReplicas` by the Available condition) and
+// ready endpoints, and returns true if they changed.
+func (m *DeploymentHandler) setReplicaCounts(ctx context.Context, cluster *v1alpha1.SpiceDBCluster, deployment *appsv1.Deployment, replicas int32) bool {
+	counts := v1alpha1.ClusterStatus{
+		Replicas:          deployment.Status.Replicas,
+		DesiredReplicas:   replicas,
+		UpdatedReplicas:   deployment.Status.UpdatedReplicas,
+		ReadyReplicas:     deployment.Status.ReadyReplicas,
+		AvailableReplicas: deployment.Status.AvailableReplicas,
+		ReadyEndpoints:    readyEndpoints(m.getEndpointSlices(ctx)),
+	}
+	if cluster.Status.Replicas == counts.Replicas &&
+		cluster.Status.DesiredReplicas == counts.DesiredReplicas &&
+		cluster.Status.UpdatedReplicas == counts.UpdatedReplicas &&
+		cluster.Status.ReadyReplicas == counts.ReadyReplicas &&
+		cluster.Status.AvailableReplicas == counts.AvailableReplicas &&
+		cluster.Status.ReadyEndpoints == counts.ReadyEndpoints {
+		return false
+	}
+	cluster.Status.Replicas = counts.Replicas
+	cluster.Status.DesiredReplicas = counts.DesiredReplicas
+	cluster.Status.UpdatedReplicas = counts.UpdatedReplicas
+	cluster.Status.ReadyReplicas = counts.ReadyReplicas
+	cluster.Status.AvailableReplicas = counts.AvailableReplicas
+	cluster.Status.ReadyEndpoints = counts.ReadyEndpoints
+	return true
+}
+
+// rollbackCause returns why the rollout of the cluster's current version has
+// failed, if the cluster's update policy rolls it back. It returns "" if the
+// rollout hasn't failed, or can't be rolled back: config changes and steps
+// that ran a migration stay on the version they're rolling out.
+func (m *DeploymentHandler) rollbackCause(cluster *v1alpha1.SpiceDBCluster, pods []*corev1.Pod) string {
+	policy, rollout, version := cluster.Spec.UpdatePolicy, cluster.Status.Rollout, cluster.Status.CurrentVersion
+	if policy == nil || policy.Rollback == nil || rollout == nil || rollout.StartTime == nil || rollout.CompletionTime != nil ||
+		version == nil || slices.Contains(version.Attributes, v1alpha1.SpiceDBVersionAttributesMigration) {
+		return ""
+	}
+	if previous := cluster.Status.LastSucceededHistory(); previous == nil || len(previous.ToVersion) == 0 || previous.ToVersion == version.Name {
+		return ""
+	}
+
+	maxAttempts := policy.Rollback.MaxFailedAttempts
+	if maxAttempts == 0 && policy.Rollback.Timeout == nil {
+		maxAttempts = v1alpha1.DefaultRollbackFailedAttempts
+	}
+	if attempts := failedAttempts(pods, rollout.Image); maxAttempts > 0 && attempts >= maxAttempts {
+		return fmt.Sprintf("%s failed to start %d times", rollout.Image, attempts)
+	}
+	if timeout := policy.Rollback.Timeout; timeout != nil && m.now().Sub(rollout.StartTime.Time) >= timeout.Duration {
+		return fmt.Sprintf("%s didn't become available within %s", rollout.Image, timeout.Duration)
+	}
+	return ""
+}
+
+// rollback records the failed step and moves the cluster back to the version
+// of the last step that succeeded. The next reconciliation pins the cluster to
+// that version and applies its deployment again.
+func (m *DeploymentHandler) rollback(ctx context.Context, cluster *v1alpha1.SpiceDBCluster, cause string) {
+	previous := *cluster.Status.LastSucceededHistory()
+	failed := cluster.Status.CurrentVersion.Name
+	now := metav1.NewTime(m.now())
+
+	cluster.Status.AppendHistory(newHistoryEntry(cluster, nil, v1alpha1.HistoryOutcomeFailed, now))
+	cluster.Status.CurrentVersion = &v1alpha1.SpiceDBVersion{Name: previous.ToVersion, Channel: previous.Channel}
+	cluster.Status.Image = previous.Image
+	cluster.Status.Rollout = &v1alpha1.RolloutStatus{Image: previous.Image, PreviousImage: cluster.Status.Rollout.Image, StartTime: &now}
+	cluster.RemoveStatusCondition(v1alpha1.ConditionTypeRolling)
+	cluster.RemoveStatusCondition(v1alpha1.ConditionTypeRolloutError)
+	cluster.SetStatusCondition(v1alpha1.NewRolledBackCondition(failed, previous.ToVersion, cause))
+	if err := m.patchStatus(ctx, cluster); err != nil {
+		QueueOps.RequeueAPIErr(ctx, err)
+		return
+	}
+	m.recorder.Eventf(cluster, corev1.EventTypeWarning, EventRolledBack, "Rolled back from %s to %s because %s", failed, previous.ToVersion, cause)
+	QueueOps.Requeue(ctx)
+}
+
+// servingDuringBlueGreen returns true if a deployment keeps serving requests
+// while a blue/green rollout brings up the deployment in the other slot.
+func servingDuringBlueGreen(cfg *config.Config, deployment *appsv1.Deployment) bool {
+	return cfg.DeploymentSlot != cfg.ServingSlot && deployment.GetName() == config.DeploymentName(cfg.Name, cfg.ServingSlot)
+}
+
+// progressDeadline returns how long a rollout can take before it's reported
+// as stuck.
+func progressDeadline(cfg *config.Config) time.Duration {
+	if cfg.ProgressDeadline == 0 {
+		return config.DefaultProgressDeadline
+	}
+	return cfg.ProgressDeadline
+}
+
+// rolloutStuck returns true if a rollout has been running for longer than the
+// progress deadline.
+func rolloutStuck(rollout *v1alpha1.RolloutStatus, deadline time.Duration, now time.Time) bool {
+	return rollout != nil && rollout.StartTime != nil && rollout.CompletionTime == nil &&
+		now.Sub(rollout.StartTime.Time) >= deadline
+}
+
+// failedAttempts returns the most times that any SpiceDB container running
+// image has restarted.
+func failedAttempts(pods []*corev1.Pod, image string) int32 {
+	var attempts int32
+	for _, p := range pods {
+		if !slices.ContainsFunc(p.Spec.Containers, func(c corev1.Container) bool {
+			return c.Name == config.ContainerNameSpiceDB && c.Image == image
+		}) {
+			continue
+		}
+		for _, s := range p.Status.ContainerStatuses {
+			if s.Name == config.ContainerNameSpiceDB && s.RestartCount > attempts {
+				attempts = s.RestartCount
+			}
+		}
+	}
+	return attempts
+}
+
+// readyEndpoints counts the ready pods behind the Service. A pod can appear
+// in more than one slice (one per address type), so they're counted by name.
+func readyEndpoints(endpointSlices []*discoveryv1.EndpointSlice) int32 {
+	ready := make(map[string]struct{})
+	for _, slice := range endpointSlices {
+		for _, e := range slice.Endpoints {
+			// a nil ready condition means the state is unknown, which
+			// consumers are expected to treat as ready
+			if e.Conditions.Ready != nil && !*e.Conditions.Ready {
+				continue
+			}
+			key := strings.Join(e.Addresses, ",")
+			if e.TargetRef != nil {
+				key = e.TargetRef.Name
+			}
+			ready[key] = struct{}{}
+		}
+	}
+	return int32(len(ready))
+}
@@ -9,9 +9,12 @@ import (
 	"github.com/stretchr/testify/require"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	applyappsv1 "k8s.io/client-go/applyconfigurations/apps/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
 
 	"github.com/authzed/controller-idioms/handler"
 	"github.com/authzed/controller-idioms/queue/fake"
@@ -23,7 +26,62 @@ import (
 
 func TestEnsureDeploymentHandler(t *testing.T) {
 	now := metav1.Now()
+	startedRollout := &v1alpha1.RolloutStatus{Image: "test", StartTime: &now}
 	var nextKey handler.Key = "next"
+
+	// a rollout from v1 to v2 that hasn't become available
+	failingRollout := func(policy v1alpha1.RollbackPolicy, attributes ...v1alpha1.SpiceDBVersionAttributes) *v1alpha1.SpiceDBCluster {
+		return &v1alpha1.SpiceDBCluster{
+			Spec: v1alpha1.ClusterSpec{UpdatePolicy: &v1alpha1.UpdatePolicy{Rollback: &policy}},
+			Status: v1alpha1.ClusterStatus{
+				Image:          "test",
+				CurrentVersion: &v1alpha1.SpiceDBVersion{Name: "v2", Channel: "stable", Attributes: attributes},
+				History:        []v1alpha1.HistoryEntry{{ToVersion: "v1", Channel: "stable", Image: "old", Outcome: v1alpha1.HistoryOutcomeSucceeded}},
+				Rollout:        &v1alpha1.RolloutStatus{Image: "test", PreviousImage: "old", StartTime: &now},
+			},
+		}
+	}
+	rolledBack := func(policy v1alpha1.RollbackPolicy, cause string) *v1alpha1.SpiceDBCluster {
+		c := &v1alpha1.SpiceDBCluster{
+			Spec: v1alpha1.ClusterSpec{UpdatePolicy: &v1alpha1.UpdatePolicy{Rollback: &policy}},
+			Status: v1alpha1.ClusterStatus{
+				Image:          "old",
+				CurrentVersion: &v1alpha1.SpiceDBVersion{Name: "v1", Channel: "stable"},
+				History: []v1alpha1.HistoryEntry{
+					{ToVersion: "v1", Channel: "stable", Image: "old", Outcome: v1alpha1.HistoryOutcomeSucceeded},
+					{FromVersion: "v1", ToVersion: "v2", Channel: "stable", Image: "test", StartTime: &now, EndTime: &now, Outcome: v1alpha1.HistoryOutcomeFailed},
+				},
+				Rollout:    &v1alpha1.RolloutStatus{Image: "old", PreviousImage: "test", StartTime: &now},
+				Conditions: []metav1.Condition{v1alpha1.NewRolledBackCondition("v2", "v1", cause)},
+			},
+		}
+		c.Status.Conditions[0].LastTransitionTime = now
+		return c
+	}
+	unavailableDeployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+			metadata.SpiceDBConfigKey: "n5fdh657h99h67dh57dh87h64dh5f8q",
+		}},
+		Status: appsv1.DeploymentStatus{
+			Replicas:            2,
+			UpdatedReplicas:     1,
+			AvailableReplicas:   1,
+			ReadyReplicas:       1,
+			UnavailableReplicas: 1,
+		},
+	}
+	crashingPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "spicedb-a"},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: config.ContainerNameSpiceDB, Image: "test"}}},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+			Name:         config.ContainerNameSpiceDB,
+			RestartCount: 3,
+			LastTerminationState: corev1.ContainerState{
+				Terminated: &corev1.ContainerStateTerminated{Message: "pod error"},
+			},
+		}}},
+	}
+
 	tests := []struct {
 		name string
 
@@ -31,22 +89,31 @@ func TestEnsureDeploymentHandler(t *testing.T) {
 		secretHash          string
 		existingDeployments []*appsv1.Deployment
 		pods                []*corev1.Pod
+		endpointSlices      []*discoveryv1.EndpointSlice
 		currentStatus       *v1alpha1.SpiceDBCluster
 		replicas            int32
+		autoscaling         bool
+		servingSlot         string
+		deploymentSlot      string
 
-		expectNext         handler.Key
-		expectStatus       *v1alpha1.SpiceDBCluster
-		expectRequeueErr   error
-		expectRequeueAfter bool
-		expectApply        bool
-		expectDelete       bool
-		expectPatchStatus  bool
+		expectNext          handler.Key
+		expectStatus        *v1alpha1.SpiceDBCluster
+		expectRequeueErr    error
+		expectRequeueAfter  bool
+		expectApply         bool
+		expectApplyReplicas *int32
+		expectDelete        bool
+		expectPatchStatus   bool
+		expectRequeue       bool
+		expectEvents        []string
 	}{
 		{
 			name:               "creates if no deployments",
 			migrationHash:      "testtesttesttest",
 			secretHash:         "secret",
 			expectApply:        true,
+			expectPatchStatus:  true,
+			expectStatus:       &v1alpha1.SpiceDBCluster{Status: v1alpha1.ClusterStatus{Rollout: startedRollout}},
 			expectRequeueAfter: true,
 		},
 		{
@@ -55,6 +122,8 @@ func TestEnsureDeploymentHandler(t *testing.T) {
 			secretHash:          "secret",
 			existingDeployments: []*appsv1.Deployment{{}},
 			expectApply:         true,
+			expectPatchStatus:   true,
+			expectStatus:        &v1alpha1.SpiceDBCluster{Status: v1alpha1.ClusterStatus{Rollout: startedRollout}},
 			expectRequeueAfter:  true,
 		},
 		{
@@ -84,6 +153,8 @@ func TestEnsureDeploymentHandler(t *testing.T) {
 				metadata.SpiceDBConfigKey: "n5ffh5b4h544h57bh649hbbh5fchb4q",
 			}}}},
 			expectApply:        true,
+			expectPatchStatus:  true,
+			expectStatus:       &v1alpha1.SpiceDBCluster{Status: v1alpha1.ClusterStatus{Rollout: startedRollout}},
 			expectRequeueAfter: true,
 		},
 		{
@@ -102,7 +173,7 @@ func TestEnsureDeploymentHandler(t *testing.T) {
 				LastTransitionTime: now,
 				Reason:             "WaitingForDeploymentAvailability",
 				Message:            "Rolling deployment to latest version",
-			}}}},
+			}}, Rollout: startedRollout}},
 			expectRequeueAfter: true,
 		},
 		{
@@ -129,7 +200,7 @@ func TestEnsureDeploymentHandler(t *testing.T) {
 			migrationHash:     "testtesttesttest",
 			secretHash:        "secret",
 			expectPatchStatus: true,
-			expectStatus: &v1alpha1.SpiceDBCluster{Status: v1alpha1.ClusterStatus{Conditions: []metav1.Condition{{
+			expectStatus: &v1alpha1.SpiceDBCluster{Status: v1alpha1.ClusterStatus{Replicas: 2, DesiredReplicas: 2, UpdatedReplicas: 1, ReadyReplicas: 1, AvailableReplicas: 1, Conditions: []metav1.Condition{{
 				Type:               v1alpha1.ConditionTypeRolling,
 				Status:             metav1.ConditionTrue,
 				LastTransitionTime: now,
@@ -163,7 +234,262 @@ func TestEnsureDeploymentHandler(t *testing.T) {
 			secretHash:        "secret",
 			expectPatchStatus: true,
 			expectNext:        nextKey,
-			expectStatus:      &v1alpha1.SpiceDBCluster{Status: v1alpha1.ClusterStatus{Conditions: []metav1.Condition{}}},
+			expectStatus:      &v1alpha1.SpiceDBCluster{Status: v1alpha1.ClusterStatus{Replicas: 2, DesiredReplicas: 2, UpdatedReplicas: 2, ReadyReplicas: 2, AvailableReplicas: 2, Conditions: []metav1.Condition{}}},
+		},
+		{
+			name: "follows the live replica count when autoscaling",
+			currentStatus: &v1alpha1.SpiceDBCluster{Status: v1alpha1.ClusterStatus{Replicas: 3, Conditions: []metav1.Condition{{
+				Type:               v1alpha1.ConditionTypeRolling,
+				Status:             metav1.ConditionTrue,
+				LastTransitionTime: now,
+				Reason:             "WaitingForDeploymentAvailability",
+				Message:            "Rolling deployment to latest version",
+			}}}},
+			existingDeployments: []*appsv1.Deployment{{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+					metadata.SpiceDBConfigKey: "n5dfhffh5d5h67ch56fh57dh649h57q",
+				}},
+				Spec: appsv1.DeploymentSpec{Replicas: ptr.To[int32](3)},
+				Status: appsv1.DeploymentStatus{
+					Replicas:          3,
+					UpdatedReplicas:   3,
+					AvailableReplicas: 3,
+					ReadyReplicas:     3,
+				},
+			}},
+			replicas:          2,
+			autoscaling:       true,
+			migrationHash:     "testtesttesttest",
+			secretHash:        "secret",
+			expectPatchStatus: true,
+			expectNext:        nextKey,
+			expectStatus:      &v1alpha1.SpiceDBCluster{Status: v1alpha1.ClusterStatus{Replicas: 3, DesiredReplicas: 3, UpdatedReplicas: 3, ReadyReplicas: 3, AvailableReplicas: 3, Conditions: []metav1.Condition{}}},
+		},
+		{
+			name: "keeps the live replica count when applying an autoscaled deployment",
+			existingDeployments: []*appsv1.Deployment{{
+				Spec: appsv1.DeploymentSpec{Replicas: ptr.To[int32](4)},
+			}},
+			replicas:            2,
+			autoscaling:         true,
+			migrationHash:       "testtesttesttest",
+			secretHash:          "secret",
+			expectApply:         true,
+			expectApplyReplicas: ptr.To[int32](4),
+			expectPatchStatus:   true,
+			expectStatus:        &v1alpha1.SpiceDBCluster{Status: v1alpha1.ClusterStatus{Rollout: startedRollout}},
+			expectRequeueAfter:  true,
+		},
+		{
+			name: "starts a new rollout once the last one completed",
+			currentStatus: &v1alpha1.SpiceDBCluster{Status: v1alpha1.ClusterStatus{Rollout: &v1alpha1.RolloutStatus{
+				Image:          "old",
+				StartTime:      &now,
+				CompletionTime: &now,
+			}}},
+			migrationHash:     "testtesttesttest",
+			secretHash:        "secret",
+			expectApply:       true,
+			expectPatchStatus: true,
+			expectStatus: &v1alpha1.SpiceDBCluster{Status: v1alpha1.ClusterStatus{Rollout: &v1alpha1.RolloutStatus{
+				Image:         "test",
+				PreviousImage: "old",
+				StartTime:     &now,
+			}}},
+			expectRequeueAfter: true,
+		},
+		{
+			name: "keeps the start of a rollout in progress",
+			currentStatus: &v1alpha1.SpiceDBCluster{Status: v1alpha1.ClusterStatus{Rollout: &v1alpha1.RolloutStatus{
+				Image:         "old",
+				PreviousImage: "older",
+				StartTime:     &now,
+			}}},
+			migrationHash:     "testtesttesttest",
+			secretHash:        "secret",
+			expectApply:       true,
+			expectPatchStatus: true,
+			expectStatus: &v1alpha1.SpiceDBCluster{Status: v1alpha1.ClusterStatus{Rollout: &v1alpha1.RolloutStatus{
+				Image:         "test",
+				PreviousImage: "older",
+				StartTime:     &now,
+			}}},
+			expectRequeueAfter: true,
+		},
+		{
+			name: "moves a rollout in progress to the new slot",
+			currentStatus: &v1alpha1.SpiceDBCluster{Status: v1alpha1.ClusterStatus{Rollout: &v1alpha1.RolloutStatus{
+				Image:         "old",
+				PreviousImage: "older",
+				StartTime:     &now,
+			}}},
+			deploymentSlot:    "blue",
+			migrationHash:     "testtesttesttest",
+			secretHash:        "secret",
+			expectApply:       true,
+			expectPatchStatus: true,
+			expectStatus: &v1alpha1.SpiceDBCluster{Status: v1alpha1.ClusterStatus{Rollout: &v1alpha1.RolloutStatus{
+				Image:         "test",
+				PreviousImage: "older",
+				Slot:          "blue",
+				StartTime:     &now,
+			}}},
+			expectRequeueAfter: true,
+		},
+		{
+			name: "completes the rollout and reports ready endpoints",
+			currentStatus: &v1alpha1.SpiceDBCluster{Status: v1alpha1.ClusterStatus{
+				Image:          "test",
+				CurrentVersion: &v1alpha1.SpiceDBVersion{Name: "v2", Channel: "stable"},
+				History:        []v1alpha1.HistoryEntry{{ToVersion: "v1", Outcome: v1alpha1.HistoryOutcomeSucceeded}},
+				Rollout: &v1alpha1.RolloutStatus{
+					Image:         "test",
+					PreviousImage: "old",
+					StartTime:     &now,
+				},
+			}},
+			existingDeployments: []*appsv1.Deployment{{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+					metadata.SpiceDBConfigKey: "n5fdh657h99h67dh57dh87h64dh5f8q",
+				}},
+				Status: appsv1.DeploymentStatus{
+					Replicas:          2,
+					UpdatedReplicas:   2,
+					AvailableReplicas: 2,
+					ReadyReplicas:     2,
+				},
+			}},
+			endpointSlices: []*discoveryv1.EndpointSlice{{Endpoints: []discoveryv1.Endpoint{
+				{Addresses: []string{"10.0.0.1"}},
+				{Addresses: []string{"10.0.0.2"}},
+			}}},
+			replicas:          2,
+			migrationHash:     "testtesttesttest",
+			secretHash:        "secret",
+			expectPatchStatus: true,
+			expectNext:        nextKey,
+			expectStatus: &v1alpha1.SpiceDBCluster{Status: v1alpha1.ClusterStatus{
+				Image:             "test",
+				CurrentVersion:    &v1alpha1.SpiceDBVersion{Name: "v2", Channel: "stable"},
+				Replicas:          2,
+				DesiredReplicas:   2,
+				UpdatedReplicas:   2,
+				ReadyReplicas:     2,
+				AvailableReplicas: 2,
+				ReadyEndpoints:    2,
+				Rollout: &v1alpha1.RolloutStatus{
+					Image:          "test",
+					PreviousImage:  "old",
+					StartTime:      &now,
+					CompletionTime: &now,
+				},
+				History: []v1alpha1.HistoryEntry{
+					{ToVersion: "v1", Outcome: v1alpha1.HistoryOutcomeSucceeded},
+					{
+						FromVersion: "v1",
+						ToVersion:   "v2",
+						Channel:     "stable",
+						Image:       "test",
+						StartTime:   &now,
+						EndTime:     &now,
+						Outcome:     v1alpha1.HistoryOutcomeSucceeded,
+					},
+				},
+			}},
+		},
+		{
+			name:           "keeps the serving deployment during a blue/green rollout",
+			migrationHash:  "testtesttesttest",
+			secretHash:     "secret",
+			deploymentSlot: "blue",
+			existingDeployments: []*appsv1.Deployment{
+				{ObjectMeta: metav1.ObjectMeta{Name: config.DeploymentName("", "")}},
+				{ObjectMeta: metav1.ObjectMeta{Name: config.DeploymentName("", "blue"), Annotations: map[string]string{
+					metadata.SpiceDBConfigKey: "n549hb6h5b9h596h5b7hbbh564h557q",
+				}}},
+			},
+			expectNext: nextKey,
+		},
+		{
+			name: "switches requests to the new slot once its deployment is available",
+			currentStatus: &v1alpha1.SpiceDBCluster{Status: v1alpha1.ClusterStatus{
+				Image: "test",
+				Rollout: &v1alpha1.RolloutStatus{
+					Image:         "test",
+					PreviousImage: "old",
+					Slot:          "blue",
+					StartTime:     &now,
+				},
+			}},
+			existingDeployments: []*appsv1.Deployment{{
+				ObjectMeta: metav1.ObjectMeta{Name: config.DeploymentName("", "blue"), Annotations: map[string]string{
+					metadata.SpiceDBConfigKey: "n554h5f6h57fhcdh549h696h5cbh99q",
+				}},
+				Status: appsv1.DeploymentStatus{
+					Replicas:          2,
+					UpdatedReplicas:   2,
+					AvailableReplicas: 2,
+					ReadyReplicas:     2,
+				},
+			}},
+			replicas:          2,
+			deploymentSlot:    "blue",
+			migrationHash:     "testtesttesttest",
+			secretHash:        "secret",
+			expectPatchStatus: true,
+			expectNext:        nextKey,
+			expectStatus: &v1alpha1.SpiceDBCluster{Status: v1alpha1.ClusterStatus{
+				Image:             "test",
+				DeploymentSlot:    "blue",
+				Replicas:          2,
+				DesiredReplicas:   2,
+				UpdatedReplicas:   2,
+				ReadyReplicas:     2,
+				AvailableReplicas: 2,
+				Rollout: &v1alpha1.RolloutStatus{
+					Image:          "test",
+					PreviousImage:  "old",
+					Slot:           "blue",
+					StartTime:      &now,
+					CompletionTime: &now,
+				},
+				History: []v1alpha1.HistoryEntry{{Image: "test", StartTime: &now, EndTime: &now, Outcome: v1alpha1.HistoryOutcomeSucceeded}},
+			}},
+			expectEvents: []string{"Normal SwitchedDeploymentSlot Switched requests over to the blue deployment running test"},
+		},
+		{
+			name: "doesn't record a rollout that only changed the config",
+			currentStatus: &v1alpha1.SpiceDBCluster{Status: v1alpha1.ClusterStatus{
+				Image:   "test",
+				History: []v1alpha1.HistoryEntry{{Image: "test", Outcome: v1alpha1.HistoryOutcomeSucceeded}},
+				Rollout: &v1alpha1.RolloutStatus{Image: "test", StartTime: &now},
+			}},
+			existingDeployments: []*appsv1.Deployment{{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+					metadata.SpiceDBConfigKey: "n5fdh657h99h67dh57dh87h64dh5f8q",
+				}},
+				Status: appsv1.DeploymentStatus{
+					Replicas:          2,
+					UpdatedReplicas:   2,
+					AvailableReplicas: 2,
+					ReadyReplicas:     2,
+				},
+			}},
+			replicas:          2,
+			migrationHash:     "testtesttesttest",
+			secretHash:        "secret",
+			expectPatchStatus: true,
+			expectNext:        nextKey,
+			expectStatus: &v1alpha1.SpiceDBCluster{Status: v1alpha1.ClusterStatus{
+				Image:             "test",
+				Replicas:          2,
+				DesiredReplicas:   2,
+				UpdatedReplicas:   2,
+				ReadyReplicas:     2,
+				AvailableReplicas: 2,
+				History:           []v1alpha1.HistoryEntry{{Image: "test", Outcome: v1alpha1.HistoryOutcomeSucceeded}},
+				Rollout:           &v1alpha1.RolloutStatus{Image: "test", StartTime: &now, CompletionTime: &now},
+			}},
 		},
 		{
 			name: "reports error on status if pod has an error",
@@ -186,7 +512,7 @@ func TestEnsureDeploymentHandler(t *testing.T) {
 					UnavailableReplicas: 1,
 				},
 			}},
-			pods: []*corev1.Pod{{Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+			pods: []*corev1.Pod{{ObjectMeta: metav1.ObjectMeta{Name: "spicedb-a"}, Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
 				LastTerminationState: corev1.ContainerState{
 					Terminated: &corev1.ContainerStateTerminated{
 						Message: "pod error",
@@ -197,7 +523,7 @@ func TestEnsureDeploymentHandler(t *testing.T) {
 			migrationHash:     "testtesttesttest",
 			secretHash:        "secret",
 			expectPatchStatus: true,
-			expectStatus: &v1alpha1.SpiceDBCluster{Status: v1alpha1.ClusterStatus{Conditions: []metav1.Condition{{
+			expectStatus: &v1alpha1.SpiceDBCluster{Status: v1alpha1.ClusterStatus{Replicas: 2, DesiredReplicas: 2, UpdatedReplicas: 1, ReadyReplicas: 1, AvailableReplicas: 1, Conditions: []metav1.Condition{{
 				Type:               v1alpha1.ConditionTypeRolling,
 				Status:             metav1.ConditionTrue,
 				LastTransitionTime: now,
@@ -208,10 +534,130 @@ func TestEnsureDeploymentHandler(t *testing.T) {
 				Status:             metav1.ConditionTrue,
 				LastTransitionTime: now,
 				Reason:             "PodError",
-				Message:            "pod error",
+				Message:            "pod error (pods: spicedb-a)",
 			}}}},
 			expectRequeueAfter: true,
 		},
+		{
+			name:                "rolls back a version that keeps failing to start",
+			currentStatus:       failingRollout(v1alpha1.RollbackPolicy{}),
+			existingDeployments: []*appsv1.Deployment{unavailableDeployment},
+			pods:                []*corev1.Pod{crashingPod},
+			replicas:            2,
+			migrationHash:       "testtesttesttest",
+			secretHash:          "secret",
+			expectPatchStatus:   true,
+			expectStatus:        rolledBack(v1alpha1.RollbackPolicy{}, "test failed to start 3 times"),
+			expectRequeue:       true,
+			expectEvents:        []string{"Warning RolledBack Rolled back from v2 to v1 because test failed to start 3 times"},
+		},
+		{
+			name:                "rolls back a version that doesn't become available in time",
+			currentStatus:       failingRollout(v1alpha1.RollbackPolicy{Timeout: &metav1.Duration{Duration: 30 * time.Second}}),
+			existingDeployments: []*appsv1.Deployment{unavailableDeployment},
+			replicas:            2,
+			migrationHash:       "testtesttesttest",
+			secretHash:          "secret",
+			expectPatchStatus:   true,
+			expectStatus:        rolledBack(v1alpha1.RollbackPolicy{Timeout: &metav1.Duration{Duration: 30 * time.Second}}, "test didn't become available within 30s"),
+			expectRequeue:       true,
+			expectEvents:        []string{"Warning RolledBack Rolled back from v2 to v1 because test didn't become available within 30s"},
+		},
+		{
+			name:                "doesn't roll back a step that ran a migration",
+			currentStatus:       failingRollout(v1alpha1.RollbackPolicy{}, v1alpha1.SpiceDBVersionAttributesMigration),
+			existingDeployments: []*appsv1.Deployment{unavailableDeployment},
+			pods:                []*corev1.Pod{crashingPod},
+			replicas:            2,
+			migrationHash:       "testtesttesttest",
+			secretHash:          "secret",
+			expectPatchStatus:   true,
+			expectStatus: func() *v1alpha1.SpiceDBCluster {
+				c := failingRollout(v1alpha1.RollbackPolicy{}, v1alpha1.SpiceDBVersionAttributesMigration)
+				c.Status.Replicas, c.Status.DesiredReplicas, c.Status.UpdatedReplicas, c.Status.ReadyReplicas, c.Status.AvailableReplicas = 2, 2, 1, 1, 1
+				c.SetStatusCondition(v1alpha1.NewPodErrorCondition("pod error (pods: spicedb-a)"))
+				c.Status.Conditions[0].LastTransitionTime = now
+				return c
+			}(),
+			expectRequeueAfter: true,
+		},
+		{
+			name: "reports a rollout that passed its progress deadline",
+			currentStatus: &v1alpha1.SpiceDBCluster{Status: v1alpha1.ClusterStatus{
+				Rollout: &v1alpha1.RolloutStatus{Image: "test", StartTime: ptr.To(metav1.NewTime(now.Add(-config.DefaultProgressDeadline)))},
+			}},
+			existingDeployments: []*appsv1.Deployment{unavailableDeployment},
+			replicas:            2,
+			migrationHash:       "testtesttesttest",
+			secretHash:          "secret",
+			expectPatchStatus:   true,
+			expectStatus: &v1alpha1.SpiceDBCluster{Status: v1alpha1.ClusterStatus{
+				Replicas: 2, DesiredReplicas: 2, UpdatedReplicas: 1, ReadyReplicas: 1, AvailableReplicas: 1,
+				Rollout: startedRollout,
+				Conditions: []metav1.Condition{{
+					Type:               v1alpha1.ConditionTypeRolling,
+					Status:             metav1.ConditionTrue,
+					LastTransitionTime: now,
+					Reason:             "WaitingForDeploymentAvailability",
+					Message:            "Waiting for deployment to be available: 1/2 available, 1/2 ready, 1/2 updated, 0/0 generation.",
+				}, {
+					Type:               v1alpha1.ConditionTypeRolloutError,
+					Status:             metav1.ConditionTrue,
+					LastTransitionTime: now,
+					Reason:             v1alpha1.ConditionReasonProgressDeadline,
+					Message:            "Rollout of test hasn't finished within the progress deadline of 10m0s",
+				}},
+			}},
+			expectRequeueAfter: true,
+		},
+		{
+			name: "reports the progress deadline along with a pod failure",
+			currentStatus: &v1alpha1.SpiceDBCluster{Status: v1alpha1.ClusterStatus{
+				Rollout: &v1alpha1.RolloutStatus{Image: "test", StartTime: ptr.To(metav1.NewTime(now.Add(-config.DefaultProgressDeadline)))},
+			}},
+			existingDeployments: []*appsv1.Deployment{unavailableDeployment},
+			pods:                []*corev1.Pod{crashingPod},
+			replicas:            2,
+			migrationHash:       "testtesttesttest",
+			secretHash:          "secret",
+			expectPatchStatus:   true,
+			expectStatus: &v1alpha1.SpiceDBCluster{Status: v1alpha1.ClusterStatus{
+				Replicas: 2, DesiredReplicas: 2, UpdatedReplicas: 1, ReadyReplicas: 1, AvailableReplicas: 1,
+				Rollout: startedRollout,
+				Conditions: []metav1.Condition{{
+					Type:               v1alpha1.ConditionTypeRolloutError,
+					Status:             metav1.ConditionTrue,
+					LastTransitionTime: now,
+					Reason:             v1alpha1.ConditionReasonPodError,
+					Message:            "pod error (pods: spicedb-a); the rollout hasn't finished within the progress deadline of 10m0s",
+				}},
+			}},
+			expectRequeueAfter: true,
+		},
+		{
+			name: "clears a pod failure once the pods have recovered",
+			currentStatus: &v1alpha1.SpiceDBCluster{Status: v1alpha1.ClusterStatus{
+				Rollout:    startedRollout,
+				Conditions: []metav1.Condition{v1alpha1.NewPodErrorCondition("pod error (pods: spicedb-a)")},
+			}},
+			existingDeployments: []*appsv1.Deployment{unavailableDeployment},
+			replicas:            2,
+			migrationHash:       "testtesttesttest",
+			secretHash:          "secret",
+			expectPatchStatus:   true,
+			expectStatus: &v1alpha1.SpiceDBCluster{Status: v1alpha1.ClusterStatus{
+				Replicas: 2, DesiredReplicas: 2, UpdatedReplicas: 1, ReadyReplicas: 1, AvailableReplicas: 1,
+				Rollout: startedRollout,
+				Conditions: []metav1.Condition{{
+					Type:               v1alpha1.ConditionTypeRolling,
+					Status:             metav1.ConditionTrue,
+					LastTransitionTime: now,
+					Reason:             "WaitingForDeploymentAvailability",
+					Message:            "Waiting for deployment to be available: 1/2 available, 1/2 ready, 1/2 updated, 0/0 generation.",
+				}},
+			}},
+			expectRequeueAfter: true,
+		},
 		{
 			name: "updates error message if newer pod has a different message",
 			currentStatus: &v1alpha1.SpiceDBCluster{Status: v1alpha1.ClusterStatus{Conditions: []metav1.Condition{{
@@ -235,7 +681,7 @@ func TestEnsureDeploymentHandler(t *testing.T) {
 			}},
 			pods: []*corev1.Pod{
 				{
-					ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(now.Add(1 * time.Hour))},
+					ObjectMeta: metav1.ObjectMeta{Name: "spicedb-b", CreationTimestamp: metav1.NewTime(now.Add(1 * time.Hour))},
 					Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
 						LastTerminationState: corev1.ContainerState{
 							Terminated: &corev1.ContainerStateTerminated{
@@ -245,7 +691,7 @@ func TestEnsureDeploymentHandler(t *testing.T) {
 					}}},
 				},
 				{
-					ObjectMeta: metav1.ObjectMeta{CreationTimestamp: now},
+					ObjectMeta: metav1.ObjectMeta{Name: "spicedb-a", CreationTimestamp: now},
 					Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
 						LastTerminationState: corev1.ContainerState{
 							Terminated: &corev1.ContainerStateTerminated{
@@ -259,7 +705,7 @@ func TestEnsureDeploymentHandler(t *testing.T) {
 			migrationHash:     "testtesttesttest",
 			secretHash:        "secret",
 			expectPatchStatus: true,
-			expectStatus: &v1alpha1.SpiceDBCluster{Status: v1alpha1.ClusterStatus{Conditions: []metav1.Condition{{
+			expectStatus: &v1alpha1.SpiceDBCluster{Status: v1alpha1.ClusterStatus{Replicas: 2, DesiredReplicas: 2, UpdatedReplicas: 1, ReadyReplicas: 1, AvailableReplicas: 1, Conditions: []metav1.Condition{{
 				Type:               v1alpha1.ConditionTypeRolling,
 				Status:             metav1.ConditionTrue,
 				LastTransitionTime: now,
@@ -270,7 +716,7 @@ func TestEnsureDeploymentHandler(t *testing.T) {
 				Status:             metav1.ConditionTrue,
 				LastTransitionTime: now,
 				Reason:             "PodError",
-				Message:            "new pod error",
+				Message:            "new pod error (pods: spicedb-a, spicedb-b)",
 			}}}},
 			expectRequeueAfter: true,
 		},
@@ -314,7 +760,7 @@ func TestEnsureDeploymentHandler(t *testing.T) {
 			secretHash:        "secret",
 			expectPatchStatus: true,
 			expectNext:        nextKey,
-			expectStatus:      &v1alpha1.SpiceDBCluster{Status: v1alpha1.ClusterStatus{Conditions: []metav1.Condition{}}},
+			expectStatus:      &v1alpha1.SpiceDBCluster{Status: v1alpha1.ClusterStatus{Replicas: 2, DesiredReplicas: 2, UpdatedReplicas: 2, ReadyReplicas: 2, AvailableReplicas: 2, Conditions: []metav1.Condition{}}},
 		},
 		{
 			name: "removes migrating failed status",
@@ -337,7 +783,7 @@ func TestEnsureDeploymentHandler(t *testing.T) {
 			secretHash:        "secret",
 			expectPatchStatus: true,
 			expectNext:        nextKey,
-			expectStatus:      &v1alpha1.SpiceDBCluster{Status: v1alpha1.ClusterStatus{Conditions: []metav1.Condition{}}},
+			expectStatus:      &v1alpha1.SpiceDBCluster{Status: v1alpha1.ClusterStatus{Replicas: 2, DesiredReplicas: 2, UpdatedReplicas: 2, ReadyReplicas: 2, AvailableReplicas: 2, Conditions: []metav1.Condition{}}},
 		},
 	}
 	for _, tt := range tests {
@@ -354,9 +800,13 @@ func TestEnsureDeploymentHandler(t *testing.T) {
 				tt.expectStatus = &v1alpha1.SpiceDBCluster{}
 			}
 
+			spiceConfig := config.SpiceConfig{Replicas: tt.replicas, ServingSlot: tt.servingSlot, DeploymentSlot: tt.deploymentSlot}
+			if tt.autoscaling {
+				spiceConfig.Autoscaling = &v1alpha1.ClusterAutoscaling{MaxReplicas: 5}
+			}
 			ctx := CtxConfig.WithValue(context.Background(), &config.Config{
 				MigrationConfig: config.MigrationConfig{TargetSpiceDBImage: "test"},
-				SpiceConfig:     config.SpiceConfig{Replicas: tt.replicas},
+				SpiceConfig:     spiceConfig,
 			})
 			ctx = QueueOps.WithValue(ctx, ctrls)
 			ctx = CtxCluster.WithValue(ctx, tt.currentStatus)
@@ -365,9 +815,13 @@ func TestEnsureDeploymentHandler(t *testing.T) {
 			ctx = CtxDeployments.WithValue(ctx, tt.existingDeployments)
 
 			var called handler.Key
+			recorder := record.NewFakeRecorder(1)
 			h := &DeploymentHandler{
-				applyDeployment: func(_ context.Context, _ *applyappsv1.DeploymentApplyConfiguration) (*appsv1.Deployment, error) {
+				applyDeployment: func(_ context.Context, dep *applyappsv1.DeploymentApplyConfiguration) (*appsv1.Deployment, error) {
 					applyCalled = true
+					if tt.expectApplyReplicas != nil {
+						require.Equal(t, tt.expectApplyReplicas, dep.Spec.Replicas)
+					}
 					return nil, nil
 				},
 				deleteDeployment: func(_ context.Context, _ types.NamespacedName) error {
@@ -377,10 +831,17 @@ func TestEnsureDeploymentHandler(t *testing.T) {
 				getDeploymentPods: func(_ context.Context) []*corev1.Pod {
 					return tt.pods
 				},
+				getEndpointSlices: func(_ context.Context) []*discoveryv1.EndpointSlice {
+					return tt.endpointSlices
+				},
 				patchStatus: func(_ context.Context, _ *v1alpha1.SpiceDBCluster) error {
 					patchCalled = true
 					return nil
 				},
+				recorder: recorder,
+				now: func() time.Time {
+					return now.Add(time.Minute)
+				},
 				next: handler.ContextHandlerFunc(func(_ context.Context) {
 					called = nextKey
 				}),
@@ -391,6 +852,19 @@ func TestEnsureDeploymentHandler(t *testing.T) {
 			for i := range cluster.Status.Conditions {
 				cluster.Status.Conditions[i].LastTransitionTime = now
 			}
+			if rollout := cluster.Status.Rollout; rollout != nil {
+				if rollout.StartTime != nil {
+					rollout.StartTime = &now
+				}
+				if rollout.CompletionTime != nil {
+					rollout.CompletionTime = &now
+				}
+			}
+			for i := range cluster.Status.History {
+				if cluster.Status.History[i].EndTime != nil {
+					cluster.Status.History[i].EndTime = &now
+				}
+			}
 			require.Equal(t, tt.expectStatus, cluster)
 			require.Equal(t, tt.expectApply, applyCalled)
 			require.Equal(t, tt.expectDelete, deleteCalled)
@@ -401,6 +875,33 @@ func TestEnsureDeploymentHandler(t *testing.T) {
 				require.Equal(t, tt.expectRequeueErr, ctrls.RequeueErrArgsForCall(0))
 			}
 			require.Equal(t, tt.expectRequeueAfter, ctrls.RequeueAfterCallCount() == 1)
+			require.Equal(t, tt.expectRequeue, ctrls.RequeueCallCount() == 1)
+			ExpectEvents(t, recorder, tt.expectEvents)
 		})
 	}
 }
+
+func TestReadyEndpoints(t *testing.T) {
+	pod := func(name string) *corev1.ObjectReference {
+		return &corev1.ObjectReference{Kind: "Pod", Name: name}
+	}
+	require.Equal(t, int32(0), readyEndpoints(nil))
+	require.Equal(t, int32(2), readyEndpoints([]*discoveryv1.EndpointSlice{
+		{
+			AddressType: discoveryv1.AddressTypeIPv4,
+			Endpoints: []discoveryv1.Endpoint{
+				{Addresses: []string{"10.0.0.1"}, TargetRef: pod("a"), Conditions: discoveryv1.EndpointConditions{Ready: ptr.To(true)}},
+				{Addresses: []string{"10.0.0.2"}, TargetRef: pod("b")},
+				{Addresses: []string{"10.0.0.3"}, TargetRef: pod("c"), Conditions: discoveryv1.EndpointConditions{Ready: ptr.To(false)}},
+			},
+		},
+		{
+			// the same pods in a dual-stack service
+			AddressType: discoveryv1.AddressTypeIPv6,
+			Endpoints: []discoveryv1.Endpoint{
+				{Addresses: []string{"fd00::1"}, TargetRef: pod("a"), Conditions: discoveryv1.EndpointConditions{Ready: ptr.To(true)}},
+				{Addresses: []string{"fd00::2"}, TargetRef: pod("b")},
+			},
+		},
+	}))
+}
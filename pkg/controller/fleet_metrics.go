@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/component-base/metrics"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+	"github.com/authzed/spicedb-operator/pkg/config"
+)
+
+// FleetRolloutCollector reports the progress of each wave of the fleet
+// rollout policy towards the current update graph.
+type FleetRolloutCollector struct {
+	metrics.BaseStableCollector
+
+	operatorConfig func() *config.OperatorConfig
+	listerBuilders []func() ([]*v1alpha1.SpiceDBCluster, error)
+	now            func() time.Time
+
+	WaveClusters *metrics.Desc
+	WaveUpdated  *metrics.Desc
+	WaveComplete *metrics.Desc
+}
+
+// NewFleetRolloutCollector creates a FleetRolloutCollector for the rollout
+// policy in the operator config.
+func NewFleetRolloutCollector(namespace string, operatorConfig func() *config.OperatorConfig) *FleetRolloutCollector {
+	return &FleetRolloutCollector{
+		operatorConfig: operatorConfig,
+		now:            time.Now,
+		WaveClusters: metrics.NewDesc(
+			prometheus.BuildFQName(namespace, "fleet_rollout", "wave_clusters"),
+			"Gauge showing the number of SpiceDBClusters in each rollout wave",
+			[]string{"wave"}, nil, metrics.ALPHA, "",
+		),
+		WaveUpdated: metrics.NewDesc(
+			prometheus.BuildFQName(namespace, "fleet_rollout", "wave_updated_clusters"),
+			"Gauge showing the number of SpiceDBClusters in each rollout wave that are ready on the current update graph",
+			[]string{"wave"}, nil, metrics.ALPHA, "",
+		),
+		WaveComplete: metrics.NewDesc(
+			prometheus.BuildFQName(namespace, "fleet_rollout", "wave_complete"),
+			"Gauge that is 1 if every SpiceDBCluster in a rollout wave has been healthy on the current update graph for long enough for the next wave to start",
+			[]string{"wave"}, nil, metrics.ALPHA, "",
+		),
+	}
+}
+
+func (c *FleetRolloutCollector) AddListerBuilder(lb func() ([]*v1alpha1.SpiceDBCluster, error)) {
+	c.listerBuilders = append(c.listerBuilders, lb)
+}
+
+func (c *FleetRolloutCollector) DescribeWithStability(ch chan<- *metrics.Desc) {
+	ch <- c.WaveClusters
+	ch <- c.WaveUpdated
+	ch <- c.WaveComplete
+}
+
+func (c *FleetRolloutCollector) CollectWithStability(ch chan<- metrics.Metric) {
+	operatorConfig := c.operatorConfig()
+	if operatorConfig.FleetRollout == nil {
+		return
+	}
+
+	var clusters []*v1alpha1.SpiceDBCluster
+	for _, lb := range c.listerBuilders {
+		objs, err := lb()
+		if err != nil {
+			return
+		}
+		clusters = append(clusters, objs...)
+	}
+
+	progress, err := operatorConfig.FleetRollout.Progress(operatorConfig.UpdateGraph.Hash(), clusters)
+	if err != nil {
+		return
+	}
+	now := c.now()
+	for _, w := range progress {
+		complete := 0.0
+		if w.Complete(now) {
+			complete = 1
+		}
+		ch <- metrics.NewLazyConstMetric(c.WaveClusters, metrics.GaugeValue, float64(w.Clusters), w.Name)
+		ch <- metrics.NewLazyConstMetric(c.WaveUpdated, metrics.GaugeValue, float64(w.Updated), w.Name)
+		ch <- metrics.NewLazyConstMetric(c.WaveComplete, metrics.GaugeValue, complete, w.Name)
+	}
+}
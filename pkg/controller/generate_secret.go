@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/authzed/controller-idioms/handler"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+	"github.com/authzed/spicedb-operator/pkg/config"
+	"github.com/authzed/spicedb-operator/pkg/metadata"
+)
+
+const EventSecretGenerated = "SecretGenerated"
+
+// GenerateSecretHandler creates a config secret for clusters that don't
+// reference one. The secret is only ever created, never updated, so that
+// any edits (i.e. adding a datastore_uri) are kept.
+type GenerateSecretHandler struct {
+	recorder     record.EventRecorder
+	getSecret    func(ctx context.Context, nn types.NamespacedName) (*corev1.Secret, error)
+	createSecret func(ctx context.Context, secret *corev1.Secret) (*corev1.Secret, error)
+	patchStatus  func(ctx context.Context, patch *v1alpha1.SpiceDBCluster) error
+	next         handler.ContextHandler
+}
+
+func (g *GenerateSecretHandler) Handle(ctx context.Context) {
+	cluster := CtxCluster.MustValue(ctx)
+
+	var generatedName string
+	if len(cluster.Spec.SecretRef) == 0 {
+		nn := types.NamespacedName{Namespace: cluster.Namespace, Name: config.GeneratedSecretName(cluster.Name)}
+		_, err := g.getSecret(ctx, nn)
+		if err != nil && !apierrors.IsNotFound(err) {
+			QueueOps.RequeueErr(ctx, err)
+			return
+		}
+		if apierrors.IsNotFound(err) {
+			secret, err := newGeneratedSecret(cluster, nn)
+			if err != nil {
+				QueueOps.RequeueErr(ctx, err)
+				return
+			}
+			// the secret may exist without the operator's labels, in which
+			// case it isn't in the cache but should still be left alone
+			_, err = g.createSecret(ctx, secret)
+			switch {
+			case err == nil:
+				g.recorder.Eventf(cluster, corev1.EventTypeNormal, EventSecretGenerated, "Generated secret %s because spec.secretName is not set.", nn.Name)
+			case !apierrors.IsAlreadyExists(err):
+				QueueOps.RequeueAPIErr(ctx, err)
+				return
+			}
+		}
+		generatedName = nn.Name
+		ctx = CtxSecretNN.WithValue(ctx, nn)
+	}
+
+	if cluster.Status.SecretName != generatedName {
+		status := &v1alpha1.SpiceDBCluster{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       v1alpha1.SpiceDBClusterKind,
+				APIVersion: v1alpha1.SchemeGroupVersion.String(),
+			},
+			ObjectMeta: metav1.ObjectMeta{Namespace: cluster.Namespace, Name: cluster.Name, Generation: cluster.Generation},
+			Status:     *cluster.Status.DeepCopy(),
+		}
+		status.Status.SecretName = generatedName
+		if err := g.patchStatus(ctx, status); err != nil {
+			QueueOps.RequeueAPIErr(ctx, err)
+			return
+		}
+		cluster.Status = status.Status
+		ctx = CtxCluster.WithValue(ctx, cluster)
+	}
+
+	g.next.Handle(ctx)
+}
+
+// newGeneratedSecret returns a secret with a random preshared key. There's no
+// way to generate a datastore_uri, so it has to be added to the secret for any
+// engine other than memory.
+func newGeneratedSecret(cluster *v1alpha1.SpiceDBCluster, nn types.NamespacedName) (*corev1.Secret, error) {
+	key, err := newPresharedKey()
+	if err != nil {
+		return nil, err
+	}
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      nn.Name,
+			Namespace: nn.Namespace,
+			Labels:    metadata.LabelsForComponent(cluster.Name, metadata.ComponentGeneratedSecretLabel),
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: v1alpha1.SchemeGroupVersion.String(),
+				Kind:       v1alpha1.SpiceDBClusterKind,
+				Name:       cluster.Name,
+				UID:        cluster.UID,
+			}},
+		},
+		Data: map[string][]byte{
+			config.PresharedKeySecretKey: key,
+		},
+	}, nil
+}
+
+// newPresharedKey returns a random 256-bit key, encoded so that it can be
+// used in a header.
+func newPresharedKey() ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("unable to generate preshared key: %w", err)
+	}
+	return []byte(base64.RawURLEncoding.EncodeToString(key)), nil
+}
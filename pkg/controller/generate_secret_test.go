@@ -0,0 +1,169 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/authzed/controller-idioms/handler"
+	"github.com/authzed/controller-idioms/queue/fake"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+	"github.com/authzed/spicedb-operator/pkg/metadata"
+)
+
+func TestGenerateSecretHandler(t *testing.T) {
+	var nextKey handler.Key = "next"
+	secretsGR := schema.GroupResource{Resource: "secrets"}
+	generatedNN := types.NamespacedName{Namespace: "test", Name: "test-spicedb-config"}
+	tests := []struct {
+		name string
+
+		cluster     *v1alpha1.SpiceDBCluster
+		existing    *corev1.Secret
+		createError error
+		patchError  error
+
+		expectCreate      bool
+		expectEvents      []string
+		expectPatchStatus bool
+		expectSecretName  string
+		expectSecretNN    types.NamespacedName
+		expectNext        handler.Key
+		expectRequeue     bool
+	}{
+		{
+			name: "uses the referenced secret",
+			cluster: &v1alpha1.SpiceDBCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"},
+				Spec:       v1alpha1.ClusterSpec{SecretRef: "user-secret"},
+			},
+			expectNext: nextKey,
+		},
+		{
+			name: "generates a missing secret",
+			cluster: &v1alpha1.SpiceDBCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test", UID: "1"},
+			},
+			expectCreate:      true,
+			expectEvents:      []string{"Normal SecretGenerated Generated secret test-spicedb-config because spec.secretName is not set."},
+			expectPatchStatus: true,
+			expectSecretName:  generatedNN.Name,
+			expectSecretNN:    generatedNN,
+			expectNext:        nextKey,
+		},
+		{
+			name: "doesn't touch an existing generated secret",
+			cluster: &v1alpha1.SpiceDBCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"},
+				Status:     v1alpha1.ClusterStatus{SecretName: generatedNN.Name},
+			},
+			existing:         &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: generatedNN.Name, Namespace: generatedNN.Namespace}},
+			expectSecretName: generatedNN.Name,
+			expectSecretNN:   generatedNN,
+			expectNext:       nextKey,
+		},
+		{
+			name: "uses an unlabelled secret with the generated name",
+			cluster: &v1alpha1.SpiceDBCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"},
+				Status:     v1alpha1.ClusterStatus{SecretName: generatedNN.Name},
+			},
+			createError:      apierrors.NewAlreadyExists(secretsGR, generatedNN.Name),
+			expectCreate:     true,
+			expectSecretName: generatedNN.Name,
+			expectSecretNN:   generatedNN,
+			expectNext:       nextKey,
+		},
+		{
+			name: "requeues on create error",
+			cluster: &v1alpha1.SpiceDBCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"},
+			},
+			createError:   fmt.Errorf("error creating"),
+			expectCreate:  true,
+			expectRequeue: true,
+		},
+		{
+			name: "clears status when a secret is referenced",
+			cluster: &v1alpha1.SpiceDBCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"},
+				Spec:       v1alpha1.ClusterSpec{SecretRef: "user-secret"},
+				Status:     v1alpha1.ClusterStatus{SecretName: generatedNN.Name},
+			},
+			expectPatchStatus: true,
+			expectNext:        nextKey,
+		},
+		{
+			name: "requeues on status patch error",
+			cluster: &v1alpha1.SpiceDBCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"},
+			},
+			existing:          &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: generatedNN.Name, Namespace: generatedNN.Namespace}},
+			patchError:        fmt.Errorf("error patching"),
+			expectPatchStatus: true,
+			expectSecretName:  generatedNN.Name,
+			expectRequeue:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrls := &fake.FakeInterface{}
+			recorder := record.NewFakeRecorder(1)
+			createCalled := false
+			patchCalled := false
+
+			ctx := context.Background()
+			ctx = QueueOps.WithValue(ctx, ctrls)
+			ctx = CtxClusterNN.WithValue(ctx, tt.cluster.NamespacedName())
+			ctx = CtxCluster.WithValue(ctx, tt.cluster)
+			var called handler.Key
+			var secretNN types.NamespacedName
+			h := &GenerateSecretHandler{
+				recorder: recorder,
+				getSecret: func(_ context.Context, nn types.NamespacedName) (*corev1.Secret, error) {
+					require.Equal(t, generatedNN, nn)
+					if tt.existing == nil {
+						return nil, apierrors.NewNotFound(secretsGR, nn.Name)
+					}
+					return tt.existing, nil
+				},
+				createSecret: func(_ context.Context, secret *corev1.Secret) (*corev1.Secret, error) {
+					createCalled = true
+					require.Equal(t, generatedNN.Name, secret.Name)
+					require.Equal(t, metadata.LabelsForComponent("test", metadata.ComponentGeneratedSecretLabel), secret.Labels)
+					require.Len(t, secret.OwnerReferences, 1)
+					require.NotEmpty(t, secret.Data["preshared_key"])
+					require.NotContains(t, secret.Data, "datastore_uri")
+					return secret, tt.createError
+				},
+				patchStatus: func(_ context.Context, patch *v1alpha1.SpiceDBCluster) error {
+					patchCalled = true
+					require.Equal(t, tt.expectSecretName, patch.Status.SecretName)
+					return tt.patchError
+				},
+				next: handler.ContextHandlerFunc(func(ctx context.Context) {
+					called = nextKey
+					secretNN = CtxSecretNN.Value(ctx)
+					require.Equal(t, tt.expectSecretName, CtxCluster.MustValue(ctx).Status.SecretName)
+				}),
+			}
+			h.Handle(ctx)
+
+			require.Equal(t, tt.expectCreate, createCalled)
+			require.Equal(t, tt.expectPatchStatus, patchCalled)
+			require.Equal(t, tt.expectNext, called)
+			require.Equal(t, tt.expectSecretNN, secretNN)
+			require.Equal(t, tt.expectRequeue, ctrls.RequeueAPIErrCallCount()+ctrls.RequeueErrCallCount() == 1)
+			ExpectEvents(t, recorder, tt.expectEvents)
+		})
+	}
+}
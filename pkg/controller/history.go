@@ -0,0 +1,45 @@
+package controller
+
+import (
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+)
+
+// newHistoryEntry describes the update step the cluster is taking, as given
+// by its status. The step started when the migration job did, or when the
+// rollout did if no migration was needed.
+func newHistoryEntry(cluster *v1alpha1.SpiceDBCluster, job *batchv1.Job, outcome v1alpha1.HistoryOutcome, end metav1.Time) v1alpha1.HistoryEntry {
+	status := cluster.Status
+	entry := v1alpha1.HistoryEntry{
+		Image:     status.Image,
+		Migration: status.Migration,
+		Phase:     status.Phase,
+		EndTime:   &end,
+		Outcome:   outcome,
+	}
+	if version := status.CurrentVersion; version != nil {
+		entry.ToVersion = version.Name
+		entry.Channel = version.Channel
+	}
+	if last := status.LastSucceededHistory(); last != nil {
+		entry.FromVersion = last.ToVersion
+	}
+	switch {
+	case job != nil:
+		entry.MigrationJob = job.Name
+		entry.StartTime = job.Status.StartTime
+	case status.Rollout != nil:
+		entry.StartTime = status.Rollout.StartTime
+	}
+	return entry
+}
+
+// sameStep returns true if both entries updated to the same target.
+func sameStep(a, b *v1alpha1.HistoryEntry) bool {
+	return a.ToVersion == b.ToVersion &&
+		a.Image == b.Image &&
+		a.Migration == b.Migration &&
+		a.Phase == b.Phase
+}
@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jzelinskie/stringz"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+)
+
+// podFailureReasons are the ways a pod can fail to become ready, most severe
+// first: a pod that can't be scheduled never pulls its image, a pod that
+// can't pull its image never starts, and so on.
+var podFailureReasons = []string{
+	v1alpha1.ConditionReasonUnschedulable,
+	v1alpha1.ConditionReasonImagePullFailed,
+	v1alpha1.ConditionReasonInitContainerFailed,
+	v1alpha1.ConditionReasonContainerConfigError,
+	v1alpha1.ConditionReasonPodError,
+	v1alpha1.ConditionReasonReadinessProbeFailed,
+}
+
+var (
+	imagePullWaitingReasons       = []string{"ErrImagePull", "ImagePullBackOff", "InvalidImageName", "ErrImageNeverPull"}
+	containerConfigWaitingReasons = []string{"CreateContainerConfigError", "CreateContainerError"}
+)
+
+// podFailure is a reason that pods of a rollout can't become ready, along with
+// the pods it applies to.
+type podFailure struct {
+	reason string
+	detail string
+	pods   []string
+}
+
+func (f *podFailure) condition() metav1.Condition {
+	return v1alpha1.NewRolloutErrorCondition(f.reason, fmt.Sprintf("%s (pods: %s)", f.detail, strings.Join(f.pods, ", ")))
+}
+
+// classifyPodFailures returns the most severe failure among the pods, with
+// the detail of the first pod that has it, or nil if no pod has failed.
+func classifyPodFailures(pods []*corev1.Pod, now time.Time) *podFailure {
+	failures := make(map[string]*podFailure)
+	for _, p := range pods {
+		reason, detail := classifyPod(p, now)
+		if len(reason) == 0 {
+			continue
+		}
+		f, ok := failures[reason]
+		if !ok {
+			f = &podFailure{reason: reason, detail: detail}
+			failures[reason] = f
+		}
+		f.pods = append(f.pods, p.Name)
+	}
+	for _, reason := range podFailureReasons {
+		if f, ok := failures[reason]; ok {
+			return f
+		}
+	}
+	return nil
+}
+
+// classifyPod returns the most severe reason that a pod isn't ready, and a
+// description of it, or "" if the pod hasn't failed.
+func classifyPod(pod *corev1.Pod, now time.Time) (string, string) {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodScheduled && c.Status == corev1.ConditionFalse && c.Reason == corev1.PodReasonUnschedulable {
+			return v1alpha1.ConditionReasonUnschedulable, c.Message
+		}
+	}
+
+	statuses := slices.Concat(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses)
+	for _, s := range statuses {
+		if w := s.State.Waiting; w != nil && slices.Contains(imagePullWaitingReasons, w.Reason) {
+			return v1alpha1.ConditionReasonImagePullFailed, fmt.Sprintf("%s: %s", w.Reason, w.Message)
+		}
+	}
+
+	for _, s := range pod.Status.InitContainerStatuses {
+		for _, t := range []*corev1.ContainerStateTerminated{s.State.Terminated, s.LastTerminationState.Terminated} {
+			if t != nil && t.ExitCode != 0 {
+				return v1alpha1.ConditionReasonInitContainerFailed, fmt.Sprintf("init container %s exited with code %d: %s", s.Name, t.ExitCode, stringz.DefaultEmpty(t.Message, t.Reason))
+			}
+		}
+	}
+
+	for _, s := range statuses {
+		if w := s.State.Waiting; w != nil && slices.Contains(containerConfigWaitingReasons, w.Reason) {
+			return v1alpha1.ConditionReasonContainerConfigError, fmt.Sprintf("%s: %s", w.Reason, w.Message)
+		}
+	}
+
+	for _, s := range pod.Status.ContainerStatuses {
+		if t := s.LastTerminationState.Terminated; t != nil && !s.Ready {
+			return v1alpha1.ConditionReasonPodError, stringz.DefaultEmpty(t.Message, t.Reason)
+		}
+	}
+
+	for _, s := range pod.Status.ContainerStatuses {
+		if since, failing := readinessProbeFailing(pod, s, now); failing {
+			return v1alpha1.ConditionReasonReadinessProbeFailed, fmt.Sprintf("container %s has been running for %s without passing its readiness probe", s.Name, since.Round(time.Second))
+		}
+	}
+	return "", ""
+}
+
+// readinessProbeFailing returns true if a running container still isn't
+// ready after its readiness probe could have failed `failureThreshold` times,
+// along with how long it has been running.
+func readinessProbeFailing(pod *corev1.Pod, s corev1.ContainerStatus, now time.Time) (time.Duration, bool) {
+	if s.Ready || s.State.Running == nil {
+		return 0, false
+	}
+	i := slices.IndexFunc(pod.Spec.Containers, func(c corev1.Container) bool { return c.Name == s.Name })
+	if i < 0 || pod.Spec.Containers[i].ReadinessProbe == nil {
+		return 0, false
+	}
+	probe := pod.Spec.Containers[i].ReadinessProbe
+
+	// kube defaults for unset probe fields
+	period, threshold := probe.PeriodSeconds, probe.FailureThreshold
+	if period == 0 {
+		period = 10
+	}
+	if threshold == 0 {
+		threshold = 3
+	}
+	grace := time.Duration(probe.InitialDelaySeconds+period*threshold) * time.Second
+	running := now.Sub(s.State.Running.StartedAt.Time)
+	return running, running > grace
+}
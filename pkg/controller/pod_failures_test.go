@@ -0,0 +1,133 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+	"github.com/authzed/spicedb-operator/pkg/config"
+)
+
+func TestClassifyPodFailures(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	pod := func(name string, mutate func(*corev1.Pod)) *corev1.Pod {
+		p := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name:           config.ContainerNameSpiceDB,
+				ReadinessProbe: &corev1.Probe{InitialDelaySeconds: 5, PeriodSeconds: 5},
+			}}},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{Name: config.ContainerNameSpiceDB}}},
+		}
+		mutate(p)
+		return p
+	}
+	unschedulable := func(p *corev1.Pod) {
+		p.Status.Conditions = []corev1.PodCondition{{
+			Type:    corev1.PodScheduled,
+			Status:  corev1.ConditionFalse,
+			Reason:  corev1.PodReasonUnschedulable,
+			Message: "0/3 nodes are available: 3 Insufficient cpu.",
+		}}
+	}
+	imagePull := func(p *corev1.Pod) {
+		p.Status.ContainerStatuses[0].State.Waiting = &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "Back-off pulling image \"spicedb:v9\""}
+	}
+	running := func(since time.Duration) func(*corev1.Pod) {
+		return func(p *corev1.Pod) {
+			p.Status.ContainerStatuses[0].State.Running = &corev1.ContainerStateRunning{StartedAt: metav1.NewTime(now.Add(-since))}
+		}
+	}
+
+	tests := []struct {
+		name         string
+		pods         []*corev1.Pod
+		reason       string
+		detail       string
+		expectedPods []string
+	}{
+		{
+			name: "healthy pods",
+			pods: []*corev1.Pod{pod("a", func(p *corev1.Pod) {
+				p.Status.ContainerStatuses[0].Ready = true
+				running(time.Hour)(p)
+			})},
+		},
+		{
+			name:         "unschedulable",
+			pods:         []*corev1.Pod{pod("a", unschedulable)},
+			reason:       v1alpha1.ConditionReasonUnschedulable,
+			detail:       "0/3 nodes are available: 3 Insufficient cpu.",
+			expectedPods: []string{"a"},
+		},
+		{
+			name:         "image pull",
+			pods:         []*corev1.Pod{pod("a", imagePull), pod("b", imagePull)},
+			reason:       v1alpha1.ConditionReasonImagePullFailed,
+			detail:       "ImagePullBackOff: Back-off pulling image \"spicedb:v9\"",
+			expectedPods: []string{"a", "b"},
+		},
+		{
+			name: "init container failed",
+			pods: []*corev1.Pod{pod("a", func(p *corev1.Pod) {
+				p.Status.InitContainerStatuses = []corev1.ContainerStatus{{
+					Name:                 "init",
+					LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 2, Reason: "Error"}},
+				}}
+			})},
+			reason:       v1alpha1.ConditionReasonInitContainerFailed,
+			detail:       "init container init exited with code 2: Error",
+			expectedPods: []string{"a"},
+		},
+		{
+			name: "container config error",
+			pods: []*corev1.Pod{pod("a", func(p *corev1.Pod) {
+				p.Status.ContainerStatuses[0].State.Waiting = &corev1.ContainerStateWaiting{Reason: "CreateContainerConfigError", Message: "secret \"spicedb\" not found"}
+			})},
+			reason:       v1alpha1.ConditionReasonContainerConfigError,
+			detail:       "CreateContainerConfigError: secret \"spicedb\" not found",
+			expectedPods: []string{"a"},
+		},
+		{
+			name: "crashing container",
+			pods: []*corev1.Pod{pod("a", func(p *corev1.Pod) {
+				p.Status.ContainerStatuses[0].LastTerminationState.Terminated = &corev1.ContainerStateTerminated{ExitCode: 1, Message: "invalid datastore uri"}
+			})},
+			reason:       v1alpha1.ConditionReasonPodError,
+			detail:       "invalid datastore uri",
+			expectedPods: []string{"a"},
+		},
+		{
+			name:         "readiness probe failing",
+			pods:         []*corev1.Pod{pod("a", running(time.Minute))},
+			reason:       v1alpha1.ConditionReasonReadinessProbeFailed,
+			detail:       "container spicedb has been running for 1m0s without passing its readiness probe",
+			expectedPods: []string{"a"},
+		},
+		{
+			name: "readiness probe still within its failure threshold",
+			pods: []*corev1.Pod{pod("a", running(15*time.Second))},
+		},
+		{
+			name:         "most severe failure wins",
+			pods:         []*corev1.Pod{pod("a", running(time.Minute)), pod("b", imagePull), pod("c", unschedulable)},
+			reason:       v1alpha1.ConditionReasonUnschedulable,
+			detail:       "0/3 nodes are available: 3 Insufficient cpu.",
+			expectedPods: []string{"c"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failure := classifyPodFailures(tt.pods, now)
+			if len(tt.reason) == 0 {
+				require.Nil(t, failure)
+				return
+			}
+			require.Equal(t, &podFailure{reason: tt.reason, detail: tt.detail, pods: tt.expectedPods}, failure)
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package controller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/authzed/controller-idioms/handler"
+	"github.com/authzed/controller-idioms/hash"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+	"github.com/authzed/spicedb-operator/pkg/config"
+	"github.com/authzed/spicedb-operator/pkg/metadata"
+)
+
+const (
+	EventPresharedKeyRotation = "PresharedKeyRotation"
+
+	// values of the metadata.PresharedKeyRotationKey annotation
+	PresharedKeyRotationStart    = "Start"
+	PresharedKeyRotationComplete = "Complete"
+)
+
+// PresharedKeyRotationHandler rotates the preshared key in the config secret
+// without breaking clients, driven by the `authzed.com/preshared-key-rotation`
+// annotation on the cluster:
+//
+//   - Start adds a random preshared_key_next (unless one was added by hand),
+//     which rolls the pods to accept both keys.
+//   - Complete moves preshared_key_next to preshared_key once clients have
+//     switched, which rolls the pods to accept only the new key.
+//
+// The PresharedKeyRotation condition records which step the rotation is in:
+// Complete is only accepted once the pods accept both keys, and a leftover
+// Complete annotation doesn't promote a key that is added later.
+type PresharedKeyRotationHandler struct {
+	recorder     record.EventRecorder
+	updateSecret func(ctx context.Context, secret *corev1.Secret) (*corev1.Secret, error)
+	patchStatus  func(ctx context.Context, patch *v1alpha1.SpiceDBCluster) error
+	next         handler.ContextHandler
+}
+
+func (h *PresharedKeyRotationHandler) Handle(ctx context.Context) {
+	cluster := CtxCluster.MustValue(ctx)
+	secret := CtxSecret.Value(ctx)
+	if secret == nil {
+		h.next.Handle(ctx)
+		return
+	}
+
+	condition := cluster.FindStatusCondition(v1alpha1.ConditionTypePresharedKeyRotation)
+	rollingOut := condition != nil && condition.Reason == v1alpha1.ConditionReasonRollingOutNextKey
+	inProgress := condition != nil && condition.Reason == v1alpha1.ConditionReasonWaitingForClients
+	_, hasNext := secret.Data[config.PresharedKeyNextSecretKey]
+	annotation := cluster.Annotations[metadata.PresharedKeyRotationKey]
+
+	// clients are only asked to switch once the pods accept both keys
+	if rollingOut && annotation != "" && hasNext && nextKeyRolledOut(cluster, secret, condition.LastTransitionTime) {
+		if !h.setCondition(ctx, cluster, v1alpha1.NewPresharedKeyRotationInProgressCondition()) {
+			return
+		}
+		rollingOut, inProgress = false, true
+	}
+
+	switch annotation {
+	case PresharedKeyRotationStart:
+		if !hasNext {
+			key, err := newPresharedKey()
+			if err != nil {
+				QueueOps.RequeueErr(ctx, err)
+				return
+			}
+			updated := secret.DeepCopy()
+			if updated.Data == nil {
+				updated.Data = make(map[string][]byte, 1)
+			}
+			updated.Data[config.PresharedKeyNextSecretKey] = key
+			if _, err := h.updateSecret(ctx, updated); err != nil {
+				QueueOps.RequeueAPIErr(ctx, err)
+				return
+			}
+			h.recorder.Eventf(cluster, corev1.EventTypeNormal, EventPresharedKeyRotation, "Added a new key to %s in secret %s.", config.PresharedKeyNextSecretKey, secret.Name)
+
+			// the secret update requeues the cluster with the new key
+			QueueOps.Done(ctx)
+			return
+		}
+		if !rollingOut && !inProgress {
+			// a key that was added by hand may already be rolled out
+			next := v1alpha1.NewPresharedKeyRotationRollingOutCondition()
+			if nextKeyRolledOut(cluster, secret, metav1.Time{}) {
+				next = v1alpha1.NewPresharedKeyRotationInProgressCondition()
+			}
+			if !h.setCondition(ctx, cluster, next) {
+				return
+			}
+		}
+	case PresharedKeyRotationComplete:
+		if !inProgress {
+			break
+		}
+		if hasNext {
+			updated := secret.DeepCopy()
+			updated.Data[config.PresharedKeySecretKey] = updated.Data[config.PresharedKeyNextSecretKey]
+			delete(updated.Data, config.PresharedKeyNextSecretKey)
+			if _, err := h.updateSecret(ctx, updated); err != nil {
+				QueueOps.RequeueAPIErr(ctx, err)
+				return
+			}
+			h.recorder.Eventf(cluster, corev1.EventTypeNormal, EventPresharedKeyRotation, "Replaced %s with %s in secret %s.", config.PresharedKeySecretKey, config.PresharedKeyNextSecretKey, secret.Name)
+		}
+		if !h.setCondition(ctx, cluster, v1alpha1.NewPresharedKeyRotationCompleteCondition()) {
+			return
+		}
+		if hasNext {
+			QueueOps.Done(ctx)
+			return
+		}
+	default:
+		if condition != nil {
+			cluster.RemoveStatusCondition(v1alpha1.ConditionTypePresharedKeyRotation)
+			if err := h.patchStatus(ctx, cluster); err != nil {
+				QueueOps.RequeueAPIErr(ctx, err)
+				return
+			}
+		}
+	}
+
+	ctx = CtxCluster.WithValue(ctx, cluster)
+	h.next.Handle(ctx)
+}
+
+// nextKeyRolledOut returns true if the pods were rolled out with the current
+// contents of the secret, i.e. with both keys, by a rollout that finished no
+// earlier than `since`. With a zero `since`, a cluster without any recorded
+// rollout counts as rolled out.
+func nextKeyRolledOut(cluster *v1alpha1.SpiceDBCluster, secret *corev1.Secret, since metav1.Time) bool {
+	if cluster.Status.SecretHash != hash.SecureObject(secret.Data) {
+		return false
+	}
+	rollout := cluster.Status.Rollout
+	if rollout == nil {
+		return since.IsZero()
+	}
+	return rollout.CompletionTime != nil && !rollout.CompletionTime.Before(&since)
+}
+
+// setCondition returns false if the status couldn't be patched, in which case
+// the cluster has been requeued.
+func (h *PresharedKeyRotationHandler) setCondition(ctx context.Context, cluster *v1alpha1.SpiceDBCluster, condition metav1.Condition) bool {
+	cluster.SetStatusCondition(condition)
+	if err := h.patchStatus(ctx, cluster); err != nil {
+		QueueOps.RequeueAPIErr(ctx, err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,264 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/authzed/controller-idioms/handler"
+	"github.com/authzed/controller-idioms/hash"
+	"github.com/authzed/controller-idioms/queue/fake"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+	"github.com/authzed/spicedb-operator/pkg/metadata"
+)
+
+func TestPresharedKeyRotationHandler(t *testing.T) {
+	var nextKey handler.Key = "next"
+	inProgress := v1alpha1.NewPresharedKeyRotationInProgressCondition()
+	complete := v1alpha1.NewPresharedKeyRotationCompleteCondition()
+	keyAdded := metav1.NewTime(time.Now().Add(-time.Hour))
+	rollingOut := v1alpha1.NewPresharedKeyRotationRollingOutCondition()
+	rollingOut.LastTransitionTime = keyAdded
+	before, after := metav1.NewTime(keyAdded.Add(-time.Minute)), metav1.NewTime(keyAdded.Add(time.Minute))
+
+	tests := []struct {
+		name string
+
+		annotation string
+		data       map[string][]byte
+		conditions []metav1.Condition
+		applied    bool
+		rollout    *v1alpha1.RolloutStatus
+		updateErr  error
+
+		expectUpdated     map[string][]byte
+		expectEvents      []string
+		expectPatchStatus bool
+		expectCondition   string
+		expectNext        handler.Key
+		expectRequeue     bool
+		expectDone        bool
+	}{
+		{
+			name:       "no rotation",
+			data:       map[string][]byte{"preshared_key": []byte("old")},
+			expectNext: nextKey,
+		},
+		{
+			name:         "start generates a next key",
+			annotation:   PresharedKeyRotationStart,
+			data:         map[string][]byte{"preshared_key": []byte("old")},
+			expectEvents: []string{"Normal PresharedKeyRotation Added a new key to preshared_key_next in secret secret."},
+			expectDone:   true,
+		},
+		{
+			name:              "start rolls out the next key",
+			annotation:        PresharedKeyRotationStart,
+			data:              map[string][]byte{"preshared_key": []byte("old"), "preshared_key_next": []byte("new")},
+			expectPatchStatus: true,
+			expectCondition:   v1alpha1.ConditionReasonRollingOutNextKey,
+			expectNext:        nextKey,
+		},
+		{
+			name:              "start with a next key that is already rolled out",
+			annotation:        PresharedKeyRotationStart,
+			data:              map[string][]byte{"preshared_key": []byte("old"), "preshared_key_next": []byte("new")},
+			applied:           true,
+			rollout:           &v1alpha1.RolloutStatus{StartTime: &before, CompletionTime: &before},
+			expectPatchStatus: true,
+			expectCondition:   v1alpha1.ConditionReasonWaitingForClients,
+			expectNext:        nextKey,
+		},
+		{
+			name:            "waits for the pods to accept both keys",
+			annotation:      PresharedKeyRotationStart,
+			data:            map[string][]byte{"preshared_key": []byte("old"), "preshared_key_next": []byte("new")},
+			conditions:      []metav1.Condition{rollingOut},
+			applied:         true,
+			rollout:         &v1alpha1.RolloutStatus{StartTime: &after},
+			expectCondition: v1alpha1.ConditionReasonRollingOutNextKey,
+			expectNext:      nextKey,
+		},
+		{
+			name:            "ignores a rollout that finished before the key was added",
+			annotation:      PresharedKeyRotationStart,
+			data:            map[string][]byte{"preshared_key": []byte("old"), "preshared_key_next": []byte("new")},
+			conditions:      []metav1.Condition{rollingOut},
+			applied:         true,
+			rollout:         &v1alpha1.RolloutStatus{StartTime: &before, CompletionTime: &before},
+			expectCondition: v1alpha1.ConditionReasonRollingOutNextKey,
+			expectNext:      nextKey,
+		},
+		{
+			name:              "waits for clients once both keys are rolled out",
+			annotation:        PresharedKeyRotationStart,
+			data:              map[string][]byte{"preshared_key": []byte("old"), "preshared_key_next": []byte("new")},
+			conditions:        []metav1.Condition{rollingOut},
+			applied:           true,
+			rollout:           &v1alpha1.RolloutStatus{StartTime: &after, CompletionTime: &after},
+			expectPatchStatus: true,
+			expectCondition:   v1alpha1.ConditionReasonWaitingForClients,
+			expectNext:        nextKey,
+		},
+		{
+			name:            "waits for clients",
+			annotation:      PresharedKeyRotationStart,
+			data:            map[string][]byte{"preshared_key": []byte("old"), "preshared_key_next": []byte("new")},
+			conditions:      []metav1.Condition{inProgress},
+			expectCondition: v1alpha1.ConditionReasonWaitingForClients,
+			expectNext:      nextKey,
+		},
+		{
+			name:              "complete promotes the next key",
+			annotation:        PresharedKeyRotationComplete,
+			data:              map[string][]byte{"preshared_key": []byte("old"), "preshared_key_next": []byte("new")},
+			conditions:        []metav1.Condition{inProgress},
+			expectUpdated:     map[string][]byte{"preshared_key": []byte("new")},
+			expectEvents:      []string{"Normal PresharedKeyRotation Replaced preshared_key with preshared_key_next in secret secret."},
+			expectPatchStatus: true,
+			expectCondition:   v1alpha1.ConditionReasonRotationComplete,
+			expectDone:        true,
+		},
+		{
+			name:            "complete waits for the pods to accept both keys",
+			annotation:      PresharedKeyRotationComplete,
+			data:            map[string][]byte{"preshared_key": []byte("old"), "preshared_key_next": []byte("new")},
+			conditions:      []metav1.Condition{rollingOut},
+			applied:         true,
+			rollout:         &v1alpha1.RolloutStatus{StartTime: &after},
+			expectCondition: v1alpha1.ConditionReasonRollingOutNextKey,
+			expectNext:      nextKey,
+		},
+		{
+			name:              "complete promotes the next key once it is rolled out",
+			annotation:        PresharedKeyRotationComplete,
+			data:              map[string][]byte{"preshared_key": []byte("old"), "preshared_key_next": []byte("new")},
+			conditions:        []metav1.Condition{rollingOut},
+			applied:           true,
+			rollout:           &v1alpha1.RolloutStatus{StartTime: &after, CompletionTime: &after},
+			expectUpdated:     map[string][]byte{"preshared_key": []byte("new")},
+			expectEvents:      []string{"Normal PresharedKeyRotation Replaced preshared_key with preshared_key_next in secret secret."},
+			expectPatchStatus: true,
+			expectCondition:   v1alpha1.ConditionReasonRotationComplete,
+			expectDone:        true,
+		},
+		{
+			name:            "complete is idempotent",
+			annotation:      PresharedKeyRotationComplete,
+			data:            map[string][]byte{"preshared_key": []byte("new")},
+			conditions:      []metav1.Condition{complete},
+			expectCondition: v1alpha1.ConditionReasonRotationComplete,
+			expectNext:      nextKey,
+		},
+		{
+			name:            "complete doesn't promote a key added after the rotation",
+			annotation:      PresharedKeyRotationComplete,
+			data:            map[string][]byte{"preshared_key": []byte("new"), "preshared_key_next": []byte("newer")},
+			conditions:      []metav1.Condition{complete},
+			expectCondition: v1alpha1.ConditionReasonRotationComplete,
+			expectNext:      nextKey,
+		},
+		{
+			name:       "complete without start does nothing",
+			annotation: PresharedKeyRotationComplete,
+			data:       map[string][]byte{"preshared_key": []byte("old"), "preshared_key_next": []byte("new")},
+			expectNext: nextKey,
+		},
+		{
+			name:              "removing the annotation clears the condition",
+			data:              map[string][]byte{"preshared_key": []byte("new")},
+			conditions:        []metav1.Condition{complete},
+			expectPatchStatus: true,
+			expectNext:        nextKey,
+		},
+		{
+			name:          "requeues on update error",
+			annotation:    PresharedKeyRotationComplete,
+			data:          map[string][]byte{"preshared_key": []byte("old"), "preshared_key_next": []byte("new")},
+			conditions:    []metav1.Condition{inProgress},
+			updateErr:     fmt.Errorf("error updating"),
+			expectUpdated: map[string][]byte{"preshared_key": []byte("new")},
+			// the condition is unchanged until the secret is updated
+			expectCondition: v1alpha1.ConditionReasonWaitingForClients,
+			expectRequeue:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrls := &fake.FakeInterface{}
+			recorder := record.NewFakeRecorder(1)
+			cluster := &v1alpha1.SpiceDBCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"},
+				Status:     v1alpha1.ClusterStatus{Conditions: tt.conditions, Rollout: tt.rollout},
+			}
+			if tt.applied {
+				cluster.Status.SecretHash = hash.SecureObject(tt.data)
+			}
+			if tt.annotation != "" {
+				cluster.Annotations = map[string]string{metadata.PresharedKeyRotationKey: tt.annotation}
+			}
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "secret", Namespace: "test"},
+				Data:       tt.data,
+			}
+
+			ctx := context.Background()
+			ctx = QueueOps.WithValue(ctx, ctrls)
+			ctx = CtxCluster.WithValue(ctx, cluster)
+			ctx = CtxSecret.WithValue(ctx, secret)
+
+			var updated *corev1.Secret
+			patchCalled := false
+			var called handler.Key
+			h := &PresharedKeyRotationHandler{
+				recorder: recorder,
+				updateSecret: func(_ context.Context, s *corev1.Secret) (*corev1.Secret, error) {
+					updated = s
+					return s, tt.updateErr
+				},
+				patchStatus: func(_ context.Context, patch *v1alpha1.SpiceDBCluster) error {
+					patchCalled = true
+					return nil
+				},
+				next: handler.ContextHandlerFunc(func(ctx context.Context) {
+					called = nextKey
+				}),
+			}
+			h.Handle(ctx)
+
+			if tt.annotation == PresharedKeyRotationStart && tt.data["preshared_key_next"] == nil {
+				require.NotNil(t, updated)
+				require.Equal(t, tt.data["preshared_key"], updated.Data["preshared_key"])
+				require.NotEmpty(t, updated.Data["preshared_key_next"])
+			} else if tt.expectUpdated != nil {
+				require.NotNil(t, updated)
+				require.Equal(t, tt.expectUpdated, updated.Data)
+			} else {
+				require.Nil(t, updated)
+			}
+			// the cached secret is never modified
+			require.Equal(t, tt.data, secret.Data)
+
+			require.Equal(t, tt.expectPatchStatus, patchCalled)
+			require.Equal(t, tt.expectNext, called)
+			require.Equal(t, tt.expectRequeue, ctrls.RequeueAPIErrCallCount() == 1)
+			require.Equal(t, tt.expectDone, ctrls.DoneCallCount() == 1)
+			ExpectEvents(t, recorder, tt.expectEvents)
+
+			cond := cluster.FindStatusCondition(v1alpha1.ConditionTypePresharedKeyRotation)
+			if tt.expectCondition == "" {
+				require.Nil(t, cond)
+				return
+			}
+			require.NotNil(t, cond)
+			require.Equal(t, tt.expectCondition, cond.Reason)
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	applycorev1 "k8s.io/client-go/applyconfigurations/core/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/authzed/controller-idioms/adopt"
+	"github.com/authzed/controller-idioms/handler"
+	"github.com/authzed/controller-idioms/hash"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+	"github.com/authzed/spicedb-operator/pkg/metadata"
+)
+
+// ReferencedSecretsHandler adopts the TLS, CA and credential secrets named in
+// the config and mixes their contents into the secret hash, so that rotating
+// any of them rolls the pods. Secrets that are no longer referenced are
+// released.
+//
+// Unlike the config secret, a secret may be referenced by many clusters, so
+// each cluster labels and annotates it with its own field manager.
+type ReferencedSecretsHandler struct {
+	recorder       record.EventRecorder
+	getSecret      func(ctx context.Context, nn types.NamespacedName) (*corev1.Secret, error)
+	listReferenced func(ctx context.Context, owner types.NamespacedName) ([]*corev1.Secret, error)
+	applySecret    func(ctx context.Context, secret *applycorev1.SecretApplyConfiguration, opts metav1.ApplyOptions) (*corev1.Secret, error)
+	patchStatus    func(ctx context.Context, patch *v1alpha1.SpiceDBCluster) error
+	next           handler.ContextHandler
+}
+
+func (h *ReferencedSecretsHandler) Handle(ctx context.Context) {
+	cfg := CtxConfig.MustValue(ctx)
+	cluster := CtxCluster.MustValue(ctx)
+	owner := cluster.NamespacedName()
+	annotationKey := metadata.ReferenceAnnotationKeyPrefix + owner.Name
+	applyOpts := metav1.ApplyOptions{Force: true, FieldManager: "spicedbcluster-reference-" + owner.Namespace + "-" + owner.Name}
+
+	var referenced []v1alpha1.ReferencedSecret
+	for _, name := range cfg.ReferencedSecrets {
+		nn := types.NamespacedName{Namespace: owner.Namespace, Name: name}
+		secret, err := h.getSecret(ctx, nn)
+		if apierrors.IsNotFound(err) {
+			h.secretMissing(ctx, cluster, nn)
+			return
+		}
+		if err != nil {
+			QueueOps.RequeueErr(ctx, err)
+			return
+		}
+		if secret.Annotations[annotationKey] != adopt.Owned {
+			secret, err = h.applySecret(ctx, applycorev1.Secret(nn.Name, nn.Namespace).
+				WithLabels(map[string]string{metadata.OperatorManagedLabelKey: metadata.OperatorManagedLabelValue}).
+				WithAnnotations(map[string]string{annotationKey: adopt.Owned}), applyOpts)
+			if err != nil {
+				QueueOps.RequeueAPIErr(ctx, err)
+				return
+			}
+			h.recorder.Eventf(secret, corev1.EventTypeNormal, EventSecretAdoptedBySpiceDBCluster, "Secret was referenced in the config of SpiceDBCluster %s; it has been labelled so that changes to it roll the cluster.", owner.String())
+		}
+		referenced = append(referenced, v1alpha1.ReferencedSecret{Name: name, Hash: hash.SecureObject(secret.Data)})
+	}
+
+	previous, err := h.listReferenced(ctx, owner)
+	if err != nil {
+		QueueOps.RequeueErr(ctx, err)
+		return
+	}
+	for _, old := range previous {
+		if slices.Contains(cfg.ReferencedSecrets, old.Name) {
+			continue
+		}
+		// applying nothing with the cluster's field manager removes only the
+		// label and annotation that this cluster added
+		if _, err := h.applySecret(ctx, applycorev1.Secret(old.Name, old.Namespace), applyOpts); err != nil {
+			QueueOps.RequeueAPIErr(ctx, err)
+			return
+		}
+	}
+
+	condition := cluster.FindStatusCondition(v1alpha1.ConditionTypePreconditionsFailed)
+	missingCondition := condition != nil && condition.Reason == v1alpha1.ConditionReasonMissingReferencedSecret
+	if missingCondition || !slices.Equal(cluster.Status.ReferencedSecrets, referenced) {
+		if missingCondition {
+			cluster.RemoveStatusCondition(v1alpha1.ConditionTypePreconditionsFailed)
+		}
+		cluster.Status.ReferencedSecrets = referenced
+		if err := h.patchStatus(ctx, cluster); err != nil {
+			QueueOps.RequeueAPIErr(ctx, err)
+			return
+		}
+		ctx = CtxCluster.WithValue(ctx, cluster)
+	}
+
+	// the hash is unchanged for clusters that don't reference any secrets
+	if len(referenced) > 0 {
+		hashes := []string{CtxSecretHash.Value(ctx)}
+		for _, s := range referenced {
+			hashes = append(hashes, s.Name, s.Hash)
+		}
+		ctx = CtxSecretHash.WithValue(ctx, hash.SecureObject(hashes))
+	}
+	h.next.Handle(ctx)
+}
+
+// secretMissing reports the missing secret and keeps checking for it, since
+// a secret that isn't labelled yet doesn't trigger a sync when it's created.
+func (h *ReferencedSecretsHandler) secretMissing(ctx context.Context, cluster *v1alpha1.SpiceDBCluster, nn types.NamespacedName) {
+	condition := v1alpha1.NewMissingReferencedSecretCondition(nn)
+	if existing := cluster.FindStatusCondition(condition.Type); existing == nil || existing.Reason != condition.Reason || existing.Message != condition.Message {
+		cluster.SetStatusCondition(condition)
+		if err := h.patchStatus(ctx, cluster); err != nil {
+			QueueOps.RequeueAPIErr(ctx, err)
+			return
+		}
+	}
+	QueueOps.RequeueErr(ctx, fmt.Errorf("secret %s not found", nn.String()))
+}
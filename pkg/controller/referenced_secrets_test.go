@@ -0,0 +1,204 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	applycorev1 "k8s.io/client-go/applyconfigurations/core/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/authzed/controller-idioms/handler"
+	"github.com/authzed/controller-idioms/hash"
+	"github.com/authzed/controller-idioms/queue/fake"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+	"github.com/authzed/spicedb-operator/pkg/config"
+	"github.com/authzed/spicedb-operator/pkg/metadata"
+)
+
+func TestReferencedSecretsHandler(t *testing.T) {
+	var nextKey handler.Key = "next"
+	annotationKey := metadata.ReferenceAnnotationKeyPrefix + "test"
+	secret := func(name, data string, adopted bool) *corev1.Secret {
+		s := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test"},
+			Data:       map[string][]byte{"tls.crt": []byte(data)},
+		}
+		if adopted {
+			s.Annotations = map[string]string{annotationKey: "owned"}
+		}
+		return s
+	}
+	tlsStatus := v1alpha1.ReferencedSecret{Name: "tls", Hash: hash.SecureObject(map[string][]byte{"tls.crt": []byte("cert")})}
+	missing := v1alpha1.NewMissingReferencedSecretCondition(types.NamespacedName{Namespace: "test", Name: "tls"})
+
+	tests := []struct {
+		name string
+
+		referenced []string
+		secrets    map[string]*corev1.Secret
+		previous   []*corev1.Secret
+		status     v1alpha1.ClusterStatus
+		applyErr   error
+
+		expectApplied     []string
+		expectEvents      []string
+		expectPatchStatus bool
+		expectStatus      []v1alpha1.ReferencedSecret
+		expectCondition   bool
+		expectHashChanged bool
+		expectNext        handler.Key
+		expectRequeue     bool
+	}{
+		{
+			name:       "no referenced secrets",
+			expectNext: nextKey,
+		},
+		{
+			name:              "adopts a referenced secret",
+			referenced:        []string{"tls"},
+			secrets:           map[string]*corev1.Secret{"tls": secret("tls", "cert", false)},
+			expectApplied:     []string{"tls"},
+			expectEvents:      []string{"Normal SecretAdoptedBySpiceDB Secret was referenced in the config of SpiceDBCluster test/test; it has been labelled so that changes to it roll the cluster."},
+			expectPatchStatus: true,
+			expectStatus:      []v1alpha1.ReferencedSecret{tlsStatus},
+			expectHashChanged: true,
+			expectNext:        nextKey,
+		},
+		{
+			name:              "doesn't reapply an adopted secret",
+			referenced:        []string{"tls"},
+			secrets:           map[string]*corev1.Secret{"tls": secret("tls", "cert", true)},
+			previous:          []*corev1.Secret{secret("tls", "cert", true)},
+			status:            v1alpha1.ClusterStatus{ReferencedSecrets: []v1alpha1.ReferencedSecret{tlsStatus}},
+			expectStatus:      []v1alpha1.ReferencedSecret{tlsStatus},
+			expectHashChanged: true,
+			expectNext:        nextKey,
+		},
+		{
+			name:              "reports rotated contents",
+			referenced:        []string{"tls"},
+			secrets:           map[string]*corev1.Secret{"tls": secret("tls", "rotated", true)},
+			previous:          []*corev1.Secret{secret("tls", "rotated", true)},
+			status:            v1alpha1.ClusterStatus{ReferencedSecrets: []v1alpha1.ReferencedSecret{tlsStatus}},
+			expectPatchStatus: true,
+			expectStatus:      []v1alpha1.ReferencedSecret{{Name: "tls", Hash: hash.SecureObject(map[string][]byte{"tls.crt": []byte("rotated")})}},
+			expectHashChanged: true,
+			expectNext:        nextKey,
+		},
+		{
+			name:              "releases secrets that are no longer referenced",
+			previous:          []*corev1.Secret{secret("tls", "cert", true)},
+			status:            v1alpha1.ClusterStatus{ReferencedSecrets: []v1alpha1.ReferencedSecret{tlsStatus}},
+			expectApplied:     []string{"tls"},
+			expectPatchStatus: true,
+			expectNext:        nextKey,
+		},
+		{
+			name:              "reports a missing secret",
+			referenced:        []string{"tls"},
+			expectPatchStatus: true,
+			expectCondition:   true,
+			expectRequeue:     true,
+		},
+		{
+			name:            "doesn't repatch a missing secret",
+			referenced:      []string{"tls"},
+			status:          v1alpha1.ClusterStatus{Conditions: []metav1.Condition{missing}},
+			expectCondition: true,
+			expectRequeue:   true,
+		},
+		{
+			name:              "clears the condition once the secret exists",
+			referenced:        []string{"tls"},
+			secrets:           map[string]*corev1.Secret{"tls": secret("tls", "cert", true)},
+			status:            v1alpha1.ClusterStatus{Conditions: []metav1.Condition{missing}},
+			expectPatchStatus: true,
+			expectStatus:      []v1alpha1.ReferencedSecret{tlsStatus},
+			expectHashChanged: true,
+			expectNext:        nextKey,
+		},
+		{
+			name:          "requeues on apply error",
+			referenced:    []string{"tls"},
+			secrets:       map[string]*corev1.Secret{"tls": secret("tls", "cert", false)},
+			applyErr:      fmt.Errorf("error applying"),
+			expectApplied: []string{"tls"},
+			expectRequeue: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrls := &fake.FakeInterface{}
+			recorder := record.NewFakeRecorder(1)
+			cluster := &v1alpha1.SpiceDBCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"},
+				Status:     tt.status,
+			}
+			cfg := &config.Config{SpiceConfig: config.SpiceConfig{ReferencedSecrets: tt.referenced}}
+
+			ctx := context.Background()
+			ctx = QueueOps.WithValue(ctx, ctrls)
+			ctx = CtxCluster.WithValue(ctx, cluster)
+			ctx = CtxConfig.WithValue(ctx, cfg)
+			ctx = CtxSecretHash.WithValue(ctx, "secret")
+
+			var applied []string
+			patchCalled := false
+			var called handler.Key
+			var gotHash string
+			h := &ReferencedSecretsHandler{
+				recorder: recorder,
+				getSecret: func(_ context.Context, nn types.NamespacedName) (*corev1.Secret, error) {
+					if s, ok := tt.secrets[nn.Name]; ok {
+						return s, nil
+					}
+					return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, nn.Name)
+				},
+				listReferenced: func(_ context.Context, owner types.NamespacedName) ([]*corev1.Secret, error) {
+					require.Equal(t, cluster.NamespacedName(), owner)
+					return tt.previous, nil
+				},
+				applySecret: func(_ context.Context, apply *applycorev1.SecretApplyConfiguration, opts metav1.ApplyOptions) (*corev1.Secret, error) {
+					applied = append(applied, *apply.Name)
+					require.Equal(t, "spicedbcluster-reference-test-test", opts.FieldManager)
+					if s, ok := tt.secrets[*apply.Name]; ok {
+						require.Equal(t, "owned", apply.Annotations[annotationKey])
+						require.Equal(t, metadata.OperatorManagedLabelValue, apply.Labels[metadata.OperatorManagedLabelKey])
+						return s, tt.applyErr
+					}
+					require.Empty(t, apply.Annotations)
+					require.Empty(t, apply.Labels)
+					return nil, tt.applyErr
+				},
+				patchStatus: func(_ context.Context, patch *v1alpha1.SpiceDBCluster) error {
+					patchCalled = true
+					return nil
+				},
+				next: handler.ContextHandlerFunc(func(ctx context.Context) {
+					called = nextKey
+					gotHash = CtxSecretHash.Value(ctx)
+				}),
+			}
+			h.Handle(ctx)
+
+			require.Equal(t, tt.expectApplied, applied)
+			require.Equal(t, tt.expectPatchStatus, patchCalled)
+			require.Equal(t, tt.expectNext, called)
+			require.Equal(t, tt.expectRequeue, ctrls.RequeueAPIErrCallCount()+ctrls.RequeueErrCallCount() == 1)
+			ExpectEvents(t, recorder, tt.expectEvents)
+			require.Equal(t, tt.expectCondition, cluster.FindStatusCondition(v1alpha1.ConditionTypePreconditionsFailed) != nil)
+			if tt.expectNext == nextKey {
+				require.Equal(t, tt.expectStatus, cluster.Status.ReferencedSecrets)
+				require.Equal(t, tt.expectHashChanged, gotHash != "secret")
+			}
+		})
+	}
+}
@@ -30,7 +30,7 @@ func NewSecretAdoptionHandler(recorder record.EventRecorder, getFromCache func(c
 		ObjectMissingFunc: missingFunc,
 		GetFromCache:      getFromCache,
 		Indexer:           secretIndexer,
-		IndexName:         metadata.OwningClusterIndex,
+		IndexName:         metadata.AdoptingClusterIndex,
 		Labels:            map[string]string{metadata.OperatorManagedLabelKey: metadata.OperatorManagedLabelValue},
 		NewPatch: func(nn types.NamespacedName) *applycorev1.SecretApplyConfiguration {
 			return applycorev1.Secret(nn.Name, nn.Namespace)
@@ -296,11 +296,37 @@ func TestSecretAdopterHandler(t *testing.T) {
 			expectCtxSecret: testSecret,
 			expectNext:      true,
 		},
+		{
+			// regression: secrets the operator creates for a cluster carry
+			// its owner label, and used to be released as if they had been
+			// adopted, only to be re-applied on the next sync
+			name: "secrets created for the cluster are left alone",
+			cluster: types.NamespacedName{
+				Namespace: "test",
+				Name:      "test",
+			},
+			secretName:    "secret",
+			secretInCache: testSecret,
+			secretsInIndex: []*corev1.Secret{testSecret, {
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-spicedb-tls",
+					Namespace: "test",
+					Labels:    metadata.LabelsForComponent("test", metadata.ComponentSelfSignedTLSLabel),
+				},
+			}},
+			expectEvents:    []string{},
+			expectCtxSecret: testSecret,
+			expectNext:      true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ctrls := &fake.FakeInterface{}
-			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{metadata.OwningClusterIndex: metadata.GetClusterKeyFromMeta})
+			// the same indexes the controller registers for secrets
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{
+				metadata.OwningClusterIndex:   metadata.GetClusterKeyFromMeta,
+				metadata.AdoptingClusterIndex: metadata.GetAdoptingClusterKeyFromMeta,
+			})
 			IndexAddUnstructured(t, indexer, tt.secretsInIndex)
 
 			recorder := record.NewFakeRecorder(1)
@@ -0,0 +1,217 @@
+package controller
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"slices"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	applycorev1 "k8s.io/client-go/applyconfigurations/core/v1"
+	applymetav1 "k8s.io/client-go/applyconfigurations/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/authzed/controller-idioms/handler"
+	"github.com/authzed/controller-idioms/hash"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+	"github.com/authzed/spicedb-operator/pkg/config"
+	"github.com/authzed/spicedb-operator/pkg/metadata"
+)
+
+const (
+	EventCertificateIssued = "CertificateIssued"
+
+	caValidity      = 10 * 365 * 24 * time.Hour
+	servingValidity = 90 * 24 * time.Hour
+)
+
+// SelfSignedTLSHandler issues a per-cluster CA and a serving certificate
+// signed by it when `selfSignedTLS` is enabled. Certificates are reissued
+// once two thirds of their lifetime has passed, and the serving certificate
+// is mixed into the secret hash so that a new one rolls the pods.
+type SelfSignedTLSHandler struct {
+	recorder        record.EventRecorder
+	now             func() time.Time
+	getSecret       func(ctx context.Context, nn types.NamespacedName) (*corev1.Secret, error)
+	applySecret     func(ctx context.Context, secret *applycorev1.SecretApplyConfiguration) (*corev1.Secret, error)
+	scheduleRenewal func(ctx context.Context, after time.Duration)
+	next            handler.ContextHandler
+}
+
+func (h *SelfSignedTLSHandler) Handle(ctx context.Context) {
+	cfg := CtxConfig.MustValue(ctx)
+	if !cfg.SelfSignedTLS {
+		h.next.Handle(ctx)
+		return
+	}
+	cluster := CtxCluster.MustValue(ctx)
+	now := h.now()
+
+	caSecret, err := h.getSecret(ctx, types.NamespacedName{Namespace: cluster.Namespace, Name: config.SelfSignedCAName(cluster.Name)})
+	if err != nil && !apierrors.IsNotFound(err) {
+		QueueOps.RequeueErr(ctx, err)
+		return
+	}
+	ca := parseKeyPair(caSecret, corev1.TLSCertKey)
+	if ca == nil || needsRenewal(ca.cert, now) {
+		ca, err = newCertificate(cluster.Name+"-ca", nil, nil, caValidity, now)
+		if err != nil {
+			QueueOps.RequeueErr(ctx, err)
+			return
+		}
+		if _, err := h.applySecret(ctx, h.secret(cfg, config.SelfSignedCAName(cluster.Name), metadata.ComponentSelfSignedCALabel, map[string][]byte{
+			corev1.TLSCertKey:       ca.certPEM,
+			corev1.TLSPrivateKeyKey: ca.keyPEM,
+		})); err != nil {
+			QueueOps.RequeueAPIErr(ctx, err)
+			return
+		}
+		h.recorder.Eventf(cluster, corev1.EventTypeNormal, EventCertificateIssued, "Issued a new CA certificate, valid until %s.", ca.cert.NotAfter.Format(time.RFC3339))
+	}
+
+	tlsSecret, err := h.getSecret(ctx, types.NamespacedName{Namespace: cluster.Namespace, Name: cfg.TLSSecretName})
+	if err != nil && !apierrors.IsNotFound(err) {
+		QueueOps.RequeueErr(ctx, err)
+		return
+	}
+	serving := parseKeyPair(tlsSecret, corev1.TLSCertKey)
+	if serving == nil || needsRenewal(serving.cert, now) ||
+		serving.cert.CheckSignatureFrom(ca.cert) != nil ||
+		!slices.Equal(serving.cert.DNSNames, cfg.SelfSignedDNSNames) {
+		serving, err = newCertificate(cluster.Name, cfg.SelfSignedDNSNames, ca, servingValidity, now)
+		if err != nil {
+			QueueOps.RequeueErr(ctx, err)
+			return
+		}
+		if _, err := h.applySecret(ctx, h.secret(cfg, cfg.TLSSecretName, metadata.ComponentSelfSignedTLSLabel, map[string][]byte{
+			corev1.TLSCertKey:       serving.certPEM,
+			corev1.TLSPrivateKeyKey: serving.keyPEM,
+			config.CACertKey:        ca.certPEM,
+		})); err != nil {
+			QueueOps.RequeueAPIErr(ctx, err)
+			return
+		}
+		h.recorder.Eventf(cluster, corev1.EventTypeNormal, EventCertificateIssued, "Issued a new serving certificate, valid until %s.", serving.cert.NotAfter.Format(time.RFC3339))
+	}
+
+	renewAt := renewalTime(serving.cert)
+	if caRenewAt := renewalTime(ca.cert); caRenewAt.Before(renewAt) {
+		renewAt = caRenewAt
+	}
+	h.scheduleRenewal(ctx, renewAt.Sub(now))
+
+	ctx = CtxSelfSignedCA.WithValue(ctx, string(ca.certPEM))
+	ctx = CtxSecretHash.WithValue(ctx, hash.SecureObject([]string{CtxSecretHash.Value(ctx), string(serving.certPEM)}))
+	h.next.Handle(ctx)
+}
+
+func (h *SelfSignedTLSHandler) secret(cfg *config.Config, name, component string, data map[string][]byte) *applycorev1.SecretApplyConfiguration {
+	return applycorev1.Secret(name, cfg.Namespace).
+		WithLabels(metadata.LabelsForComponent(cfg.Name, component)).
+		WithOwnerReferences(applymetav1.OwnerReference().
+			WithName(cfg.Name).
+			WithKind(v1alpha1.SpiceDBClusterKind).
+			WithAPIVersion(v1alpha1.SchemeGroupVersion.String()).
+			WithUID(types.UID(cfg.UID))).
+		WithType(corev1.SecretTypeTLS).
+		WithData(data)
+}
+
+type keyPair struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// parseKeyPair returns nil if the secret doesn't hold a valid key pair, so
+// that a new one is issued.
+func parseKeyPair(secret *corev1.Secret, certKey string) *keyPair {
+	if secret == nil {
+		return nil
+	}
+	certPEM, keyPEM := secret.Data[certKey], secret.Data[corev1.TLSPrivateKeyKey]
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil
+	}
+	key, ok := pair.PrivateKey.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil
+	}
+	return &keyPair{cert: cert, key: key, certPEM: certPEM, keyPEM: keyPEM}
+}
+
+// newCertificate issues a certificate signed by the parent, or a self-signed
+// CA certificate if there is no parent.
+func newCertificate(commonName string, dnsNames []string, parent *keyPair, validity time.Duration, now time.Time) (*keyPair, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate key: %w", err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate serial number: %w", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     dnsNames,
+		NotBefore:    now.Add(-5 * time.Minute),
+		NotAfter:     now.Add(validity),
+	}
+
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+	} else {
+		template.KeyUsage = x509.KeyUsageDigitalSignature
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create certificate: %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return &keyPair{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}, nil
+}
+
+// renewalTime is when two thirds of the certificate's lifetime has passed.
+func renewalTime(cert *x509.Certificate) time.Time {
+	return cert.NotBefore.Add(cert.NotAfter.Sub(cert.NotBefore) * 2 / 3)
+}
+
+func needsRenewal(cert *x509.Certificate, now time.Time) bool {
+	return !now.Before(renewalTime(cert))
+}
@@ -0,0 +1,192 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	applycorev1 "k8s.io/client-go/applyconfigurations/core/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/authzed/controller-idioms/handler"
+	"github.com/authzed/controller-idioms/queue/fake"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+	"github.com/authzed/spicedb-operator/pkg/config"
+)
+
+func TestSelfSignedTLSHandler(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	dnsNames := []string{"test", "test.test", "test.test.svc", "test.test.svc.cluster.local"}
+
+	ca, err := newCertificate("test-ca", nil, nil, caValidity, now)
+	require.NoError(t, err)
+	otherCA, err := newCertificate("other-ca", nil, nil, caValidity, now)
+	require.NoError(t, err)
+	serving, err := newCertificate("test", dnsNames, ca, servingValidity, now)
+	require.NoError(t, err)
+	caSecret := &corev1.Secret{Data: map[string][]byte{"tls.crt": ca.certPEM, "tls.key": ca.keyPEM}}
+	servingSecret := func(pair *keyPair) *corev1.Secret {
+		return &corev1.Secret{Data: map[string][]byte{"tls.crt": pair.certPEM, "tls.key": pair.keyPEM, "ca.crt": ca.certPEM}}
+	}
+	mustIssue := func(parent *keyPair, names []string, validity time.Duration, at time.Time) *keyPair {
+		pair, err := newCertificate("test", names, parent, validity, at)
+		require.NoError(t, err)
+		return pair
+	}
+
+	tests := []struct {
+		name string
+
+		selfSigned bool
+		secrets    map[string]*corev1.Secret
+		now        time.Time
+
+		expectApplied []string
+		expectEvents  int
+		expectCA      string
+		expectRenewal time.Duration
+		expectNext    bool
+	}{
+		{
+			name:       "disabled",
+			expectNext: true,
+		},
+		{
+			name:          "issues a CA and serving certificate",
+			selfSigned:    true,
+			now:           now,
+			expectApplied: []string{"test-spicedb-ca", "test-spicedb-tls"},
+			expectEvents:  2,
+			expectRenewal: 60 * 24 * time.Hour,
+			expectNext:    true,
+		},
+		{
+			name:       "keeps valid certificates",
+			selfSigned: true,
+			secrets: map[string]*corev1.Secret{
+				"test-spicedb-ca":  caSecret,
+				"test-spicedb-tls": servingSecret(serving),
+			},
+			now:           now.Add(24 * time.Hour),
+			expectCA:      string(ca.certPEM),
+			expectRenewal: 59 * 24 * time.Hour,
+			expectNext:    true,
+		},
+		{
+			name:       "renews the serving certificate before it expires",
+			selfSigned: true,
+			secrets: map[string]*corev1.Secret{
+				"test-spicedb-ca":  caSecret,
+				"test-spicedb-tls": servingSecret(serving),
+			},
+			now:           now.Add(61 * 24 * time.Hour),
+			expectApplied: []string{"test-spicedb-tls"},
+			expectEvents:  1,
+			expectCA:      string(ca.certPEM),
+			expectRenewal: 60 * 24 * time.Hour,
+			expectNext:    true,
+		},
+		{
+			name:       "reissues when the names change",
+			selfSigned: true,
+			secrets: map[string]*corev1.Secret{
+				"test-spicedb-ca":  caSecret,
+				"test-spicedb-tls": servingSecret(mustIssue(ca, dnsNames[:1], servingValidity, now)),
+			},
+			now:           now,
+			expectApplied: []string{"test-spicedb-tls"},
+			expectEvents:  1,
+			expectCA:      string(ca.certPEM),
+			expectRenewal: 60 * 24 * time.Hour,
+			expectNext:    true,
+		},
+		{
+			name:       "reissues when signed by another CA",
+			selfSigned: true,
+			secrets: map[string]*corev1.Secret{
+				"test-spicedb-ca":  caSecret,
+				"test-spicedb-tls": servingSecret(mustIssue(otherCA, dnsNames, servingValidity, now)),
+			},
+			now:           now,
+			expectApplied: []string{"test-spicedb-tls"},
+			expectEvents:  1,
+			expectCA:      string(ca.certPEM),
+			expectRenewal: 60 * 24 * time.Hour,
+			expectNext:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrls := &fake.FakeInterface{}
+			recorder := record.NewFakeRecorder(5)
+			cluster := &v1alpha1.SpiceDBCluster{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test", UID: "1"}}
+			cfg := &config.Config{SpiceConfig: config.SpiceConfig{Name: "test", Namespace: "test", UID: "1"}}
+			if tt.selfSigned {
+				cfg.SelfSignedTLS = true
+				cfg.TLSSecretName = config.SelfSignedTLSSecretName("test")
+				cfg.SelfSignedDNSNames = dnsNames
+			}
+
+			ctx := context.Background()
+			ctx = QueueOps.WithValue(ctx, ctrls)
+			ctx = CtxClusterNN.WithValue(ctx, cluster.NamespacedName())
+			ctx = CtxCluster.WithValue(ctx, cluster)
+			ctx = CtxConfig.WithValue(ctx, cfg)
+			ctx = CtxSecretHash.WithValue(ctx, "secret")
+
+			var applied []string
+			var renewal time.Duration
+			var called bool
+			var gotCA, gotHash string
+			h := &SelfSignedTLSHandler{
+				recorder: recorder,
+				now:      func() time.Time { return tt.now },
+				getSecret: func(_ context.Context, nn types.NamespacedName) (*corev1.Secret, error) {
+					if s, ok := tt.secrets[nn.Name]; ok {
+						return s, nil
+					}
+					return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, nn.Name)
+				},
+				applySecret: func(_ context.Context, secret *applycorev1.SecretApplyConfiguration) (*corev1.Secret, error) {
+					applied = append(applied, *secret.Name)
+					require.Len(t, secret.OwnerReferences, 1)
+					require.Equal(t, corev1.SecretTypeTLS, *secret.Type)
+					return nil, nil
+				},
+				scheduleRenewal: func(_ context.Context, after time.Duration) {
+					renewal = after
+				},
+				next: handler.ContextHandlerFunc(func(ctx context.Context) {
+					called = true
+					gotCA = CtxSelfSignedCA.Value(ctx)
+					gotHash = CtxSecretHash.Value(ctx)
+				}),
+			}
+			h.Handle(ctx)
+
+			require.Equal(t, tt.expectNext, called)
+			require.Equal(t, tt.expectApplied, applied)
+			require.Len(t, recorder.Events, tt.expectEvents)
+			require.Equal(t, 0, ctrls.RequeueErrCallCount()+ctrls.RequeueAPIErrCallCount())
+			if !tt.selfSigned {
+				require.Equal(t, "secret", gotHash)
+				return
+			}
+			if len(tt.expectCA) > 0 {
+				require.Equal(t, tt.expectCA, gotCA)
+			} else {
+				require.NotEmpty(t, gotCA)
+			}
+			require.NotEqual(t, "secret", gotHash)
+			// certificates are backdated to allow for clock skew
+			require.InDelta(t, tt.expectRenewal, renewal, float64(5*time.Minute))
+		})
+	}
+}
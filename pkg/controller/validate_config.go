@@ -2,6 +2,8 @@ package controller
 
 import (
 	"context"
+	"slices"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
@@ -14,15 +16,28 @@ import (
 
 	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
 	"github.com/authzed/spicedb-operator/pkg/config"
+	"github.com/authzed/spicedb-operator/pkg/metadata"
+	"github.com/authzed/spicedb-operator/pkg/updates"
 )
 
-const EventInvalidSpiceDBConfig = "InvalidSpiceDBConfig"
+const (
+	EventInvalidSpiceDBConfig = "InvalidSpiceDBConfig"
+	EventWaitingForWave       = "WaitingForRolloutWave"
+	EventWaveStarted          = "RolloutWaveStarted"
+)
+
+// fleetRolloutPollInterval is how often a cluster that is waiting for an
+// earlier rollout wave checks on it.
+const fleetRolloutPollInterval = time.Minute
 
 type ValidateConfigHandler struct {
-	recorder    record.EventRecorder
-	resources   openapi.Resources
-	patchStatus func(ctx context.Context, patch *v1alpha1.SpiceDBCluster) error
-	next        handler.ContextHandler
+	recorder       record.EventRecorder
+	resources      openapi.Resources
+	now            func() time.Time
+	listClusters   func() ([]*v1alpha1.SpiceDBCluster, error)
+	patchStatus    func(ctx context.Context, patch *v1alpha1.SpiceDBCluster) error
+	scheduleUpdate func(ctx context.Context, after time.Duration)
+	next           handler.ContextHandler
 }
 
 func (c *ValidateConfigHandler) Handle(ctx context.Context) {
@@ -31,6 +46,38 @@ func (c *ValidateConfigHandler) Handle(ctx context.Context) {
 	operatorConfig := CtxOperatorConfig.MustValue(ctx)
 
 	validatedConfig, warning, err := config.NewConfig(cluster, operatorConfig, secret, c.resources)
+	if notSoaked, ok := config.NotSoaked(err); ok {
+		c.waitForFirstRelease(ctx, cluster, notSoaked)
+		return
+	}
+	var pendingCondition *metav1.Condition
+	var target *v1alpha1.SpiceDBVersion
+	if err == nil {
+		if target = pendingUpdate(cluster, validatedConfig); target != nil {
+			pin := target.Name == rolledBackFrom(cluster)
+			if !pin {
+				// errors here are about the fleet, not the cluster's config,
+				// so they're retried rather than reported on the cluster
+				pendingCondition, err = c.deferredUpdate(ctx, cluster, operatorConfig, *target)
+				if err != nil {
+					QueueOps.RequeueErr(ctx, err)
+					return
+				}
+				pin = pendingCondition != nil
+			}
+			if pin {
+				// hold the cluster at its current version, so that everything
+				// else in the config is still applied
+				pinned := cluster.DeepCopy()
+				pinned.Spec.Version = cluster.Status.CurrentVersion.Name
+				validatedConfig, warning, err = config.NewConfig(pinned, operatorConfig, secret, c.resources)
+			}
+		} else if soaking, until := c.soakingUpdate(cluster, operatorConfig, validatedConfig); soaking != nil {
+			cond := v1alpha1.NewReleaseSoakingCondition(*soaking, until)
+			pendingCondition = &cond
+			c.scheduleUpdate(ctx, until.Sub(c.now()))
+		}
+	}
 	if err != nil {
 		failedCondition := v1alpha1.NewInvalidConfigCondition(CtxSecretHash.Value(ctx), err)
 		if existing := cluster.FindStatusCondition(v1alpha1.ConditionValidatingFailed); existing != nil && existing.Message == failedCondition.Message {
@@ -64,18 +111,49 @@ func (c *ValidateConfigHandler) Handle(ctx context.Context) {
 		TargetMigrationHash:  migrationHash,
 		CurrentMigrationHash: cluster.Status.CurrentMigrationHash,
 		SecretHash:           cluster.Status.SecretHash,
+		SecretName:           cluster.Status.SecretName,
+		ReferencedSecrets:    cluster.Status.ReferencedSecrets,
+		ConnectionSecret:     cluster.Status.ConnectionSecret,
 		Image:                validatedConfig.TargetSpiceDBImage,
 		Migration:            validatedConfig.TargetMigration,
 		Phase:                validatedConfig.TargetPhase,
 		CurrentVersion:       validatedConfig.SpiceDBVersion,
+		Replicas:             cluster.Status.Replicas,
+		DesiredReplicas:      cluster.Status.DesiredReplicas,
+		UpdatedReplicas:      cluster.Status.UpdatedReplicas,
+		ReadyReplicas:        cluster.Status.ReadyReplicas,
+		AvailableReplicas:    cluster.Status.AvailableReplicas,
+		ReadyEndpoints:       cluster.Status.ReadyEndpoints,
+		Rollout:              cluster.Status.Rollout,
+		DeploymentSlot:       cluster.Status.DeploymentSlot,
+		History:              cluster.Status.History,
+		Selector:             metadata.SelectorForComponent(cluster.Name, metadata.ComponentSpiceDBLabelValue).String(),
+		Endpoints:            cluster.Status.Endpoints,
 		Conditions:           *cluster.GetStatusConditions(),
 	}
+	if operatorConfig.FleetRollout != nil {
+		computedStatus.UpdateGraphHash = operatorConfig.UpdateGraph.Hash()
+	}
+	if entry := abandonedStep(cluster, computedStatus.Image, c.now()); entry != nil {
+		computedStatus.History = slices.Clone(cluster.Status.History)
+		computedStatus.AppendHistory(*entry)
+	}
 	if version := validatedConfig.SpiceDBVersion; version != nil {
-		computedStatus.AvailableVersions, err = operatorConfig.AvailableVersions(validatedConfig.DatastoreEngine, *version)
+		soak := updates.NewSoak(cluster.Spec.UpdatePolicy, c.now())
+		computedStatus.AvailableVersions, err = operatorConfig.AvailableVersions(validatedConfig.DatastoreEngine, *version, soak)
 		if err != nil {
 			QueueOps.RequeueErr(ctx, err)
 			return
 		}
+
+		// the plan is only informational, so it's left empty if there's no
+		// path to spec.version
+		computedStatus.UpgradePlan, _ = operatorConfig.UpgradePlan(validatedConfig.DatastoreEngine, *version, cluster.Spec.Version, soak)
+		if validatedConfig.SkipMigrations || validatedConfig.DatastoreEngine == "memory" {
+			for i := range computedStatus.UpgradePlan {
+				computedStatus.UpgradePlan[i].RequiresMigrationJob = false
+			}
+		}
 	}
 	meta.RemoveStatusCondition(&computedStatus.Conditions, v1alpha1.ConditionValidatingFailed)
 	meta.RemoveStatusCondition(&computedStatus.Conditions, v1alpha1.ConditionTypeValidating)
@@ -84,6 +162,19 @@ func (c *ValidateConfigHandler) Handle(ctx context.Context) {
 	} else {
 		meta.RemoveStatusCondition(&computedStatus.Conditions, v1alpha1.ConditionTypeConfigWarnings)
 	}
+	if pendingCondition != nil {
+		meta.SetStatusCondition(&computedStatus.Conditions, *pendingCondition)
+	} else {
+		meta.RemoveStatusCondition(&computedStatus.Conditions, v1alpha1.ConditionTypeUpdatePending)
+	}
+	// a rolled back cluster stays pinned until a version is chosen for it,
+	// or the release that failed no longer comes next
+	if len(cluster.Spec.Version) > 0 || (target == nil && !cluster.RolloutInProgress()) ||
+		(target != nil && target.Name != rolledBackFrom(cluster)) {
+		meta.RemoveStatusCondition(&computedStatus.Conditions, v1alpha1.ConditionTypeRolledBack)
+	}
+
+	wasWaiting := waitingForWave(cluster.FindStatusCondition(v1alpha1.ConditionTypeUpdatePending))
 
 	// Remove invalid config status and set image and hash
 	if !cluster.Status.Equals(computedStatus) {
@@ -94,7 +185,138 @@ func (c *ValidateConfigHandler) Handle(ctx context.Context) {
 		}
 	}
 
+	switch waiting := waitingForWave(pendingCondition); {
+	case waiting && !wasWaiting:
+		c.recorder.Event(cluster, corev1.EventTypeNormal, EventWaitingForWave, pendingCondition.Message)
+	case !waiting && wasWaiting:
+		c.recorder.Event(cluster, corev1.EventTypeNormal, EventWaveStarted, "The rollout waves before this cluster have finished, starting the update")
+	}
+
 	ctx = CtxConfig.WithValue(ctx, validatedConfig)
 	ctx = CtxCluster.WithValue(ctx, cluster)
 	c.next.Handle(ctx)
 }
+
+// waitForFirstRelease holds a new cluster back until the first release of its
+// channel has soaked, and checks again once it has.
+func (c *ValidateConfigHandler) waitForFirstRelease(ctx context.Context, cluster *v1alpha1.SpiceDBCluster, notSoaked *updates.NotSoakedError) {
+	soaking := v1alpha1.NewReleaseSoakingCondition(v1alpha1.SpiceDBVersion{Name: notSoaked.Release.ID, Channel: notSoaked.Channel}, notSoaked.Until)
+	if existing := cluster.FindStatusCondition(v1alpha1.ConditionTypeUpdatePending); existing == nil || existing.Message != soaking.Message ||
+		cluster.Status.ObservedGeneration != cluster.GetGeneration() {
+		cluster.Status.ObservedGeneration = cluster.GetGeneration()
+		cluster.RemoveStatusCondition(v1alpha1.ConditionValidatingFailed)
+		cluster.RemoveStatusCondition(v1alpha1.ConditionTypeValidating)
+		cluster.SetStatusCondition(soaking)
+		if err := c.patchStatus(ctx, cluster); err != nil {
+			QueueOps.RequeueAPIErr(ctx, err)
+			return
+		}
+	}
+	c.scheduleUpdate(ctx, notSoaked.Until.Sub(c.now()))
+	QueueOps.Done(ctx)
+}
+
+// abandonedStep returns a failed history entry for the update step that the
+// cluster is rolling out, if it moves on to image before the rollout has
+// finished. Rollouts that only change the config aren't update steps, and
+// steps that are already recorded aren't recorded again.
+func abandonedStep(cluster *v1alpha1.SpiceDBCluster, image string, now time.Time) *v1alpha1.HistoryEntry {
+	rollout := cluster.Status.Rollout
+	if rollout == nil || rollout.StartTime == nil || rollout.CompletionTime != nil || image == cluster.Status.Image {
+		return nil
+	}
+	entry := newHistoryEntry(cluster, nil, v1alpha1.HistoryOutcomeFailed, metav1.NewTime(now))
+	if last := cluster.Status.LastSucceededHistory(); last != nil && sameStep(last, &entry) {
+		return nil
+	}
+	if history := cluster.Status.History; len(history) > 0 && sameStep(&history[len(history)-1], &entry) {
+		return nil
+	}
+	return &entry
+}
+
+// pendingUpdate returns the version that a cluster following the head of its
+// channel would move to, if it isn't running it already. Rollouts in progress
+// are never deferred, so there is no pending update while one is.
+func pendingUpdate(cluster *v1alpha1.SpiceDBCluster, validatedConfig *config.Config) *v1alpha1.SpiceDBVersion {
+	current, target := cluster.Status.CurrentVersion, validatedConfig.SpiceDBVersion
+	if len(cluster.Spec.Version) > 0 || current == nil || len(current.Name) == 0 ||
+		target == nil || target.Name == current.Name || cluster.RolloutInProgress() {
+		return nil
+	}
+	return target
+}
+
+// deferredUpdate returns a condition explaining why the update to target has
+// to wait, either for earlier rollout waves to finish or for the next
+// maintenance window, and schedules the cluster to be checked again. It
+// returns nil if the update can start now.
+func (c *ValidateConfigHandler) deferredUpdate(ctx context.Context, cluster *v1alpha1.SpiceDBCluster, operatorConfig *config.OperatorConfig, target v1alpha1.SpiceDBVersion) (*metav1.Condition, error) {
+	now := c.now()
+	if rollout := operatorConfig.FleetRollout; rollout != nil {
+		clusters, err := c.listClusters()
+		if err != nil {
+			return nil, err
+		}
+		blocking, err := rollout.Blocking(operatorConfig.UpdateGraph.Hash(), cluster, clusters, now)
+		if err != nil {
+			return nil, err
+		}
+		if blocking != nil {
+			// clusters becoming ready don't requeue this one, so poll until
+			// the wave is updated, and then wait out its healthy time
+			after := fleetRolloutPollInterval
+			if !blocking.HealthyAt.IsZero() {
+				after = blocking.HealthyAt.Sub(now)
+			}
+			c.scheduleUpdate(ctx, after)
+			cond := v1alpha1.NewWaitingForWaveCondition(target, blocking.Name, blocking.Updated, blocking.Clusters)
+			return &cond, nil
+		}
+	}
+
+	// the policy has already been validated by NewConfig
+	schedule, err := updates.NewMaintenanceSchedule(cluster.Spec.UpdatePolicy)
+	if err != nil {
+		return nil, nil
+	}
+	next := schedule.NextUpdateTime(now)
+	if next.Equal(now) {
+		return nil, nil
+	}
+	if !next.IsZero() {
+		c.scheduleUpdate(ctx, next.Sub(now))
+	}
+	cond := v1alpha1.NewUpdatePendingCondition(target, next)
+	return &cond, nil
+}
+
+// rolledBackFrom returns the version that a cluster was rolled back from, if
+// it's still pinned to the version before it.
+func rolledBackFrom(cluster *v1alpha1.SpiceDBCluster) string {
+	if !cluster.IsStatusConditionTrue(v1alpha1.ConditionTypeRolledBack) {
+		return ""
+	}
+	if failed := cluster.Status.LastFailedHistory(); failed != nil {
+		return failed.ToVersion
+	}
+	return ""
+}
+
+func waitingForWave(cond *metav1.Condition) bool {
+	return cond != nil && cond.Reason == v1alpha1.ConditionReasonWaitingForWave
+}
+
+// soakingUpdate returns the release that a cluster following the head of its
+// channel would be updated to next if it had soaked, and when it will have.
+func (c *ValidateConfigHandler) soakingUpdate(cluster *v1alpha1.SpiceDBCluster, operatorConfig *config.OperatorConfig, validatedConfig *config.Config) (*v1alpha1.SpiceDBVersion, time.Time) {
+	current, target := cluster.Status.CurrentVersion, validatedConfig.SpiceDBVersion
+	if len(cluster.Spec.Version) > 0 || current == nil || target == nil || target.Name != current.Name {
+		return nil, time.Time{}
+	}
+	state, until, err := operatorConfig.SoakingRelease(validatedConfig.DatastoreEngine, *target, updates.NewSoak(cluster.Spec.UpdatePolicy, c.now()))
+	if err != nil || len(state.ID) == 0 {
+		return nil, time.Time{}
+	}
+	return &v1alpha1.SpiceDBVersion{Name: state.ID, Channel: target.Channel}, until
+}
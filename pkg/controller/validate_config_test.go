@@ -3,7 +3,9 @@ package controller
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
@@ -16,16 +18,25 @@ import (
 
 	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
 	"github.com/authzed/spicedb-operator/pkg/config"
+	"github.com/authzed/spicedb-operator/pkg/metadata"
 	"github.com/authzed/spicedb-operator/pkg/updates"
 )
 
 func TestValidateConfigHandler(t *testing.T) {
 	var nextKey handler.Key = "next"
+	// a saturday at 3am, in the maintenance window
+	saturday := time.Date(2024, 3, 9, 3, 0, 0, 0, time.UTC)
+	// releases are soaked against the wall clock when the config is built
+	now := time.Now().Truncate(time.Second)
 	tests := []struct {
 		name string
 
+		now            time.Time
 		cluster        *v1alpha1.SpiceDBCluster
 		existingSecret *corev1.Secret
+		fleetRollout   *updates.FleetRollout
+		fleet          func(graphHash string) []*v1alpha1.SpiceDBCluster
+		fleetErr       error
 
 		expectNext        handler.Key
 		expectEvents      []string
@@ -33,7 +44,9 @@ func TestValidateConfigHandler(t *testing.T) {
 		expectPatchStatus bool
 		expectConditions  []string
 		expectRequeue     bool
+		expectRequeueErr  bool
 		expectDone        bool
+		expectScheduled   time.Duration
 	}{
 		{
 			name: "valid config, no changes, no warnings",
@@ -52,6 +65,7 @@ func TestValidateConfigHandler(t *testing.T) {
 						Channel: "cockroachdb",
 					},
 					AvailableVersions: []v1alpha1.SpiceDBVersion{},
+					Selector:          metadata.SelectorForComponent("", metadata.ComponentSpiceDBLabelValue).String(),
 				},
 			},
 			existingSecret: &corev1.Secret{
@@ -213,6 +227,256 @@ func TestValidateConfigHandler(t *testing.T) {
 			expectPatchStatus: true,
 			expectDone:        true,
 		},
+		{
+			name:    "updates inside a maintenance window",
+			cluster: windowedCluster(&v1alpha1.UpdatePolicy{MaintenanceWindows: saturdays}),
+			existingSecret: &corev1.Secret{
+				Data: map[string][]byte{
+					"datastore_uri": []byte("uri"),
+					"preshared_key": []byte("testtest"),
+				},
+			},
+			expectPatchStatus: true,
+			expectStatusImage: "image:v3",
+			expectNext:        nextKey,
+		},
+		{
+			name:    "defers an update outside a maintenance window",
+			cluster: windowedCluster(&v1alpha1.UpdatePolicy{MaintenanceWindows: saturdays, BlackoutDates: []string{"2024-03-09"}}),
+			existingSecret: &corev1.Secret{
+				Data: map[string][]byte{
+					"datastore_uri": []byte("uri"),
+					"preshared_key": []byte("testtest"),
+				},
+			},
+			expectPatchStatus: true,
+			expectConditions:  []string{v1alpha1.ConditionTypeUpdatePending},
+			expectStatusImage: "image:v1",
+			expectScheduled:   7*24*time.Hour - time.Hour,
+			expectNext:        nextKey,
+		},
+		{
+			name: "doesn't defer an explicit version",
+			cluster: func() *v1alpha1.SpiceDBCluster {
+				c := windowedCluster(&v1alpha1.UpdatePolicy{BlackoutDates: []string{"2024-03-09"}})
+				c.Spec.Version = "w2"
+				return c
+			}(),
+			existingSecret: &corev1.Secret{
+				Data: map[string][]byte{
+					"datastore_uri": []byte("uri"),
+					"preshared_key": []byte("testtest"),
+				},
+			},
+			expectPatchStatus: true,
+			expectStatusImage: "image:v2",
+			expectNext:        nextKey,
+		},
+		{
+			name: "holds back a release that's soaking",
+			now:  now,
+			cluster: func() *v1alpha1.SpiceDBCluster {
+				c := windowedCluster(&v1alpha1.UpdatePolicy{MinimumSoak: &metav1.Duration{Duration: time.Hour}})
+				c.Status.CurrentVersion.Name = "w2"
+				c.Status.Image = "image:v2"
+				return c
+			}(),
+			existingSecret: &corev1.Secret{
+				Data: map[string][]byte{
+					"datastore_uri": []byte("uri"),
+					"preshared_key": []byte("testtest"),
+				},
+			},
+			expectPatchStatus: true,
+			expectConditions:  []string{v1alpha1.ConditionTypeUpdatePending},
+			expectStatusImage: "image:v2",
+			expectScheduled:   time.Hour,
+			expectNext:        nextKey,
+		},
+		{
+			name: "waits for the first release of the channel to soak",
+			now:  now,
+			cluster: &v1alpha1.SpiceDBCluster{
+				Spec: v1alpha1.ClusterSpec{
+					Channel:      "soaking",
+					UpdatePolicy: &v1alpha1.UpdatePolicy{MinimumSoak: &metav1.Duration{Duration: time.Hour}},
+					Config:       json.RawMessage(`{"datastoreEngine": "cockroachdb"}`),
+				},
+			},
+			existingSecret: &corev1.Secret{
+				Data: map[string][]byte{
+					"datastore_uri": []byte("uri"),
+					"preshared_key": []byte("testtest"),
+				},
+			},
+			expectPatchStatus: true,
+			expectConditions:  []string{v1alpha1.ConditionTypeUpdatePending},
+			expectScheduled:   time.Hour,
+			expectDone:        true,
+		},
+		{
+			name:         "waits for an earlier rollout wave",
+			cluster:      fleetCluster("prod"),
+			fleetRollout: devThenProd,
+			fleet: func(_ string) []*v1alpha1.SpiceDBCluster {
+				return []*v1alpha1.SpiceDBCluster{fleetCluster("dev"), fleetCluster("prod")}
+			},
+			existingSecret: &corev1.Secret{
+				Data: map[string][]byte{
+					"datastore_uri": []byte("uri"),
+					"preshared_key": []byte("testtest"),
+				},
+			},
+			expectEvents:      []string{`Normal WaitingForRolloutWave Update to w3 in channel windowed is waiting for rollout wave "dev" to finish (0 of 1 clusters updated)`},
+			expectPatchStatus: true,
+			expectConditions:  []string{v1alpha1.ConditionTypeUpdatePending},
+			expectStatusImage: "image:v1",
+			expectScheduled:   time.Minute,
+			expectNext:        nextKey,
+		},
+		{
+			name:         "waits for an earlier rollout wave to be healthy",
+			cluster:      fleetCluster("prod"),
+			fleetRollout: devThenProd,
+			fleet: func(graphHash string) []*v1alpha1.SpiceDBCluster {
+				return []*v1alpha1.SpiceDBCluster{updatedFleetCluster("dev", graphHash, saturday.Add(-30*time.Minute))}
+			},
+			existingSecret: &corev1.Secret{
+				Data: map[string][]byte{
+					"datastore_uri": []byte("uri"),
+					"preshared_key": []byte("testtest"),
+				},
+			},
+			expectEvents:      []string{`Normal WaitingForRolloutWave Update to w3 in channel windowed is waiting for rollout wave "dev" to finish (1 of 1 clusters updated)`},
+			expectPatchStatus: true,
+			expectConditions:  []string{v1alpha1.ConditionTypeUpdatePending},
+			expectStatusImage: "image:v1",
+			expectScheduled:   30 * time.Minute,
+			expectNext:        nextKey,
+		},
+		{
+			name: "starts once earlier rollout waves are healthy",
+			cluster: func() *v1alpha1.SpiceDBCluster {
+				c := fleetCluster("prod")
+				c.SetStatusCondition(v1alpha1.NewWaitingForWaveCondition(v1alpha1.SpiceDBVersion{Name: "w3", Channel: "windowed"}, "dev", 0, 1))
+				return c
+			}(),
+			fleetRollout: devThenProd,
+			fleet: func(graphHash string) []*v1alpha1.SpiceDBCluster {
+				return []*v1alpha1.SpiceDBCluster{updatedFleetCluster("dev", graphHash, saturday.Add(-2*time.Hour))}
+			},
+			existingSecret: &corev1.Secret{
+				Data: map[string][]byte{
+					"datastore_uri": []byte("uri"),
+					"preshared_key": []byte("testtest"),
+				},
+			},
+			expectEvents:      []string{"Normal RolloutWaveStarted The rollout waves before this cluster have finished, starting the update"},
+			expectPatchStatus: true,
+			expectStatusImage: "image:v3",
+			expectNext:        nextKey,
+		},
+		{
+			name:         "first rollout wave doesn't wait",
+			cluster:      fleetCluster("dev"),
+			fleetRollout: devThenProd,
+			fleet: func(_ string) []*v1alpha1.SpiceDBCluster {
+				return []*v1alpha1.SpiceDBCluster{fleetCluster("dev"), fleetCluster("prod")}
+			},
+			existingSecret: &corev1.Secret{
+				Data: map[string][]byte{
+					"datastore_uri": []byte("uri"),
+					"preshared_key": []byte("testtest"),
+				},
+			},
+			expectPatchStatus: true,
+			expectStatusImage: "image:v3",
+			expectNext:        nextKey,
+		},
+		{
+			name: "doesn't wait for clusters outside their maintenance window",
+			cluster: func() *v1alpha1.SpiceDBCluster {
+				c := fleetCluster("prod")
+				c.SetStatusCondition(v1alpha1.NewWaitingForWaveCondition(v1alpha1.SpiceDBVersion{Name: "w3", Channel: "windowed"}, "dev", 0, 1))
+				return c
+			}(),
+			fleetRollout: devThenProd,
+			fleet: func(graphHash string) []*v1alpha1.SpiceDBCluster {
+				c := fleetCluster("dev")
+				c.SetStatusCondition(v1alpha1.NewUpdatePendingCondition(v1alpha1.SpiceDBVersion{Name: "w3", Channel: "windowed"}, saturday.Add(7*24*time.Hour)))
+				return []*v1alpha1.SpiceDBCluster{c, updatedFleetCluster("dev", graphHash, saturday.Add(-2*time.Hour))}
+			},
+			existingSecret: &corev1.Secret{
+				Data: map[string][]byte{
+					"datastore_uri": []byte("uri"),
+					"preshared_key": []byte("testtest"),
+				},
+			},
+			expectEvents:      []string{"Normal RolloutWaveStarted The rollout waves before this cluster have finished, starting the update"},
+			expectPatchStatus: true,
+			expectStatusImage: "image:v3",
+			expectNext:        nextKey,
+		},
+		{
+			name:         "requeues if the fleet can't be listed",
+			cluster:      fleetCluster("prod"),
+			fleetRollout: devThenProd,
+			fleetErr:     errors.New("cache not synced"),
+			existingSecret: &corev1.Secret{
+				Data: map[string][]byte{
+					"datastore_uri": []byte("uri"),
+					"preshared_key": []byte("testtest"),
+				},
+			},
+			expectStatusImage: "image:v1",
+			expectRequeueErr:  true,
+		},
+		{
+			name:    "stays on the previous version after a rollback",
+			cluster: rolledBackCluster(),
+			existingSecret: &corev1.Secret{
+				Data: map[string][]byte{
+					"datastore_uri": []byte("uri"),
+					"preshared_key": []byte("testtest"),
+				},
+			},
+			expectPatchStatus: true,
+			expectConditions:  []string{v1alpha1.ConditionTypeRolledBack},
+			expectStatusImage: "image:v2",
+			expectNext:        nextKey,
+		},
+		{
+			name: "retries the version that was rolled back once it's set explicitly",
+			cluster: func() *v1alpha1.SpiceDBCluster {
+				c := rolledBackCluster()
+				c.Spec.Version = "w3"
+				return c
+			}(),
+			existingSecret: &corev1.Secret{
+				Data: map[string][]byte{
+					"datastore_uri": []byte("uri"),
+					"preshared_key": []byte("testtest"),
+				},
+			},
+			expectPatchStatus: true,
+			expectStatusImage: "image:v3",
+			expectNext:        nextKey,
+		},
+		{
+			name:    "invalid maintenance window",
+			cluster: windowedCluster(&v1alpha1.UpdatePolicy{TimeZone: "Mars/Olympus_Mons"}),
+			existingSecret: &corev1.Secret{
+				Data: map[string][]byte{
+					"datastore_uri": []byte("uri"),
+					"preshared_key": []byte("testtest"),
+				},
+			},
+			expectEvents:      []string{"Warning InvalidSpiceDBConfig invalid config: invalid updatePolicy.timeZone \"Mars/Olympus_Mons\": unknown time zone Mars/Olympus_Mons"},
+			expectConditions:  []string{"ValidatingFailed"},
+			expectStatusImage: "image:v1",
+			expectPatchStatus: true,
+			expectDone:        true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -226,8 +490,9 @@ func TestValidateConfigHandler(t *testing.T) {
 			ctx = CtxClusterNN.WithValue(ctx, types.NamespacedName{Namespace: "test", Name: "test"})
 			ctx = CtxCluster.WithValue(ctx, tt.cluster)
 			ctx = CtxCluster.WithValue(ctx, tt.cluster)
-			ctx = CtxOperatorConfig.WithValue(ctx, &config.OperatorConfig{
-				ImageName: "image",
+			operatorConfig := &config.OperatorConfig{
+				ImageName:    "image",
+				FleetRollout: tt.fleetRollout,
 				UpdateGraph: updates.UpdateGraph{
 					Channels: []updates.Channel{
 						{
@@ -238,15 +503,50 @@ func TestValidateConfigHandler(t *testing.T) {
 							},
 							Edges: map[string][]string{"v1": {}},
 						},
+						{
+							Name:     "windowed",
+							Metadata: map[string]string{"datastore": "cockroachdb"},
+							Nodes: []updates.State{
+								{ID: "w3", Tag: "v3", ReleasedAt: &metav1.Time{Time: now}},
+								{ID: "w2", Tag: "v2"},
+								{ID: "w1", Tag: "v1"},
+							},
+							Edges: map[string][]string{"w1": {"w2", "w3"}, "w2": {"w3"}},
+						},
+						{
+							Name:     "soaking",
+							Metadata: map[string]string{"datastore": "cockroachdb"},
+							Nodes: []updates.State{
+								{ID: "s1", Tag: "v1", ReleasedAt: &metav1.Time{Time: now}},
+							},
+							Edges: map[string][]string{"s1": {}},
+						},
 					},
 				},
-			})
+			}
+			ctx = CtxOperatorConfig.WithValue(ctx, operatorConfig)
 			var called handler.Key
+			var scheduled time.Duration
 			h := &ValidateConfigHandler{
 				patchStatus: func(_ context.Context, _ *v1alpha1.SpiceDBCluster) error {
 					patchCalled = true
 					return nil
 				},
+				now: func() time.Time {
+					if tt.now.IsZero() {
+						return saturday
+					}
+					return tt.now
+				},
+				listClusters: func() ([]*v1alpha1.SpiceDBCluster, error) {
+					if tt.fleetErr != nil {
+						return nil, tt.fleetErr
+					}
+					return tt.fleet(operatorConfig.UpdateGraph.Hash()), nil
+				},
+				scheduleUpdate: func(_ context.Context, after time.Duration) {
+					scheduled = after
+				},
 				recorder: recorder,
 				next: handler.ContextHandlerFunc(func(_ context.Context) {
 					called = nextKey
@@ -264,8 +564,139 @@ func TestValidateConfigHandler(t *testing.T) {
 			require.Equal(t, tt.expectPatchStatus, patchCalled)
 			require.Equal(t, tt.expectNext, called)
 			require.Equal(t, tt.expectRequeue, ctrls.RequeueCallCount() == 1)
+			require.Equal(t, tt.expectRequeueErr, ctrls.RequeueErrCallCount() == 1)
 			require.Equal(t, tt.expectDone, ctrls.DoneCallCount() == 1)
+			require.Equal(t, tt.expectScheduled, scheduled)
 			ExpectEvents(t, recorder, tt.expectEvents)
 		})
 	}
 }
+
+// saturdays from 2am to 6am
+var saturdays = []v1alpha1.MaintenanceWindow{{Schedule: "0 2 * * SAT", Duration: metav1.Duration{Duration: 4 * time.Hour}}}
+
+func windowedCluster(policy *v1alpha1.UpdatePolicy) *v1alpha1.SpiceDBCluster {
+	return &v1alpha1.SpiceDBCluster{
+		Spec: v1alpha1.ClusterSpec{
+			Channel:      "windowed",
+			UpdatePolicy: policy,
+			Config: json.RawMessage(`{
+				"datastoreEngine": "cockroachdb",
+				"tlsSecretName":   "secret"
+			}`),
+		},
+		Status: v1alpha1.ClusterStatus{
+			Image:          "image:v1",
+			CurrentVersion: &v1alpha1.SpiceDBVersion{Name: "w1", Channel: "windowed"},
+		},
+	}
+}
+
+// dev clusters have to be healthy for an hour before prod clusters update
+var devThenProd = &updates.FleetRollout{Waves: []updates.RolloutWave{
+	{Name: "dev", Selector: metav1.LabelSelector{MatchLabels: map[string]string{"tier": "dev"}}, HealthyFor: metav1.Duration{Duration: time.Hour}},
+	{Name: "prod", Selector: metav1.LabelSelector{MatchLabels: map[string]string{"tier": "prod"}}},
+}}
+
+func fleetCluster(tier string) *v1alpha1.SpiceDBCluster {
+	c := windowedCluster(nil)
+	c.Name = tier
+	c.Labels = map[string]string{"tier": tier}
+	return c
+}
+
+// updatedFleetCluster returns a cluster that has been ready on the graph
+// since readySince.
+func updatedFleetCluster(tier, graphHash string, readySince time.Time) *v1alpha1.SpiceDBCluster {
+	c := fleetCluster(tier)
+	c.Status.UpdateGraphHash = graphHash
+	c.Status.CurrentVersion.Name = "w3"
+	c.Status.Conditions = []metav1.Condition{{
+		Type:               v1alpha1.ConditionTypeReady,
+		Status:             metav1.ConditionTrue,
+		LastTransitionTime: metav1.NewTime(readySince),
+	}}
+	return c
+}
+
+// rolledBackCluster returns a cluster that failed to roll out w3 and went back
+// to w2.
+func rolledBackCluster() *v1alpha1.SpiceDBCluster {
+	c := windowedCluster(nil)
+	c.Status.Image = "image:v2"
+	c.Status.CurrentVersion.Name = "w2"
+	c.Status.History = []v1alpha1.HistoryEntry{
+		{ToVersion: "w2", Channel: "windowed", Image: "image:v2", Outcome: v1alpha1.HistoryOutcomeSucceeded},
+		{FromVersion: "w2", ToVersion: "w3", Channel: "windowed", Image: "image:v3", Outcome: v1alpha1.HistoryOutcomeFailed},
+	}
+	c.SetStatusCondition(v1alpha1.NewRolledBackCondition("w3", "w2", "image:v3 failed to start 3 times"))
+	return c
+}
+
+func TestAbandonedStep(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	started := metav1.NewTime(now.Add(-time.Hour))
+	succeeded := v1alpha1.HistoryEntry{ToVersion: "v1", Channel: "stable", Image: "image:v1", Outcome: v1alpha1.HistoryOutcomeSucceeded}
+	rollingOut := func(image, version string, history ...v1alpha1.HistoryEntry) *v1alpha1.SpiceDBCluster {
+		return &v1alpha1.SpiceDBCluster{Status: v1alpha1.ClusterStatus{
+			Image:          image,
+			CurrentVersion: &v1alpha1.SpiceDBVersion{Name: version, Channel: "stable"},
+			Rollout:        &v1alpha1.RolloutStatus{Image: image, PreviousImage: "image:v1", StartTime: &started},
+			History:        history,
+		}}
+	}
+
+	tests := []struct {
+		name    string
+		cluster *v1alpha1.SpiceDBCluster
+		image   string
+
+		expectEntry *v1alpha1.HistoryEntry
+	}{
+		{
+			name:    "rollout continues",
+			cluster: rollingOut("image:v2", "v2", succeeded),
+			image:   "image:v2",
+		},
+		{
+			name: "finished rollout",
+			cluster: func() *v1alpha1.SpiceDBCluster {
+				c := rollingOut("image:v2", "v2", succeeded)
+				c.Status.Rollout.CompletionTime = &started
+				return c
+			}(),
+			image: "image:v3",
+		},
+		{
+			name:    "superseded rollout",
+			cluster: rollingOut("image:v2", "v2", succeeded),
+			image:   "image:v3",
+			expectEntry: &v1alpha1.HistoryEntry{
+				FromVersion: "v1",
+				ToVersion:   "v2",
+				Channel:     "stable",
+				Image:       "image:v2",
+				StartTime:   &started,
+				EndTime:     &metav1.Time{Time: now},
+				Outcome:     v1alpha1.HistoryOutcomeFailed,
+			},
+		},
+		{
+			name:    "superseded config change",
+			cluster: rollingOut("image:v1", "v1", succeeded),
+			image:   "image:v2",
+		},
+		{
+			name: "superseded step that already failed",
+			cluster: rollingOut("image:v2", "v2", succeeded, v1alpha1.HistoryEntry{
+				FromVersion: "v1", ToVersion: "v2", Channel: "stable", Image: "image:v2", Outcome: v1alpha1.HistoryOutcomeFailed,
+			}),
+			image: "image:v3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expectEntry, abandonedStep(tt.cluster, tt.image, now))
+		})
+	}
+}
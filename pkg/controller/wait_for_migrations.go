@@ -33,6 +33,13 @@ func (m *WaitForMigrationsHandler) Handle(ctx context.Context) {
 		err := fmt.Errorf("migration job failed: %s", c.Message)
 		runtime.HandleError(err)
 		currentStatus.SetStatusCondition(v1alpha1.NewMigrationFailedCondition(config.DatastoreEngine, "head", err))
+		// the failed job is seen again if the cluster is unpaused without
+		// changing anything
+		if history := currentStatus.Status.History; len(history) == 0 ||
+			history[len(history)-1].Outcome != v1alpha1.HistoryOutcomeFailed ||
+			history[len(history)-1].MigrationJob != job.Name {
+			currentStatus.Status.AppendHistory(newHistoryEntry(currentStatus, job, v1alpha1.HistoryOutcomeFailed, c.LastTransitionTime))
+		}
 		ctx = CtxSelfPauseObject.WithValue(ctx, currentStatus)
 		m.nextSelfPause.Handle(ctx)
 		return
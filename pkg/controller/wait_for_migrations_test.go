@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/require"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/record"
 
 	"github.com/authzed/controller-idioms/handler"
@@ -18,14 +19,18 @@ import (
 )
 
 func TestWaitForMigrationsHandler(t *testing.T) {
+	start := metav1.NewTime(time.Unix(100, 0))
+	end := metav1.NewTime(time.Unix(200, 0))
 	tests := []struct {
 		name string
 
 		migrationJob *batchv1.Job
+		history      []v1alpha1.HistoryEntry
 
 		expectNext         handler.Key
 		expectRequeueAfter time.Duration
 		expectEvents       []string
+		expectHistory      []v1alpha1.HistoryEntry
 	}{
 		{
 			name:               "job is still running, requeue with delay",
@@ -45,11 +50,43 @@ func TestWaitForMigrationsHandler(t *testing.T) {
 		},
 		{
 			name: "job failed, pause reconciliation",
-			migrationJob: &batchv1.Job{Status: batchv1.JobStatus{Conditions: []batchv1.JobCondition{{
-				Type:   batchv1.JobFailed,
-				Status: corev1.ConditionTrue,
-			}}}},
+			migrationJob: &batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{Name: "migrate"},
+				Status: batchv1.JobStatus{StartTime: &start, Conditions: []batchv1.JobCondition{{
+					Type:               batchv1.JobFailed,
+					Status:             corev1.ConditionTrue,
+					LastTransitionTime: end,
+				}}},
+			},
+			history:    []v1alpha1.HistoryEntry{{ToVersion: "v1", Outcome: v1alpha1.HistoryOutcomeSucceeded}},
 			expectNext: HandlerSelfPauseKey,
+			expectHistory: []v1alpha1.HistoryEntry{
+				{ToVersion: "v1", Outcome: v1alpha1.HistoryOutcomeSucceeded},
+				{
+					FromVersion:  "v1",
+					ToVersion:    "v2",
+					Channel:      "stable",
+					Image:        "test",
+					Migration:    "head",
+					MigrationJob: "migrate",
+					StartTime:    &start,
+					EndTime:      &end,
+					Outcome:      v1alpha1.HistoryOutcomeFailed,
+				},
+			},
+		},
+		{
+			name: "job failed again after unpausing, don't record it twice",
+			migrationJob: &batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{Name: "migrate"},
+				Status: batchv1.JobStatus{Conditions: []batchv1.JobCondition{{
+					Type:   batchv1.JobFailed,
+					Status: corev1.ConditionTrue,
+				}}},
+			},
+			history:       []v1alpha1.HistoryEntry{{MigrationJob: "migrate", Outcome: v1alpha1.HistoryOutcomeFailed}},
+			expectNext:    HandlerSelfPauseKey,
+			expectHistory: []v1alpha1.HistoryEntry{{MigrationJob: "migrate", Outcome: v1alpha1.HistoryOutcomeFailed}},
 		},
 	}
 	for _, tt := range tests {
@@ -58,7 +95,13 @@ func TestWaitForMigrationsHandler(t *testing.T) {
 
 			ctx := CtxConfig.WithValue(context.Background(), &config.Config{MigrationConfig: config.MigrationConfig{TargetSpiceDBImage: "test"}})
 			ctx = QueueOps.WithValue(ctx, ctrls)
-			ctx = CtxCluster.WithValue(ctx, &v1alpha1.SpiceDBCluster{})
+			cluster := &v1alpha1.SpiceDBCluster{Status: v1alpha1.ClusterStatus{
+				Image:          "test",
+				Migration:      "head",
+				CurrentVersion: &v1alpha1.SpiceDBVersion{Name: "v2", Channel: "stable"},
+				History:        tt.history,
+			}}
+			ctx = CtxCluster.WithValue(ctx, cluster)
 			ctx = CtxCurrentMigrationJob.WithValue(ctx, tt.migrationJob)
 
 			recorder := record.NewFakeRecorder(1)
@@ -77,6 +120,7 @@ func TestWaitForMigrationsHandler(t *testing.T) {
 
 			require.Equal(t, tt.expectNext, called)
 			ExpectEvents(t, recorder, tt.expectEvents)
+			require.Equal(t, tt.expectHistory, cluster.Status.History)
 
 			if tt.expectRequeueAfter != 0 {
 				require.Equal(t, 1, ctrls.RequeueAfterCallCount())
@@ -3,8 +3,15 @@ package crds
 import (
 	"context"
 	"embed"
+	"fmt"
 
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/retry"
 
 	libbootstrap "github.com/authzed/controller-idioms/bootstrap"
 )
@@ -12,6 +19,68 @@ import (
 //go:embed *.yaml
 var crdFS embed.FS
 
+// BootstrapCRD installs or updates the CRDs. A SpiceDBCluster CRD that
+// already converts between versions with a webhook keeps its conversion and
+// the versions it serves, since the embedded CRD only serves v1alpha1.
 func BootstrapCRD(ctx context.Context, restConfig *rest.Config) error {
-	return libbootstrap.CRDs(ctx, restConfig, crdFS, ".")
+	c, err := clientset.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+	crdClient := c.ApiextensionsV1().CustomResourceDefinitions()
+	existing, err := crdClient.Get(ctx, SpiceDBClusterCRDName, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) || (err == nil && !convertsWithWebhook(existing)) {
+		return libbootstrap.CRDs(ctx, restConfig, crdFS, ".")
+	}
+	if err != nil {
+		return fmt.Errorf("failed when fetching CRD %q: %w", SpiceDBClusterCRDName, err)
+	}
+
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		got, err := crdClient.Get(ctx, SpiceDBClusterCRDName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		crd, err := embeddedCRD()
+		if err != nil {
+			return err
+		}
+		keepConversion(crd, got)
+		crd.SetResourceVersion(got.GetResourceVersion())
+		_, err = crdClient.Update(ctx, crd, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// embeddedCRD returns the SpiceDBCluster CRD that ships with the operator.
+func embeddedCRD() (*apiextensionsv1.CustomResourceDefinition, error) {
+	contents, err := crdFS.ReadFile("authzed.com_spicedbclusters.yaml")
+	if err != nil {
+		return nil, err
+	}
+	var crd apiextensionsv1.CustomResourceDefinition
+	if err := yaml.Unmarshal(contents, &crd); err != nil {
+		return nil, err
+	}
+	return &crd, nil
+}
+
+func convertsWithWebhook(crd *apiextensionsv1.CustomResourceDefinition) bool {
+	return crd.Spec.Conversion != nil && crd.Spec.Conversion.Strategy == apiextensionsv1.WebhookConverter
+}
+
+// keepConversion copies the conversion of the existing CRD onto crd, and
+// keeps serving the versions that the existing CRD serves.
+func keepConversion(crd, existing *apiextensionsv1.CustomResourceDefinition) {
+	if !convertsWithWebhook(existing) {
+		return
+	}
+	crd.Spec.Conversion = existing.Spec.Conversion.DeepCopy()
+	served := make(map[string]bool, len(existing.Spec.Versions))
+	for _, v := range existing.Spec.Versions {
+		served[v.Name] = v.Served
+	}
+	for i, v := range crd.Spec.Versions {
+		crd.Spec.Versions[i].Served = v.Served || served[v.Name]
+	}
 }
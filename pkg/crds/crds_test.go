@@ -0,0 +1,49 @@
+package crds
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+func served(crd *apiextensionsv1.CustomResourceDefinition) map[string]bool {
+	versions := make(map[string]bool, len(crd.Spec.Versions))
+	for _, v := range crd.Spec.Versions {
+		versions[v.Name] = v.Served
+	}
+	return versions
+}
+
+func TestEmbeddedCRDOnlyServesStorageVersion(t *testing.T) {
+	crd, err := embeddedCRD()
+	require.NoError(t, err)
+	require.Nil(t, crd.Spec.Conversion)
+	require.Equal(t, map[string]bool{"v1alpha1": true, "v1beta1": false}, served(crd))
+}
+
+func TestKeepConversion(t *testing.T) {
+	webhook := &apiextensionsv1.CustomResourceConversion{
+		Strategy: apiextensionsv1.WebhookConverter,
+		Webhook:  &apiextensionsv1.WebhookConversion{ConversionReviewVersions: []string{"v1"}},
+	}
+
+	crd, err := embeddedCRD()
+	require.NoError(t, err)
+	existing := crd.DeepCopy()
+	existing.Spec.Conversion = webhook
+	for i := range existing.Spec.Versions {
+		existing.Spec.Versions[i].Served = true
+	}
+	keepConversion(crd, existing)
+	require.Equal(t, webhook, crd.Spec.Conversion)
+	require.Equal(t, map[string]bool{"v1alpha1": true, "v1beta1": true}, served(crd))
+
+	crd, err = embeddedCRD()
+	require.NoError(t, err)
+	existing = crd.DeepCopy()
+	existing.Spec.Conversion = &apiextensionsv1.CustomResourceConversion{Strategy: apiextensionsv1.NoneConverter}
+	keepConversion(crd, existing)
+	require.Nil(t, crd.Spec.Conversion)
+	require.Equal(t, map[string]bool{"v1alpha1": true, "v1beta1": false}, served(crd))
+}
@@ -0,0 +1,130 @@
+package crds
+
+import (
+	"context"
+	"fmt"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2/textlogger"
+	"k8s.io/utils/ptr"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed"
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+)
+
+// +kubebuilder:rbac:groups="apiextensions.k8s.io",resources=customresourcedefinitions,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups="apiextensions.k8s.io",resources=customresourcedefinitions/status,verbs=get;update;patch
+
+// SpiceDBClusterCRDName is the name of the SpiceDBCluster CRD
+const SpiceDBClusterCRDName = v1alpha1.SpiceDBClusterResourceName + "." + authzed.GroupName
+
+// EnableConversionWebhook configures the SpiceDBCluster CRD to convert between
+// versions by calling the operator's conversion webhook via the given service,
+// and serves every version once they can be converted.
+func EnableConversionWebhook(ctx context.Context, restConfig *rest.Config, service types.NamespacedName, path string, caBundle []byte) error {
+	c, err := clientset.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+	crdClient := c.ApiextensionsV1().CustomResourceDefinitions()
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		crd, err := crdClient.Get(ctx, SpiceDBClusterCRDName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		crd.Spec.Conversion = &apiextensionsv1.CustomResourceConversion{
+			Strategy: apiextensionsv1.WebhookConverter,
+			Webhook: &apiextensionsv1.WebhookConversion{
+				ClientConfig: &apiextensionsv1.WebhookClientConfig{
+					Service: &apiextensionsv1.ServiceReference{
+						Namespace: service.Namespace,
+						Name:      service.Name,
+						Path:      ptr.To(path),
+					},
+					CABundle: caBundle,
+				},
+				ConversionReviewVersions: []string{"v1"},
+			},
+		}
+		for i := range crd.Spec.Versions {
+			crd.Spec.Versions[i].Served = true
+		}
+		_, err = crdClient.Update(ctx, crd, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// MigrateStoredVersions rewrites every SpiceDBCluster so that it is persisted
+// in the current storage version of the CRD, and then removes all other
+// versions from the CRD's `status.storedVersions`. After this completes,
+// versions that are no longer the storage version can safely stop being
+// served.
+func MigrateStoredVersions(ctx context.Context, restConfig *rest.Config) error {
+	logger := textlogger.NewLogger(textlogger.NewConfig())
+
+	c, err := clientset.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+	dclient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+	crdClient := c.ApiextensionsV1().CustomResourceDefinitions()
+
+	crd, err := crdClient.Get(ctx, SpiceDBClusterCRDName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	var storageVersion string
+	for _, v := range crd.Spec.Versions {
+		if v.Storage {
+			storageVersion = v.Name
+			break
+		}
+	}
+	if storageVersion == "" {
+		return fmt.Errorf("no storage version found for %s", SpiceDBClusterCRDName)
+	}
+	if len(crd.Status.StoredVersions) == 1 && crd.Status.StoredVersions[0] == storageVersion {
+		logger.V(3).Info("stored versions already migrated", "version", storageVersion)
+		return nil
+	}
+
+	gvr := v1alpha1.SchemeGroupVersion.WithResource(v1alpha1.SpiceDBClusterResourceName)
+	gvr.Version = storageVersion
+	clusters, err := dclient.Resource(gvr).Namespace(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, cluster := range clusters.Items {
+		// an update with no changes re-encodes the object in the storage version
+		if err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+			latest, err := dclient.Resource(gvr).Namespace(cluster.GetNamespace()).Get(ctx, cluster.GetName(), metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			_, err = dclient.Resource(gvr).Namespace(cluster.GetNamespace()).Update(ctx, latest, metav1.UpdateOptions{})
+			return err
+		}); err != nil {
+			return fmt.Errorf("unable to migrate %s/%s to %s: %w", cluster.GetNamespace(), cluster.GetName(), storageVersion, err)
+		}
+		logger.V(4).Info("migrated stored version", "namespace", cluster.GetNamespace(), "name", cluster.GetName(), "version", storageVersion)
+	}
+
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		crd, err := crdClient.Get(ctx, SpiceDBClusterCRDName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		crd.Status.StoredVersions = []string{storageVersion}
+		_, err = crdClient.UpdateStatus(ctx, crd, metav1.UpdateOptions{})
+		return err
+	})
+}
@@ -16,29 +16,56 @@ import (
 )
 
 const (
-	OwningClusterIndex              = "owning-cluster"
-	OperatorManagedLabelKey         = "authzed.com/managed-by"
-	OperatorManagedLabelValue       = "operator"
-	OwnerLabelKey                   = "authzed.com/cluster"
-	OwnerAnnotationKeyPrefix        = "authzed.com.cluster-owner/"
-	ComponentLabelKey               = "authzed.com/cluster-component"
-	ComponentSpiceDBLabelValue      = "spicedb"
-	ComponentMigrationJobLabelValue = "migration-job"
-	ComponentServiceAccountLabel    = "spicedb-serviceaccount"
-	ComponentRoleLabel              = "spicedb-role"
-	ComponentServiceLabel           = "spicedb-service"
-	ComponentRoleBindingLabel       = "spicedb-rolebinding"
-	SpiceDBMigrationRequirementsKey = "authzed.com/spicedb-migration"
-	SpiceDBTargetMigrationKey       = "authzed.com/spicedb-target-migration"
-	SpiceDBSecretRequirementsKey    = "authzed.com/spicedb-secret" // nolint: gosec
-	SpiceDBConfigKey                = "authzed.com/spicedb-configuration"
-	FieldManager                    = "spicedb-operator"
+	OwningClusterIndex                = "owning-cluster"
+	AdoptingClusterIndex              = "adopting-cluster"
+	ReferencingClusterIndex           = "referencing-cluster"
+	OperatorManagedLabelKey           = "authzed.com/managed-by"
+	OperatorManagedLabelValue         = "operator"
+	OwnerLabelKey                     = "authzed.com/cluster"
+	OwnerNamespaceLabelKey            = "authzed.com/cluster-namespace"
+	OwnerAnnotationKeyPrefix          = "authzed.com.cluster-owner/"
+	ReferenceAnnotationKeyPrefix      = "authzed.com.cluster-reference/"
+	ComponentLabelKey                 = "authzed.com/cluster-component"
+	ComponentSpiceDBLabelValue        = "spicedb"
+	ComponentMigrationJobLabelValue   = "migration-job"
+	ComponentServiceAccountLabel      = "spicedb-serviceaccount"
+	ComponentRoleLabel                = "spicedb-role"
+	ComponentServiceLabel             = "spicedb-service"
+	ComponentDispatchServiceLabel     = "spicedb-dispatch-service"
+	ComponentRoleBindingLabel         = "spicedb-rolebinding"
+	ComponentHPALabel                 = "spicedb-hpa"
+	ComponentPDBLabel                 = "spicedb-pdb"
+	ComponentNetworkPolicyLabel       = "spicedb-networkpolicy"
+	ComponentServiceMonitorLabel      = "spicedb-servicemonitor"
+	ComponentPodMonitorLabel          = "spicedb-podmonitor"
+	ComponentPrometheusRuleLabel      = "spicedb-prometheusrule"
+	ComponentIngressLabel             = "spicedb-ingress"
+	ComponentGRPCRouteLabel           = "spicedb-grpcroute"
+	ComponentHTTPRouteLabel           = "spicedb-httproute"
+	ComponentGeneratedSecretLabel     = "spicedb-secret"
+	ComponentSelfSignedCALabel        = "spicedb-ca"
+	ComponentSelfSignedTLSLabel       = "spicedb-tls"
+	ComponentCAConfigMapLabel         = "spicedb-ca-configmap"
+	ComponentCertificateLabel         = "spicedb-certificate"
+	ComponentDispatchCertificateLabel = "spicedb-dispatch-certificate"
+	ComponentCertificateSecretLabel   = "spicedb-certificate-secret"
+	ComponentConnectionSecretLabel    = "spicedb-connection-secret"
+	SpiceDBMigrationRequirementsKey   = "authzed.com/spicedb-migration"
+	SpiceDBTargetMigrationKey         = "authzed.com/spicedb-target-migration"
+	SpiceDBSecretRequirementsKey      = "authzed.com/spicedb-secret" // nolint: gosec
+	SpiceDBConfigKey                  = "authzed.com/spicedb-configuration"
+	PresharedKeyRotationKey           = "authzed.com/preshared-key-rotation"
+	ApprovedMigrationKey              = "authzed.com/approved-migration"
+	ConnectionSecretFinalizer         = "authzed.com/connection-secret"
+	FieldManager                      = "spicedb-operator"
+	FinalizerFieldManager             = "spicedb-operator-finalizers"
 )
 
 var (
-	ApplyForceOwned          = metav1.ApplyOptions{FieldManager: FieldManager, Force: true}
-	PatchForceOwned          = metav1.PatchOptions{FieldManager: FieldManager, Force: ptr.To(true)}
-	ManagedDependentSelector = MustParseSelector(fmt.Sprintf("%s=%s", OperatorManagedLabelKey, OperatorManagedLabelValue))
+	ApplyForceOwned           = metav1.ApplyOptions{FieldManager: FieldManager, Force: true}
+	PatchForceOwned           = metav1.PatchOptions{FieldManager: FieldManager, Force: ptr.To(true)}
+	PatchFinalizersForceOwned = metav1.PatchOptions{FieldManager: FinalizerFieldManager, Force: ptr.To(true)}
+	ManagedDependentSelector  = MustParseSelector(fmt.Sprintf("%s=%s", OperatorManagedLabelKey, OperatorManagedLabelValue))
 )
 
 func SelectorForComponent(owner, component string) labels.Selector {
@@ -94,15 +121,34 @@ func GetClusterKeyFromMeta(in interface{}) ([]string, error) {
 	if err != nil {
 		return nil, err
 	}
+	referencingNames, err := GetReferencingClusterKeyFromMeta(obj)
+	if err != nil {
+		return nil, err
+	}
+	clusterNames = append(clusterNames, referencingNames...)
 
 	objLabels := objMeta.GetLabels()
 	if len(objLabels) > 0 {
 		clusterName, ok := objLabels[OwnerLabelKey]
 		if ok {
-			nn := types.NamespacedName{Name: clusterName, Namespace: objMeta.GetNamespace()}
+			// objects published outside the cluster's namespace name it
+			namespace, ok := objLabels[OwnerNamespaceLabelKey]
+			if !ok {
+				namespace = objMeta.GetNamespace()
+			}
+			nn := types.NamespacedName{Name: clusterName, Namespace: namespace}
 			clusterNames = append(clusterNames, nn.String())
 		}
 	}
 
 	return clusterNames, nil
 }
+
+// GetAdoptingClusterKeyFromMeta indexes objects by the clusters that adopted
+// them as their config secret. Unlike GetClusterKeyFromMeta it ignores the
+// owner label, so objects the operator creates are never seen as adopted.
+var GetAdoptingClusterKeyFromMeta = adopt.OwnerKeysFromMeta(OwnerAnnotationKeyPrefix)
+
+// GetReferencingClusterKeyFromMeta indexes objects by the clusters whose
+// config references them (i.e. TLS or CA secrets).
+var GetReferencingClusterKeyFromMeta = adopt.OwnerKeysFromMeta(ReferenceAnnotationKeyPrefix)
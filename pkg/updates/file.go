@@ -8,6 +8,7 @@ import (
 	"github.com/samber/lo"
 	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 
 	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
@@ -86,6 +87,16 @@ type State struct {
 	Phase     string `json:"phase,omitempty"`
 	Digest    string `json:"digest,omitempty"`
 
+	// Dispatch identifies the dispatch API of the release. Releases with
+	// different dispatch APIs can't dispatch to each other, so an edge
+	// between them is rolled out blue/green instead of pod by pod.
+	Dispatch string `json:"dispatch,omitempty"`
+
+	// ReleasedAt is when the release was published. Clusters aren't updated
+	// to it automatically until it has soaked for the channel's minimum soak
+	// time.
+	ReleasedAt *metav1.Time `json:"releasedAt,omitempty"`
+
 	// Deprecated releases can be updated from, but not to
 	Deprecated bool `json:"-"`
 }
@@ -109,12 +120,20 @@ func (g *UpdateGraph) DefaultChannelForDatastore(datastore string) (string, erro
 
 // SourceForChannel returns a channel represented as a Source for querying
 func (g *UpdateGraph) SourceForChannel(engine, channel string) (Source, error) {
+	c, ok := g.channel(engine, channel)
+	if !ok {
+		return nil, fmt.Errorf("no channel for %q found with name %q", engine, channel)
+	}
+	return NewMemorySource(c.Nodes, c.Edges)
+}
+
+func (g *UpdateGraph) channel(engine, channel string) (Channel, bool) {
 	for _, c := range g.Channels {
 		if strings.EqualFold(c.Name, channel) && strings.EqualFold(c.Metadata["datastore"], engine) {
-			return NewMemorySource(c.Nodes, c.Edges)
+			return c, true
 		}
 	}
-	return nil, fmt.Errorf("no channel for %q found with name %q", engine, channel)
+	return Channel{}, false
 }
 
 // Copy returns a copy of the graph. The controller gets a copy so that
@@ -124,18 +143,23 @@ func (g *UpdateGraph) Copy() UpdateGraph {
 }
 
 // AvailableVersions traverses an UpdateGraph and collects a list of the
-// safe versions for updating from the provided currentVersion.
-func (g *UpdateGraph) AvailableVersions(engine string, v v1alpha1.SpiceDBVersion) ([]v1alpha1.SpiceDBVersion, error) {
-	source, err := g.SourceForChannel(engine, v.Channel)
+// safe versions for updating from the provided currentVersion. Releases that
+// are still soaking aren't included.
+func (g *UpdateGraph) AvailableVersions(engine string, v v1alpha1.SpiceDBVersion, soak Soak) ([]v1alpha1.SpiceDBVersion, error) {
+	source, held, _, err := g.soakedSource(engine, v.Channel, soak)
 	if err != nil {
 		return nil, fmt.Errorf("no source found for channel %q, can't compute available versions: %w", v.Channel, err)
 	}
 
 	availableVersions := make([]v1alpha1.SpiceDBVersion, 0)
-	nextWithoutMigrations := source.NextVersionWithoutMigrations(v.Name)
-	latest := source.LatestVersion(v.Name)
+	var nextWithoutMigrations, next, latest string
+	// nothing newer than a release that's soaking has soaked
+	if !slices.ContainsFunc(held, func(s State) bool { return s.ID == v.Name }) {
+		nextWithoutMigrations = source.NextVersionWithoutMigrations(v.Name)
+		next = source.NextVersion(v.Name)
+		latest = source.LatestVersion(v.Name)
+	}
 	if len(nextWithoutMigrations) > 0 {
-		// TODO: should also account for downtime, i.e. dispatch api changes
 		nextDirectVersion := v1alpha1.SpiceDBVersion{
 			Name:        nextWithoutMigrations,
 			Channel:     v.Channel,
@@ -146,10 +170,10 @@ func (g *UpdateGraph) AvailableVersions(engine string, v v1alpha1.SpiceDBVersion
 			nextDirectVersion.Description += ", head of channel"
 			nextDirectVersion.Attributes = append(nextDirectVersion.Attributes, v1alpha1.SpiceDBVersionAttributesLatest)
 		}
+		markIncompatibleDispatch(source, v.Name, &nextDirectVersion)
 		availableVersions = append(availableVersions, nextDirectVersion)
 	}
 
-	next := source.NextVersion(v.Name)
 	if len(next) > 0 && next != nextWithoutMigrations {
 		nextVersion := v1alpha1.SpiceDBVersion{
 			Name:        next,
@@ -161,15 +185,18 @@ func (g *UpdateGraph) AvailableVersions(engine string, v v1alpha1.SpiceDBVersion
 			nextVersion.Description += ", head of channel"
 			nextVersion.Attributes = append(nextVersion.Attributes, v1alpha1.SpiceDBVersionAttributesLatest)
 		}
+		markIncompatibleDispatch(source, v.Name, &nextVersion)
 		availableVersions = append(availableVersions, nextVersion)
 	}
 	if len(latest) > 0 && next != latest && nextWithoutMigrations != latest {
-		availableVersions = append(availableVersions, v1alpha1.SpiceDBVersion{
+		latestVersion := v1alpha1.SpiceDBVersion{
 			Name:        latest,
 			Channel:     v.Channel,
 			Attributes:  []v1alpha1.SpiceDBVersionAttributes{v1alpha1.SpiceDBVersionAttributesLatest, v1alpha1.SpiceDBVersionAttributesMigration},
 			Description: "head of the channel, multiple updates will run in sequence",
-		})
+		}
+		markIncompatibleDispatch(source, v.Name, &latestVersion)
+		availableVersions = append(availableVersions, latestVersion)
 	}
 
 	// Check for options in other channels, but only show the safest update for
@@ -181,32 +208,100 @@ func (g *UpdateGraph) AvailableVersions(engine string, v v1alpha1.SpiceDBVersion
 		if c.Metadata["datastore"] != engine {
 			continue
 		}
-		source, err := g.SourceForChannel(engine, c.Name)
+		source, _, _, err := g.soakedSource(engine, c.Name, soak)
 		if err != nil {
 			continue
 		}
 		if next := source.NextVersionWithoutMigrations(v.Name); len(next) > 0 {
-			availableVersions = append(availableVersions, v1alpha1.SpiceDBVersion{
+			nextVersion := v1alpha1.SpiceDBVersion{
 				Name:        next,
 				Channel:     c.Name,
 				Attributes:  []v1alpha1.SpiceDBVersionAttributes{v1alpha1.SpiceDBVersionAttributesNext},
 				Description: "direct update with no migrations, different channel",
-			})
+			}
+			markIncompatibleDispatch(source, v.Name, &nextVersion)
+			availableVersions = append(availableVersions, nextVersion)
 			continue
 		}
 		if next := source.NextVersion(v.Name); len(next) > 0 {
-			availableVersions = append(availableVersions, v1alpha1.SpiceDBVersion{
+			nextVersion := v1alpha1.SpiceDBVersion{
 				Name:        next,
 				Channel:     c.Name,
 				Attributes:  []v1alpha1.SpiceDBVersionAttributes{v1alpha1.SpiceDBVersionAttributesNext, v1alpha1.SpiceDBVersionAttributesMigration},
 				Description: "update will run a migration, different channel",
-			})
+			}
+			markIncompatibleDispatch(source, v.Name, &nextVersion)
+			availableVersions = append(availableVersions, nextVersion)
 		}
 	}
 
 	return availableVersions, nil
 }
 
+// markIncompatibleDispatch flags an update to a release that can't dispatch
+// to the release it's updating from.
+func markIncompatibleDispatch(source Source, from string, v *v1alpha1.SpiceDBVersion) {
+	if source.IncompatibleDispatch(from, v.Name) {
+		v.Attributes = append(v.Attributes, v1alpha1.SpiceDBVersionAttributesIncompatibleDispatch)
+		v.Description += ", rolled out blue/green"
+	}
+}
+
+// UpgradePlan lists every step the operator takes to update a cluster
+// running `from`, following the same edges as ComputeTarget. The plan ends at
+// `version` if it's set, or at the newest release of the channel that has
+// soaked otherwise, and is empty if the cluster is already there.
+func (g *UpdateGraph) UpgradePlan(engine string, from v1alpha1.SpiceDBVersion, version string, soak Soak) ([]v1alpha1.UpgradeStep, error) {
+	if version == from.Name {
+		return nil, nil
+	}
+	source, err := g.SourceForChannel(engine, from.Channel)
+	if err != nil {
+		return nil, fmt.Errorf("no source found for channel %q, can't compute upgrade plan: %w", from.Channel, err)
+	}
+	if len(source.State(from.Name).ID) == 0 {
+		return nil, fmt.Errorf("version %q is not in channel %q", from.Name, from.Channel)
+	}
+	if len(version) == 0 {
+		var held []State
+		source, held, _, err = g.soakedSource(engine, from.Channel, soak)
+		if err != nil {
+			return nil, err
+		}
+		if slices.ContainsFunc(held, func(s State) bool { return s.ID == from.Name }) {
+			return nil, nil
+		}
+	}
+	if len(version) > 0 {
+		source, err = source.Subgraph(version)
+		if err != nil {
+			return nil, fmt.Errorf("error finding update path from %s to %s: %w", from.Name, version, err)
+		}
+	}
+
+	var plan []v1alpha1.UpgradeStep
+	for current := from.Name; ; {
+		next := source.NextVersion(current)
+		if len(next) == 0 {
+			break
+		}
+		state := source.State(next)
+		plan = append(plan, v1alpha1.UpgradeStep{
+			Version:              state.ID,
+			Channel:              from.Channel,
+			Migration:            state.Migration,
+			Phase:                state.Phase,
+			RequiresMigrationJob: next != source.NextVersionWithoutMigrations(current),
+			IncompatibleDispatch: source.IncompatibleDispatch(current, next),
+		})
+		current = next
+	}
+	if len(version) > 0 && (len(plan) == 0 || plan[len(plan)-1].Version != version) {
+		return nil, fmt.Errorf("no update path from %s to %s in channel %q", from.Name, version, from.Channel)
+	}
+	return plan, nil
+}
+
 func explodeImage(image string) (baseImage, tag, digest string) {
 	imageMaybeTag, digest, hasDigest := strings.Cut(image, "@")
 	if !hasDigest {
@@ -221,7 +316,7 @@ func explodeImage(image string) (baseImage, tag, digest string) {
 
 // ComputeTarget determines the target update version and state given an update
 // graph and the proper context.
-func (g *UpdateGraph) ComputeTarget(defaultBaseImage, image, version, channel, engine string, currentVersion *v1alpha1.SpiceDBVersion, rolling bool) (baseImage string, target *v1alpha1.SpiceDBVersion, state State, err error) {
+func (g *UpdateGraph) ComputeTarget(defaultBaseImage, image, version, channel, engine string, currentVersion *v1alpha1.SpiceDBVersion, rolling bool, soak Soak) (baseImage string, target *v1alpha1.SpiceDBVersion, state State, err error) {
 	baseImage, tag, digest := explodeImage(image)
 
 	// If digest or tag are set, don't use an update graph.
@@ -329,6 +424,16 @@ func (g *UpdateGraph) ComputeTarget(defaultBaseImage, image, version, channel, e
 		}
 	}
 
+	// Releases that are still soaking aren't picked unless they're requested
+	// explicitly.
+	if len(version) == 0 && len(channel) > 0 {
+		updateSource, _, _, err = g.soakedSource(engine, channel, soak)
+		if err != nil {
+			err = fmt.Errorf("error fetching update source: %w", err)
+			return
+		}
+	}
+
 	var targetVersion string
 	if currentVersion != nil && len(currentVersion.Name) > 0 {
 		targetVersion = updateSource.NextVersion(currentVersion.Name)
@@ -337,13 +442,28 @@ func (g *UpdateGraph) ComputeTarget(defaultBaseImage, image, version, channel, e
 			state = currentState
 			return
 		}
+		target.Attributes = slices.DeleteFunc(target.Attributes, func(a v1alpha1.SpiceDBVersionAttributes) bool {
+			return a == v1alpha1.SpiceDBVersionAttributesIncompatibleDispatch
+		})
 		if targetVersion != updateSource.NextVersionWithoutMigrations(currentVersion.Name) {
 			target.Attributes = []v1alpha1.SpiceDBVersionAttributes{v1alpha1.SpiceDBVersionAttributesMigration}
 		}
+		if updateSource.IncompatibleDispatch(currentVersion.Name, targetVersion) {
+			target.Attributes = append(target.Attributes, v1alpha1.SpiceDBVersionAttributesIncompatibleDispatch)
+		}
 	} else {
 		// There's no current currentVersion, so install head.
 		targetVersion = updateSource.LatestVersion("")
 		target.Attributes = []v1alpha1.SpiceDBVersionAttributes{v1alpha1.SpiceDBVersionAttributesMigration}
+		if len(targetVersion) == 0 {
+			target = nil
+			notSoaked := &NotSoakedError{Channel: channel}
+			notSoaked.Release, notSoaked.Until, err = g.SoakingRelease(engine, v1alpha1.SpiceDBVersion{Channel: channel}, soak)
+			if err == nil {
+				err = notSoaked
+			}
+			return
+		}
 	}
 
 	// If we found the next step to take, return it.
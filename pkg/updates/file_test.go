@@ -152,11 +152,29 @@ func TestAvailableVersions(t *testing.T) {
 			currentVersion: v1alpha1.SpiceDBVersion{Name: "v1.0.1", Channel: "cockroachdb"},
 			expected:       []v1alpha1.SpiceDBVersion{{Name: "v1.1.0", Channel: "cockroachdb", Attributes: []v1alpha1.SpiceDBVersionAttributes{"next", "latest"}, Description: "direct update with no migrations, head of channel"}},
 		},
+		{
+			name: "updates to a new dispatch api are rolled out blue/green",
+			graph: &UpdateGraph{Channels: []Channel{{
+				Name:     "cockroachdb",
+				Metadata: map[string]string{"datastore": "cockroachdb"},
+				Edges: EdgeSet{
+					"v1.0.0": {"v1.0.1", "v1.1.0"},
+					"v1.0.1": {"v1.1.0"},
+				},
+				Nodes: []State{{ID: "v1.1.0", Migration: "a", Dispatch: "v2"}, {ID: "v1.0.1", Dispatch: "v1"}, {ID: "v1.0.0", Dispatch: "v1"}},
+			}}},
+			engine:         "cockroachdb",
+			currentVersion: v1alpha1.SpiceDBVersion{Name: "v1.0.0", Channel: "cockroachdb"},
+			expected: []v1alpha1.SpiceDBVersion{
+				{Name: "v1.0.1", Channel: "cockroachdb", Attributes: []v1alpha1.SpiceDBVersionAttributes{"next"}, Description: "direct update with no migrations"},
+				{Name: "v1.1.0", Channel: "cockroachdb", Attributes: []v1alpha1.SpiceDBVersionAttributes{"next", "migration", "latest", "incompatibleDispatch"}, Description: "update will run a migration, head of channel, rolled out blue/green"},
+			},
+		},
 	}
 
 	for _, tt := range table {
 		t.Run(tt.name, func(t *testing.T) {
-			versions, err := tt.graph.AvailableVersions(tt.engine, tt.currentVersion)
+			versions, err := tt.graph.AvailableVersions(tt.engine, tt.currentVersion, Soak{})
 
 			switch tt.expectedErr {
 			case "":
@@ -171,6 +189,102 @@ func TestAvailableVersions(t *testing.T) {
 	}
 }
 
+func TestUpgradePlan(t *testing.T) {
+	graph := &UpdateGraph{Channels: []Channel{{
+		Name:     "stable",
+		Metadata: map[string]string{"datastore": "postgres"},
+		Nodes: []State{
+			{ID: "v1.14.1", Migration: "b", Dispatch: "v2"},
+			{ID: "v1.14.0-phase2", Migration: "b", Phase: "phase2", Dispatch: "v2"},
+			{ID: "v1.14.0-phase1", Migration: "b", Phase: "phase1", Dispatch: "v2"},
+			{ID: "v1.13.0", Migration: "a", Dispatch: "v1"},
+			{ID: "v1.12.0", Migration: "a", Dispatch: "v1"},
+		},
+		Edges: EdgeSet{
+			"v1.12.0":        {"v1.13.0", "v1.14.0-phase1"},
+			"v1.13.0":        {"v1.14.0-phase1"},
+			"v1.14.0-phase1": {"v1.14.0-phase2"},
+			"v1.14.0-phase2": {"v1.14.1"},
+		},
+	}}}
+
+	table := []struct {
+		name        string
+		from        v1alpha1.SpiceDBVersion
+		version     string
+		expected    []v1alpha1.UpgradeStep
+		expectedErr string
+	}{
+		{
+			name: "to the head of the channel",
+			from: v1alpha1.SpiceDBVersion{Name: "v1.12.0", Channel: "stable"},
+			expected: []v1alpha1.UpgradeStep{
+				{Version: "v1.14.0-phase1", Channel: "stable", Migration: "b", Phase: "phase1", RequiresMigrationJob: true, IncompatibleDispatch: true},
+				{Version: "v1.14.0-phase2", Channel: "stable", Migration: "b", Phase: "phase2", RequiresMigrationJob: true},
+				{Version: "v1.14.1", Channel: "stable", Migration: "b", RequiresMigrationJob: true},
+			},
+		},
+		{
+			name:     "to a version without migrations",
+			from:     v1alpha1.SpiceDBVersion{Name: "v1.12.0", Channel: "stable"},
+			version:  "v1.13.0",
+			expected: []v1alpha1.UpgradeStep{{Version: "v1.13.0", Channel: "stable", Migration: "a"}},
+		},
+		{
+			name:    "to a version partway through the channel",
+			from:    v1alpha1.SpiceDBVersion{Name: "v1.13.0", Channel: "stable"},
+			version: "v1.14.0-phase2",
+			expected: []v1alpha1.UpgradeStep{
+				{Version: "v1.14.0-phase1", Channel: "stable", Migration: "b", Phase: "phase1", RequiresMigrationJob: true, IncompatibleDispatch: true},
+				{Version: "v1.14.0-phase2", Channel: "stable", Migration: "b", Phase: "phase2", RequiresMigrationJob: true},
+			},
+		},
+		{
+			name:    "already at the version",
+			from:    v1alpha1.SpiceDBVersion{Name: "v1.13.0", Channel: "stable"},
+			version: "v1.13.0",
+		},
+		{
+			name: "already at the head",
+			from: v1alpha1.SpiceDBVersion{Name: "v1.14.1", Channel: "stable"},
+		},
+		{
+			name:        "older version",
+			from:        v1alpha1.SpiceDBVersion{Name: "v1.13.0", Channel: "stable"},
+			version:     "v1.12.0",
+			expectedErr: "no update path from v1.13.0 to v1.12.0",
+		},
+		{
+			name:        "version not in the channel",
+			from:        v1alpha1.SpiceDBVersion{Name: "v1.13.0", Channel: "stable"},
+			version:     "v2.0.0",
+			expectedErr: "no update path from v1.13.0 to v2.0.0",
+		},
+		{
+			name:        "current version not in the channel",
+			from:        v1alpha1.SpiceDBVersion{Name: "v1.0.0", Channel: "stable"},
+			expectedErr: `version "v1.0.0" is not in channel "stable"`,
+		},
+		{
+			name:        "unknown channel",
+			from:        v1alpha1.SpiceDBVersion{Name: "v1.13.0", Channel: "rapid"},
+			expectedErr: `no source found for channel "rapid"`,
+		},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := graph.UpgradePlan("postgres", tt.from, tt.version, Soak{})
+			if tt.expectedErr != "" {
+				require.ErrorContains(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, plan)
+		})
+	}
+}
+
 func TestComputeTarget(t *testing.T) {
 	table := []struct {
 		name              string
@@ -592,6 +706,44 @@ func TestComputeTarget(t *testing.T) {
 			},
 			expectedState: State{ID: "v1.0.1"},
 		},
+		{
+			name: "update to a new dispatch api",
+			graph: &UpdateGraph{Channels: []Channel{{
+				Name:     "cockroachdb",
+				Metadata: map[string]string{"datastore": "cockroachdb"},
+				Edges:    EdgeSet{"v1.0.0": {"v1.1.0"}, "v1.1.0": {"v1.1.1"}},
+				Nodes:    []State{{ID: "v1.1.1", Dispatch: "v2"}, {ID: "v1.1.0", Dispatch: "v2"}, {ID: "v1.0.0", Dispatch: "v1"}},
+			}}},
+			engine:            "cockroachdb",
+			currentVersion:    &v1alpha1.SpiceDBVersion{Name: "v1.0.0", Channel: "cockroachdb"},
+			baseImage:         "ghcr.io/authzed/spicedb",
+			expectedBaseImage: "ghcr.io/authzed/spicedb",
+			expectedTarget: &v1alpha1.SpiceDBVersion{
+				Name:       "v1.1.0",
+				Channel:    "cockroachdb",
+				Attributes: []v1alpha1.SpiceDBVersionAttributes{v1alpha1.SpiceDBVersionAttributesIncompatibleDispatch},
+			},
+			expectedState: State{ID: "v1.1.0", Dispatch: "v2"},
+		},
+		{
+			name: "the step after a new dispatch api rolls out normally",
+			graph: &UpdateGraph{Channels: []Channel{{
+				Name:     "cockroachdb",
+				Metadata: map[string]string{"datastore": "cockroachdb"},
+				Edges:    EdgeSet{"v1.0.0": {"v1.1.0"}, "v1.1.0": {"v1.1.1"}},
+				Nodes:    []State{{ID: "v1.1.1", Dispatch: "v2"}, {ID: "v1.1.0", Dispatch: "v2"}, {ID: "v1.0.0", Dispatch: "v1"}},
+			}}},
+			engine: "cockroachdb",
+			currentVersion: &v1alpha1.SpiceDBVersion{
+				Name:       "v1.1.0",
+				Channel:    "cockroachdb",
+				Attributes: []v1alpha1.SpiceDBVersionAttributes{v1alpha1.SpiceDBVersionAttributesIncompatibleDispatch},
+			},
+			baseImage:         "ghcr.io/authzed/spicedb",
+			expectedBaseImage: "ghcr.io/authzed/spicedb",
+			expectedTarget:    &v1alpha1.SpiceDBVersion{Name: "v1.1.1", Channel: "cockroachdb", Attributes: []v1alpha1.SpiceDBVersionAttributes{}},
+			expectedState:     State{ID: "v1.1.1", Dispatch: "v2"},
+		},
 	}
 
 	for _, tt := range table {
@@ -604,6 +756,7 @@ func TestComputeTarget(t *testing.T) {
 				tt.engine,
 				tt.currentVersion,
 				tt.rolling,
+				Soak{},
 			)
 
 			switch tt.expectedErr {
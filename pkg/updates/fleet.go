@@ -0,0 +1,207 @@
+package updates
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/authzed/controller-idioms/hash"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+)
+
+// FleetRollout rolls changes to the update graph out to clusters in ordered
+// waves. Clusters in a wave only start moving to a new version once every
+// cluster in the waves before it has been ready on the current graph for
+// that wave's `healthyFor`. Clusters that no wave selects go last.
+//
+// Clusters that their own update policy holds back, waiting for a
+// maintenance window or for a migration to be approved, don't hold back the
+// waves after them.
+type FleetRollout struct {
+	Waves []RolloutWave `json:"waves,omitempty"`
+}
+
+// RolloutWave is a group of clusters that is updated together. A cluster
+// belongs to the first wave whose selector matches its labels.
+type RolloutWave struct {
+	Name     string               `json:"name"`
+	Selector metav1.LabelSelector `json:"selector"`
+
+	// HealthyFor is how long every cluster in the wave has to be ready after
+	// updating before the next wave can start.
+	HealthyFor metav1.Duration `json:"healthyFor,omitempty"`
+}
+
+// UnmatchedWave is the name that Progress reports the clusters that no wave
+// selects under.
+const UnmatchedWave = "unmatched"
+
+// WaveProgress reports how far a wave has got with updating to a graph.
+type WaveProgress struct {
+	Name     string
+	Clusters int
+	Updated  int
+
+	// Deferred is the number of clusters that haven't updated because their
+	// own update policy holds them back.
+	Deferred int
+
+	// HealthyAt is when the wave will have been healthy for long enough for
+	// the next wave to start. It's zero until every cluster in the wave has
+	// been updated.
+	HealthyAt time.Time
+}
+
+// Complete returns true if the next wave can start.
+func (w WaveProgress) Complete(now time.Time) bool {
+	return w.Updated+w.Deferred == w.Clusters && !w.HealthyAt.After(now)
+}
+
+// Copy returns a copy of the rollout policy.
+func (f *FleetRollout) Copy() *FleetRollout {
+	if f == nil {
+		return nil
+	}
+	waves := make([]RolloutWave, 0, len(f.Waves))
+	for _, w := range f.Waves {
+		waves = append(waves, RolloutWave{Name: w.Name, Selector: *w.Selector.DeepCopy(), HealthyFor: w.HealthyFor})
+	}
+	return &FleetRollout{Waves: waves}
+}
+
+// Validate returns an error if a wave has an invalid selector, or if two
+// waves have the same name.
+func (f *FleetRollout) Validate() error {
+	if f == nil {
+		return nil
+	}
+	names := make(map[string]struct{}, len(f.Waves))
+	for _, w := range f.Waves {
+		if w.Name == UnmatchedWave {
+			return fmt.Errorf("rollout wave name %q is reserved", w.Name)
+		}
+		if _, ok := names[w.Name]; ok {
+			return fmt.Errorf("more than one rollout wave with name %q", w.Name)
+		}
+		names[w.Name] = struct{}{}
+	}
+	_, err := f.selectors()
+	return err
+}
+
+func (f *FleetRollout) selectors() ([]labels.Selector, error) {
+	selectors := make([]labels.Selector, 0, len(f.Waves))
+	for _, w := range f.Waves {
+		selector, err := metav1.LabelSelectorAsSelector(&w.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector for rollout wave %q: %w", w.Name, err)
+		}
+		selectors = append(selectors, selector)
+	}
+	return selectors, nil
+}
+
+func wave(selectors []labels.Selector, cluster *v1alpha1.SpiceDBCluster) int {
+	for i, s := range selectors {
+		if s.Matches(labels.Set(cluster.GetLabels())) {
+			return i
+		}
+	}
+	return len(selectors)
+}
+
+// Progress returns the progress of each wave towards the graph identified by
+// graphHash (see UpdateGraph.Hash), followed by the progress of the clusters
+// that no wave selects, as UnmatchedWave.
+func (f *FleetRollout) Progress(graphHash string, clusters []*v1alpha1.SpiceDBCluster) ([]WaveProgress, error) {
+	selectors, err := f.selectors()
+	if err != nil {
+		return nil, err
+	}
+	progress := make([]WaveProgress, 0, len(f.Waves)+1)
+	for _, w := range f.Waves {
+		progress = append(progress, WaveProgress{Name: w.Name})
+	}
+	progress = append(progress, WaveProgress{Name: UnmatchedWave})
+	for _, cluster := range clusters {
+		i := wave(selectors, cluster)
+		progress[i].Clusters++
+		since, ok := updatedSince(cluster, graphHash)
+		if !ok {
+			if deferred(cluster) {
+				progress[i].Deferred++
+			}
+			continue
+		}
+		progress[i].Updated++
+		var healthyFor time.Duration
+		if i < len(f.Waves) {
+			healthyFor = f.Waves[i].HealthyFor.Duration
+		}
+		if healthyAt := since.Add(healthyFor); healthyAt.After(progress[i].HealthyAt) {
+			progress[i].HealthyAt = healthyAt
+		}
+	}
+	for i := range progress {
+		if progress[i].Updated+progress[i].Deferred < progress[i].Clusters {
+			progress[i].HealthyAt = time.Time{}
+		}
+	}
+	return progress, nil
+}
+
+// Blocking returns the first wave before the cluster's own wave that hasn't
+// completed, if there is one.
+func (f *FleetRollout) Blocking(graphHash string, cluster *v1alpha1.SpiceDBCluster, clusters []*v1alpha1.SpiceDBCluster, now time.Time) (*WaveProgress, error) {
+	selectors, err := f.selectors()
+	if err != nil {
+		return nil, err
+	}
+	progress, err := f.Progress(graphHash, clusters)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range progress[:wave(selectors, cluster)] {
+		if !p.Complete(now) {
+			return &p, nil
+		}
+	}
+	return nil, nil
+}
+
+// deferred returns true if a cluster's own update policy is holding it back,
+// waiting for its maintenance window or for a migration to be approved.
+func deferred(cluster *v1alpha1.SpiceDBCluster) bool {
+	if cluster.IsStatusConditionTrue(v1alpha1.ConditionTypeUpdatePendingApproval) {
+		return true
+	}
+	pending := cluster.FindStatusCondition(v1alpha1.ConditionTypeUpdatePending)
+	return pending != nil && pending.Status == metav1.ConditionTrue && pending.Reason == v1alpha1.ConditionReasonOutsideMaintenance
+}
+
+// updatedSince returns when a cluster became ready after catching up with
+// the graph, or false if it hasn't yet.
+func updatedSince(cluster *v1alpha1.SpiceDBCluster, graphHash string) (time.Time, bool) {
+	status := cluster.Status
+	if status.ObservedGeneration != cluster.GetGeneration() || status.UpdateGraphHash != graphHash ||
+		len(status.UpgradePlan) > 0 || cluster.RolloutInProgress() {
+		return time.Time{}, false
+	}
+	ready := cluster.FindStatusCondition(v1alpha1.ConditionTypeReady)
+	if ready == nil || ready.Status != metav1.ConditionTrue {
+		return time.Time{}, false
+	}
+	return ready.LastTransitionTime.Time, true
+}
+
+// Hash identifies the contents of the graph. The graph is hashed as json
+// rather than with hash.Object, which would include pointer addresses.
+func (g *UpdateGraph) Hash() string {
+	// a graph always marshals, it's the same structure it was decoded from
+	contents, _ := json.Marshal(g)
+	return hash.Object(string(contents))
+}
@@ -0,0 +1,107 @@
+package updates
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+)
+
+func TestFleetRolloutProgress(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	rollout := &FleetRollout{Waves: []RolloutWave{
+		{Name: "dev", Selector: metav1.LabelSelector{MatchLabels: map[string]string{"tier": "dev"}}, HealthyFor: metav1.Duration{Duration: time.Hour}},
+		{Name: "staging", Selector: metav1.LabelSelector{MatchLabels: map[string]string{"tier": "staging"}}, HealthyFor: metav1.Duration{Duration: time.Hour}},
+		{Name: "prod", Selector: metav1.LabelSelector{MatchLabels: map[string]string{"tier": "prod"}}},
+	}}
+	cluster := func(tier, graphHash string, readySince time.Time, mutate ...func(*v1alpha1.SpiceDBCluster)) *v1alpha1.SpiceDBCluster {
+		c := &v1alpha1.SpiceDBCluster{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"tier": tier}},
+			Status: v1alpha1.ClusterStatus{
+				UpdateGraphHash: graphHash,
+				Conditions: []metav1.Condition{{
+					Type:               v1alpha1.ConditionTypeReady,
+					Status:             metav1.ConditionTrue,
+					LastTransitionTime: metav1.NewTime(readySince),
+				}},
+			},
+		}
+		for _, m := range mutate {
+			m(c)
+		}
+		return c
+	}
+
+	clusters := []*v1alpha1.SpiceDBCluster{
+		cluster("dev", "current", now.Add(-2*time.Hour)),
+		cluster("dev", "current", now.Add(-90*time.Minute)),
+		cluster("staging", "current", now.Add(-10*time.Minute)),
+		cluster("staging", "previous", now.Add(-2*time.Hour)),
+		cluster("prod", "current", now.Add(-2*time.Hour), func(c *v1alpha1.SpiceDBCluster) {
+			c.Status.UpgradePlan = []v1alpha1.UpgradeStep{{Version: "v1.1.0"}}
+		}),
+		cluster("prod", "current", now.Add(-2*time.Hour), func(c *v1alpha1.SpiceDBCluster) {
+			c.Status.Conditions[0].Status = metav1.ConditionFalse
+		}),
+		cluster("prod", "previous", now.Add(-2*time.Hour), func(c *v1alpha1.SpiceDBCluster) {
+			c.SetStatusCondition(v1alpha1.NewUpdatePendingCondition(v1alpha1.SpiceDBVersion{Name: "v1.1.0"}, now.Add(time.Hour)))
+		}),
+		cluster("sandbox", "previous", now),
+	}
+
+	progress, err := rollout.Progress("current", clusters)
+	require.NoError(t, err)
+	require.Equal(t, []WaveProgress{
+		{Name: "dev", Clusters: 2, Updated: 2, HealthyAt: now.Add(-30 * time.Minute)},
+		{Name: "staging", Clusters: 2, Updated: 1},
+		{Name: "prod", Clusters: 3, Deferred: 1},
+		{Name: UnmatchedWave, Clusters: 1},
+	}, progress)
+	require.True(t, progress[0].Complete(now))
+	require.False(t, progress[1].Complete(now))
+
+	blocking, err := rollout.Blocking("current", cluster("dev", "", now), clusters, now)
+	require.NoError(t, err)
+	require.Nil(t, blocking)
+
+	blocking, err = rollout.Blocking("current", cluster("sandbox", "", now), clusters, now)
+	require.NoError(t, err)
+	require.Equal(t, "staging", blocking.Name)
+}
+
+func TestFleetRolloutInvalidSelector(t *testing.T) {
+	rollout := &FleetRollout{Waves: []RolloutWave{{
+		Name: "dev",
+		Selector: metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "tier", Operator: "Near", Values: []string{"dev"}},
+		}},
+	}}}
+	_, err := rollout.Progress("", nil)
+	require.ErrorContains(t, err, `invalid selector for rollout wave "dev"`)
+	require.ErrorContains(t, rollout.Validate(), `invalid selector for rollout wave "dev"`)
+}
+
+func TestFleetRolloutValidate(t *testing.T) {
+	require.NoError(t, (*FleetRollout)(nil).Validate())
+	require.ErrorContains(t, (&FleetRollout{Waves: []RolloutWave{{Name: "dev"}, {Name: "dev"}}}).Validate(), `more than one rollout wave with name "dev"`)
+	require.ErrorContains(t, (&FleetRollout{Waves: []RolloutWave{{Name: UnmatchedWave}}}).Validate(), `rollout wave name "unmatched" is reserved`)
+}
+
+func TestUpdateGraphHash(t *testing.T) {
+	graph := UpdateGraph{Channels: []Channel{{
+		Name:     "stable",
+		Metadata: map[string]string{"datastore": "postgres"},
+		Nodes:    []State{{ID: "v1.0.0", Tag: "v1.0.0", ReleasedAt: ptr.To(metav1.NewTime(time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)))}},
+		Edges:    EdgeSet{"v1.0.0": {}},
+	}}}
+	copied := graph.Copy()
+	copied.Channels[0].Nodes = []State{{ID: "v1.0.0", Tag: "v1.0.0", ReleasedAt: ptr.To(metav1.NewTime(time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)))}}
+	require.Equal(t, graph.Hash(), copied.Hash())
+
+	copied.Channels[0].Nodes[0].Tag = "v1.0.1"
+	require.NotEqual(t, graph.Hash(), copied.Hash())
+}
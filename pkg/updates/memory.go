@@ -33,8 +33,8 @@ func (m *MemorySource) NextVersion(from string) string {
 }
 
 func (m *MemorySource) NextVersionWithoutMigrations(from string) (found string) {
-	initial := m.OrderedNodes[m.Nodes[from]]
 	if to, ok := m.Edges[from]; ok && len(to) > 0 {
+		initial := m.OrderedNodes[m.Nodes[from]]
 		for _, n := range m.Edges[from] {
 			node := m.OrderedNodes[m.Nodes[n]]
 
@@ -50,6 +50,12 @@ func (m *MemorySource) NextVersionWithoutMigrations(from string) (found string)
 	return found
 }
 
+func (m *MemorySource) IncompatibleDispatch(from, to string) bool {
+	// a release without a dispatch version isn't known to be incompatible
+	fromDispatch, toDispatch := m.State(from).Dispatch, m.State(to).Dispatch
+	return len(fromDispatch) > 0 && len(toDispatch) > 0 && fromDispatch != toDispatch
+}
+
 func (m *MemorySource) LatestVersion(id string) string {
 	if len(m.OrderedNodes) == 0 || id == m.OrderedNodes[0].ID {
 		return ""
@@ -65,6 +71,10 @@ func (m *MemorySource) State(id string) State {
 	return m.OrderedNodes[index]
 }
 
+func (m *MemorySource) Releases() []State {
+	return m.OrderedNodes
+}
+
 func (m *MemorySource) Subgraph(head string) (Source, error) {
 	// copy the ordered node list from `to` onward
 	var index int
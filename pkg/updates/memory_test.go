@@ -348,3 +348,17 @@ func TestMemorySourceState(t *testing.T) {
 		})
 	}
 }
+
+func TestMemorySourceIncompatibleDispatch(t *testing.T) {
+	m, err := NewMemorySource([]State{
+		{ID: "4", Dispatch: "v3"},
+		{ID: "3", Dispatch: "v2"},
+		{ID: "2", Dispatch: "v2"},
+		{ID: "1"},
+	}, EdgeSet{"1": {"2"}, "2": {"3"}, "3": {"4"}})
+	require.NoError(t, err)
+	require.True(t, m.IncompatibleDispatch("3", "4"))
+	require.False(t, m.IncompatibleDispatch("2", "3"))
+	require.False(t, m.IncompatibleDispatch("1", "2"))
+	require.False(t, m.IncompatibleDispatch("1", "4"))
+}
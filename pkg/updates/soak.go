@@ -0,0 +1,144 @@
+package updates
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+)
+
+// MinimumSoakMetadataKey is the channel metadata key for the minimum time a
+// release has to be out before clusters are updated to it automatically,
+// i.e. `minimumSoak: 72h`.
+const MinimumSoakMetadataKey = "minimumSoak"
+
+// Soak holds back the newest releases of a channel until they have been out
+// for a minimum amount of time. Releases without a `releasedAt` are never
+// held back.
+type Soak struct {
+	// MinimumSoak overrides the `minimumSoak` metadata of the channel.
+	MinimumSoak *time.Duration
+
+	// Now is the time that release dates are compared to. Nothing is held
+	// back if it's zero.
+	Now time.Time
+}
+
+// NewSoak returns the Soak for a cluster with the given update policy.
+func NewSoak(policy *v1alpha1.UpdatePolicy, now time.Time) Soak {
+	soak := Soak{Now: now}
+	if policy != nil && policy.MinimumSoak != nil {
+		soak.MinimumSoak = &policy.MinimumSoak.Duration
+	}
+	return soak
+}
+
+// minimum returns the soak time that applies to a channel.
+func (s Soak) minimum(c Channel) (time.Duration, error) {
+	if s.MinimumSoak != nil {
+		return *s.MinimumSoak, nil
+	}
+	value, ok := c.Metadata[MinimumSoakMetadataKey]
+	if !ok {
+		return 0, nil
+	}
+	minimum, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q for channel %q: %w", MinimumSoakMetadataKey, value, c.Name, err)
+	}
+	return minimum, nil
+}
+
+// NotSoakedError is returned for a cluster without a version when no release
+// of its channel has soaked yet. Release is the first one to finish soaking,
+// at Until.
+type NotSoakedError struct {
+	Channel string
+	Release State
+	Until   time.Time
+}
+
+func (e *NotSoakedError) Error() string {
+	return fmt.Sprintf("no release in channel %q has soaked yet", e.Channel)
+}
+
+// eligibleAt returns when a release has soaked for `minimum`.
+func eligibleAt(state State, minimum time.Duration) time.Time {
+	if state.ReleasedAt == nil {
+		return time.Time{}
+	}
+	return state.ReleasedAt.Add(minimum)
+}
+
+// apply returns the subgraph of source that ends at the newest release that
+// has soaked, along with the releases that were held back, newest first. If
+// no release has soaked yet, the subgraph is empty and every release is held
+// back.
+func (s Soak) apply(source Source, minimum time.Duration) (Source, []State, error) {
+	if s.Now.IsZero() || minimum <= 0 {
+		return source, nil, nil
+	}
+	nodes := source.Releases()
+	head := slices.IndexFunc(nodes, func(n State) bool {
+		return !eligibleAt(n, minimum).After(s.Now)
+	})
+	if head < 0 {
+		return &MemorySource{Nodes: NodeSet{}, Edges: EdgeSet{}}, nodes, nil
+	}
+	if head == 0 {
+		return source, nil, nil
+	}
+	soaked, err := source.Subgraph(nodes[head].ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return soaked, nodes[:head], nil
+}
+
+// soakedSource returns the source for a channel without the releases that
+// are still soaking, the releases that were held back, and the soak time that
+// applies to the channel.
+func (g *UpdateGraph) soakedSource(engine, channel string, soak Soak) (Source, []State, time.Duration, error) {
+	c, ok := g.channel(engine, channel)
+	if !ok {
+		return nil, nil, 0, fmt.Errorf("no channel for %q found with name %q", engine, channel)
+	}
+	source, err := NewMemorySource(c.Nodes, c.Edges)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	minimum, err := soak.minimum(c)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	source, held, err := soak.apply(source, minimum)
+	return source, held, minimum, err
+}
+
+// SoakingRelease returns the release that a cluster running `v` would be
+// updated to next if it weren't still soaking, and when it will have
+// soaked. It returns an empty State if no update is being held back.
+func (g *UpdateGraph) SoakingRelease(engine string, v v1alpha1.SpiceDBVersion, soak Soak) (State, time.Time, error) {
+	_, held, minimum, err := g.soakedSource(engine, v.Channel, soak)
+	if err != nil {
+		return State{}, time.Time{}, err
+	}
+	// nothing is held back from a cluster that already runs a release that's
+	// soaking
+	if len(held) == 0 || slices.ContainsFunc(held, func(s State) bool { return s.ID == v.Name }) {
+		return State{}, time.Time{}, nil
+	}
+
+	// release dates don't have to follow the order of the channel, so the
+	// next release to finish soaking is the one that's eligible first
+	var next State
+	var until time.Time
+	for _, s := range held {
+		if at := eligibleAt(s, minimum); until.IsZero() || at.Before(until) {
+			next, until = s, at
+		}
+	}
+	return next, until, nil
+}
@@ -0,0 +1,183 @@
+package updates
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+)
+
+func TestSoak(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	releasedAt := func(ago time.Duration) *metav1.Time {
+		return ptr.To(metav1.NewTime(now.Add(-ago)))
+	}
+	graph := func(metadata map[string]string) *UpdateGraph {
+		return &UpdateGraph{Channels: []Channel{{
+			Name:     "stable",
+			Metadata: metadata,
+			Nodes: []State{
+				{ID: "v1.3.0", Tag: "v1.3.0", Migration: "b", ReleasedAt: releasedAt(time.Hour)},
+				{ID: "v1.2.0", Tag: "v1.2.0", Migration: "a", ReleasedAt: releasedAt(48 * time.Hour)},
+				{ID: "v1.1.0", Tag: "v1.1.0", Migration: "a", ReleasedAt: releasedAt(96 * time.Hour)},
+				{ID: "v1.0.0", Tag: "v1.0.0", Migration: "a"},
+			},
+			Edges: EdgeSet{
+				"v1.0.0": {"v1.1.0", "v1.2.0"},
+				"v1.1.0": {"v1.2.0", "v1.3.0"},
+				"v1.2.0": {"v1.3.0"},
+			},
+		}}}
+	}
+	channel := map[string]string{"datastore": "postgres", "default": "true", MinimumSoakMetadataKey: "72h"}
+	released := func(ages ...time.Duration) *UpdateGraph {
+		g := graph(channel)
+		for i, ago := range ages {
+			g.Channels[0].Nodes[i].ReleasedAt = releasedAt(ago)
+		}
+		return g
+	}
+
+	tests := []struct {
+		name    string
+		graph   *UpdateGraph
+		soak    Soak
+		version string
+		current *v1alpha1.SpiceDBVersion
+
+		expectedTarget    string
+		expectedErr       string
+		expectedAvailable []string
+		expectedSoaking   string
+		expectedUntil     time.Time
+	}{
+		{
+			name:              "channel soak holds back new releases",
+			graph:             graph(channel),
+			soak:              Soak{Now: now},
+			current:           &v1alpha1.SpiceDBVersion{Name: "v1.0.0", Channel: "stable"},
+			expectedTarget:    "v1.1.0",
+			expectedAvailable: []string{"v1.1.0"},
+			expectedSoaking:   "v1.2.0",
+			expectedUntil:     now.Add(24 * time.Hour),
+		},
+		{
+			name:              "cluster soak overrides the channel",
+			graph:             graph(channel),
+			soak:              Soak{Now: now, MinimumSoak: ptr.To(30 * time.Minute)},
+			current:           &v1alpha1.SpiceDBVersion{Name: "v1.1.0", Channel: "stable"},
+			expectedTarget:    "v1.3.0",
+			expectedAvailable: []string{"v1.2.0", "v1.3.0"},
+		},
+		{
+			name:              "no soak",
+			graph:             graph(map[string]string{"datastore": "postgres", "default": "true"}),
+			soak:              Soak{Now: now},
+			current:           &v1alpha1.SpiceDBVersion{Name: "v1.1.0", Channel: "stable"},
+			expectedTarget:    "v1.3.0",
+			expectedAvailable: []string{"v1.2.0", "v1.3.0"},
+		},
+		{
+			name:           "explicit versions aren't held back",
+			graph:          graph(channel),
+			soak:           Soak{Now: now},
+			version:        "v1.3.0",
+			current:        &v1alpha1.SpiceDBVersion{Name: "v1.2.0", Channel: "stable"},
+			expectedTarget: "v1.3.0",
+		},
+		{
+			name:           "install the newest release that has soaked",
+			graph:          graph(channel),
+			soak:           Soak{Now: now},
+			expectedTarget: "v1.1.0",
+		},
+		{
+			name:              "stay on a release that's soaking",
+			graph:             graph(channel),
+			soak:              Soak{Now: now},
+			current:           &v1alpha1.SpiceDBVersion{Name: "v1.2.0", Channel: "stable"},
+			expectedTarget:    "v1.2.0",
+			expectedAvailable: []string{},
+		},
+		{
+			name:              "release dates out of order",
+			graph:             released(time.Hour, 60*time.Hour, 10*time.Hour),
+			soak:              Soak{Now: now},
+			current:           &v1alpha1.SpiceDBVersion{Name: "v1.0.0", Channel: "stable"},
+			expectedTarget:    "v1.0.0",
+			expectedAvailable: []string{},
+			expectedSoaking:   "v1.2.0",
+			expectedUntil:     now.Add(12 * time.Hour),
+		},
+		{
+			name:            "don't install a release before one has soaked",
+			graph:           released(time.Hour, 2*time.Hour, 3*time.Hour, 4*time.Hour),
+			soak:            Soak{Now: now},
+			expectedErr:     `no release in channel "stable" has soaked yet`,
+			expectedSoaking: "v1.0.0",
+			expectedUntil:   now.Add(68 * time.Hour),
+		},
+		{
+			name:              "stay on a release that's soaking when none has soaked",
+			graph:             released(time.Hour, 2*time.Hour, 3*time.Hour, 4*time.Hour),
+			soak:              Soak{Now: now},
+			current:           &v1alpha1.SpiceDBVersion{Name: "v1.0.0", Channel: "stable"},
+			expectedTarget:    "v1.0.0",
+			expectedAvailable: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, target, _, err := tt.graph.ComputeTarget("image", "", tt.version, "stable", "postgres", tt.current, false, tt.soak)
+			if len(tt.expectedErr) > 0 {
+				require.ErrorContains(t, err, tt.expectedErr)
+				var notSoaked *NotSoakedError
+				require.ErrorAs(t, err, &notSoaked)
+				require.Equal(t, tt.expectedSoaking, notSoaked.Release.ID)
+				require.True(t, tt.expectedUntil.Equal(notSoaked.Until), "expected %s, got %s", tt.expectedUntil, notSoaked.Until)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedTarget, target.Name)
+
+			if tt.current == nil || len(tt.version) > 0 {
+				return
+			}
+			available, err := tt.graph.AvailableVersions("postgres", *tt.current, tt.soak)
+			require.NoError(t, err)
+			names := make([]string, 0, len(available))
+			for _, v := range available {
+				names = append(names, v.Name)
+			}
+			require.Equal(t, tt.expectedAvailable, names)
+
+			plan, err := tt.graph.UpgradePlan("postgres", *tt.current, "", tt.soak)
+			require.NoError(t, err)
+			if len(tt.expectedAvailable) == 0 {
+				require.Empty(t, plan)
+			} else {
+				require.Equal(t, tt.expectedAvailable[len(tt.expectedAvailable)-1], plan[len(plan)-1].Version)
+			}
+
+			soaking, until, err := tt.graph.SoakingRelease("postgres", *tt.current, tt.soak)
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedSoaking, soaking.ID)
+			require.True(t, tt.expectedUntil.Equal(until), "expected %s, got %s", tt.expectedUntil, until)
+		})
+	}
+}
+
+func TestSoakInvalidMetadata(t *testing.T) {
+	graph := &UpdateGraph{Channels: []Channel{{
+		Name:     "stable",
+		Metadata: map[string]string{"datastore": "postgres", MinimumSoakMetadataKey: "three days"},
+		Nodes:    []State{{ID: "v1.0.0", Tag: "v1.0.0"}},
+		Edges:    EdgeSet{"v1.0.0": {}},
+	}}}
+	_, err := graph.AvailableVersions("postgres", v1alpha1.SpiceDBVersion{Name: "v1.0.0", Channel: "stable"}, Soak{Now: time.Now()})
+	require.ErrorContains(t, err, `invalid minimumSoak "three days" for channel "stable"`)
+}
@@ -20,6 +20,11 @@ type Source interface {
 	// in a series of updates).
 	LatestVersion(from string) string
 
+	// IncompatibleDispatch returns true if the release `to` can't dispatch
+	// to the release `from`, so they can't serve requests side by side in
+	// one dispatch ring.
+	IncompatibleDispatch(from, to string) bool
+
 	// State returns the information that is required to update to the provided
 	// node.
 	State(id string) State
@@ -27,4 +32,7 @@ type Source interface {
 	// Subgraph returns a new Source that is a subgraph of the current source,
 	// but where `head` is set to the provided node.
 	Subgraph(head string) (Source, error)
+
+	// Releases returns every node in the source, newest first.
+	Releases() []State
 }
@@ -0,0 +1,118 @@
+package updates
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+)
+
+const blackoutDateLayout = time.DateOnly
+
+// maxWindowSearch bounds the search for the next time an update can start,
+// so that a policy whose windows all fall on blackout dates can't loop
+// forever.
+const maxWindowSearch = 1000
+
+type maintenanceWindow struct {
+	schedule cron.Schedule
+	duration time.Duration
+}
+
+// MaintenanceSchedule decides when a cluster can start moving to a new
+// version, based on the maintenance windows and blackout dates in its
+// UpdatePolicy.
+type MaintenanceSchedule struct {
+	location  *time.Location
+	windows   []maintenanceWindow
+	blackouts map[string]struct{}
+}
+
+// NewMaintenanceSchedule parses the maintenance windows and blackout dates of
+// a policy. A nil policy allows updates at any time.
+func NewMaintenanceSchedule(policy *v1alpha1.UpdatePolicy) (*MaintenanceSchedule, error) {
+	s := &MaintenanceSchedule{location: time.UTC, blackouts: make(map[string]struct{})}
+	if policy == nil {
+		return s, nil
+	}
+
+	if len(policy.TimeZone) > 0 {
+		location, err := time.LoadLocation(policy.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid updatePolicy.timeZone %q: %w", policy.TimeZone, err)
+		}
+		s.location = location
+	}
+
+	for _, w := range policy.MaintenanceWindows {
+		schedule, err := cron.ParseStandard(w.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid maintenance window schedule %q: %w", w.Schedule, err)
+		}
+		if w.Duration.Duration <= 0 {
+			return nil, fmt.Errorf("maintenance window %q must have a positive duration", w.Schedule)
+		}
+		s.windows = append(s.windows, maintenanceWindow{schedule: schedule, duration: w.Duration.Duration})
+	}
+
+	for _, d := range policy.BlackoutDates {
+		date, err := time.ParseInLocation(blackoutDateLayout, d, s.location)
+		if err != nil {
+			return nil, fmt.Errorf("invalid blackout date %q, expected YYYY-MM-DD: %w", d, err)
+		}
+		s.blackouts[date.Format(blackoutDateLayout)] = struct{}{}
+	}
+	return s, nil
+}
+
+// NextUpdateTime returns the earliest time at or after `now` when an update
+// can start. If an update can't start within the search limit, the zero time
+// is returned.
+func (s *MaintenanceSchedule) NextUpdateTime(now time.Time) time.Time {
+	t := now.In(s.location)
+	for i := 0; i < maxWindowSearch; i++ {
+		if s.blackedOut(t) {
+			year, month, day := t.Date()
+			t = time.Date(year, month, day+1, 0, 0, 0, 0, s.location)
+			continue
+		}
+		if open, next := s.window(t); !open {
+			if next.IsZero() {
+				return next
+			}
+			t = next
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (s *MaintenanceSchedule) blackedOut(t time.Time) bool {
+	_, ok := s.blackouts[t.Format(blackoutDateLayout)]
+	return ok
+}
+
+// window returns true if a maintenance window is open at t. If not, it
+// returns when the next one opens.
+func (s *MaintenanceSchedule) window(t time.Time) (bool, time.Time) {
+	if len(s.windows) == 0 {
+		return true, t
+	}
+
+	var next time.Time
+	for _, w := range s.windows {
+		// the most recent start before t is within the window if the first
+		// start after t - duration isn't later than t. Schedules that never
+		// fire (i.e. `0 0 30 2 *`) return the zero time.
+		if start := w.schedule.Next(t.Add(-w.duration)); !start.IsZero() && !start.After(t) {
+			return true, t
+		}
+		if start := w.schedule.Next(t); !start.IsZero() && (next.IsZero() || start.Before(next)) {
+			next = start
+		}
+	}
+	return false, next
+}
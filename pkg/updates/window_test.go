@@ -0,0 +1,136 @@
+package updates
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+)
+
+func TestMaintenanceSchedule(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	// saturdays from 2am to 6am
+	weekend := []v1alpha1.MaintenanceWindow{{Schedule: "0 2 * * SAT", Duration: metav1.Duration{Duration: 4 * time.Hour}}}
+
+	tests := []struct {
+		name   string
+		policy *v1alpha1.UpdatePolicy
+		now    time.Time
+		want   time.Time
+	}{
+		{
+			name: "no policy",
+			now:  time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC),
+			want: time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "inside a window",
+			policy: &v1alpha1.UpdatePolicy{MaintenanceWindows: weekend},
+			now:    time.Date(2024, 3, 9, 3, 0, 0, 0, time.UTC),
+			want:   time.Date(2024, 3, 9, 3, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "at the start of a window",
+			policy: &v1alpha1.UpdatePolicy{MaintenanceWindows: weekend},
+			now:    time.Date(2024, 3, 9, 2, 0, 0, 0, time.UTC),
+			want:   time.Date(2024, 3, 9, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "at the end of a window",
+			policy: &v1alpha1.UpdatePolicy{MaintenanceWindows: weekend},
+			now:    time.Date(2024, 3, 9, 6, 0, 0, 0, time.UTC),
+			want:   time.Date(2024, 3, 16, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "before a window",
+			policy: &v1alpha1.UpdatePolicy{MaintenanceWindows: weekend},
+			now:    time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC),
+			want:   time.Date(2024, 3, 9, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "earliest of several windows",
+			policy: &v1alpha1.UpdatePolicy{MaintenanceWindows: append([]v1alpha1.MaintenanceWindow{
+				{Schedule: "0 22 * * THU", Duration: metav1.Duration{Duration: time.Hour}},
+			}, weekend...)},
+			now:  time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC),
+			want: time.Date(2024, 3, 7, 22, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "window in another time zone",
+			policy: &v1alpha1.UpdatePolicy{MaintenanceWindows: weekend, TimeZone: "Europe/Berlin"},
+			now:    time.Date(2024, 3, 9, 1, 30, 0, 0, time.UTC),
+			want:   time.Date(2024, 3, 9, 1, 30, 0, 0, time.UTC),
+		},
+		{
+			name:   "blackout date inside a window",
+			policy: &v1alpha1.UpdatePolicy{MaintenanceWindows: weekend, BlackoutDates: []string{"2024-03-09"}},
+			now:    time.Date(2024, 3, 9, 3, 0, 0, 0, time.UTC),
+			want:   time.Date(2024, 3, 16, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "blackout dates without windows",
+			policy: &v1alpha1.UpdatePolicy{BlackoutDates: []string{"2024-12-24", "2024-12-25"}},
+			now:    time.Date(2024, 12, 24, 9, 0, 0, 0, time.UTC),
+			want:   time.Date(2024, 12, 26, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "blackout date in another time zone",
+			policy: &v1alpha1.UpdatePolicy{BlackoutDates: []string{"2024-12-25"}, TimeZone: "Europe/Berlin"},
+			now:    time.Date(2024, 12, 24, 23, 30, 0, 0, time.UTC),
+			want:   time.Date(2024, 12, 26, 0, 0, 0, 0, berlin),
+		},
+		{
+			name:   "window that never opens",
+			policy: &v1alpha1.UpdatePolicy{MaintenanceWindows: []v1alpha1.MaintenanceWindow{{Schedule: "0 0 30 2 *", Duration: metav1.Duration{Duration: time.Hour}}}},
+			now:    time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewMaintenanceSchedule(tt.policy)
+			require.NoError(t, err)
+			got := s.NextUpdateTime(tt.now)
+			require.True(t, tt.want.Equal(got), "expected %s, got %s", tt.want, got)
+		})
+	}
+}
+
+func TestNewMaintenanceScheduleErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  *v1alpha1.UpdatePolicy
+		wantErr string
+	}{
+		{
+			name:    "time zone",
+			policy:  &v1alpha1.UpdatePolicy{TimeZone: "Mars/Olympus_Mons"},
+			wantErr: `invalid updatePolicy.timeZone "Mars/Olympus_Mons"`,
+		},
+		{
+			name:    "schedule",
+			policy:  &v1alpha1.UpdatePolicy{MaintenanceWindows: []v1alpha1.MaintenanceWindow{{Schedule: "saturday", Duration: metav1.Duration{Duration: time.Hour}}}},
+			wantErr: `invalid maintenance window schedule "saturday"`,
+		},
+		{
+			name:    "duration",
+			policy:  &v1alpha1.UpdatePolicy{MaintenanceWindows: []v1alpha1.MaintenanceWindow{{Schedule: "0 2 * * SAT"}}},
+			wantErr: `maintenance window "0 2 * * SAT" must have a positive duration`,
+		},
+		{
+			name:    "blackout date",
+			policy:  &v1alpha1.UpdatePolicy{BlackoutDates: []string{"12/25/2024"}},
+			wantErr: `invalid blackout date "12/25/2024"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewMaintenanceSchedule(tt.policy)
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
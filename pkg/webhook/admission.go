@@ -0,0 +1,103 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1beta1"
+)
+
+// admitFunc reviews a single admission request.
+type admitFunc func(ctx context.Context, req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse
+
+// serveAdmission decodes an AdmissionReview, passes the request to admit,
+// and writes back the response.
+func serveAdmission(admit admitFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var review admissionv1.AdmissionReview
+		if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
+			http.Error(w, fmt.Sprintf("couldn't decode admission review: %v", err), http.StatusBadRequest)
+			return
+		}
+		if review.Request == nil {
+			http.Error(w, "admission review has no request", http.StatusBadRequest)
+			return
+		}
+
+		response := admit(r.Context(), review.Request)
+		response.UID = review.Request.UID
+		review.Request = nil
+		review.Response = response
+		writeJSON(w, review)
+	})
+}
+
+// clusterFromRaw decodes a SpiceDBCluster of any served version into
+// v1alpha1, which is the version the operator works with internally.
+func clusterFromRaw(raw []byte) (*v1alpha1.SpiceDBCluster, error) {
+	var typeMeta metav1.TypeMeta
+	if err := json.Unmarshal(raw, &typeMeta); err != nil {
+		return nil, fmt.Errorf("couldn't decode object: %w", err)
+	}
+
+	var cluster v1alpha1.SpiceDBCluster
+	switch typeMeta.APIVersion {
+	case v1alpha1.SchemeGroupVersion.String():
+		if err := json.Unmarshal(raw, &cluster); err != nil {
+			return nil, fmt.Errorf("couldn't decode %s: %w", typeMeta.APIVersion, err)
+		}
+	case v1beta1.SchemeGroupVersion.String():
+		var in v1beta1.SpiceDBCluster
+		if err := json.Unmarshal(raw, &in); err != nil {
+			return nil, fmt.Errorf("couldn't decode %s: %w", typeMeta.APIVersion, err)
+		}
+		if err := in.ConvertTo(&cluster); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported api version %q", typeMeta.APIVersion)
+	}
+	return &cluster, nil
+}
+
+func allowed(warnings ...string) *admissionv1.AdmissionResponse {
+	return &admissionv1.AdmissionResponse{Allowed: true, Warnings: warnings}
+}
+
+func denied(code int32, err error, warnings ...string) *admissionv1.AdmissionResponse {
+	return &admissionv1.AdmissionResponse{
+		Allowed: false,
+		Result: &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Code:    code,
+			Reason:  metav1.StatusReasonInvalid,
+			Message: err.Error(),
+		},
+		Warnings: warnings,
+	}
+}
+
+// messages returns the messages of an (optionally aggregated) error.
+func messages(err error) []string {
+	if err == nil {
+		return nil
+	}
+	var agg utilerrors.Aggregate
+	if !errors.As(err, &agg) {
+		return []string{err.Error()}
+	}
+	flat := utilerrors.Flatten(agg).Errors()
+	out := make([]string, 0, len(flat))
+	for _, e := range flat {
+		out = append(out, e.Error())
+	}
+	return out
+}
@@ -0,0 +1,56 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// review sends an AdmissionReview for the given objects to h and returns
+// the response.
+func review(t *testing.T, h http.Handler, operation admissionv1.Operation, object, oldObject string) *admissionv1.AdmissionResponse {
+	t.Helper()
+
+	req := admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+		Request: &admissionv1.AdmissionRequest{
+			UID:       types.UID("uid"),
+			Operation: operation,
+		},
+	}
+	if object != "" {
+		req.Request.Object = runtime.RawExtension{Raw: []byte(object)}
+	}
+	if oldObject != "" {
+		req.Request.OldObject = runtime.RawExtension{Raw: []byte(oldObject)}
+	}
+	body, err := json.Marshal(req)
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body)))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp admissionv1.AdmissionReview
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.NotNil(t, resp.Response)
+	require.Equal(t, types.UID("uid"), resp.Response.UID)
+	return resp.Response
+}
+
+func cluster(apiVersion, spec string) string {
+	return `{
+		"apiVersion": "` + apiVersion + `",
+		"kind": "SpiceDBCluster",
+		"metadata": {"name": "test", "namespace": "test"},
+		"spec": ` + spec + `
+	}`
+}
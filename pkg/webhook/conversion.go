@@ -0,0 +1,103 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1beta1"
+)
+
+const ConversionPath = "/convert"
+
+// NewConversionHandler returns a handler that serves ConversionReviews for
+// SpiceDBClusters, converting between v1alpha1 and v1beta1.
+func NewConversionHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var review apiextensionsv1.ConversionReview
+		if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
+			http.Error(w, fmt.Sprintf("couldn't decode conversion review: %v", err), http.StatusBadRequest)
+			return
+		}
+		if review.Request == nil {
+			http.Error(w, "conversion review has no request", http.StatusBadRequest)
+			return
+		}
+
+		response := &apiextensionsv1.ConversionResponse{
+			UID:    review.Request.UID,
+			Result: metav1.Status{Status: metav1.StatusSuccess},
+		}
+		for _, obj := range review.Request.Objects {
+			converted, err := convert(obj, review.Request.DesiredAPIVersion)
+			if err != nil {
+				response.ConvertedObjects = nil
+				response.Result = metav1.Status{
+					Status:  metav1.StatusFailure,
+					Message: err.Error(),
+				}
+				break
+			}
+			response.ConvertedObjects = append(response.ConvertedObjects, converted)
+		}
+
+		review.Request = nil
+		review.Response = response
+		writeJSON(w, review)
+	})
+}
+
+// convert converts a single SpiceDBCluster to the desired api version.
+func convert(obj runtime.RawExtension, desiredAPIVersion string) (runtime.RawExtension, error) {
+	var typeMeta metav1.TypeMeta
+	if err := json.Unmarshal(obj.Raw, &typeMeta); err != nil {
+		return runtime.RawExtension{}, fmt.Errorf("couldn't decode object: %w", err)
+	}
+	if typeMeta.APIVersion == desiredAPIVersion {
+		return obj, nil
+	}
+
+	var out any
+	switch {
+	case typeMeta.APIVersion == v1alpha1.SchemeGroupVersion.String() && desiredAPIVersion == v1beta1.SchemeGroupVersion.String():
+		var in v1alpha1.SpiceDBCluster
+		if err := json.Unmarshal(obj.Raw, &in); err != nil {
+			return runtime.RawExtension{}, fmt.Errorf("couldn't decode %s: %w", typeMeta.APIVersion, err)
+		}
+		var cluster v1beta1.SpiceDBCluster
+		if err := cluster.ConvertFrom(&in); err != nil {
+			return runtime.RawExtension{}, err
+		}
+		out = &cluster
+	case typeMeta.APIVersion == v1beta1.SchemeGroupVersion.String() && desiredAPIVersion == v1alpha1.SchemeGroupVersion.String():
+		var in v1beta1.SpiceDBCluster
+		if err := json.Unmarshal(obj.Raw, &in); err != nil {
+			return runtime.RawExtension{}, fmt.Errorf("couldn't decode %s: %w", typeMeta.APIVersion, err)
+		}
+		var cluster v1alpha1.SpiceDBCluster
+		if err := in.ConvertTo(&cluster); err != nil {
+			return runtime.RawExtension{}, err
+		}
+		out = &cluster
+	default:
+		return runtime.RawExtension{}, fmt.Errorf("unsupported conversion from %q to %q", typeMeta.APIVersion, desiredAPIVersion)
+	}
+
+	raw, err := json.Marshal(out)
+	if err != nil {
+		return runtime.RawExtension{}, err
+	}
+	return runtime.RawExtension{Raw: raw}, nil
+}
+
+func writeJSON(w http.ResponseWriter, obj any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(obj); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,124 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1beta1"
+)
+
+func TestConversionHandler(t *testing.T) {
+	alpha := `{
+		"apiVersion": "authzed.com/v1alpha1",
+		"kind": "SpiceDBCluster",
+		"metadata": {"name": "test", "namespace": "test"},
+		"spec": {"config": {"datastoreEngine": "memory", "replicas": 1, "logLevel": "debug"}}
+	}`
+	beta := `{
+		"apiVersion": "authzed.com/v1beta1",
+		"kind": "SpiceDBCluster",
+		"metadata": {"name": "test", "namespace": "test"},
+		"spec": {"config": {"datastoreEngine": "memory", "replicas": 1, "passthrough": {"logLevel": "debug"}}}
+	}`
+
+	tests := []struct {
+		name          string
+		objects       []string
+		desired       string
+		expectSuccess bool
+		expectObjects []string
+	}{
+		{
+			name:          "v1alpha1 to v1beta1",
+			objects:       []string{alpha},
+			desired:       v1beta1.SchemeGroupVersion.String(),
+			expectSuccess: true,
+			expectObjects: []string{`{
+				"apiVersion": "authzed.com/v1beta1",
+				"kind": "SpiceDBCluster",
+				"metadata": {"name": "test", "namespace": "test", "creationTimestamp": null},
+				"spec": {"config": {"datastoreEngine": "memory", "replicas": 1, "logLevel": "debug"}},
+				"status": {}
+			}`},
+		},
+		{
+			name:          "v1beta1 to v1alpha1",
+			objects:       []string{beta},
+			desired:       v1alpha1.SchemeGroupVersion.String(),
+			expectSuccess: true,
+			expectObjects: []string{`{
+				"apiVersion": "authzed.com/v1alpha1",
+				"kind": "SpiceDBCluster",
+				"metadata": {"name": "test", "namespace": "test", "creationTimestamp": null},
+				"spec": {"config": {"datastoreEngine": "memory", "replicas": 1, "logLevel": "debug"}},
+				"status": {}
+			}`},
+		},
+		{
+			name:          "same version is unchanged",
+			objects:       []string{alpha},
+			desired:       v1alpha1.SchemeGroupVersion.String(),
+			expectSuccess: true,
+			expectObjects: []string{alpha},
+		},
+		{
+			name: "passthrough keys can't repeat typed fields",
+			objects: []string{`{
+				"apiVersion": "authzed.com/v1beta1",
+				"kind": "SpiceDBCluster",
+				"metadata": {"name": "test", "namespace": "test"},
+				"spec": {"config": {"datastoreEngine": "memory", "replicas": 1, "passthrough": {"replicas": "2"}}}
+			}`},
+			desired: v1alpha1.SchemeGroupVersion.String(),
+		},
+		{
+			name:    "unknown version",
+			objects: []string{alpha},
+			desired: "authzed.com/v2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			review := apiextensionsv1.ConversionReview{
+				TypeMeta: metav1.TypeMeta{Kind: "ConversionReview", APIVersion: "apiextensions.k8s.io/v1"},
+				Request: &apiextensionsv1.ConversionRequest{
+					UID:               types.UID("uid"),
+					DesiredAPIVersion: tt.desired,
+				},
+			}
+			for _, o := range tt.objects {
+				review.Request.Objects = append(review.Request.Objects, runtime.RawExtension{Raw: []byte(o)})
+			}
+			body, err := json.Marshal(review)
+			require.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			NewConversionHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, ConversionPath, bytes.NewReader(body)))
+			require.Equal(t, http.StatusOK, rec.Code)
+
+			var got apiextensionsv1.ConversionReview
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			require.NotNil(t, got.Response)
+			require.Equal(t, types.UID("uid"), got.Response.UID)
+			if !tt.expectSuccess {
+				require.Equal(t, metav1.StatusFailure, got.Response.Result.Status)
+				return
+			}
+			require.Equal(t, metav1.StatusSuccess, got.Response.Result.Status)
+			require.Len(t, got.Response.ConvertedObjects, len(tt.expectObjects))
+			for i, o := range tt.expectObjects {
+				require.JSONEq(t, o, string(got.Response.ConvertedObjects[i].Raw))
+			}
+		})
+	}
+}
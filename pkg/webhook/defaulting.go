@@ -0,0 +1,76 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	admissionv1 "k8s.io/api/admission/v1"
+
+	"github.com/authzed/spicedb-operator/pkg/config"
+)
+
+// DefaultingPath is the path that the defaulting webhook is served on.
+const DefaultingPath = "/default"
+
+// DefaultingHandler pins the channel, version, and replicas that the
+// operator would otherwise pick implicitly for a new cluster, so that they
+// are visible in the stored spec.
+type DefaultingHandler struct {
+	operatorConfig func() *config.OperatorConfig
+}
+
+// NewDefaultingHandler returns a handler for the defaulting webhook.
+func NewDefaultingHandler(operatorConfig func() *config.OperatorConfig) http.Handler {
+	d := &DefaultingHandler{operatorConfig: operatorConfig}
+	return serveAdmission(d.admit)
+}
+
+type jsonPatchOp struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value any    `json:"value"`
+}
+
+func (d *DefaultingHandler) admit(_ context.Context, req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	if req.Operation != admissionv1.Create {
+		return allowed()
+	}
+
+	cluster, err := clusterFromRaw(req.Object.Raw)
+	if err != nil {
+		return denied(http.StatusBadRequest, err)
+	}
+
+	// clusters that can't be defaulted are admitted as-is, validation
+	// reports why they are invalid
+	defaults, err := config.NewDefaults(cluster, d.operatorConfig())
+	if err != nil {
+		return allowed(fmt.Sprintf("channel, version and replicas weren't defaulted: %v", err))
+	}
+
+	var patch []jsonPatchOp
+	if len(cluster.Spec.Channel) == 0 && len(defaults.Channel) > 0 {
+		patch = append(patch, jsonPatchOp{Op: "add", Path: "/spec/channel", Value: defaults.Channel})
+	}
+	if len(cluster.Spec.Version) == 0 && len(defaults.Version) > 0 {
+		patch = append(patch, jsonPatchOp{Op: "add", Path: "/spec/version", Value: defaults.Version})
+	}
+	if !defaults.ReplicasSet {
+		patch = append(patch, jsonPatchOp{Op: "add", Path: "/spec/replicas", Value: defaults.Replicas})
+	}
+	if len(patch) == 0 {
+		return allowed()
+	}
+
+	encoded, err := json.Marshal(patch)
+	if err != nil {
+		return denied(http.StatusInternalServerError, err)
+	}
+	patchType := admissionv1.PatchTypeJSONPatch
+	response := allowed()
+	response.Patch = encoded
+	response.PatchType = &patchType
+	return response
+}
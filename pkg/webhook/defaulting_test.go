@@ -0,0 +1,95 @@
+package webhook
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+
+	"github.com/authzed/spicedb-operator/pkg/config"
+	"github.com/authzed/spicedb-operator/pkg/updates"
+)
+
+func TestDefaultingHandler(t *testing.T) {
+	operatorConfig := &config.OperatorConfig{
+		ImageName: "image",
+		UpdateGraph: updates.UpdateGraph{
+			Channels: []updates.Channel{
+				{
+					Name:     "stable",
+					Metadata: map[string]string{"datastore": "postgres", "default": "true"},
+					Nodes: []updates.State{
+						{ID: "v2", Tag: "v2"},
+						{ID: "v1", Tag: "v1"},
+					},
+					Edges: map[string][]string{"v1": {"v2"}},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		operation   admissionv1.Operation
+		object      string
+		expectPatch string
+		expectWarn  bool
+	}{
+		{
+			name:        "pins channel, version and replicas",
+			operation:   admissionv1.Create,
+			object:      cluster("authzed.com/v1alpha1", `{"config": {"datastoreEngine": "postgres"}}`),
+			expectPatch: `[{"op":"add","path":"/spec/channel","value":"stable"},{"op":"add","path":"/spec/version","value":"v2"},{"op":"add","path":"/spec/replicas","value":2}]`,
+		},
+		{
+			name:        "keeps explicit values",
+			operation:   admissionv1.Create,
+			object:      cluster("authzed.com/v1beta1", `{"channel": "stable", "version": "v1", "config": {"datastoreEngine": "postgres", "replicas": 3}}`),
+			expectPatch: "",
+		},
+		{
+			name:        "pins only version",
+			operation:   admissionv1.Create,
+			object:      cluster("authzed.com/v1alpha1", `{"channel": "stable", "config": {"datastoreEngine": "postgres", "replicas": "1"}}`),
+			expectPatch: `[{"op":"add","path":"/spec/version","value":"v2"}]`,
+		},
+		{
+			name:        "doesn't pin replicas when autoscaling",
+			operation:   admissionv1.Create,
+			object:      cluster("authzed.com/v1alpha1", `{"channel": "stable", "version": "v1", "autoscaling": {"maxReplicas": 3}, "config": {"datastoreEngine": "postgres"}}`),
+			expectPatch: "",
+		},
+		{
+			name:        "invalid clusters are not defaulted",
+			operation:   admissionv1.Create,
+			object:      cluster("authzed.com/v1alpha1", `{"channel": "unknown", "config": {"datastoreEngine": "postgres"}}`),
+			expectPatch: "",
+			expectWarn:  true,
+		},
+		{
+			name:        "updates are not defaulted",
+			operation:   admissionv1.Update,
+			object:      cluster("authzed.com/v1alpha1", `{"config": {"datastoreEngine": "postgres"}}`),
+			expectPatch: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewDefaultingHandler(func() *config.OperatorConfig { return operatorConfig })
+			response := review(t, h, tt.operation, tt.object, tt.object)
+			require.True(t, response.Allowed)
+			if tt.expectWarn {
+				require.Len(t, response.Warnings, 1)
+			} else {
+				require.Empty(t, response.Warnings)
+			}
+			if tt.expectPatch == "" {
+				require.Nil(t, response.Patch)
+				require.Nil(t, response.PatchType)
+				return
+			}
+			require.JSONEq(t, tt.expectPatch, string(response.Patch))
+			require.Equal(t, admissionv1.PatchTypeJSONPatch, *response.PatchType)
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package webhook
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/klog/v2/textlogger"
+
+	"github.com/authzed/controller-idioms/manager"
+)
+
+const (
+	CertFileName = "tls.crt"
+	KeyFileName  = "tls.key"
+	CAFileName   = "ca.crt"
+)
+
+// Server serves the operator's webhooks over TLS. It implements
+// manager.Controller so that it is started and stopped along with the
+// controllers.
+type Server struct {
+	*manager.BasicController
+	addr    string
+	certDir string
+	mux     *http.ServeMux
+}
+
+var _ manager.Controller = &Server{}
+
+// NewServer returns a webhook server that listens on addr and serves the
+// certificate and key found in certDir.
+func NewServer(addr, certDir string) *Server {
+	return &Server{
+		BasicController: manager.NewBasicController("webhooks"),
+		addr:            addr,
+		certDir:         certDir,
+		mux:             http.NewServeMux(),
+	}
+}
+
+// Handle registers a webhook handler for the given path.
+func (s *Server) Handle(path string, h http.Handler) {
+	s.mux.Handle(path, h)
+}
+
+// Start serves webhooks until the context is cancelled.
+func (s *Server) Start(ctx context.Context, _ int) {
+	logger := textlogger.NewLogger(textlogger.NewConfig())
+	srv := &http.Server{
+		Addr:              s.addr,
+		Handler:           s.mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		TLSConfig: &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			// certs are loaded on each handshake so that rotated certs are
+			// picked up without a restart
+			GetCertificate: func(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+				cert, err := tls.LoadX509KeyPair(filepath.Join(s.certDir, CertFileName), filepath.Join(s.certDir, KeyFileName))
+				if err != nil {
+					return nil, err
+				}
+				return &cert, nil
+			},
+		},
+	}
+
+	go func() {
+		<-ctx.Done()
+		utilruntime.HandleError(srv.Shutdown(context.Background()))
+	}()
+
+	logger.V(3).Info("serving webhooks", "address", s.addr, "certDir", s.certDir)
+	if err := srv.ListenAndServeTLS("", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		utilruntime.HandleError(err)
+	}
+}
@@ -0,0 +1,116 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/kubectl/pkg/util/openapi"
+
+	"github.com/authzed/spicedb-operator/pkg/apis/authzed/v1alpha1"
+	"github.com/authzed/spicedb-operator/pkg/config"
+)
+
+// ValidationPath is the path that the validating webhook is served on.
+const ValidationPath = "/validate"
+
+// SecretGetter fetches the secret referenced by a cluster.
+type SecretGetter func(ctx context.Context, nn types.NamespacedName) (*corev1.Secret, error)
+
+// ValidationHandler rejects SpiceDBClusters that the controller would fail to
+// validate, by running the same config.NewConfig checks before the object is
+// persisted.
+type ValidationHandler struct {
+	operatorConfig func() *config.OperatorConfig
+	resources      openapi.Resources
+	getSecret      SecretGetter
+}
+
+// NewValidationHandler returns a handler for the validating webhook.
+// operatorConfig is called on every request so that the handler always sees
+// the config currently loaded by the controller.
+func NewValidationHandler(operatorConfig func() *config.OperatorConfig, resources openapi.Resources, getSecret SecretGetter) http.Handler {
+	v := &ValidationHandler{
+		operatorConfig: operatorConfig,
+		resources:      resources,
+		getSecret:      getSecret,
+	}
+	return serveAdmission(v.admit)
+}
+
+func (v *ValidationHandler) admit(ctx context.Context, req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	if req.Operation != admissionv1.Create && req.Operation != admissionv1.Update {
+		return allowed()
+	}
+
+	cluster, err := clusterFromRaw(req.Object.Raw)
+	if err != nil {
+		return denied(http.StatusBadRequest, err)
+	}
+
+	// metadata-only updates (i.e. the operator adding a pause label) are
+	// allowed even if the spec is no longer valid against the current
+	// operator config, so that the cluster can still be managed.
+	if req.Operation == admissionv1.Update && len(req.OldObject.Raw) > 0 {
+		old, err := clusterFromRaw(req.OldObject.Raw)
+		if err != nil {
+			return denied(http.StatusBadRequest, err)
+		}
+		if equality.Semantic.DeepEqual(old.Spec, cluster.Spec) {
+			return allowed()
+		}
+	}
+
+	secret, warnings, err := v.secretFor(ctx, cluster)
+	if err != nil {
+		return denied(http.StatusInternalServerError, err)
+	}
+
+	_, warning, err := config.NewConfig(cluster, v.operatorConfig(), secret, v.resources)
+	warnings = append(warnings, messages(warning)...)
+	if notSoaked, ok := config.NotSoaked(err); ok {
+		warnings = append(warnings, fmt.Sprintf("%v; the cluster will be created with %s once it has, at %s", notSoaked, notSoaked.Release.ID, notSoaked.Until.Format(time.RFC3339)))
+		return allowed(warnings...)
+	}
+	if err != nil {
+		return denied(http.StatusUnprocessableEntity, fmt.Errorf("invalid config: %w", err), warnings...)
+	}
+	return allowed(warnings...)
+}
+
+// secretFor returns the secret referenced by the cluster, or the one the
+// operator generates if it doesn't reference one. Secrets are often created
+// alongside the cluster, so a missing secret is reported as a warning and a
+// placeholder is validated in its place.
+func (v *ValidationHandler) secretFor(ctx context.Context, cluster *v1alpha1.SpiceDBCluster) (*corev1.Secret, []string, error) {
+	nn := types.NamespacedName{Namespace: cluster.Namespace, Name: cluster.Spec.SecretRef}
+	if len(nn.Name) == 0 {
+		nn.Name = config.GeneratedSecretName(cluster.Name)
+	}
+
+	secret, err := v.getSecret(ctx, nn)
+	switch {
+	case apierrors.IsNotFound(err):
+		warning := fmt.Sprintf("secret %s not found, the cluster will not be deployed until it exists", nn)
+		if len(cluster.Spec.SecretRef) == 0 {
+			warning = fmt.Sprintf("secret %s will be generated with a preshared key; add a datastore_uri to it for datastores other than memory", nn)
+		}
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: nn.Namespace, Name: nn.Name},
+			Data: map[string][]byte{
+				"datastore_uri": {},
+				"preshared_key": {},
+			},
+		}, []string{warning}, nil
+	case err != nil:
+		return nil, nil, fmt.Errorf("unable to fetch secret %s: %w", nn, err)
+	}
+	return secret, nil, nil
+}
@@ -0,0 +1,171 @@
+package webhook
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	openapitesting "k8s.io/kubectl/pkg/util/openapi/testing"
+
+	"github.com/authzed/spicedb-operator/pkg/config"
+	"github.com/authzed/spicedb-operator/pkg/updates"
+)
+
+func TestValidationHandler(t *testing.T) {
+	released := metav1.NewTime(time.Now())
+	operatorConfig := &config.OperatorConfig{
+		ImageName: "image",
+		UpdateGraph: updates.UpdateGraph{
+			Channels: []updates.Channel{
+				{
+					Name:     "memory",
+					Metadata: map[string]string{"datastore": "memory", "default": "true"},
+					Nodes:    []updates.State{{ID: "v1", Tag: "v1"}},
+					Edges:    map[string][]string{"v1": {}},
+				},
+				{
+					Name:     "soaking",
+					Metadata: map[string]string{"datastore": "memory", updates.MinimumSoakMetadataKey: "24h"},
+					Nodes:    []updates.State{{ID: "v2", Tag: "v2", ReleasedAt: &released}},
+					Edges:    map[string][]string{"v2": {}},
+				},
+			},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "secret"},
+		Data:       map[string][]byte{"preshared_key": []byte("psk")},
+	}
+	generated := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "test-spicedb-config"},
+		Data:       map[string][]byte{"preshared_key": []byte("generated")},
+	}
+	tests := []struct {
+		name          string
+		operation     admissionv1.Operation
+		object        string
+		oldObject     string
+		generated     bool
+		expectAllowed bool
+		expectMessage string
+		expectWarning []string
+	}{
+		{
+			name:          "valid v1alpha1",
+			operation:     admissionv1.Create,
+			object:        cluster("authzed.com/v1alpha1", `{"secretName": "secret", "config": {"datastoreEngine": "memory", "tlsSecretName": "tls"}}`),
+			expectAllowed: true,
+		},
+		{
+			name:          "valid v1beta1",
+			operation:     admissionv1.Create,
+			object:        cluster("authzed.com/v1beta1", `{"secretName": "secret", "config": {"datastoreEngine": "memory", "tlsSecretName": "tls"}}`),
+			expectAllowed: true,
+		},
+		{
+			name:          "missing datastore engine",
+			operation:     admissionv1.Create,
+			object:        cluster("authzed.com/v1alpha1", `{"secretName": "secret", "config": {"tlsSecretName": "tls"}}`),
+			expectMessage: `datastoreEngine is a required field`,
+		},
+		{
+			name:          "memory with multiple replicas",
+			operation:     admissionv1.Create,
+			object:        cluster("authzed.com/v1alpha1", `{"secretName": "secret", "config": {"datastoreEngine": "memory", "tlsSecretName": "tls", "replicas": 2}}`),
+			expectMessage: `invalid config: cannot set replicas > 1 for memory engine`,
+		},
+		{
+			name:          "unknown version",
+			operation:     admissionv1.Create,
+			object:        cluster("authzed.com/v1alpha1", `{"version": "v2", "secretName": "secret", "config": {"datastoreEngine": "memory", "tlsSecretName": "tls"}}`),
+			expectMessage: `invalid config: no update found in channel`,
+		},
+		{
+			name:          "channel without a soaked release",
+			operation:     admissionv1.Create,
+			object:        cluster("authzed.com/v1alpha1", `{"channel": "soaking", "secretName": "secret", "config": {"datastoreEngine": "memory", "tlsSecretName": "tls"}}`),
+			expectAllowed: true,
+			expectWarning: []string{`no release in channel "soaking" has soaked yet; the cluster will be created with v2 once it has, at ` + released.Add(24*time.Hour).Format(time.RFC3339)},
+		},
+		{
+			name:          "warnings are returned",
+			operation:     admissionv1.Create,
+			object:        cluster("authzed.com/v1alpha1", `{"secretName": "secret", "config": {"datastoreEngine": "memory"}}`),
+			expectAllowed: true,
+			expectWarning: []string{`no TLS configured, consider setting "tlsSecretName"`},
+		},
+		{
+			name:          "missing secret is a warning",
+			operation:     admissionv1.Create,
+			object:        cluster("authzed.com/v1alpha1", `{"secretName": "missing", "config": {"datastoreEngine": "memory", "tlsSecretName": "tls"}}`),
+			expectAllowed: true,
+			expectWarning: []string{"secret test/missing not found, the cluster will not be deployed until it exists"},
+		},
+		{
+			name:          "generated secret",
+			operation:     admissionv1.Create,
+			object:        cluster("authzed.com/v1alpha1", `{"config": {"datastoreEngine": "memory", "tlsSecretName": "tls"}}`),
+			expectAllowed: true,
+			expectWarning: []string{"secret test/test-spicedb-config will be generated with a preshared key; add a datastore_uri to it for datastores other than memory"},
+		},
+		{
+			name:          "already generated secret",
+			operation:     admissionv1.Update,
+			object:        cluster("authzed.com/v1alpha1", `{"config": {"datastoreEngine": "memory", "tlsSecretName": "tls", "logLevel": "debug"}}`),
+			oldObject:     cluster("authzed.com/v1alpha1", `{"config": {"datastoreEngine": "memory", "tlsSecretName": "tls"}}`),
+			generated:     true,
+			expectAllowed: true,
+		},
+		{
+			name:          "update with invalid spec",
+			operation:     admissionv1.Update,
+			object:        cluster("authzed.com/v1alpha1", `{"secretName": "secret", "config": {"tlsSecretName": "tls"}}`),
+			oldObject:     cluster("authzed.com/v1alpha1", `{"secretName": "secret", "config": {"datastoreEngine": "memory", "tlsSecretName": "tls"}}`),
+			expectMessage: `datastoreEngine is a required field`,
+		},
+		{
+			name:          "update with unchanged spec",
+			operation:     admissionv1.Update,
+			object:        cluster("authzed.com/v1alpha1", `{"secretName": "secret", "config": {}}`),
+			oldObject:     cluster("authzed.com/v1alpha1", `{"secretName": "secret", "config": {}}`),
+			expectAllowed: true,
+		},
+		{
+			name:          "delete is allowed",
+			operation:     admissionv1.Delete,
+			oldObject:     cluster("authzed.com/v1alpha1", `{"config": {}}`),
+			expectAllowed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewValidationHandler(
+				func() *config.OperatorConfig { return operatorConfig },
+				openapitesting.NewFakeResources(filepath.Join("..", "config", "testdata", "swagger.1.30.2.json")),
+				func(_ context.Context, nn types.NamespacedName) (*corev1.Secret, error) {
+					switch {
+					case nn.Name == secret.Name:
+						return secret, nil
+					case nn.Name == generated.Name && tt.generated:
+						return generated, nil
+					}
+					return nil, apierrors.NewNotFound(corev1.Resource("secrets"), nn.Name)
+				},
+			)
+
+			response := review(t, h, tt.operation, tt.object, tt.oldObject)
+			require.Equal(t, tt.expectAllowed, response.Allowed)
+			require.Equal(t, tt.expectWarning, response.Warnings)
+			if tt.expectMessage != "" {
+				require.Contains(t, response.Result.Message, tt.expectMessage)
+			}
+		})
+	}
+}